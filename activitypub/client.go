@@ -0,0 +1,171 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+	"yolo/unfurl"
+)
+
+// 客户端相关错误
+var (
+	ErrInvalidURL       = errors.New("only absolute https urls are allowed")
+	ErrForbiddenAddress = errors.New("destination address is not allowed")
+	ErrUnexpectedType   = errors.New("response is not an activitypub document")
+)
+
+// maxDocumentBytes 远程文档的最大字节数
+const maxDocumentBytes = 1 << 20
+
+// ClientConfig 客户端配置
+type ClientConfig struct {
+	Timeout   time.Duration
+	UserAgent string
+	Insecure  bool // 允许 http 和内网地址，仅用于测试
+}
+
+// Client 获取远程参与者并向远程收件箱投递活动。
+// 连接时按解析后的IP校验目标地址，防止借助联邦请求访问内网
+type Client struct {
+	config ClientConfig
+	client *http.Client
+}
+
+// NewClient 创建客户端
+func NewClient(config ClientConfig) *Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.Insecure {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !unfurl.IsPublicAddr(addr) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+
+	return &Client{
+		config: config,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   config.Timeout,
+				ResponseHeaderTimeout: config.Timeout,
+				MaxIdleConnsPerHost:   4,
+				IdleConnTimeout:       90 * time.Second,
+			},
+			Timeout: config.Timeout,
+			// 重定向后的地址同样由拨号时校验IP，另外不允许改变协议，避免 https 被降级为 http
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 3 {
+					return errors.New("too many redirects")
+				}
+				if req.URL.Scheme != via[0].URL.Scheme {
+					return ErrInvalidURL
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// checkURL 只允许 https 地址（测试模式允许 http）
+func (c *Client) checkURL(rawURL string) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" || target.User != nil {
+		return nil, ErrInvalidURL
+	}
+	if target.Scheme != "https" && !(c.config.Insecure && target.Scheme == "http") {
+		return nil, ErrInvalidURL
+	}
+	return target, nil
+}
+
+// FetchActor 获取远程参与者文档，返回的ID必须与实际返回文档的地址（重定向后）同源
+func (c *Client) FetchActor(ctx context.Context, actorURL string) (*Actor, error) {
+	target, err := c.checkURL(actorURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	req.Header.Set("Accept", ContentType+", "+LDContentType)
+	req.Header.Set("User-Agent", c.config.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return nil, ErrForbiddenAddress
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if !IsActivityPubType(resp.Header.Get("Content-Type")) {
+		return nil, ErrUnexpectedType
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentBytes)).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("invalid actor document: %w", err)
+	}
+	// 与最终返回文档的地址比较，重定向到其他主机后不能冒充原主机上的参与者
+	id, err := url.Parse(actor.ID)
+	if err != nil || id.Host != resp.Request.URL.Host || actor.Inbox == "" || actor.PublicKey.PublicKeyPEM == "" {
+		return nil, fmt.Errorf("%w: invalid actor", ErrUnexpectedType)
+	}
+	if actor.PublicKey.Owner != "" && actor.PublicKey.Owner != actor.ID {
+		return nil, fmt.Errorf("%w: key owner mismatch", ErrUnexpectedType)
+	}
+	return &actor, nil
+}
+
+// Deliver 签名后把活动投递到远程收件箱，2xx 视为成功
+func (c *Client) Deliver(ctx context.Context, inbox, keyID string, key *rsa.PrivateKey, body []byte) error {
+	target, err := c.checkURL(inbox)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return ErrInvalidURL
+	}
+	req.Header.Set("Content-Type", LDContentType)
+	req.Header.Set("User-Agent", c.config.UserAgent)
+	if err := SignRequest(req, keyID, key, body); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return ErrForbiddenAddress
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDocumentBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
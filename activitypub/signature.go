@@ -0,0 +1,189 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 签名相关错误
+var (
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrInvalidSignature = errors.New("invalid http signature")
+	ErrSignatureExpired = errors.New("signed date is outside the accepted window")
+	ErrDigestMismatch   = errors.New("digest does not match body")
+)
+
+// MaxClockSkew 签名的 Date 与当前时间允许的最大偏差，超出视为重放
+const MaxClockSkew = time.Hour
+
+// Digest 计算请求体的 SHA-256 摘要头
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// signedHeaderValue 签名串中某个头的值，host 和 (request-target) 不在 Header 中
+func signedHeaderValue(r *http.Request, name string) (string, bool) {
+	switch name {
+	case "(request-target)":
+		return strings.ToLower(r.Method) + " " + r.URL.RequestURI(), true
+	case "host":
+		if r.Host != "" {
+			return r.Host, true
+		}
+		return r.URL.Host, r.URL.Host != ""
+	default:
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			return "", false
+		}
+		return strings.Join(values, ", "), true
+	}
+}
+
+// signingString 按签名头顺序拼接待签名的字符串
+func signingString(r *http.Request, headers []string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		value, ok := signedHeaderValue(r, name)
+		if !ok {
+			return "", fmt.Errorf("%w: missing signed header %s", ErrInvalidSignature, name)
+		}
+		lines = append(lines, name+": "+value)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// SignRequest 按 draft-cavage-http-signatures 对请求签名 (rsa-sha256)。
+// 有请求体时同时设置 Digest 头并纳入签名
+func SignRequest(r *http.Request, keyID string, key *rsa.PrivateKey, body []byte) error {
+	if r.Header.Get("Date") == "" {
+		r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	if r.Host == "" {
+		r.Host = r.URL.Host
+	}
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		r.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+
+	signed, err := signingString(r, headers)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	r.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// parseSignatureHeader 解析 Signature 头中的 key="value" 参数
+func parseSignatureHeader(header string) (map[string]string, error) {
+	params := make(map[string]string)
+	for header != "" {
+		header = strings.TrimLeft(header, " ,")
+		key, rest, ok := strings.Cut(header, "=")
+		if !ok || !strings.HasPrefix(rest, `"`) {
+			return nil, ErrInvalidSignature
+		}
+		end := strings.Index(rest[1:], `"`)
+		if end < 0 {
+			return nil, ErrInvalidSignature
+		}
+		params[strings.TrimSpace(key)] = rest[1 : end+1]
+		header = rest[end+2:]
+	}
+	return params, nil
+}
+
+// KeyResolver 根据 keyId 获取公钥及其所属参与者
+type KeyResolver func(keyID string) (key *rsa.PublicKey, owner string, err error)
+
+// VerifyRequest 校验请求的 HTTP Signature，返回签名公钥所属的参与者ID。
+// 必须签名 (request-target)、host 和 date，有请求体时还必须签名 digest 并与请求体一致
+func VerifyRequest(r *http.Request, body []byte, now time.Time, resolve KeyResolver) (string, error) {
+	header := r.Header.Get("Signature")
+	if header == "" {
+		return "", ErrMissingSignature
+	}
+	params, err := parseSignatureHeader(header)
+	if err != nil {
+		return "", err
+	}
+	if algorithm := params["algorithm"]; algorithm != "" && algorithm != "rsa-sha256" && algorithm != "hs2019" {
+		return "", fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidSignature, algorithm)
+	}
+	keyID := params["keyId"]
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if keyID == "" || err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, name := range required {
+		found := false
+		for _, signed := range headers {
+			if signed == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("%w: %s must be signed", ErrInvalidSignature, name)
+		}
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid date", ErrInvalidSignature)
+	}
+	if skew := now.Sub(date); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return "", ErrSignatureExpired
+	}
+	if len(body) > 0 {
+		matched := false
+		for _, digest := range strings.Split(r.Header.Get("Digest"), ",") {
+			if strings.TrimSpace(digest) == Digest(body) {
+				matched = true
+			}
+		}
+		if !matched {
+			return "", ErrDigestMismatch
+		}
+	}
+
+	signed, err := signingString(r, headers)
+	if err != nil {
+		return "", err
+	}
+	key, owner, err := resolve(keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve key %s: %w", keyID, err)
+	}
+	hash := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+		return "", ErrInvalidSignature
+	}
+	return owner, nil
+}
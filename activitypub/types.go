@@ -0,0 +1,180 @@
+// Package activitypub 实现与 Mastodon 等实例互通所需的 ActivityPub 协议部分：
+// 对象类型、WebFinger、RSA 密钥、HTTP Signatures 以及带内网防护的投递客户端
+package activitypub
+
+import (
+	"encoding/json"
+	"mime"
+	"strings"
+)
+
+// ActivityPub 媒体类型
+const (
+	ContentType   = "application/activity+json"
+	LDContentType = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+	JRDType       = "application/jrd+json"
+)
+
+// 常用常量
+const (
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	SecurityContext        = "https://w3id.org/security/v1"
+	PublicCollection       = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+// 活动和对象类型
+const (
+	TypePerson            = "Person"
+	TypeNote              = "Note"
+	TypeTombstone         = "Tombstone"
+	TypeCreate            = "Create"
+	TypeDelete            = "Delete"
+	TypeFollow            = "Follow"
+	TypeAccept            = "Accept"
+	TypeUndo              = "Undo"
+	TypeOrderedCollection = "OrderedCollection"
+)
+
+// DefaultContext 带公钥字段的对象使用的 JSON-LD 上下文
+var DefaultContext = []string{ActivityStreamsContext, SecurityContext}
+
+// PublicKey 参与者的公钥，用于校验 HTTP Signatures
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPEM string `json:"publicKeyPem"`
+}
+
+// Image 头像等图片
+type Image struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Endpoints 参与者的附加端点
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+// Actor 参与者（用户）文档
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Icon              *Image     `json:"icon,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+// DeliveryInbox 投递地址，有共享收件箱时优先使用
+func (a *Actor) DeliveryInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+// Note 帖子
+type Note struct {
+	Context      any      `json:"@context,omitempty"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo,omitempty"`
+	Content      string   `json:"content,omitempty"`
+	Published    string   `json:"published,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	To           []string `json:"to,omitempty"`
+	CC           []string `json:"cc,omitempty"`
+}
+
+// Activity 活动，object 可能是对象ID字符串也可能是内嵌对象
+type Activity struct {
+	Context   any             `json:"@context,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object,omitempty"`
+	Published string          `json:"published,omitempty"`
+	To        []string        `json:"to,omitempty"`
+	CC        []string        `json:"cc,omitempty"`
+}
+
+// NewActivity 创建活动并内嵌对象
+func NewActivity(id, activityType, actor string, object any) (*Activity, error) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	return &Activity{Context: ActivityStreamsContext, ID: id, Type: activityType, Actor: actor, Object: raw}, nil
+}
+
+// ObjectID 返回 object 的ID，object 为字符串时即为ID
+func (a *Activity) ObjectID() string {
+	var id string
+	if json.Unmarshal(a.Object, &id) == nil {
+		return id
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(a.Object, &object) == nil {
+		return object.ID
+	}
+	return ""
+}
+
+// InnerActivity 将 object 解析为内嵌的活动，例如 Undo 中的 Follow
+func (a *Activity) InnerActivity() (*Activity, bool) {
+	var inner Activity
+	if json.Unmarshal(a.Object, &inner) != nil || inner.Type == "" {
+		return nil, false
+	}
+	return &inner, true
+}
+
+// OrderedCollection 有序集合（发件箱、关注者）
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int64  `json:"totalItems"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+// WebFingerLink WebFinger 链接
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href,omitempty"`
+}
+
+// WebFinger WebFinger 资源描述 (RFC 7033)
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// IsActivityPubType 判断媒体类型是否为 ActivityPub 文档
+func IsActivityPubType(contentType string) bool {
+	for _, part := range strings.Split(contentType, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if mediaType == ContentType {
+			return true
+		}
+		if mediaType == "application/ld+json" && strings.Contains(params["profile"], ActivityStreamsContext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AddBookmarkRequest 收藏帖子请求
+type AddBookmarkRequest struct {
+	PostID       string  `json:"post_id" binding:"required,uuid"`
+	CollectionID *string `json:"collection_id" binding:"omitempty,uuid"` // 为空时不放入收藏夹
+}
+
+// BookmarkCollectionRequest 创建或重命名收藏夹请求
+type BookmarkCollectionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// BookmarkResponse 收藏响应，帖子已删除或不再可见时 post.unavailable 为 true
+type BookmarkResponse struct {
+	ID           string        `json:"id"`
+	PostID       string        `json:"postId"`
+	CollectionID *string       `json:"collectionId"`
+	CreatedAt    string        `json:"createdAt"`
+	Post         *EmbeddedPost `json:"post,omitempty"`
+}
+
+// BookmarksResponse 游标分页的收藏列表响应
+type BookmarksResponse struct {
+	Bookmarks []BookmarkResponse `json:"bookmarks"`
+	PageInfo  CursorPageInfo     `json:"pageInfo"`
+}
+
+// BookmarkCollectionResponse 收藏夹响应
+type BookmarkCollectionResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Count     int64  `json:"count"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// buildBookmarkResponse 将收藏转换为响应格式，未加载帖子时不返回 post
+func buildBookmarkResponse(bookmark *models.Bookmark) BookmarkResponse {
+	response := BookmarkResponse{
+		ID:        bookmark.ID.String(),
+		PostID:    bookmark.PostID.String(),
+		CreatedAt: bookmark.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if bookmark.CollectionID != nil {
+		id := bookmark.CollectionID.String()
+		response.CollectionID = &id
+	}
+	return response
+}
+
+// buildBookmarkCollectionResponse 将收藏夹转换为响应格式
+func buildBookmarkCollectionResponse(collection *models.BookmarkCollection, count int64) BookmarkCollectionResponse {
+	return BookmarkCollectionResponse{
+		ID:        collection.ID.String(),
+		Name:      collection.Name,
+		Count:     count,
+		CreatedAt: collection.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// respondBookmarkError 将收藏服务错误映射为HTTP响应
+func respondBookmarkError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrPostNotFound), errors.Is(err, services.ErrBookmarkNotFound),
+		errors.Is(err, services.ErrCollectionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidCollectionName), errors.Is(err, services.ErrTooManyCollections),
+		errors.Is(err, services.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrCollectionExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// parseCollectionIDParam 解析路径中的收藏夹ID
+func parseCollectionIDParam(c *gin.Context) (uuid.UUID, bool) {
+	collectionID, err := uuid.Parse(c.Param("collectionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collection ID",
+		})
+		return uuid.Nil, false
+	}
+	return collectionID, true
+}
+
+// AddBookmark 收藏帖子 (POST /bookmarks)，已收藏时移动到指定收藏夹
+func AddBookmark(c *gin.Context) {
+	var req AddBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	var collectionID *uuid.UUID
+	if req.CollectionID != nil {
+		id := uuid.MustParse(*req.CollectionID)
+		collectionID = &id
+	}
+
+	bookmark, created, err := services.BookmarkService.AddBookmark(utils.GetUserIDFromContext(c), uuid.MustParse(req.PostID), collectionID)
+	if err != nil {
+		respondBookmarkError(c, err, "Failed to add bookmark")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, buildBookmarkResponse(bookmark))
+}
+
+// RemoveBookmark 取消收藏 (DELETE /bookmarks/:postId)
+func RemoveBookmark(c *gin.Context) {
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := services.BookmarkService.RemoveBookmark(utils.GetUserIDFromContext(c), postID); err != nil {
+		respondBookmarkError(c, err, "Failed to remove bookmark")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bookmark removed successfully",
+	})
+}
+
+// GetBookmarks 获取自己的收藏 (GET /bookmarks)，按收藏时间倒序游标分页
+// 可通过 collection_id 只查看某个收藏夹
+func GetBookmarks(c *gin.Context) {
+	var collectionID *uuid.UUID
+	if raw := c.Query("collection_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid collection ID",
+			})
+			return
+		}
+		collectionID = &id
+	}
+	cursor, withTotal, _ := getCursorFromQuery(c)
+
+	page, err := services.BookmarkService.GetBookmarks(utils.GetUserIDFromContext(c), collectionID, cursor, utils.GetLimitFromQuery(c), withTotal)
+	if err != nil {
+		respondBookmarkError(c, err, "Failed to get bookmarks")
+		return
+	}
+
+	response := BookmarksResponse{
+		Bookmarks: make([]BookmarkResponse, 0, len(page.Bookmarks)),
+		PageInfo:  CursorPageInfo{TotalPosts: page.Total},
+	}
+	for i := range page.Bookmarks {
+		item := &page.Bookmarks[i]
+		bookmark := buildBookmarkResponse(&item.Bookmark)
+		bookmark.Post = buildEmbeddedPost(item.PostID, item.Post)
+		response.Bookmarks = append(response.Bookmarks, bookmark)
+	}
+	if page.NextCursor != "" {
+		response.PageInfo.NextCursor = &page.NextCursor
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBookmarkCollections 获取自己的收藏夹 (GET /bookmarks/collections)
+func GetBookmarkCollections(c *gin.Context) {
+	collections, err := services.BookmarkService.GetCollections(utils.GetUserIDFromContext(c))
+	if err != nil {
+		respondBookmarkError(c, err, "Failed to get bookmark collections")
+		return
+	}
+
+	response := make([]BookmarkCollectionResponse, 0, len(collections))
+	for i := range collections {
+		response = append(response, buildBookmarkCollectionResponse(&collections[i].BookmarkCollection, collections[i].Count))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"collections": response,
+	})
+}
+
+// CreateBookmarkCollection 创建收藏夹 (POST /bookmarks/collections)
+func CreateBookmarkCollection(c *gin.Context) {
+	var req BookmarkCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	collection, err := services.BookmarkService.CreateCollection(utils.GetUserIDFromContext(c), req.Name)
+	if err != nil {
+		respondBookmarkError(c, err, "Failed to create bookmark collection")
+		return
+	}
+
+	c.JSON(http.StatusCreated, buildBookmarkCollectionResponse(collection, 0))
+}
+
+// RenameBookmarkCollection 重命名收藏夹 (PATCH /bookmarks/collections/:collectionId)
+func RenameBookmarkCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req BookmarkCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	summary, err := services.BookmarkService.RenameCollection(utils.GetUserIDFromContext(c), collectionID, req.Name)
+	if err != nil {
+		respondBookmarkError(c, err, "Failed to rename bookmark collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildBookmarkCollectionResponse(&summary.BookmarkCollection, summary.Count))
+}
+
+// DeleteBookmarkCollection 删除收藏夹 (DELETE /bookmarks/collections/:collectionId)，其中的收藏不会被删除
+func DeleteBookmarkCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := services.BookmarkService.DeleteCollection(utils.GetUserIDFromContext(c), collectionID); err != nil {
+		respondBookmarkError(c, err, "Failed to delete bookmark collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bookmark collection deleted successfully",
+	})
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateConditionalOrderRequest 创建条件单请求结构，twap_window 为秒数，side 默认 sell
+type CreateConditionalOrderRequest struct {
+	Kind          string          `json:"kind" binding:"required"`
+	TriggerPrice  decimal.Decimal `json:"trigger_price"`
+	TriggerSource string          `json:"trigger_source"`
+	TWAPWindow    int             `json:"twap_window"`
+	Side          string          `json:"side"`
+	OrderType     string          `json:"order_type"`
+	LimitPrice    decimal.Decimal `json:"limit_price"`
+	TimeInForce   string          `json:"time_in_force"`
+	Quantity      decimal.Decimal `json:"quantity"`
+}
+
+// ConditionalOrderResponse 条件单响应结构
+type ConditionalOrderResponse struct {
+	ID             string          `json:"id"`
+	Symbol         string          `json:"symbol"`
+	Kind           string          `json:"kind"`
+	TriggerSource  string          `json:"triggerSource"`
+	TriggerPrice   decimal.Decimal `json:"triggerPrice"`
+	TWAPWindow     int             `json:"twapWindow,omitempty"`
+	Side           string          `json:"side"`
+	OrderType      string          `json:"orderType"`
+	LimitPrice     decimal.Decimal `json:"limitPrice"`
+	TimeInForce    string          `json:"timeInForce"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	Status         string          `json:"status"`
+	TriggeredPrice decimal.Decimal `json:"triggeredPrice"`
+	TriggeredAt    *string         `json:"triggeredAt"`
+	OrderID        *string         `json:"orderId"`
+	FailureReason  string          `json:"failureReason,omitempty"`
+	CreatedAt      string          `json:"createdAt"`
+}
+
+// ConditionalOrderListResponse 条件单列表响应结构
+type ConditionalOrderListResponse struct {
+	ConditionalOrders []ConditionalOrderResponse `json:"conditionalOrders"`
+	PageInfo          PageInfo                   `json:"pageInfo"`
+}
+
+// buildConditionalOrderResponse 转换为条件单响应
+func buildConditionalOrderResponse(order *models.ConditionalOrder) ConditionalOrderResponse {
+	response := ConditionalOrderResponse{
+		ID:             order.ID.String(),
+		Symbol:         order.Stock.Symbol,
+		Kind:           order.Kind,
+		TriggerSource:  order.TriggerSource,
+		TriggerPrice:   order.TriggerPrice,
+		TWAPWindow:     order.TWAPWindow,
+		Side:           order.Side,
+		OrderType:      order.OrderType,
+		LimitPrice:     order.LimitPrice,
+		TimeInForce:    order.TimeInForce,
+		Quantity:       order.Quantity,
+		Status:         order.Status,
+		TriggeredPrice: order.TriggeredPrice,
+		FailureReason:  order.FailureReason,
+		CreatedAt:      order.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if order.TriggeredAt != nil {
+		triggeredAt := order.TriggeredAt.Format("2006-01-02T15:04:05Z")
+		response.TriggeredAt = &triggeredAt
+	}
+	if order.OrderID != nil {
+		orderID := order.OrderID.String()
+		response.OrderID = &orderID
+	}
+	return response
+}
+
+// respondConditionalOrderError 条件单接口的错误响应，其余错误按订单接口处理
+func respondConditionalOrderError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrConditionalOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrConditionalOrderNotPending), errors.Is(err, services.ErrTooManyConditionalOrders):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidConditionalKind), errors.Is(err, services.ErrInvalidTriggerSource),
+		errors.Is(err, services.ErrInvalidTriggerPrice), errors.Is(err, services.ErrInvalidTWAPWindow),
+		errors.Is(err, services.ErrInvalidConditionalStatus), errors.Is(err, services.ErrTriggerConditionMet):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	default:
+		respondOrderError(c, err, message)
+	}
+}
+
+// CreateConditionalOrder 创建止损、止盈条件单 (POST /stocks/:symbol/conditional-orders)
+func CreateConditionalOrder(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req CreateConditionalOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	order, err := services.ConditionalOrderService.CreateConditionalOrder(userID, c.Param("symbol"), services.ConditionalOrderOptions{
+		Kind:          req.Kind,
+		TriggerSource: req.TriggerSource,
+		TriggerPrice:  req.TriggerPrice,
+		TWAPWindow:    time.Duration(req.TWAPWindow) * time.Second,
+		Order: services.PlaceOrderOptions{
+			Side:        req.Side,
+			Type:        req.OrderType,
+			TimeInForce: req.TimeInForce,
+			Price:       req.LimitPrice,
+			Quantity:    req.Quantity,
+		},
+	})
+	if err != nil {
+		respondConditionalOrderError(c, err, "Failed to create conditional order")
+		return
+	}
+	c.JSON(http.StatusCreated, buildConditionalOrderResponse(order))
+}
+
+// CancelConditionalOrder 撤销条件单 (DELETE /conditional-orders/:conditionalOrderId)
+func CancelConditionalOrder(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	id, err := uuid.Parse(c.Param("conditionalOrderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid conditional order ID",
+		})
+		return
+	}
+
+	order, err := services.ConditionalOrderService.CancelConditionalOrder(userID, id)
+	if err != nil {
+		respondConditionalOrderError(c, err, "Failed to cancel conditional order")
+		return
+	}
+	c.JSON(http.StatusOK, buildConditionalOrderResponse(order))
+}
+
+// GetUserConditionalOrders 获取当前用户的条件单 (GET /user/conditional-orders?status=pending)
+func GetUserConditionalOrders(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	orders, total, err := services.ConditionalOrderService.GetUserConditionalOrders(userID, c.Query("status"), page, limit)
+	if err != nil {
+		respondConditionalOrderError(c, err, "Failed to get conditional orders")
+		return
+	}
+
+	responses := make([]ConditionalOrderResponse, 0, len(orders))
+	for i := range orders {
+		responses = append(responses, buildConditionalOrderResponse(&orders[i]))
+	}
+	c.JSON(http.StatusOK, ConditionalOrderListResponse{
+		ConditionalOrders: responses,
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+			TotalPosts:  total,
+		},
+	})
+}
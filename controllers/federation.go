@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"yolo/activitypub"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// respondActivityJSON 以指定的媒体类型返回 ActivityPub 文档
+func respondActivityJSON(c *gin.Context, contentType string, document any) {
+	body, err := json.Marshal(document)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to encode document",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", body)
+}
+
+// respondFederationError 联邦接口的错误响应，联邦关闭时返回 404
+func respondFederationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrFederationDisabled), errors.Is(err, services.ErrActorNotFound),
+		errors.Is(err, services.ErrPostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, activitypub.ErrMissingSignature), errors.Is(err, activitypub.ErrInvalidSignature),
+		errors.Is(err, activitypub.ErrSignatureExpired), errors.Is(err, activitypub.ErrDigestMismatch):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrActorMismatch):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidActivity):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	default:
+		// 无法获取签名者的公钥等远程错误
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// WebFinger 根据 acct 查找参与者 (GET /.well-known/webfinger)
+func WebFinger(c *gin.Context) {
+	resource := c.Query("resource")
+	if resource == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "resource is required",
+		})
+		return
+	}
+	result, err := services.FederationService.WebFinger(resource)
+	if err != nil {
+		respondFederationError(c, err, "Failed to resolve resource")
+		return
+	}
+	respondActivityJSON(c, activitypub.JRDType, result)
+}
+
+// GetActor 获取用户的参与者文档 (GET /ap/users/:username)
+func GetActor(c *gin.Context) {
+	actor, err := services.FederationService.GetActor(c.Param("username"))
+	if err != nil {
+		respondFederationError(c, err, "Failed to get actor")
+		return
+	}
+	respondActivityJSON(c, activitypub.ContentType, actor)
+}
+
+// GetOutbox 获取用户的发件箱 (GET /ap/users/:username/outbox)
+func GetOutbox(c *gin.Context) {
+	outbox, err := services.FederationService.GetOutbox(c.Param("username"))
+	if err != nil {
+		respondFederationError(c, err, "Failed to get outbox")
+		return
+	}
+	respondActivityJSON(c, activitypub.ContentType, outbox)
+}
+
+// GetFollowersCollection 获取用户的关注者集合 (GET /ap/users/:username/followers)
+func GetFollowersCollection(c *gin.Context) {
+	followers, err := services.FederationService.GetFollowers(c.Param("username"))
+	if err != nil {
+		respondFederationError(c, err, "Failed to get followers")
+		return
+	}
+	respondActivityJSON(c, activitypub.ContentType, followers)
+}
+
+// PostInbox 接收远程实例投递的活动 (POST /ap/users/:username/inbox)
+func PostInbox(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxInboxBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := services.FederationService.HandleInbox(c.Param("username"), c.Request, body); err != nil {
+		respondFederationError(c, err, "Failed to accept activity")
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// GetNote 获取公开帖子的 Note 对象 (GET /ap/posts/:postId)
+func GetNote(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": services.ErrPostNotFound.Error(),
+		})
+		return
+	}
+	note, err := services.FederationService.GetNote(postID)
+	if err != nil {
+		respondFederationError(c, err, "Failed to get note")
+		return
+	}
+	respondActivityJSON(c, activitypub.ContentType, note)
+}
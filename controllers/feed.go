@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"yolo/feed"
+	"yolo/models"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// feedSize 订阅源包含的最新帖子数
+const feedSize = 20
+
+// GetUserAtomFeed 用户帖子的 Atom 订阅源 (GET /users/:username/feed.atom)
+func GetUserAtomFeed(c *gin.Context) {
+	serveUserFeed(c, feed.FormatAtom)
+}
+
+// GetUserRSSFeed 用户帖子的 RSS 订阅源 (GET /users/:username/feed.rss)
+func GetUserRSSFeed(c *gin.Context) {
+	serveUserFeed(c, feed.FormatRSS)
+}
+
+// GetUserJSONFeed 用户帖子的 JSON Feed 订阅源 (GET /users/:username/feed.json)
+func GetUserJSONFeed(c *gin.Context) {
+	serveUserFeed(c, feed.FormatJSON)
+}
+
+// requestOrigin 根据请求推断对外地址，用于生成订阅源中的绝对链接
+func requestOrigin(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// absoluteURL 将站内路径转换为绝对地址，已是绝对地址时原样返回
+func absoluteURL(origin, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return origin + path
+}
+
+// buildFeedItem 将帖子转换为订阅源条目，转发的原帖不可见时返回 false
+func buildFeedItem(origin string, post *models.Post) (feed.Item, bool) {
+	item := feed.Item{
+		ID:        "urn:uuid:" + post.ID.String(),
+		URL:       origin + "/api/v1/posts/" + post.ID.String(),
+		Content:   post.Content,
+		Published: post.Timestamp,
+		Updated:   post.UpdatedAt,
+	}
+
+	media := post.Media
+	switch {
+	case post.RepostOfID != nil:
+		if post.RepostOf == nil {
+			return item, false
+		}
+		item.URL = origin + "/api/v1/posts/" + post.RepostOf.ID.String()
+		item.Content = "Reposted @" + post.RepostOf.User.Username + ": " + post.RepostOf.Content
+		media = post.RepostOf.Media
+	case post.QuoteOfID != nil && post.QuoteOf != nil:
+		item.Content += "\n\nQuoting @" + post.QuoteOf.User.Username + ": " + post.QuoteOf.Content
+	}
+
+	for i := range media {
+		if media[i].Status != models.MediaStatusReady {
+			continue
+		}
+		item.Attachments = append(item.Attachments, feed.Attachment{
+			URL:      absoluteURL(origin, services.MediaService.URL(media[i].StorageKey)),
+			MIMEType: media[i].ContentType,
+			Size:     media[i].Size,
+			Title:    media[i].AltText,
+		})
+	}
+	return item, true
+}
+
+// notModified 按 If-None-Match（优先）或 If-Modified-Since 判断客户端缓存是否仍然有效
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if header := c.GetHeader("If-Modified-Since"); header != "" {
+		if since, err := http.ParseTime(header); err == nil {
+			return !lastModified.After(since)
+		}
+	}
+	return false
+}
+
+// serveUserFeed 生成用户最新公开帖子的订阅源，支持 ETag/Last-Modified 条件请求
+func serveUserFeed(c *gin.Context, format string) {
+	userID, ok := resolveUserParam(c)
+	if !ok {
+		return
+	}
+	user, err := services.UserService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// 以匿名身份查询，只包含公开帖子
+	posts, _, err := services.PostService.GetUserPosts(uuid.Nil, user.ID, 1, feedSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get user posts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	origin := requestOrigin(c)
+	userFeed := feed.Feed{
+		ID:          "urn:uuid:" + user.ID.String(),
+		Title:       user.Name + " (@" + user.Username + ")",
+		Description: "Posts by @" + user.Username,
+		HomeURL:     origin + "/api/v1/users/" + user.Username,
+		FeedURL:     origin + c.Request.URL.Path,
+		Author:      feed.Author{Name: user.Name, URL: origin + "/api/v1/users/" + user.Username},
+		Updated:     user.UpdatedAt,
+		Items:       make([]feed.Item, 0, len(posts)),
+	}
+	if user.Avatar != nil {
+		userFeed.IconURL = absoluteURL(origin, *user.Avatar)
+	}
+	for i := range posts {
+		if item, ok := buildFeedItem(origin, &posts[i]); ok {
+			userFeed.Items = append(userFeed.Items, item)
+		}
+	}
+
+	etag := userFeed.ETag(format)
+	lastModified := userFeed.LastModified()
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	c.Header("Cache-Control", "public, max-age=300")
+	if notModified(c, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	body, err := userFeed.Encode(format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to encode feed",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, feed.ContentTypes[format], body)
+}
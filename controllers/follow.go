@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FollowResponse 关注状态响应
+type FollowResponse struct {
+	Following      bool  `json:"following"`
+	FollowersCount int64 `json:"followersCount"`
+}
+
+// FollowUser 关注用户 (POST /users/:username/follow)
+func FollowUser(c *gin.Context) {
+	updateFollow(c, true)
+}
+
+// UnfollowUser 取消关注 (DELETE /users/:username/follow)
+func UnfollowUser(c *gin.Context) {
+	updateFollow(c, false)
+}
+
+// updateFollow 关注或取消关注并返回最新状态
+func updateFollow(c *gin.Context, follow bool) {
+	followerID := utils.GetUserIDFromContext(c)
+	followeeID, ok := resolveUserParam(c)
+	if !ok {
+		return
+	}
+
+	if _, err := services.UserService.GetUserByID(followeeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	var err error
+	if follow {
+		err = services.UserService.Follow(followerID, followeeID)
+	} else {
+		err = services.UserService.Unfollow(followerID, followeeID)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrCannotFollowSelf) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update follow",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildFollowResponse(followerID, followeeID))
+}
+
+// buildFollowResponse 构建关注状态
+func buildFollowResponse(followerID, followeeID uuid.UUID) FollowResponse {
+	followers, _ := services.UserService.CountFollows(followeeID)
+	return FollowResponse{
+		Following:      services.UserService.IsFollowing(followerID, followeeID),
+		FollowersCount: followers,
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LedgerPostingResponse 账户明细响应结构
+type LedgerPostingResponse struct {
+	ID            string          `json:"id"`
+	EntryID       string          `json:"entryId"`
+	Type          string          `json:"type"`
+	Amount        decimal.Decimal `json:"amount"`
+	Memo          string          `json:"memo"`
+	ReferenceType string          `json:"referenceType,omitempty"`
+	ReferenceID   *string         `json:"referenceId,omitempty"`
+	CreatedAt     string          `json:"createdAt"`
+}
+
+// LedgerStatementResponse 账户明细列表响应结构
+type LedgerStatementResponse struct {
+	Postings []LedgerPostingResponse `json:"postings"`
+	Balance  decimal.Decimal         `json:"balance"`
+	PageInfo PageInfo                `json:"pageInfo"`
+}
+
+// GiftRequest 赠送 YOLO 请求结构
+type GiftRequest struct {
+	Amount decimal.Decimal `json:"amount"`
+	Memo   string          `json:"memo"`
+}
+
+// buildLedgerPostingResponse 转换为账户明细响应
+func buildLedgerPostingResponse(posting *models.LedgerPosting) LedgerPostingResponse {
+	response := LedgerPostingResponse{
+		ID:            posting.ID.String(),
+		EntryID:       posting.EntryID.String(),
+		Type:          posting.Entry.Type,
+		Amount:        posting.Amount,
+		Memo:          posting.Entry.Memo,
+		ReferenceType: posting.Entry.ReferenceType,
+		CreatedAt:     posting.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if posting.Entry.ReferenceID != nil {
+		referenceID := posting.Entry.ReferenceID.String()
+		response.ReferenceID = &referenceID
+	}
+	return response
+}
+
+// respondLedgerError 账本接口的错误响应
+func respondLedgerError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRecipientMissing):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidGift), errors.Is(err, services.ErrGiftToSelf),
+		errors.Is(err, services.ErrGiftMemoTooLong), errors.Is(err, services.ErrInsufficientBalance):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// GetUserLedger 获取当前用户的 YOLO 账户明细 (GET /user/ledger)
+func GetUserLedger(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	postings, total, err := services.LedgerService.GetStatement(userID, page, limit)
+	if err != nil {
+		respondLedgerError(c, err, "Failed to get ledger")
+		return
+	}
+	balance, err := services.LedgerService.GetBalance(userID)
+	if err != nil {
+		respondLedgerError(c, err, "Failed to get ledger")
+		return
+	}
+
+	responses := make([]LedgerPostingResponse, 0, len(postings))
+	for i := range postings {
+		responses = append(responses, buildLedgerPostingResponse(&postings[i]))
+	}
+	c.JSON(http.StatusOK, LedgerStatementResponse{
+		Postings: responses,
+		Balance:  balance,
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+			TotalPosts:  total,
+		},
+	})
+}
+
+// SendGift 向其他用户赠送 YOLO (POST /users/:username/gifts)
+func SendGift(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req GiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	entry, err := services.LedgerService.Gift(userID, c.Param("username"), req.Amount, req.Memo)
+	if err != nil {
+		respondLedgerError(c, err, "Failed to send gift")
+		return
+	}
+	balance, err := services.LedgerService.GetBalance(userID)
+	if err != nil {
+		respondLedgerError(c, err, "Failed to send gift")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      entry.ID.String(),
+		"amount":  entry.Postings[1].Amount, // 收款方的分录
+		"memo":    entry.Memo,
+		"balance": balance,
+	})
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"yolo/models"
+	"yolo/services"
+	"yolo/storage"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UpdateMediaRequest 更新媒体请求
+type UpdateMediaRequest struct {
+	AltText string `json:"alt_text" binding:"max=1000"`
+}
+
+// MediaResponse 媒体附件响应，处理完成前只有基础信息
+type MediaResponse struct {
+	ID           string  `json:"id"`
+	Type         string  `json:"type"`   // image/video
+	Status       string  `json:"status"` // processing/ready/failed
+	URL          string  `json:"url"`
+	ThumbnailURL *string `json:"thumbnailUrl,omitempty"`
+	ContentType  string  `json:"contentType"`
+	Size         int64   `json:"size"`
+	Width        int     `json:"width,omitempty"`
+	Height       int     `json:"height,omitempty"`
+	DurationMs   int64   `json:"durationMs,omitempty"`
+	AltText      string  `json:"altText"`
+	Blurhash     *string `json:"blurhash,omitempty"`
+	Error        *string `json:"error,omitempty"`
+}
+
+// buildMediaResponse 将媒体模型转换为响应格式
+func buildMediaResponse(media *models.Media) MediaResponse {
+	response := MediaResponse{
+		ID:          media.ID.String(),
+		Type:        media.Type,
+		Status:      media.Status,
+		URL:         services.MediaService.URL(media.StorageKey),
+		ContentType: media.ContentType,
+		Size:        media.Size,
+		Width:       media.Width,
+		Height:      media.Height,
+		DurationMs:  media.DurationMs,
+		AltText:     media.AltText,
+		Blurhash:    media.Blurhash,
+		Error:       media.Error,
+	}
+	if media.ThumbnailKey != nil {
+		thumbnailURL := services.MediaService.URL(*media.ThumbnailKey)
+		response.ThumbnailURL = &thumbnailURL
+	}
+	return response
+}
+
+// respondMediaError 将媒体服务错误映射为HTTP响应
+func respondMediaError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrUnsupportedMedia):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrMediaTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// parseMediaIDParam 解析路径中的媒体ID
+func parseMediaIDParam(c *gin.Context) (uuid.UUID, bool) {
+	mediaID, err := uuid.Parse(c.Param("mediaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid media ID",
+		})
+		return uuid.Nil, false
+	}
+	return mediaID, true
+}
+
+// UploadMedia 上传图片或短视频 (POST /media)，multipart 字段 file 和可选的 alt_text
+// 返回 202，后台处理完成后状态变为 ready，发帖时通过 media_ids 附加
+func UploadMedia(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	// 多留1MB给multipart的其他字段
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxVideoBytes+1<<20)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondMediaError(c, services.ErrMediaTooLarge, "")
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "File is required",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	altText := c.PostForm("alt_text")
+	if len([]rune(altText)) > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Alt text must be at most 1000 characters",
+		})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer src.Close()
+
+	media, err := services.MediaService.Upload(c.Request.Context(), userID, src, altText)
+	if err != nil {
+		respondMediaError(c, err, "Failed to upload media")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, buildMediaResponse(media))
+}
+
+// GetMedia 查询自己上传的媒体及处理状态 (GET /media/:mediaId)
+func GetMedia(c *gin.Context) {
+	mediaID, ok := parseMediaIDParam(c)
+	if !ok {
+		return
+	}
+
+	media, err := services.MediaService.GetMedia(utils.GetUserIDFromContext(c), mediaID)
+	if err != nil {
+		respondMediaError(c, err, "Failed to get media")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildMediaResponse(media))
+}
+
+// UpdateMedia 更新媒体的替代文本 (PATCH /media/:mediaId)
+func UpdateMedia(c *gin.Context) {
+	mediaID, ok := parseMediaIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	media, err := services.MediaService.UpdateAltText(utils.GetUserIDFromContext(c), mediaID, req.AltText)
+	if err != nil {
+		respondMediaError(c, err, "Failed to update media")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildMediaResponse(media))
+}
+
+// ServeMediaFile 通过存储抽象读取媒体文件 (GET /media/files/*key)
+// 文件key为随机ID，内容不可变，允许长期缓存
+func ServeMediaFile(c *gin.Context) {
+	key, err := storage.CleanKey(c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid file key",
+		})
+		return
+	}
+
+	file, err := services.MediaService.Storage().Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "File not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to read file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, file, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateReportRequest 举报请求
+type CreateReportRequest struct {
+	TargetType string `json:"target_type" binding:"required,oneof=post user stock"`
+	TargetID   string `json:"target_id" binding:"required,uuid"`
+	Reason     string `json:"reason" binding:"required"`            // spam/harassment/hate_speech/violence/scam/misinformation/impersonation/other
+	Details    string `json:"details" binding:"omitempty,max=1000"` // 原因为other时必填
+}
+
+// ResolveReportRequest 处理举报请求
+type ResolveReportRequest struct {
+	Action        string `json:"action" binding:"required,oneof=hide_post suspend_user warn"`
+	Note          string `json:"note" binding:"omitempty,max=1000"`
+	DurationHours int    `json:"duration_hours" binding:"omitempty,min=1,max=8760"` // 封禁时长，默认7天
+}
+
+// DismissReportRequest 驳回举报请求
+type DismissReportRequest struct {
+	Note string `json:"note" binding:"omitempty,max=1000"`
+}
+
+// ReportResponse 举报响应结构
+type ReportResponse struct {
+	ID          string          `json:"id"`
+	Reporter    *UserPublicInfo `json:"reporter,omitempty"` // 仅审核员可见
+	Automatic   bool            `json:"automatic"`          // 由发帖自动过滤拦截，没有举报人
+	TargetType  string          `json:"targetType"`
+	TargetID    string          `json:"targetId"`
+	TargetUser  *UserPublicInfo `json:"targetUser,omitempty"`
+	Reason      string          `json:"reason"`
+	Details     string          `json:"details"`
+	Status      string          `json:"status"` // open/claimed/resolved/dismissed
+	ModeratorID *string         `json:"moderatorId,omitempty"`
+	ActionID    *string         `json:"actionId,omitempty"`
+	ClaimedAt   *string         `json:"claimedAt,omitempty"`
+	ClosedAt    *string         `json:"closedAt,omitempty"`
+	CreatedAt   string          `json:"createdAt"`
+}
+
+// ReportsResponse 审核队列响应
+type ReportsResponse struct {
+	Reports  []ReportResponse `json:"reports"`
+	PageInfo PageInfo         `json:"pageInfo"`
+}
+
+// ModerationActionResponse 审核处理记录，不包含举报人和审核员信息
+type ModerationActionResponse struct {
+	ID         string  `json:"id"`
+	Action     string  `json:"action"` // hide_post/suspend_user/warn/dismiss
+	TargetType string  `json:"targetType"`
+	TargetID   string  `json:"targetId"`
+	Reason     string  `json:"reason"`
+	Note       string  `json:"note"`
+	ExpiresAt  *string `json:"expiresAt,omitempty"` // 封禁截止时间
+	CreatedAt  string  `json:"createdAt"`
+}
+
+// ModerationActionsResponse 审核处理记录列表响应
+type ModerationActionsResponse struct {
+	Actions  []ModerationActionResponse `json:"actions"`
+	PageInfo PageInfo                   `json:"pageInfo"`
+}
+
+// formatOptionalTime 格式化可为空的时间
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.UTC().Format("2006-01-02T15:04:05Z")
+	return &formatted
+}
+
+// buildReportResponse 将举报模型转换为响应格式
+func buildReportResponse(report *models.Report) ReportResponse {
+	response := ReportResponse{
+		ID:         report.ID.String(),
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID.String(),
+		Reason:     report.Reason,
+		Details:    report.Details,
+		Status:     report.Status,
+		Automatic:  report.ReporterID == nil,
+		ClaimedAt:  formatOptionalTime(report.ClaimedAt),
+		ClosedAt:   formatOptionalTime(report.ClosedAt),
+		CreatedAt:  report.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if report.Reporter != nil {
+		reporter := buildUserPublicInfo(*report.Reporter)
+		response.Reporter = &reporter
+	}
+	if report.TargetUser.ID != uuid.Nil {
+		targetUser := buildUserPublicInfo(report.TargetUser)
+		response.TargetUser = &targetUser
+	}
+	if report.ModeratorID != nil {
+		moderatorID := report.ModeratorID.String()
+		response.ModeratorID = &moderatorID
+	}
+	if report.ActionID != nil {
+		actionID := report.ActionID.String()
+		response.ActionID = &actionID
+	}
+	return response
+}
+
+// buildModerationActionResponse 将处理记录转换为响应格式
+func buildModerationActionResponse(action *models.ModerationAction) ModerationActionResponse {
+	return ModerationActionResponse{
+		ID:         action.ID.String(),
+		Action:     action.Action,
+		TargetType: action.TargetType,
+		TargetID:   action.TargetID.String(),
+		Reason:     action.Reason,
+		Note:       action.Note,
+		ExpiresAt:  formatOptionalTime(action.ExpiresAt),
+		CreatedAt:  action.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// respondModerationError 将举报与审核错误转换为HTTP响应
+func respondModerationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrReportNotFound), errors.Is(err, services.ErrReportTargetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidReportReason), errors.Is(err, services.ErrCannotReportSelf),
+		errors.Is(err, services.ErrInvalidModerationAction):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrNotModerator):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAlreadyReported), errors.Is(err, services.ErrReportClaimed),
+		errors.Is(err, services.ErrReportClosed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// parseReportIDParam 解析路径中的举报ID
+func parseReportIDParam(c *gin.Context) (uuid.UUID, bool) {
+	reportID, err := uuid.Parse(c.Param("reportId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid report ID",
+		})
+		return uuid.Nil, false
+	}
+	return reportID, true
+}
+
+// CreateReport 举报帖子、用户或股票 (POST /reports)
+func CreateReport(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	report, err := services.ModerationService.CreateReport(userID, services.CreateReportOptions{
+		TargetType: req.TargetType,
+		TargetID:   uuid.MustParse(req.TargetID),
+		Reason:     req.Reason,
+		Details:    req.Details,
+	})
+	if err != nil {
+		respondModerationError(c, err, "Failed to create report")
+		return
+	}
+
+	c.JSON(http.StatusCreated, buildReportResponse(report))
+}
+
+// GetModerationActions 获取针对自己的审核处理记录 (GET /user/moderation-actions)
+func GetModerationActions(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	actions, total, err := services.ModerationService.GetActionsForUser(userID, page, limit)
+	if err != nil {
+		respondModerationError(c, err, "Failed to get moderation actions")
+		return
+	}
+
+	responses := make([]ModerationActionResponse, 0, len(actions))
+	for i := range actions {
+		responses = append(responses, buildModerationActionResponse(&actions[i]))
+	}
+
+	c.JSON(http.StatusOK, ModerationActionsResponse{
+		Actions: responses,
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+			TotalPosts:  total,
+		},
+	})
+}
+
+// GetReports 审核队列 (GET /moderation/reports?status=open)，仅审核员可用
+func GetReports(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	status := c.DefaultQuery("status", models.ReportStatusOpen)
+	switch status {
+	case models.ReportStatusOpen, models.ReportStatusClaimed, models.ReportStatusResolved, models.ReportStatusDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid report status",
+		})
+		return
+	}
+
+	reports, total, err := services.ModerationService.GetReports(userID, status, page, limit)
+	if err != nil {
+		respondModerationError(c, err, "Failed to get reports")
+		return
+	}
+
+	responses := make([]ReportResponse, 0, len(reports))
+	for i := range reports {
+		responses = append(responses, buildReportResponse(&reports[i]))
+	}
+
+	c.JSON(http.StatusOK, ReportsResponse{
+		Reports: responses,
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+			TotalPosts:  total,
+		},
+	})
+}
+
+// ClaimReport 认领举报 (POST /moderation/reports/:reportId/claim)
+func ClaimReport(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	reportID, ok := parseReportIDParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.ModerationService.ClaimReport(userID, reportID)
+	if err != nil {
+		respondModerationError(c, err, "Failed to claim report")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildReportResponse(report))
+}
+
+// ResolveReport 处理举报 (POST /moderation/reports/:reportId/resolve)
+func ResolveReport(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	reportID, ok := parseReportIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	action, err := services.ModerationService.ResolveReport(userID, reportID, services.ResolveOptions{
+		Action:   req.Action,
+		Note:     req.Note,
+		Duration: time.Duration(req.DurationHours) * time.Hour,
+	})
+	if err != nil {
+		respondModerationError(c, err, "Failed to resolve report")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildModerationActionResponse(action))
+}
+
+// DismissReport 驳回举报 (POST /moderation/reports/:reportId/dismiss)
+func DismissReport(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	reportID, ok := parseReportIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req DismissReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	action, err := services.ModerationService.DismissReport(userID, reportID, req.Note)
+	if err != nil {
+		respondModerationError(c, err, "Failed to dismiss report")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildModerationActionResponse(action))
+}
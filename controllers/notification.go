@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationResponse 通知响应结构
+type NotificationResponse struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     *UserPublicInfo `json:"actor,omitempty"`
+	PostID    *string         `json:"postId,omitempty"`
+	Data      map[string]any  `json:"data,omitempty"`
+	Read      bool            `json:"read"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+// NotificationsResponse 通知列表响应
+type NotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unreadCount"`
+	PageInfo      PageInfo               `json:"pageInfo"`
+}
+
+// MarkNotificationsReadRequest 标记已读请求，ids为空时标记全部
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids" binding:"omitempty,dive,uuid"`
+}
+
+// UpdateNotificationPreferencesRequest 更新通知偏好请求
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []services.NotificationPreferenceSetting `json:"preferences" binding:"required,min=1"`
+}
+
+// buildNotificationResponse 将通知模型转换为响应格式
+func buildNotificationResponse(notification *models.Notification) NotificationResponse {
+	response := NotificationResponse{
+		ID:        notification.ID.String(),
+		Type:      notification.Type,
+		Data:      notification.Data,
+		Read:      notification.ReadAt != nil,
+		CreatedAt: notification.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if notification.Actor != nil {
+		actor := buildUserPublicInfo(*notification.Actor)
+		response.Actor = &actor
+	}
+	if notification.PostID != nil {
+		postID := notification.PostID.String()
+		response.PostID = &postID
+	}
+	return response
+}
+
+// GetNotifications 获取当前用户的通知 (GET /notifications)
+func GetNotifications(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	// 获取分页参数
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, total, err := services.NotificationService.GetNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get notifications",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	unread, err := services.NotificationService.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get notifications",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 转换为响应格式
+	responses := make([]NotificationResponse, 0, len(notifications))
+	for i := range notifications {
+		responses = append(responses, buildNotificationResponse(&notifications[i]))
+	}
+
+	// 计算总页数
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, NotificationsResponse{
+		Notifications: responses,
+		UnreadCount:   unread,
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  totalPages,
+			TotalPosts:  total,
+		},
+	})
+}
+
+// GetUnreadNotificationCount 获取未读通知数量 (GET /notifications/unread-count)
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	count, err := services.NotificationService.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to count unread notifications",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unreadCount": count,
+	})
+}
+
+// MarkNotificationsRead 标记通知为已读 (POST /notifications/read)
+func MarkNotificationsRead(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		ids = append(ids, uuid.MustParse(id))
+	}
+
+	updated, err := services.NotificationService.MarkRead(userID, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to mark notifications as read",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"updated": updated,
+	})
+}
+
+// GetNotificationPreferences 获取通知偏好 (GET /notifications/preferences)
+func GetNotificationPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	prefs, err := services.NotificationService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get notification preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": prefs,
+	})
+}
+
+// UpdateNotificationPreferences 更新通知偏好 (PUT /notifications/preferences)
+func UpdateNotificationPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	prefs, err := services.NotificationService.UpdatePreferences(userID, req.Preferences)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidNotificationType) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to update notification preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": prefs,
+	})
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PlaceOrderRequest 下单请求结构，quantity 为股数
+type PlaceOrderRequest struct {
+	Side        string          `json:"side" binding:"required"`
+	Type        string          `json:"type"`
+	TimeInForce string          `json:"time_in_force"`
+	Price       decimal.Decimal `json:"price"`
+	Quantity    decimal.Decimal `json:"quantity"`
+}
+
+// OrderResponse 订单响应结构
+type OrderResponse struct {
+	ID          string          `json:"id"`
+	Symbol      string          `json:"symbol"`
+	Side        string          `json:"side"`
+	Type        string          `json:"type"`
+	TimeInForce string          `json:"timeInForce"`
+	Price       decimal.Decimal `json:"price"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Filled      decimal.Decimal `json:"filled"`
+	Remaining   decimal.Decimal `json:"remaining"`
+	Status      string          `json:"status"`
+	CreatedAt   string          `json:"createdAt"`
+	UpdatedAt   string          `json:"updatedAt"`
+}
+
+// OrderFillResponse 成交明细响应结构，liquidity 为 book（与挂单成交）或 pool（与流动性池成交）
+type OrderFillResponse struct {
+	ID         string          `json:"id"`
+	Shares     decimal.Decimal `json:"shares"`
+	Price      decimal.Decimal `json:"price"`
+	TotalValue decimal.Decimal `json:"totalValue"`
+	Liquidity  string          `json:"liquidity"`
+}
+
+// PlaceOrderResponse 下单响应结构
+type PlaceOrderResponse struct {
+	Order    OrderResponse       `json:"order"`
+	Fills    []OrderFillResponse `json:"fills"`
+	NewPrice decimal.Decimal     `json:"newPrice"`
+}
+
+// OrderListResponse 订单列表响应结构
+type OrderListResponse struct {
+	Orders   []OrderResponse `json:"orders"`
+	PageInfo PageInfo        `json:"pageInfo"`
+}
+
+// OrderBookLevelResponse 订单簿价位响应结构
+type OrderBookLevelResponse struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Orders   int64           `json:"orders"`
+}
+
+// OrderBookResponse 订单簿响应结构
+type OrderBookResponse struct {
+	Symbol   string                   `json:"symbol"`
+	Bids     []OrderBookLevelResponse `json:"bids"`
+	Asks     []OrderBookLevelResponse `json:"asks"`
+	MidPrice decimal.Decimal          `json:"midPrice"`
+}
+
+// buildOrderResponse 转换为订单响应
+func buildOrderResponse(order *models.Order, symbol string) OrderResponse {
+	return OrderResponse{
+		ID:          order.ID.String(),
+		Symbol:      symbol,
+		Side:        order.Side,
+		Type:        order.Type,
+		TimeInForce: order.TimeInForce,
+		Price:       order.Price,
+		Quantity:    order.Quantity,
+		Filled:      order.Filled,
+		Remaining:   order.Remaining(),
+		Status:      order.Status,
+		CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:   order.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// buildOrderBookLevels 转换为订单簿价位响应
+func buildOrderBookLevels(levels []services.OrderBookLevel) []OrderBookLevelResponse {
+	responses := make([]OrderBookLevelResponse, 0, len(levels))
+	for _, level := range levels {
+		responses = append(responses, OrderBookLevelResponse{
+			Price:    level.Price,
+			Quantity: level.Quantity,
+			Orders:   level.Orders,
+		})
+	}
+	return responses
+}
+
+// respondOrderError 订单接口的错误响应，其余错误按股票接口处理
+func respondOrderError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrOrderNotOpen), errors.Is(err, services.ErrOrderNotFillable):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidOrderType), errors.Is(err, services.ErrInvalidTimeInForce),
+		errors.Is(err, services.ErrInvalidOrderPrice), errors.Is(err, services.ErrInvalidOrderQuantity),
+		errors.Is(err, services.ErrInvalidOrderStatus), errors.Is(err, services.ErrOrderTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	default:
+		respondStockError(c, err, message)
+	}
+}
+
+// PlaceOrder 下单并撮合 (POST /stocks/:symbol/orders)
+func PlaceOrder(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := services.OrderService.PlaceOrder(userID, c.Param("symbol"), services.PlaceOrderOptions{
+		Side:        req.Side,
+		Type:        req.Type,
+		TimeInForce: req.TimeInForce,
+		Price:       req.Price,
+		Quantity:    req.Quantity,
+	})
+	if err != nil {
+		respondOrderError(c, err, "Failed to place order")
+		return
+	}
+
+	fills := make([]OrderFillResponse, 0, len(result.Trades))
+	for _, trade := range result.Trades {
+		liquidity := "book"
+		if trade.BuyerID == nil || trade.SellerID == nil {
+			liquidity = "pool"
+		}
+		fills = append(fills, OrderFillResponse{
+			ID:         trade.ID.String(),
+			Shares:     trade.Amount,
+			Price:      trade.Price,
+			TotalValue: trade.TotalValue,
+			Liquidity:  liquidity,
+		})
+	}
+	c.JSON(http.StatusCreated, PlaceOrderResponse{
+		Order:    buildOrderResponse(&result.Order, services.NormalizeSymbol(c.Param("symbol"))),
+		Fills:    fills,
+		NewPrice: result.NewPrice,
+	})
+}
+
+// CancelOrder 撤销挂单 (DELETE /orders/:orderId)
+func CancelOrder(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	orderID, err := uuid.Parse(c.Param("orderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order ID",
+		})
+		return
+	}
+
+	order, err := services.OrderService.CancelOrder(userID, orderID)
+	if err != nil {
+		respondOrderError(c, err, "Failed to cancel order")
+		return
+	}
+	c.JSON(http.StatusOK, buildOrderResponse(order, order.Stock.Symbol))
+}
+
+// GetUserOrders 获取当前用户的订单 (GET /user/orders?status=open)
+func GetUserOrders(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	orders, total, err := services.OrderService.GetUserOrders(userID, c.Query("status"), page, limit)
+	if err != nil {
+		respondOrderError(c, err, "Failed to get orders")
+		return
+	}
+
+	responses := make([]OrderResponse, 0, len(orders))
+	for i := range orders {
+		responses = append(responses, buildOrderResponse(&orders[i], orders[i].Stock.Symbol))
+	}
+	c.JSON(http.StatusOK, OrderListResponse{
+		Orders: responses,
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+			TotalPosts:  total,
+		},
+	})
+}
+
+// GetOrderBook 获取订单簿 (GET /stocks/:symbol/orderbook?depth=20)
+func GetOrderBook(c *gin.Context) {
+	depth := services.DefaultOrderBookDepth
+	if value := c.Query("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid depth",
+			})
+			return
+		}
+		depth = parsed
+	}
+
+	book, err := services.OrderService.GetOrderBook(c.Param("symbol"), depth)
+	if err != nil {
+		respondOrderError(c, err, "Failed to get order book")
+		return
+	}
+	c.JSON(http.StatusOK, OrderBookResponse{
+		Symbol:   services.NormalizeSymbol(c.Param("symbol")),
+		Bids:     buildOrderBookLevels(book.Bids),
+		Asks:     buildOrderBookLevels(book.Asks),
+		MidPrice: book.MidPrice,
+	})
+}
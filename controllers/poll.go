@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreatePollRequest 发帖时附带的投票
+type CreatePollRequest struct {
+	Options        []string  `json:"options" binding:"required"`   // 2到4个选项
+	ClosesAt       time.Time `json:"closes_at" binding:"required"` // 截止时间（RFC3339），发布后5分钟到7天
+	MultipleChoice bool      `json:"multiple_choice"`              // 是否多选
+	WeightByShares bool      `json:"weight_by_shares"`             // 按持有作者股票的数量计票，仅创作者可用
+}
+
+// VotePollRequest 投票请求
+type VotePollRequest struct {
+	OptionIDs []string `json:"option_ids" binding:"required,min=1,max=4,dive,uuid"` // 单选时只能有一个
+}
+
+// PollResponse 投票响应，投票前且未截止时不返回票数
+type PollResponse struct {
+	ID             string               `json:"id"`
+	MultipleChoice bool                 `json:"multipleChoice"`
+	WeightByShares bool                 `json:"weightByShares"`
+	ClosesAt       string               `json:"closesAt"`
+	Closed         bool                 `json:"closed"`
+	Voted          bool                 `json:"voted"`                 // 当前用户是否已投票
+	OwnChoices     []string             `json:"ownChoices"`            // 当前用户选择的选项ID
+	Voters         *int64               `json:"voters,omitempty"`      // 投票人数
+	TotalWeight    *float64             `json:"totalWeight,omitempty"` // 加权总票数，仅加权投票返回
+	Options        []PollOptionResponse `json:"options"`
+}
+
+// PollOptionResponse 投票选项
+type PollOptionResponse struct {
+	ID     string   `json:"id"`
+	Text   string   `json:"text"`
+	Votes  *int64   `json:"votes,omitempty"`  // 选择人数
+	Weight *float64 `json:"weight,omitempty"` // 加权票数，仅加权投票返回
+}
+
+// buildPollResponse 将投票模型转换为响应格式，需要预加载查看者自己的选择
+func buildPollResponse(poll *models.Poll) PollResponse {
+	response := PollResponse{
+		ID:             poll.ID.String(),
+		MultipleChoice: poll.MultipleChoice,
+		WeightByShares: poll.WeightByShares,
+		ClosesAt:       poll.ClosesAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Closed:         poll.Closed(time.Now()),
+		Voted:          len(poll.Votes) > 0,
+		OwnChoices:     make([]string, 0, len(poll.Votes)),
+		Options:        make([]PollOptionResponse, 0, len(poll.Options)),
+	}
+	for _, vote := range poll.Votes {
+		response.OwnChoices = append(response.OwnChoices, vote.OptionID.String())
+	}
+
+	// 投票后或截止后才公开结果，避免影响投票
+	showResults := response.Voted || response.Closed
+	if showResults {
+		response.Voters = &poll.Voters
+		if poll.WeightByShares {
+			response.TotalWeight = &poll.TotalWeight
+		}
+	}
+	for i := range poll.Options {
+		option := &poll.Options[i]
+		item := PollOptionResponse{ID: option.ID.String(), Text: option.Text}
+		if showResults {
+			item.Votes = &option.Votes
+			if poll.WeightByShares {
+				item.Weight = &option.Weight
+			}
+		}
+		response.Options = append(response.Options, item)
+	}
+	return response
+}
+
+// GetPoll 获取帖子中的投票 (GET /posts/:postId/poll)
+func GetPoll(c *gin.Context) {
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	poll, err := services.PollService.GetPoll(utils.GetUserIDFromContext(c), postID)
+	if err != nil {
+		respondPostError(c, err, "Failed to get poll")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPollResponse(poll))
+}
+
+// VotePoll 投票 (POST /posts/:postId/poll/votes)，每人只能投一次，返回投票结果
+func VotePoll(c *gin.Context) {
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req VotePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	optionIDs := make([]uuid.UUID, 0, len(req.OptionIDs))
+	for _, id := range req.OptionIDs {
+		optionIDs = append(optionIDs, uuid.MustParse(id))
+	}
+
+	poll, err := services.PollService.Vote(utils.GetUserIDFromContext(c), postID, optionIDs)
+	if err != nil {
+		respondPostError(c, err, "Failed to vote")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPollResponse(poll))
+}
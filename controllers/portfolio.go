@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PortfolioLotResponse 先进先出批次响应结构
+type PortfolioLotResponse struct {
+	Quantity   decimal.Decimal `json:"quantity"`
+	Cost       decimal.Decimal `json:"cost"`
+	Price      decimal.Decimal `json:"price"`
+	AcquiredAt string          `json:"acquiredAt"`
+}
+
+// PortfolioPositionResponse 持仓估值响应结构，allocation 为占组合总价值的百分比
+type PortfolioPositionResponse struct {
+	Symbol        string                 `json:"symbol"`
+	Name          string                 `json:"name"`
+	Image         *string                `json:"img"`
+	Quantity      decimal.Decimal        `json:"quantity"`
+	Locked        decimal.Decimal        `json:"locked"`
+	AverageCost   decimal.Decimal        `json:"averageCost"`
+	CostBasis     decimal.Decimal        `json:"costBasis"`
+	MidPrice      decimal.Decimal        `json:"midPrice"`
+	Value         decimal.Decimal        `json:"value"`
+	RealizedPnL   decimal.Decimal        `json:"realizedPnl"`
+	UnrealizedPnL decimal.Decimal        `json:"unrealizedPnl"`
+	Allocation    float64                `json:"allocation"`
+	Lots          []PortfolioLotResponse `json:"lots"`
+}
+
+// PortfolioResponse 投资组合响应结构
+type PortfolioResponse struct {
+	Positions      []PortfolioPositionResponse `json:"positions"`
+	Cash           decimal.Decimal             `json:"cash"`
+	OpenOrders     decimal.Decimal             `json:"openOrders"`
+	HoldingsValue  decimal.Decimal             `json:"holdingsValue"`
+	TotalValue     decimal.Decimal             `json:"totalValue"`
+	CostBasis      decimal.Decimal             `json:"costBasis"`
+	RealizedPnL    decimal.Decimal             `json:"realizedPnl"`
+	UnrealizedPnL  decimal.Decimal             `json:"unrealizedPnl"`
+	CashAllocation float64                     `json:"cashAllocation"`
+}
+
+// PortfolioSnapshotResponse 每日组合价值响应结构
+type PortfolioSnapshotResponse struct {
+	Date          string          `json:"date"`
+	TotalValue    decimal.Decimal `json:"totalValue"`
+	Cash          decimal.Decimal `json:"cash"`
+	OpenOrders    decimal.Decimal `json:"openOrders"`
+	HoldingsValue decimal.Decimal `json:"holdingsValue"`
+	CostBasis     decimal.Decimal `json:"costBasis"`
+	RealizedPnL   decimal.Decimal `json:"realizedPnl"`
+	UnrealizedPnL decimal.Decimal `json:"unrealizedPnl"`
+}
+
+// PortfolioHistoryResponse 组合价值历史响应结构
+type PortfolioHistoryResponse struct {
+	Username  string                      `json:"username"`
+	Snapshots []PortfolioSnapshotResponse `json:"snapshots"`
+}
+
+// buildPortfolioResponse 转换为投资组合响应
+func buildPortfolioResponse(portfolio *services.Portfolio) PortfolioResponse {
+	response := PortfolioResponse{
+		Positions:      make([]PortfolioPositionResponse, 0, len(portfolio.Positions)),
+		Cash:           portfolio.Cash,
+		OpenOrders:     portfolio.OpenOrders,
+		HoldingsValue:  portfolio.HoldingsValue,
+		TotalValue:     portfolio.TotalValue,
+		CostBasis:      portfolio.CostBasis,
+		RealizedPnL:    portfolio.RealizedPnL,
+		UnrealizedPnL:  portfolio.UnrealizedPnL,
+		CashAllocation: portfolio.CashAllocation,
+	}
+	for _, position := range portfolio.Positions {
+		lots := make([]PortfolioLotResponse, 0, len(position.Lots))
+		for _, lot := range position.Lots {
+			lots = append(lots, PortfolioLotResponse{
+				Quantity:   lot.Quantity,
+				Cost:       lot.Cost,
+				Price:      lot.Price,
+				AcquiredAt: lot.AcquiredAt.Format("2006-01-02T15:04:05Z"),
+			})
+		}
+		response.Positions = append(response.Positions, PortfolioPositionResponse{
+			Symbol:        position.Stock.Symbol,
+			Name:          position.Stock.Name,
+			Image:         position.Stock.Image,
+			Quantity:      position.Quantity,
+			Locked:        position.Locked,
+			AverageCost:   position.AverageCost,
+			CostBasis:     position.CostBasis,
+			MidPrice:      position.MidPrice,
+			Value:         position.Value,
+			RealizedPnL:   position.RealizedPnL,
+			UnrealizedPnL: position.UnrealizedPnL,
+			Allocation:    position.Allocation,
+			Lots:          lots,
+		})
+	}
+	return response
+}
+
+// buildPortfolioSnapshotResponse 转换为每日组合价值响应
+func buildPortfolioSnapshotResponse(snapshot *models.PortfolioSnapshot) PortfolioSnapshotResponse {
+	return PortfolioSnapshotResponse{
+		Date:          snapshot.Date,
+		TotalValue:    snapshot.TotalValue,
+		Cash:          snapshot.Cash,
+		OpenOrders:    snapshot.OpenOrders,
+		HoldingsValue: snapshot.HoldingsValue,
+		CostBasis:     snapshot.CostBasis,
+		RealizedPnL:   snapshot.RealizedPnL,
+		UnrealizedPnL: snapshot.UnrealizedPnL,
+	}
+}
+
+// GetUserPortfolio 获取当前用户的投资组合 (GET /user/portfolio)
+func GetUserPortfolio(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	portfolio, err := services.PortfolioService.GetPortfolio(userID)
+	if err != nil {
+		respondStockError(c, err, "Failed to get portfolio")
+		return
+	}
+	c.JSON(http.StatusOK, buildPortfolioResponse(portfolio))
+}
+
+// GetUserPortfolioHistory 获取用户最近若干天的组合价值，用于资料页的走势图 (GET /users/:username/portfolio/history?days=30)
+func GetUserPortfolioHistory(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid days",
+			})
+			return
+		}
+		days = parsed
+	}
+
+	user, err := services.UserService.GetUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	snapshots, err := services.PortfolioService.GetHistory(user.ID, days)
+	if errors.Is(err, services.ErrInvalidPortfolioDays) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid days",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		respondStockError(c, err, "Failed to get portfolio history")
+		return
+	}
+
+	responses := make([]PortfolioSnapshotResponse, 0, len(snapshots))
+	for i := range snapshots {
+		responses = append(responses, buildPortfolioSnapshotResponse(&snapshots[i]))
+	}
+	c.JSON(http.StatusOK, PortfolioHistoryResponse{
+		Username:  user.Username,
+		Snapshots: responses,
+	})
+}
@@ -1,25 +1,32 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+	"yolo/models"
 	"yolo/services"
 	"yolo/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // CreatePostRequest 创建帖子请求
 type CreatePostRequest struct {
-	Content string `json:"content" binding:"required,min=1,max=1000"`
+	Content     string             `json:"content" binding:"max=1000"`                                            // 有附件时可以为空
+	QuotePostID string             `json:"quote_post_id" binding:"omitempty,uuid"`                                // 引用的原帖ID（可选）
+	Visibility  string             `json:"visibility" binding:"omitempty,oneof=public followers only_me holders"` // 可见范围，默认public
+	MediaIDs    []string           `json:"media_ids" binding:"omitempty,max=4,dive,uuid"`                         // 已上传的媒体ID，按顺序展示
+	Draft       bool               `json:"draft"`                                                                 // 保存为草稿
+	PublishAt   *time.Time         `json:"publish_at"`                                                            // 定时发布时间（RFC3339）
+	Poll        *CreatePollRequest `json:"poll"`                                                                  // 附带的投票（可选）
 }
 
 // CreatePostResponse 创建帖子响应
-type CreatePostResponse struct {
-	ID        string         `json:"id"`
-	User      UserPublicInfo `json:"user"`
-	Content   string         `json:"content"`
-	Timestamp string         `json:"timestamp"`
-}
+type CreatePostResponse = PostResponse
 
 // TimelineResponse 时间线响应
 type TimelineResponse struct {
@@ -27,7 +34,169 @@ type TimelineResponse struct {
 	PageInfo PageInfo       `json:"pageInfo"`
 }
 
-// CreatePost 创建新帖子 (POST /posts)
+// buildUserPublicInfo 构建用户公开信息
+func buildUserPublicInfo(user models.User) UserPublicInfo {
+	return UserPublicInfo{
+		ID:     user.ID.String(),
+		Name:   user.Name,
+		Avatar: user.Avatar,
+		// YoloStockValue: user.YoloStockValue,
+	}
+}
+
+// buildPostResponse 将帖子模型转换为响应格式
+func buildPostResponse(post *models.Post) PostResponse {
+	response := PostResponse{
+		ID:         post.ID.String(),
+		Type:       post.Type(),
+		User:       buildUserPublicInfo(post.User),
+		Content:    post.Content,
+		Visibility: post.Visibility,
+		Status:     post.Status,
+		Timestamp:  post.Timestamp.Format("2006-01-02T15:04:05Z"),
+		Entities:   make([]PostEntity, 0, len(post.Entities)),
+		Media:      make([]MediaResponse, 0, len(post.Media)),
+		Links:      make([]LinkPreview, 0, len(post.Links)),
+	}
+
+	if post.PublishAt != nil {
+		publishAt := post.PublishAt.UTC().Format("2006-01-02T15:04:05Z")
+		response.PublishAt = &publishAt
+	}
+
+	for i := range post.Media {
+		response.Media = append(response.Media, buildMediaResponse(&post.Media[i]))
+	}
+
+	if post.Poll != nil {
+		poll := buildPollResponse(post.Poll)
+		response.Poll = &poll
+	}
+
+	// 抓取中或抓取失败的链接不展示预览
+	for _, link := range post.Links {
+		if link.Preview.Status != models.LinkPreviewStatusReady {
+			continue
+		}
+		response.Links = append(response.Links, LinkPreview{
+			URL:         link.Preview.URL,
+			Title:       link.Preview.Title,
+			Description: link.Preview.Description,
+			ImageURL:    link.Preview.ImageURL,
+			SiteName:    link.Preview.SiteName,
+		})
+	}
+
+	for _, entity := range post.Entities {
+		item := PostEntity{
+			Type:  entity.Type,
+			Value: entity.Value,
+			Start: entity.StartOffset,
+			End:   entity.EndOffset,
+		}
+		if entity.MentionedUserID != nil {
+			userID := entity.MentionedUserID.String()
+			item.UserID = &userID
+		}
+		response.Entities = append(response.Entities, item)
+	}
+
+	if post.RepostOfID != nil {
+		response.RepostOf = buildEmbeddedPost(*post.RepostOfID, post.RepostOf)
+	}
+	if post.QuoteOfID != nil {
+		response.QuotedPost = buildEmbeddedPost(*post.QuoteOfID, post.QuoteOf)
+	}
+
+	return response
+}
+
+// buildEmbeddedPost 构建被转发/引用的原帖，原帖已删除或对查看者不可见时标记为不可用
+func buildEmbeddedPost(id uuid.UUID, post *models.Post) *EmbeddedPost {
+	if post == nil {
+		return &EmbeddedPost{ID: id.String(), Unavailable: true}
+	}
+	embedded := buildPostResponse(post)
+	return &EmbeddedPost{ID: id.String(), Post: &embedded}
+}
+
+// buildPostResponses 批量转换帖子
+func buildPostResponses(posts []models.Post) []PostResponse {
+	postResponses := make([]PostResponse, 0, len(posts))
+	for i := range posts {
+		postResponses = append(postResponses, buildPostResponse(&posts[i]))
+	}
+	return postResponses
+}
+
+// respondPostError 将帖子服务错误映射为HTTP响应
+func respondPostError(c *gin.Context, err error, message string) {
+	var filterErr *services.FilterError
+	if errors.As(err, &filterErr) {
+		respondFilterError(c, filterErr)
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrPostNotFound), errors.Is(err, services.ErrRepostNotFound),
+		errors.Is(err, services.ErrMediaNotFound), errors.Is(err, services.ErrPollNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidVisibility), errors.Is(err, services.ErrEmptyPost),
+		errors.Is(err, services.ErrTooManyMedia), errors.Is(err, services.ErrMediaUnavailable),
+		errors.Is(err, services.ErrInvalidSchedule), errors.Is(err, services.ErrInvalidPollOptions),
+		errors.Is(err, services.ErrInvalidPollDuration), errors.Is(err, services.ErrInvalidPollChoice):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrPostForbidden), errors.Is(err, services.ErrHoldersOnlyForbidden),
+		errors.Is(err, services.ErrPostNotShareable), errors.Is(err, services.ErrUserSuspended),
+		errors.Is(err, services.ErrWeightedPollForbidden), errors.Is(err, services.ErrNoSharesToVote):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAlreadyReposted), errors.Is(err, services.ErrPostPublished),
+		errors.Is(err, services.ErrPostHeld), errors.Is(err, services.ErrPollClosed),
+		errors.Is(err, services.ErrAlreadyVoted):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// respondFilterError 返回被自动过滤拒绝的原因，频率限制返回429并带 Retry-After
+func respondFilterError(c *gin.Context, err *services.FilterError) {
+	status := http.StatusUnprocessableEntity
+	if err.Rule == services.FilterRuleRateLimit {
+		status = http.StatusTooManyRequests
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	}
+	c.JSON(status, gin.H{
+		"error":   err.Message,
+		"details": services.ErrPostRejected.Error(),
+		"rule":    err.Rule,
+	})
+}
+
+// parsePostIDParam 解析路径中的帖子ID
+func parsePostIDParam(c *gin.Context) (uuid.UUID, bool) {
+	postID, err := uuid.Parse(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid post ID",
+		})
+		return uuid.Nil, false
+	}
+	return postID, true
+}
+
+// CreatePost 创建新帖子，传入quote_post_id时创建引用帖子 (POST /posts)
 func CreatePost(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
 
@@ -41,38 +210,178 @@ func CreatePost(c *gin.Context) {
 	}
 
 	// 创建帖子
-	post, err := services.PostService.CreatePost(userID, req.Content)
+	opts := services.CreatePostOptions{
+		Content:    req.Content,
+		Visibility: req.Visibility,
+		Draft:      req.Draft,
+		PublishAt:  req.PublishAt,
+	}
+	if req.QuotePostID != "" {
+		quotePostID := uuid.MustParse(req.QuotePostID)
+		opts.QuotePostID = &quotePostID
+	}
+	for _, id := range req.MediaIDs {
+		opts.MediaIDs = append(opts.MediaIDs, uuid.MustParse(id))
+	}
+	if req.Poll != nil {
+		opts.Poll = &services.NewPoll{
+			Options:        req.Poll.Options,
+			ClosesAt:       req.Poll.ClosesAt,
+			MultipleChoice: req.Poll.MultipleChoice,
+			WeightByShares: req.Poll.WeightByShares,
+		}
+	}
+	post, err := services.PostService.CreatePostWithOptions(userID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create post",
+		respondPostError(c, err, "Failed to create post")
+		return
+	}
+
+	// 被拦截等待审核的帖子返回202，审核通过后才会发布
+	if post.Status == models.PostStatusHeld {
+		c.JSON(http.StatusAccepted, buildPostResponse(post))
+		return
+	}
+	c.JSON(http.StatusCreated, buildPostResponse(post))
+}
+
+// GetPost 获取单个帖子 (GET /posts/:postId)，对当前查看者不可见时返回404
+func GetPost(c *gin.Context) {
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	post, err := services.PostService.GetPostByID(utils.GetUserIDFromContext(c), postID)
+	if err != nil {
+		respondPostError(c, err, "Failed to get post")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPostResponse(post))
+}
+
+// DeletePost 删除自己的帖子 (DELETE /posts/:postId)
+func DeletePost(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := services.PostService.DeletePost(userID, postID); err != nil {
+		respondPostError(c, err, "Failed to delete post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post deleted successfully",
+	})
+}
+
+// Repost 转发帖子 (POST /posts/:postId/repost)
+func Repost(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	post, err := services.PostService.Repost(userID, postID)
+	if err != nil {
+		respondPostError(c, err, "Failed to repost")
+		return
+	}
+
+	c.JSON(http.StatusCreated, buildPostResponse(post))
+}
+
+// Unrepost 取消转发 (DELETE /posts/:postId/repost)
+func Unrepost(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := services.PostService.Unrepost(userID, postID); err != nil {
+		respondPostError(c, err, "Failed to undo repost")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Repost removed successfully",
+	})
+}
+
+// getCursorFromQuery 判断是否使用游标分页（存在cursor参数即可，首页传空值）
+func getCursorFromQuery(c *gin.Context) (cursor string, withTotal bool, ok bool) {
+	cursor, ok = c.GetQuery("cursor")
+	return cursor, c.Query("includeTotal") == "true", ok
+}
+
+// respondCursorPosts 返回游标分页结果
+func respondCursorPosts(c *gin.Context, result *services.PostCursorPage, err error, message string) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   message,
 			"details": err.Error(),
 		})
 		return
 	}
 
-	// 构建响应
-	response := CreatePostResponse{
-		ID: post.ID.String(),
-		User: UserPublicInfo{
-			ID:             post.User.ID.String(),
-			Name:           post.User.Name,
-			Avatar:         post.User.Avatar,
-			// YoloStockValue: post.User.YoloStockValue,
-		},
-		Content:   post.Content,
-		Timestamp: post.Timestamp.Format("2006-01-02T15:04:05Z"),
+	pageInfo := CursorPageInfo{TotalPosts: result.Total}
+	if result.NextCursor != "" {
+		pageInfo.NextCursor = &result.NextCursor
+	}
+	if result.PrevCursor != "" {
+		pageInfo.PrevCursor = &result.PrevCursor
 	}
 
-	c.JSON(http.StatusCreated, response)
+	c.JSON(http.StatusOK, CursorPostsResponse{
+		Posts:    buildPostResponses(result.Posts),
+		PageInfo: pageInfo,
+	})
 }
 
 // GetTimeline 获取内容时间线 (GET /posts/timeline)
+// 传入 cursor 参数时使用游标分页，否则保持原有的 page/limit 分页；sort=hot 时按热度排序；未登录时只返回公开帖子
 func GetTimeline(c *gin.Context) {
+	viewerID := utils.GetUserIDFromContext(c)
+
+	sort := c.DefaultQuery("sort", "latest")
+	if sort != "latest" && sort != "hot" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid sort, expected latest or hot",
+		})
+		return
+	}
+
+	if cursor, withTotal, ok := getCursorFromQuery(c); ok {
+		if sort == "hot" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Hot timeline uses page pagination",
+			})
+			return
+		}
+		result, err := services.PostService.GetTimelineByCursor(viewerID, cursor, utils.GetLimitFromQuery(c), withTotal)
+		respondCursorPosts(c, result, err, "Failed to get timeline")
+		return
+	}
+
 	// 获取分页参数
 	page := utils.GetPageFromQuery(c)
 	limit := utils.GetLimitFromQuery(c)
 
-	posts, total, err := services.PostService.GetTimeline(page, limit)
+	getTimeline := services.PostService.GetTimeline
+	if sort == "hot" {
+		getTimeline = services.RankingService.GetHotPosts
+	}
+	posts, total, err := getTimeline(viewerID, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get timeline",
@@ -82,20 +391,7 @@ func GetTimeline(c *gin.Context) {
 	}
 
 	// 转换为响应格式
-	var postResponses []PostResponse
-	for _, post := range posts {
-		postResponses = append(postResponses, PostResponse{
-			ID: post.ID.String(),
-			User: UserPublicInfo{
-				ID:             post.User.ID.String(),
-				Name:           post.User.Name,
-				Avatar:         post.User.Avatar,
-				// YoloStockValue: post.User.YoloStockValue,
-			},
-			Content:   post.Content,
-			Timestamp: post.Timestamp.Format("2006-01-02T15:04:05Z"),
-		})
-	}
+	postResponses := buildPostResponses(posts)
 
 	// 计算总页数
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateDraftRequest 修改草稿或定时帖子请求，省略的字段保持不变
+type UpdateDraftRequest struct {
+	Content    *string `json:"content" binding:"omitempty,max=1000"`
+	Visibility *string `json:"visibility" binding:"omitempty,oneof=public followers only_me holders"`
+}
+
+// SchedulePostRequest 设置定时发布请求
+type SchedulePostRequest struct {
+	PublishAt time.Time `json:"publish_at" binding:"required"` // RFC3339 格式
+}
+
+// getUnpublishedPosts 返回当前用户指定状态的帖子列表
+func getUnpublishedPosts(c *gin.Context, status, message string) {
+	userID := utils.GetUserIDFromContext(c)
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	posts, total, err := services.PostService.GetUnpublishedPosts(userID, status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, TimelineResponse{
+		Posts: buildPostResponses(posts),
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+			TotalPosts:  total,
+		},
+	})
+}
+
+// GetDrafts 获取自己的草稿，按最近修改排序 (GET /posts/drafts)
+func GetDrafts(c *gin.Context) {
+	getUnpublishedPosts(c, models.PostStatusDraft, "Failed to get drafts")
+}
+
+// GetScheduledPosts 获取自己的定时帖子，按发布时间排序 (GET /posts/scheduled)
+func GetScheduledPosts(c *gin.Context) {
+	getUnpublishedPosts(c, models.PostStatusScheduled, "Failed to get scheduled posts")
+}
+
+// UpdateDraft 修改草稿或定时帖子 (PUT /posts/:postId)，已发布的帖子返回409
+func UpdateDraft(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	post, err := services.PostService.UpdateDraft(userID, postID, services.UpdateDraftOptions{
+		Content:    req.Content,
+		Visibility: req.Visibility,
+	})
+	if err != nil {
+		respondPostError(c, err, "Failed to update post")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPostResponse(post))
+}
+
+// SchedulePost 设置或修改定时发布时间 (PUT /posts/:postId/schedule)
+func SchedulePost(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SchedulePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	post, err := services.PostService.SchedulePost(userID, postID, req.PublishAt)
+	if err != nil {
+		respondPostError(c, err, "Failed to schedule post")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPostResponse(post))
+}
+
+// UnschedulePost 取消定时发布，帖子变回草稿 (DELETE /posts/:postId/schedule)
+func UnschedulePost(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	post, err := services.PostService.UnschedulePost(userID, postID)
+	if err != nil {
+		respondPostError(c, err, "Failed to unschedule post")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPostResponse(post))
+}
+
+// PublishPost 立即发布草稿或定时帖子 (POST /posts/:postId/publish)
+func PublishPost(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	postID, ok := parsePostIDParam(c)
+	if !ok {
+		return
+	}
+
+	post, err := services.PostService.PublishNow(userID, postID)
+	if err != nil {
+		respondPostError(c, err, "Failed to publish post")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildPostResponse(post))
+}
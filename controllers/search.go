@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchPostsResponse 帖子搜索结果
+type SearchPostsResponse struct {
+	Query    string         `json:"query"`
+	Sort     string         `json:"sort"`
+	Posts    []PostResponse `json:"posts"`
+	PageInfo PageInfo       `json:"pageInfo"`
+}
+
+// parseSearchTime 解析时间参数，支持 RFC3339 或 YYYY-MM-DD（当天零点 UTC）
+func parseSearchTime(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Invalid " + name,
+		"details": "expected RFC3339 time or YYYY-MM-DD date",
+	})
+	return nil, false
+}
+
+// SearchPosts 全文搜索帖子 (GET /posts/search?q=)
+// 支持 author、tag、since/until 过滤，sort 可选 relevance（默认）或 recent
+func SearchPosts(c *gin.Context) {
+	since, ok := parseSearchTime(c, "since")
+	if !ok {
+		return
+	}
+	until, ok := parseSearchTime(c, "until")
+	if !ok {
+		return
+	}
+
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+	opts := services.SearchPostsOptions{
+		Query:  c.Query("q"),
+		Author: c.Query("author"),
+		Tag:    utils.NormalizeHashtag(c.Query("tag")),
+		Since:  since,
+		Until:  until,
+		Sort:   c.Query("sort"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	posts, total, err := services.PostService.SearchPosts(utils.GetUserIDFromContext(c), opts)
+	if err != nil {
+		if errors.Is(err, services.ErrEmptySearchQuery) || errors.Is(err, services.ErrInvalidSearchSort) ||
+			errors.Is(err, services.ErrInvalidDateRange) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid search",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to search posts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	sort := opts.Sort
+	if sort == "" {
+		sort = services.SearchSortRelevance
+	}
+
+	// 计算总页数
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, SearchPostsResponse{
+		Query: opts.Query,
+		Sort:  sort,
+		Posts: buildPostResponses(posts),
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  totalPages,
+			TotalPosts:  total,
+		},
+	})
+}
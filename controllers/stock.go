@@ -1,261 +1,348 @@
 package controllers
 
-// import (
-// 	"fmt"
-// 	"net/http"
-// 	"yolo/services"
-// 	"yolo/utils"
+import (
+	"errors"
+	"net/http"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
 
-// 	"github.com/gin-gonic/gin"
-// 	"github.com/google/uuid"
-// )
+	"github.com/gin-gonic/gin"
+)
 
-// // StockResponse 股票响应结构
-// type StockResponse struct {
-// 	ID          string  `json:"id"`
-// 	Name        string  `json:"name"`
-// 	Symbol      string  `json:"symbol"`
-// 	Image       *string `json:"img"`
-// 	Status      string  `json:"status"`
-// 	Price       float64 `json:"price"`
-// 	DailyChange float64 `json:"dailyChange"`
-// 	DailyVolume float64 `json:"dailyVolume"`
-// 	MarketCap   float64 `json:"marketCap"`
-// 	Owners      int     `json:"owners"`
-// 	Supply      float64 `json:"supply"`
-// }
+// StockResponse 股票响应结构
+type StockResponse struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Symbol      string          `json:"symbol"`
+	Category    string          `json:"category"`
+	Image       *string         `json:"img"`
+	Status      string          `json:"status"`
+	Price       decimal.Decimal `json:"price"`
+	DailyChange float64         `json:"dailyChange"`
+	DailyVolume decimal.Decimal `json:"dailyVolume"`
+	MarketCap   decimal.Decimal `json:"marketCap"`
+	Owners      int             `json:"owners"`
+	Supply      decimal.Decimal `json:"supply"`
+	CreatedAt   string          `json:"createdAt"`
+}
 
-// // StockDetailResponse 股票详情响应结构
-// type StockDetailResponse struct {
-// 	StockResponse
-// 	Description *string                        `json:"description"`
-// 	ChartData   map[string][]ChartDataResponse `json:"chartData"`
-// }
+// StockDetailResponse 股票详情响应结构
+type StockDetailResponse struct {
+	StockResponse
+	Description *string         `json:"description"`
+	Creator     *UserPublicInfo `json:"creator,omitempty"`
+}
 
-// // ChartDataResponse K线图数据响应
-// type ChartDataResponse struct {
-// 	Time  int64   `json:"time"`
-// 	Value float64 `json:"value"`
-// }
+// CreateStockRequest 创建股票请求结构
+type CreateStockRequest struct {
+	Symbol      string          `json:"symbol" binding:"required"`
+	Name        string          `json:"name" binding:"required"`
+	Category    string          `json:"category"`
+	Supply      decimal.Decimal `json:"supply"`
+	Description string          `json:"description"`
+	Image       string          `json:"img"`
+}
 
-// // TradeRequest 交易请求结构
-// type TradeRequest struct {
-// 	UserID int     `json:"userId" binding:"required"`
-// 	Type   string  `json:"type" binding:"required,oneof=buy sell"`
-// 	Amount float64 `json:"amount" binding:"required,gt=0"`
-// }
+// TradeQuoteResponse 交易报价响应结构
+type TradeQuoteResponse struct {
+	Type        string          `json:"type"`
+	AmountIn    decimal.Decimal `json:"amountIn"`
+	AmountOut   decimal.Decimal `json:"amountOut"`
+	Price       decimal.Decimal `json:"price"`
+	MidPrice    decimal.Decimal `json:"midPrice"`
+	PriceImpact decimal.Decimal `json:"priceImpact"`
+	Fee         decimal.Decimal `json:"fee"`
+}
 
-// // TradeResponse 交易响应结构
-// type TradeResponse struct {
-// 	Success       bool   `json:"success"`
-// 	TransactionID string `json:"transactionId"`
-// 	Message       string `json:"message"`
-// }
+// CreateQuoteRequest 签名报价请求结构，amount 与 amount_out 二选一，slippage 为可接受的偏离比例
+type CreateQuoteRequest struct {
+	Type      string          `json:"type" binding:"required"`
+	Amount    decimal.Decimal `json:"amount"`
+	AmountOut decimal.Decimal `json:"amount_out"`
+	Slippage  decimal.Decimal `json:"slippage"`
+}
 
-// // GetStocks 获取项目列表 (GET /stocks)
-// func GetStocks(c *gin.Context) {
-// 	// 获取查询参数
-// 	category := c.Query("category")
-// 	sortBy := c.Query("sortBy")
-// 	order := c.Query("order")
+// SignedQuoteResponse 签名报价响应结构
+type SignedQuoteResponse struct {
+	TradeQuoteResponse
+	QuoteID   string           `json:"quoteId"`
+	FeeRate   decimal.Decimal  `json:"feeRate"`
+	Slippage  decimal.Decimal  `json:"slippage"`
+	MinOut    *decimal.Decimal `json:"minOut,omitempty"`
+	MaxIn     *decimal.Decimal `json:"maxIn,omitempty"`
+	ExpiresAt string           `json:"expiresAt"`
+}
 
-// 	stocks, err := services.StockService.GetAllStocks(category, sortBy, order)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{
-// 			"error":   "Failed to get stocks",
-// 			"details": err.Error(),
-// 		})
-// 		return
-// 	}
+// TradeRequest 交易请求结构：amount 为精确输入（买入时为支付的 YOLO，卖出时为卖出的股数），
+// amount_out 为精确输出（买入时为要得到的股数，卖出时为要得到的 YOLO），二选一；
+// min_out / max_in 为可接受的最少得到和最多支付，超出时拒绝；传 quote_id 时其余字段忽略
+type TradeRequest struct {
+	Type      string          `json:"type"`
+	Amount    decimal.Decimal `json:"amount"`
+	AmountOut decimal.Decimal `json:"amount_out"`
+	MinOut    decimal.Decimal `json:"min_out"`
+	MaxIn     decimal.Decimal `json:"max_in"`
+	QuoteID   string          `json:"quote_id"`
+}
 
-// 	// 转换为响应格式
-// 	var stockResponses []StockResponse
-// 	for _, stock := range stocks {
-// 		stockResponses = append(stockResponses, StockResponse{
-// 			ID:          stock.ID.String(),
-// 			Name:        stock.Name,
-// 			Symbol:      stock.Symbol,
-// 			Image:       stock.Image,
-// 			Status:      stock.Status,
-// 			Price:       stock.Price,
-// 			DailyChange: stock.DailyChange,
-// 			DailyVolume: stock.DailyVolume,
-// 			MarketCap:   stock.MarketCap,
-// 			Owners:      stock.Owners,
-// 			Supply:      stock.Supply,
-// 		})
-// 	}
+// TradeResponse 交易结果响应结构
+type TradeResponse struct {
+	ID          string          `json:"id"`
+	Symbol      string          `json:"symbol"`
+	Type        string          `json:"type"`
+	Shares      decimal.Decimal `json:"shares"`
+	Price       decimal.Decimal `json:"price"`
+	TotalValue  decimal.Decimal `json:"totalValue"`
+	Fee         decimal.Decimal `json:"fee"`
+	PriceImpact decimal.Decimal `json:"priceImpact"`
+	NewPrice    decimal.Decimal `json:"newPrice"`
+	Balance     decimal.Decimal `json:"balance"`
+	Holding     decimal.Decimal `json:"holding"`
+	CreatedAt   string          `json:"createdAt"`
+}
 
-// 	c.JSON(http.StatusOK, stockResponses)
-// }
+// buildTradeQuoteResponse 转换为报价响应
+func buildTradeQuoteResponse(quote *services.TradeQuote) TradeQuoteResponse {
+	return TradeQuoteResponse{
+		Type:        quote.Type,
+		AmountIn:    quote.AmountIn,
+		AmountOut:   quote.AmountOut,
+		Price:       quote.Price,
+		MidPrice:    quote.MidPrice,
+		PriceImpact: quote.PriceImpact,
+		Fee:         quote.Fee,
+	}
+}
 
-// // GetStockByID 获取单个项目详情 (GET /stocks/{stockId})
-// func GetStockByID(c *gin.Context) {
-// 	stockIDStr := c.Param("stockId")
-// 	stockID, err := uuid.Parse(stockIDStr)
-// 	if err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{
-// 			"error": "Invalid stock ID",
-// 		})
-// 		return
-// 	}
+// buildStockResponse 转换为股票响应
+func buildStockResponse(stock *models.Stock) StockResponse {
+	return StockResponse{
+		ID:          stock.ID.String(),
+		Name:        stock.Name,
+		Symbol:      stock.Symbol,
+		Category:    stock.Category,
+		Image:       stock.Image,
+		Status:      stock.Status,
+		Price:       stock.Price,
+		DailyChange: stock.DailyChange,
+		DailyVolume: stock.DailyVolume,
+		MarketCap:   stock.MarketCap,
+		Owners:      stock.Owners,
+		Supply:      stock.Supply,
+		CreatedAt:   stock.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
 
-// 	stock, err := services.StockService.GetStockByID(stockID)
-// 	if err != nil {
-// 		c.JSON(http.StatusNotFound, gin.H{
-// 			"error": "Stock not found",
-// 		})
-// 		return
-// 	}
+// respondStockError 股票接口的错误响应，功能关闭时返回 404
+func respondStockError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrStocksDisabled), errors.Is(err, services.ErrStockNotFound),
+		errors.Is(err, services.ErrPoolNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrSymbolExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrSlippageExceeded), errors.Is(err, services.ErrQuoteExpired),
+		errors.Is(err, services.ErrQuoteUsed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidSymbol), errors.Is(err, services.ErrSymbolReserved),
+		errors.Is(err, services.ErrInvalidStockName), errors.Is(err, services.ErrInvalidStockSupply),
+		errors.Is(err, services.ErrStockDescriptionLong), errors.Is(err, services.ErrInvalidCategory),
+		errors.Is(err, services.ErrInvalidStockSort), errors.Is(err, services.ErrInvalidTradeType),
+		errors.Is(err, services.ErrInvalidTradeAmount), errors.Is(err, services.ErrTradeTooSmall),
+		errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, services.ErrInsufficientShares),
+		errors.Is(err, services.ErrInsufficientLiquidity), errors.Is(err, services.ErrTradeAmountConflict),
+		errors.Is(err, services.ErrInvalidSlippage), errors.Is(err, services.ErrQuoteInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
 
-// 	// 获取K线图数据
-// 	chartData := make(map[string][]ChartDataResponse)
-// 	timeframes := []string{"day", "week", "month", "year"}
+// GetStocks 获取股票列表 (GET /stocks?category=&sortBy=&order=)
+func GetStocks(c *gin.Context) {
+	stocks, err := services.StockService.GetStocks(services.StockListOptions{
+		Category: c.Query("category"),
+		SortBy:   c.Query("sortBy"),
+		Order:    c.Query("order"),
+	})
+	if err != nil {
+		respondStockError(c, err, "Failed to get stocks")
+		return
+	}
 
-// 	for _, timeframe := range timeframes {
-// 		data, err := services.ChartDataService.GetStockChartData(stockID, timeframe, 100)
-// 		if err == nil {
-// 			var chartResponses []ChartDataResponse
-// 			for _, item := range data {
-// 				chartResponses = append(chartResponses, ChartDataResponse{
-// 					Time:  item.Time,
-// 					Value: item.Value,
-// 				})
-// 			}
-// 			chartData[timeframe] = chartResponses
-// 		} else {
-// 			chartData[timeframe] = []ChartDataResponse{}
-// 		}
-// 	}
+	stockResponses := make([]StockResponse, 0, len(stocks))
+	for i := range stocks {
+		stockResponses = append(stockResponses, buildStockResponse(&stocks[i]))
+	}
+	c.JSON(http.StatusOK, stockResponses)
+}
 
-// 	// 构建响应
-// 	response := StockDetailResponse{
-// 		StockResponse: StockResponse{
-// 			ID:          stock.ID.String(),
-// 			Name:        stock.Name,
-// 			Symbol:      stock.Symbol,
-// 			Image:       stock.Image,
-// 			Status:      stock.Status,
-// 			Price:       stock.Price,
-// 			DailyChange: stock.DailyChange,
-// 			DailyVolume: stock.DailyVolume,
-// 			MarketCap:   stock.MarketCap,
-// 			Owners:      stock.Owners,
-// 			Supply:      stock.Supply,
-// 		},
-// 		Description: stock.Description,
-// 		ChartData:   chartData,
-// 	}
+// GetStockDetail 根据股票符号获取股票详情 (GET /stocks/:symbol)
+func GetStockDetail(c *gin.Context) {
+	stock, err := services.StockService.GetStockBySymbol(c.Param("symbol"))
+	if err != nil {
+		respondStockError(c, err, "Failed to get stock")
+		return
+	}
 
-// 	c.JSON(http.StatusOK, response)
-// }
+	response := StockDetailResponse{
+		StockResponse: buildStockResponse(stock),
+		Description:   stock.Description,
+	}
+	if stock.User.ID == stock.UserID {
+		creator := buildUserPublicInfo(stock.User)
+		response.Creator = &creator
+	}
+	c.JSON(http.StatusOK, response)
+}
 
-// // TradeStock 交易操作 (POST /stocks/{stockId}/trade)
-// func TradeStock(c *gin.Context) {
-// 	stockIDStr := c.Param("stockId")
-// 	stockID, err := uuid.Parse(stockIDStr)
-// 	if err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{
-// 			"error": "Invalid stock ID",
-// 		})
-// 		return
-// 	}
+// CreateStock 发行股票 (POST /stocks)
+func CreateStock(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
 
-// 	var req TradeRequest
-// 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{
-// 			"error":   "Invalid request data",
-// 			"details": err.Error(),
-// 		})
-// 		return
-// 	}
+	var req CreateStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
 
-// 	// 从JWT获取实际用户ID（忽略请求中的userID）
-// 	userID := utils.GetUserIDFromContext(c)
+	stock, err := services.StockService.CreateStock(userID, services.CreateStockOptions{
+		Symbol:      req.Symbol,
+		Name:        req.Name,
+		Category:    req.Category,
+		Supply:      req.Supply,
+		Description: req.Description,
+		Image:       req.Image,
+	})
+	if err != nil {
+		respondStockError(c, err, "Failed to create stock")
+		return
+	}
 
-// 	// 执行交易
-// 	trade, err := services.TradeService.ExecuteTrade(stockID, userID, req.Type, req.Amount)
-// 	if err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{
-// 			"error":   "Trade failed",
-// 			"details": err.Error(),
-// 		})
-// 		return
-// 	}
+	c.JSON(http.StatusCreated, StockDetailResponse{
+		StockResponse: buildStockResponse(stock),
+		Description:   stock.Description,
+	})
+}
 
-// 	// 获取股票信息用于响应消息
-// 	stock, _ := services.StockService.GetStockByID(stockID)
-// 	var message string
-// 	if req.Type == "buy" {
-// 		message = fmt.Sprintf("Successfully purchased %.2f units of %s.", req.Amount, stock.Name)
-// 	} else {
-// 		message = fmt.Sprintf("Successfully sold %.2f units of %s.", req.Amount, stock.Name)
-// 	}
+// GetTradeQuote 按当前流动性池报价 (GET /stocks/:symbol/quote?type=buy&amount=100)
+func GetTradeQuote(c *gin.Context) {
+	amount, err := decimal.ParseAmount(c.Query("amount"))
+	if err != nil {
+		details := services.ErrInvalidTradeAmount.Error()
+		if errors.Is(err, decimal.ErrOutOfRange) {
+			details = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid amount",
+			"details": details,
+		})
+		return
+	}
 
-// 	response := TradeResponse{
-// 		Success:       true,
-// 		TransactionID: trade.TransactionID,
-// 		Message:       message,
-// 	}
+	quote, err := services.AMMService.Quote(c.Param("symbol"), c.Query("type"), amount)
+	if err != nil {
+		respondStockError(c, err, "Failed to quote trade")
+		return
+	}
+	c.JSON(http.StatusOK, buildTradeQuoteResponse(quote))
+}
 
-// 	c.JSON(http.StatusOK, response)
-// }
+// CreateQuote 按当前流动性池生成签名报价 (POST /stocks/:symbol/quote)
+func CreateQuote(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
 
-// // GetStockDetail 根据股票符号获取股票详情 (GET /stocks/:symbol)
-// func GetStockDetail(c *gin.Context) {
-// 	symbol := c.Param("symbol")
-// 	if symbol == "" {
-// 		c.JSON(http.StatusBadRequest, gin.H{
-// 			"error": "Stock symbol is required",
-// 		})
-// 		return
-// 	}
+	var req CreateQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
 
-// 	stock, err := services.StockService.GetStockBySymbol(symbol)
-// 	if err != nil {
-// 		c.JSON(http.StatusNotFound, gin.H{
-// 			"error": "Stock not found",
-// 		})
-// 		return
-// 	}
+	quote, err := services.AMMService.CreateQuote(userID, c.Param("symbol"), services.QuoteOptions{
+		Type:      req.Type,
+		AmountIn:  req.Amount,
+		AmountOut: req.AmountOut,
+		Slippage:  req.Slippage,
+	})
+	if err != nil {
+		respondStockError(c, err, "Failed to quote trade")
+		return
+	}
 
-// 	// 获取K线图数据
-// 	chartData := make(map[string][]ChartDataResponse)
-// 	timeframes := []string{"day", "week", "month", "year"}
+	response := SignedQuoteResponse{
+		TradeQuoteResponse: buildTradeQuoteResponse(&quote.Quote),
+		QuoteID:            quote.ID,
+		FeeRate:            quote.FeeRate,
+		Slippage:           quote.Slippage,
+		ExpiresAt:          quote.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if req.AmountOut.IsPositive() {
+		response.MaxIn = &quote.MaxIn
+	} else {
+		response.MinOut = &quote.MinOut
+	}
+	c.JSON(http.StatusOK, response)
+}
 
-// 	for _, timeframe := range timeframes {
-// 		data, err := services.ChartDataService.GetStockChartData(stock.ID, timeframe, 100)
-// 		if err == nil {
-// 			var chartResponses []ChartDataResponse
-// 			for _, item := range data {
-// 				chartResponses = append(chartResponses, ChartDataResponse{
-// 					Time:  item.Time,
-// 					Value: item.Value,
-// 				})
-// 			}
-// 			chartData[timeframe] = chartResponses
-// 		} else {
-// 			chartData[timeframe] = []ChartDataResponse{}
-// 		}
-// 	}
+// TradeStock 与流动性池交易 (POST /stocks/:symbol/trade)
+func TradeStock(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
 
-// 	// 构建响应
-// 	response := StockDetailResponse{
-// 		StockResponse: StockResponse{
-// 			ID:          stock.ID.String(),
-// 			Name:        stock.Name,
-// 			Image:       stock.Image,
-// 			Status:      stock.Status,
-// 			Price:       stock.Price,
-// 			DailyChange: stock.DailyChange,
-// 			DailyVolume: stock.DailyVolume,
-// 			MarketCap:   stock.MarketCap,
-// 			Owners:      stock.Owners,
-// 			Supply:      stock.Supply,
-// 		},
-// 		Description: stock.Description,
-// 		ChartData:   chartData,
-// 	}
+	var req TradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, response)
-// }
+	result, err := services.AMMService.Trade(userID, c.Param("symbol"), services.TradeOptions{
+		Type:      req.Type,
+		AmountIn:  req.Amount,
+		AmountOut: req.AmountOut,
+		MinOut:    req.MinOut,
+		MaxIn:     req.MaxIn,
+		QuoteID:   req.QuoteID,
+	})
+	if err != nil {
+		respondStockError(c, err, "Failed to execute trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, TradeResponse{
+		ID:          result.Trade.ID.String(),
+		Symbol:      services.NormalizeSymbol(c.Param("symbol")),
+		Type:        result.Trade.Type,
+		Shares:      result.Trade.Amount,
+		Price:       result.Trade.Price,
+		TotalValue:  result.Trade.TotalValue,
+		Fee:         result.Trade.Fee,
+		PriceImpact: result.Quote.PriceImpact,
+		NewPrice:    result.NewPrice,
+		Balance:     result.Balance,
+		Holding:     result.Holding,
+		CreatedAt:   result.Trade.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
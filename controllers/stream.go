@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"yolo/hub"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxStreamChannels 单个连接最多订阅的频道数
+const maxStreamChannels = 20
+
+var (
+	errInvalidChannel   = errors.New("invalid channel")
+	errForbiddenChannel = errors.New("not allowed to subscribe to this channel")
+)
+
+// parseStreamChannels 解析并校验订阅的频道
+// 支持 notifications（当前用户通知）、timeline、user:<userId>、stock:<symbol>，未指定时订阅通知和时间线
+func parseStreamChannels(raw string, userID uuid.UUID) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []string{hub.NotificationsChannel(userID), hub.TimelineChannel}, nil
+	}
+
+	seen := make(map[string]bool)
+	var channels []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		kind, arg, _ := strings.Cut(item, ":")
+
+		var channel string
+		switch kind {
+		case "notifications":
+			if arg != "" && arg != userID.String() {
+				return nil, errForbiddenChannel
+			}
+			channel = hub.NotificationsChannel(userID)
+		case hub.TimelineChannel:
+			channel = hub.TimelineChannel
+		case "user":
+			id, err := uuid.Parse(arg)
+			if err != nil {
+				return nil, errInvalidChannel
+			}
+			channel = hub.UserChannel(id)
+		case "stock":
+			symbol := utils.NormalizeSymbol(arg)
+			if symbol == "" {
+				return nil, errInvalidChannel
+			}
+			channel = hub.StockChannel(symbol)
+		default:
+			return nil, errInvalidChannel
+		}
+
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+
+	if len(channels) > maxStreamChannels {
+		return nil, errInvalidChannel
+	}
+	return channels, nil
+}
+
+// writeStreamEvent 按SSE格式写出一个事件
+func writeStreamEvent(c *gin.Context, event hub.Event) {
+	fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: {\"channel\":%q,\"data\":%s}\n\n", event.ID, event.Type, event.Channel, event.Data)
+}
+
+// Stream 订阅实时事件 (GET /stream?channels=notifications,timeline,user:<userId>,stock:<symbol>)
+// 断线重连时通过 Last-Event-ID 请求头（或 lastEventId 查询参数）补发错过的事件
+func Stream(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	channels, err := parseStreamChannels(c.Query("channels"), userID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errForbiddenChannel) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	sub, replay, resumed := services.RealtimeHub.Subscribe(channels, lastEventID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// ready 事件不带id，不会影响客户端的 Last-Event-ID
+	fmt.Fprintf(c.Writer, "retry: 3000\nevent: ready\ndata: {\"channels\":[\"%s\"]}\n\n", strings.Join(channels, `","`))
+	if !resumed {
+		// 无法续传，客户端需要重新拉取数据
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		writeStreamEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(services.RealtimeHub.HeartbeatInterval())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Lagged() {
+					// 消费过慢被断开，客户端带 Last-Event-ID 重连即可补齐
+					fmt.Fprint(c.Writer, "event: overflow\ndata: {}\n\n")
+					c.Writer.Flush()
+				}
+				return
+			}
+			writeStreamEvent(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TopicPostsResponse 话题/股票讨论帖子列表响应
+type TopicPostsResponse struct {
+	Type     string         `json:"type"`  // hashtag/cashtag
+	Topic    string         `json:"topic"` // 归一化后的话题或股票符号
+	Posts    []PostResponse `json:"posts"`
+	PageInfo PageInfo       `json:"pageInfo"`
+}
+
+// TrendingTopicResponse 热门话题或股票符号
+type TrendingTopicResponse struct {
+	Type    string  `json:"type"`    // hashtag/cashtag
+	Topic   string  `json:"topic"`   // 归一化后的话题或股票符号
+	Score   float64 `json:"score"`   // 按时间衰减后的热度
+	Authors int     `json:"authors"` // 窗口内讨论的不同作者数
+	Posts   int     `json:"posts"`   // 窗口内的帖子数
+}
+
+// TrendingResponse 热门话题列表响应
+type TrendingResponse struct {
+	Window     string                  `json:"window"`
+	ComputedAt string                  `json:"computedAt"`
+	Topics     []TrendingTopicResponse `json:"topics"`
+}
+
+// GetTrending 获取热门话题和股票符号 (GET /trending)
+// window 可选 1h、24h（默认）、7d，type 可选 hashtag、cashtag
+func GetTrending(c *gin.Context) {
+	entityType := c.Query("type")
+	if entityType != "" && entityType != models.EntityTypeHashtag && entityType != models.EntityTypeCashtag {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid topic type",
+		})
+		return
+	}
+	window := c.DefaultQuery("window", services.TrendingWindows[0].Name)
+
+	topics, computedAt, err := services.RankingService.GetTrending(window, entityType, utils.GetLimitFromQuery(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTrendingWindow) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get trending topics",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := TrendingResponse{
+		Window:     window,
+		ComputedAt: computedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Topics:     make([]TrendingTopicResponse, 0, len(topics)),
+	}
+	for _, topic := range topics {
+		response.Topics = append(response.Topics, TrendingTopicResponse{
+			Type:    topic.Type,
+			Topic:   topic.Value,
+			Score:   topic.Score,
+			Authors: topic.Authors,
+			Posts:   topic.Posts,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTagPosts 获取话题下的帖子 (GET /tags/:tag/posts)
+func GetTagPosts(c *gin.Context) {
+	tag := utils.NormalizeHashtag(c.Param("tag"))
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tag is required",
+		})
+		return
+	}
+
+	getTopicPosts(c, models.EntityTypeHashtag, tag)
+}
+
+// GetSymbolPosts 获取股票符号的讨论帖子 (GET /symbols/:symbol/posts)
+func GetSymbolPosts(c *gin.Context) {
+	symbol := utils.NormalizeSymbol(c.Param("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Stock symbol is required",
+		})
+		return
+	}
+
+	getTopicPosts(c, models.EntityTypeCashtag, symbol)
+}
+
+// getTopicPosts 分页返回包含指定实体的帖子
+func getTopicPosts(c *gin.Context, entityType, value string) {
+	// 获取分页参数
+	page := utils.GetPageFromQuery(c)
+	limit := utils.GetLimitFromQuery(c)
+
+	posts, total, err := services.PostService.GetPostsByEntity(utils.GetUserIDFromContext(c), entityType, value, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get topic posts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 计算总页数
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, TopicPostsResponse{
+		Type:  entityType,
+		Topic: value,
+		Posts: buildPostResponses(posts),
+		PageInfo: PageInfo{
+			CurrentPage: page,
+			TotalPages:  totalPages,
+			TotalPosts:  total,
+		},
+	})
+}
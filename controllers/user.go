@@ -25,10 +25,45 @@ type UserPublicInfo struct {
 
 // PostResponse 帖子响应结构
 type PostResponse struct {
-	ID        string         `json:"id"`
-	User      UserPublicInfo `json:"user"`
-	Content   string         `json:"content"`
-	Timestamp string         `json:"timestamp"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"` // post/repost/quote
+	User       UserPublicInfo  `json:"user"`
+	Content    string          `json:"content"`
+	Visibility string          `json:"visibility"`          // public/followers/only_me/holders
+	Status     string          `json:"status"`              // published/draft/scheduled
+	PublishAt  *string         `json:"publishAt,omitempty"` // 定时发布时间
+	Timestamp  string          `json:"timestamp"`
+	Entities   []PostEntity    `json:"entities"`             // 话题/提及/股票符号及其偏移
+	Media      []MediaResponse `json:"media"`                // 图片/视频附件
+	Links      []LinkPreview   `json:"links"`                // 已抓取到的链接预览，按链接在内容中出现的顺序
+	Poll       *PollResponse   `json:"poll,omitempty"`       // 附带的投票
+	RepostOf   *EmbeddedPost   `json:"repostOf,omitempty"`   // 转发的原帖
+	QuotedPost *EmbeddedPost   `json:"quotedPost,omitempty"` // 引用的原帖
+}
+
+// PostEntity 帖子实体，start/end 为Unicode字符偏移（左闭右开）
+type PostEntity struct {
+	Type   string  `json:"type"` // hashtag/mention/cashtag
+	Value  string  `json:"value"`
+	Start  int     `json:"start"`
+	End    int     `json:"end"`
+	UserID *string `json:"userId,omitempty"` // 被提及用户ID
+}
+
+// LinkPreview 链接预览
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+}
+
+// EmbeddedPost 被转发/引用的原帖，原帖删除后仅返回ID和unavailable标记
+type EmbeddedPost struct {
+	ID          string        `json:"id"`
+	Unavailable bool          `json:"unavailable,omitempty"`
+	Post        *PostResponse `json:"post,omitempty"`
 }
 
 // PostsResponse 帖子列表响应
@@ -44,6 +79,19 @@ type PageInfo struct {
 	TotalPosts  int64 `json:"totalPosts"`
 }
 
+// CursorPageInfo 游标分页信息
+type CursorPageInfo struct {
+	NextCursor *string `json:"nextCursor"`           // 更旧一页，为null表示已到末尾
+	PrevCursor *string `json:"prevCursor"`           // 更新一页，可用于拉取新内容
+	TotalPosts *int64  `json:"totalPosts,omitempty"` // 仅在 includeTotal=true 时返回
+}
+
+// CursorPostsResponse 游标分页的帖子列表响应
+type CursorPostsResponse struct {
+	Posts    []PostResponse `json:"posts"`
+	PageInfo CursorPageInfo `json:"pageInfo"`
+}
+
 // GetUserProfile 获取用户资料
 func GetUserProfile(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
@@ -94,14 +142,36 @@ func GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetUserPosts 获取特定用户发布的内容 (/users/{userId}/posts)
-func GetUserPosts(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := uuid.Parse(userIDStr)
+// resolveUserParam 解析路由中的用户名，同时兼容直接传入用户ID
+func resolveUserParam(c *gin.Context) (uuid.UUID, bool) {
+	username := c.Param("username")
+	if userID, err := uuid.Parse(username); err == nil {
+		return userID, true
+	}
+
+	user, err := services.UserService.GetUserByUsername(username)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
 		})
+		return uuid.Nil, false
+	}
+	return user.ID, true
+}
+
+// GetUserPosts 获取特定用户发布的内容，包含转发和引用 (/users/{username}/posts)
+// 只返回当前查看者可见的帖子
+func GetUserPosts(c *gin.Context) {
+	viewerID := utils.GetUserIDFromContext(c)
+	userID, ok := resolveUserParam(c)
+	if !ok {
+		return
+	}
+
+	// 传入cursor参数时使用游标分页
+	if cursor, withTotal, ok := getCursorFromQuery(c); ok {
+		result, err := services.PostService.GetUserPostsByCursor(viewerID, userID, cursor, utils.GetLimitFromQuery(c), withTotal)
+		respondCursorPosts(c, result, err, "Failed to get user posts")
 		return
 	}
 
@@ -109,7 +179,7 @@ func GetUserPosts(c *gin.Context) {
 	page := utils.GetPageFromQuery(c)
 	limit := utils.GetLimitFromQuery(c)
 
-	posts, total, err := services.PostService.GetUserPosts(userID, page, limit)
+	posts, total, err := services.PostService.GetUserPosts(viewerID, userID, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get user posts",
@@ -119,20 +189,7 @@ func GetUserPosts(c *gin.Context) {
 	}
 
 	// 转换为响应格式
-	var postResponses []PostResponse
-	for _, post := range posts {
-		postResponses = append(postResponses, PostResponse{
-			ID: post.ID.String(),
-			User: UserPublicInfo{
-				ID:     post.User.ID.String(),
-				Name:   post.User.Name,
-				Avatar: post.User.Avatar,
-				// YoloStockValue: post.User.YoloStockValue,
-			},
-			Content:   post.Content,
-			Timestamp: post.Timestamp.Format("2006-01-02T15:04:05Z"),
-		})
-	}
+	postResponses := buildPostResponses(posts)
 
 	// 计算总页数
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -191,7 +248,17 @@ func GetUserBalance(c *gin.Context) {
 		return
 	}
 
+	balance, err := services.LedgerService.GetBalance(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get balance",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
+		"balance":     balance,
 		"token_type":  "YOLO", // 平台基础token
 		"description": "Platform base tokens for trading",
 	})
@@ -497,9 +564,9 @@ func GetUserPublicInfo(c *gin.Context) {
 
 	// 返回公开信息
 	response := UserPublicInfo{
-		ID:             user.ID.String(),
-		Name:           user.Name,
-		Avatar:         user.Avatar,
+		ID:     user.ID.String(),
+		Name:   user.Name,
+		Avatar: user.Avatar,
 		// YoloStockValue: user.YoloStockValue,
 	}
 
@@ -106,7 +106,7 @@ func initPostgresDatabase() error {
 	return nil
 }
 
-// AutoMigrate 自动迁移数据库表 - 仅迁移用户管理相关表
+// AutoMigrate 自动迁移数据库表：用户、帖子、联邦、股票、账本和交易
 func AutoMigrate() error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
@@ -116,6 +116,36 @@ func AutoMigrate() error {
 	err := DB.AutoMigrate(
 		&models.User{},
 		&models.Post{},
+		&models.PostEntity{},
+		&models.PostScore{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Follow{},
+		&models.Media{},
+		&models.LinkPreview{},
+		&models.PostLink{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.PollBallot{},
+		&models.PollVote{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
+		&models.ActorKey{},
+		&models.RemoteActor{},
+		&models.RemoteFollower{},
+		&models.FederationDelivery{},
+		&models.Report{},
+		&models.ModerationAction{},
+		&models.Stock{},
+		&models.UserHolding{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.LedgerPosting{},
+		&models.LiquidityPool{},
+		&models.Trade{},
+		&models.Order{},
+		&models.ConditionalOrder{},
+		&models.PortfolioSnapshot{},
 	)
 
 	if err != nil {
@@ -124,13 +154,17 @@ func AutoMigrate() error {
 		if strings.Contains(errStr, "constraint") && strings.Contains(errStr, "does not exist") {
 			log.Printf("Warning: Constraint error ignored during migration: %v", err)
 			log.Println("Database migration completed with warnings")
-			return nil
+			return setupPostSearch()
 		} else {
 			return fmt.Errorf("failed to migrate database: %w", err)
 		}
 	}
 
-	log.Println("Database migration completed successfully - User Management Only")
+	if err := setupPostSearch(); err != nil {
+		return err
+	}
+
+	log.Println("Database migration completed successfully")
 	return nil
 }
 
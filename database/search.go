@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 帖子全文搜索的实现方式
+const (
+	SearchBackendPostgres = "postgres" // tsvector 生成列 + GIN 索引
+	SearchBackendFTS5     = "fts5"     // SQLite FTS5 虚拟表，由触发器与 posts 表同步
+	SearchBackendLike     = "like"     // 未启用 FTS5 的 SQLite（编译时未加 sqlite_fts5 标签），逐行匹配且不支持相关度排序
+)
+
+// SearchBackend 当前数据库使用的全文搜索实现，迁移时确定
+var SearchBackend = SearchBackendLike
+
+// sqliteSearchStatements 创建 FTS5 表和同步触发器。posts 的主键不是整数，
+// 因此 FTS 表单独保存 post_id，而不是用外部内容表关联 rowid（VACUUM 后 rowid 可能变化）
+var sqliteSearchStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS posts_fts USING fts5(post_id UNINDEXED, content, tokenize = 'unicode61 remove_diacritics 2')`,
+	`CREATE TRIGGER IF NOT EXISTS posts_fts_insert AFTER INSERT ON posts BEGIN
+		INSERT INTO posts_fts (post_id, content) VALUES (new.id, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS posts_fts_update AFTER UPDATE OF content ON posts BEGIN
+		DELETE FROM posts_fts WHERE post_id = old.id;
+		INSERT INTO posts_fts (post_id, content) VALUES (new.id, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS posts_fts_delete AFTER DELETE ON posts BEGIN
+		DELETE FROM posts_fts WHERE post_id = old.id;
+	END`,
+}
+
+// setupPostSearch 创建帖子全文索引。Postgres 使用 'simple' 配置，不做词干处理，中英文混排时结果更可预期
+func setupPostSearch() error {
+	switch DB.Dialector.Name() {
+	case "postgres":
+		statements := []string{
+			`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED`,
+			`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)`,
+		}
+		for _, statement := range statements {
+			if err := DB.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to create search index: %w", err)
+			}
+		}
+		SearchBackend = SearchBackendPostgres
+
+	case "sqlite":
+		var exists int64
+		DB.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'posts_fts'").Scan(&exists)
+		if err := DB.Exec(sqliteSearchStatements[0]).Error; err != nil {
+			if strings.Contains(err.Error(), "no such module") {
+				log.Println("Warning: SQLite was built without FTS5, post search falls back to LIKE matching")
+				SearchBackend = SearchBackendLike
+				return nil
+			}
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+		for _, statement := range sqliteSearchStatements[1:] {
+			if err := DB.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to create search trigger: %w", err)
+			}
+		}
+		// 首次创建时为已有帖子建立索引
+		if exists == 0 {
+			if err := DB.Exec("INSERT INTO posts_fts (post_id, content) SELECT id, content FROM posts").Error; err != nil {
+				return fmt.Errorf("failed to build search index: %w", err)
+			}
+		}
+		SearchBackend = SearchBackendFTS5
+
+	default:
+		SearchBackend = SearchBackendLike
+	}
+	return nil
+}
@@ -0,0 +1,573 @@
+// Package decimal 定点小数，用于金额、价格和股数
+//
+// Decimal 以 int64 保存放大 10^Scale 倍后的整数，加减法精确，乘除法的中间结果使用 128 位整数，
+// 结果按调用方指定的舍入模式舍入到 Scale 位小数，因此任意多笔金额相加都能精确对账。
+// 超出 int64 范围（约 ±9.2 万亿）属于程序错误，直接 panic。
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Scale 小数位数
+const Scale = 6
+
+// unit 10^Scale
+const unit = 1000000
+
+// 解析错误
+var (
+	ErrInvalid    = errors.New("invalid decimal")
+	ErrTooPrecise = errors.New("decimal has too many fractional digits")
+	ErrOutOfRange = errors.New("decimal is out of range")
+)
+
+// 运算溢出和除零属于程序错误，直接 panic
+const (
+	errOverflow     = "decimal overflow"
+	errDivideByZero = "decimal division by zero"
+	errNegativeSqrt = "decimal square root of negative number"
+)
+
+// RoundingMode 舍入模式
+type RoundingMode int
+
+const (
+	RoundDown     RoundingMode = iota // 向零舍入（截断）
+	RoundUp                           // 远离零舍入
+	RoundHalfUp                       // 四舍五入，恰好一半时远离零
+	RoundHalfEven                     // 银行家舍入，恰好一半时取偶数
+	RoundFloor                        // 向负无穷舍入
+	RoundCeiling                      // 向正无穷舍入
+)
+
+// Decimal 定点小数，零值为 0
+type Decimal struct {
+	units int64
+}
+
+// Zero 0
+var Zero = Decimal{}
+
+// MaxAmount 请求中允许的最大绝对值 999999999.999999。
+// SQLite 的 NUMERIC 列以双精度浮点数保存小数，15 位有效数字以内才能按 Scale 位精确往返
+var MaxAmount = Decimal{units: 999999999999999}
+
+// FromUnits 由最小单位（10^-Scale）构造
+func FromUnits(units int64) Decimal {
+	return Decimal{units: units}
+}
+
+// FromInt 由整数构造
+func FromInt(value int64) Decimal {
+	if value > math.MaxInt64/unit || value < math.MinInt64/unit {
+		panic(errOverflow)
+	}
+	return Decimal{units: value * unit}
+}
+
+// FromFloat 由浮点数构造，按最短的十进制表示再舍入到 Scale 位，0.1 得到精确的 0.1
+func FromFloat(value float64, mode RoundingMode) (Decimal, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Zero, ErrInvalid
+	}
+	return ParseRound(strconv.FormatFloat(value, 'f', -1, 64), mode)
+}
+
+// RequireFromString 解析字面量，失败时 panic，用于常量
+func RequireFromString(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Parse 精确解析十进制字符串，小数位超过 Scale 时返回 ErrTooPrecise
+func Parse(s string) (Decimal, error) {
+	return parse(s, nil)
+}
+
+// ParseAmount 解析请求中的金额或数量，绝对值超过 MaxAmount 时返回 ErrOutOfRange
+func ParseAmount(s string) (Decimal, error) {
+	d, err := Parse(s)
+	if err != nil {
+		return Zero, err
+	}
+	if d.GreaterThan(MaxAmount) || d.LessThan(MaxAmount.Neg()) {
+		return Zero, ErrOutOfRange
+	}
+	return d, nil
+}
+
+// ParseRound 解析十进制字符串，多余的小数位按 mode 舍入
+func ParseRound(s string, mode RoundingMode) (Decimal, error) {
+	return parse(s, &mode)
+}
+
+// parse 解析 [+-]digits[.digits]，mode 为空时不允许舍入
+func parse(s string, mode *RoundingMode) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Zero, ErrInvalid
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return Zero, ErrInvalid
+			}
+		}
+	}
+
+	// 多余的小数位作为余数参与舍入
+	var remainder, divisor uint64 = 0, 1
+	if len(fracPart) > Scale {
+		extra := strings.TrimRight(fracPart[Scale:], "0")
+		if extra != "" {
+			if mode == nil {
+				return Zero, ErrTooPrecise
+			}
+			// 只需要判断与一半的大小关系：保留前 18 位，后面还有非零位时补一位 1，以免被误判为恰好一半
+			if len(extra) > 18 {
+				extra = extra[:18] + "1"
+			}
+			remainder, _ = strconv.ParseUint(extra, 10, 64)
+			divisor = pow10(len(extra))
+		}
+		fracPart = fracPart[:Scale]
+	}
+	fracPart += strings.Repeat("0", Scale-len(fracPart))
+
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	var magnitude uint64
+	if digits != "" {
+		var err error
+		if magnitude, err = strconv.ParseUint(digits, 10, 64); err != nil {
+			return Zero, ErrOutOfRange
+		}
+	}
+	if remainder > 0 {
+		magnitude = round(magnitude, remainder, divisor, negative, *mode)
+	}
+	return fromMagnitude(magnitude, negative)
+}
+
+// pow10 10^n，n 不超过 19
+func pow10(n int) uint64 {
+	result := uint64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// fromMagnitude 由绝对值和符号构造
+func fromMagnitude(magnitude uint64, negative bool) (Decimal, error) {
+	if negative {
+		if magnitude > 1<<63 {
+			return Zero, ErrOutOfRange
+		}
+		return Decimal{units: int64(-magnitude)}, nil
+	}
+	if magnitude > math.MaxInt64 {
+		return Zero, ErrOutOfRange
+	}
+	return Decimal{units: int64(magnitude)}, nil
+}
+
+// mustFromMagnitude 同 fromMagnitude，溢出时 panic
+func mustFromMagnitude(magnitude uint64, negative bool) Decimal {
+	d, err := fromMagnitude(magnitude, negative)
+	if err != nil {
+		panic(errOverflow)
+	}
+	return d
+}
+
+// round 按舍入模式处理商的余数，quotient、remainder 均为绝对值，remainder < divisor
+func round(quotient, remainder, divisor uint64, negative bool, mode RoundingMode) uint64 {
+	if remainder == 0 {
+		return quotient
+	}
+	up := false
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		up = true
+	case RoundHalfUp:
+		up = remainder >= divisor-remainder
+	case RoundHalfEven:
+		half := divisor - remainder
+		up = remainder > half || (remainder == half && quotient%2 == 1)
+	case RoundFloor:
+		up = negative
+	case RoundCeiling:
+		up = !negative
+	}
+	if up {
+		if quotient == math.MaxUint64 {
+			panic(errOverflow)
+		}
+		quotient++
+	}
+	return quotient
+}
+
+// abs 绝对值和符号
+func (d Decimal) abs() (uint64, bool) {
+	if d.units < 0 {
+		return uint64(-d.units), true
+	}
+	return uint64(d.units), false
+}
+
+// Units 放大 10^Scale 倍后的整数
+func (d Decimal) Units() int64 {
+	return d.units
+}
+
+// Add 加法
+func (d Decimal) Add(other Decimal) Decimal {
+	sum, err := d.TryAdd(other)
+	if err != nil {
+		panic(errOverflow)
+	}
+	return sum
+}
+
+// TryAdd 同 Add，结果超出范围时返回 ErrOutOfRange，用于由用户输入决定大小的计算
+func (d Decimal) TryAdd(other Decimal) (Decimal, error) {
+	sum := d.units + other.units
+	if (sum > d.units) != (other.units > 0) {
+		return Zero, ErrOutOfRange
+	}
+	return Decimal{units: sum}, nil
+}
+
+// Sub 减法
+func (d Decimal) Sub(other Decimal) Decimal {
+	return d.Add(other.Neg())
+}
+
+// Neg 相反数
+func (d Decimal) Neg() Decimal {
+	if d.units == math.MinInt64 {
+		panic(errOverflow)
+	}
+	return Decimal{units: -d.units}
+}
+
+// Abs 绝对值
+func (d Decimal) Abs() Decimal {
+	if d.units < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Mul 乘法，结果按 mode 舍入到 Scale 位
+func (d Decimal) Mul(other Decimal, mode RoundingMode) Decimal {
+	a, negA := d.abs()
+	b, negB := other.abs()
+	hi, lo := bits.Mul64(a, b)
+	if hi >= unit {
+		panic(errOverflow)
+	}
+	quotient, remainder := bits.Div64(hi, lo, unit)
+	negative := negA != negB
+	return mustFromMagnitude(round(quotient, remainder, unit, negative, mode), negative)
+}
+
+// MulInt 乘以整数，结果精确
+func (d Decimal) MulInt(n int64) Decimal {
+	return d.Mul(FromInt(n), RoundDown)
+}
+
+// Div 除法，结果按 mode 舍入到 Scale 位，除数为 0 时 panic
+func (d Decimal) Div(other Decimal, mode RoundingMode) Decimal {
+	if other.units == 0 {
+		panic(errDivideByZero)
+	}
+	a, negA := d.abs()
+	b, negB := other.abs()
+	hi, lo := bits.Mul64(a, unit)
+	if hi >= b {
+		panic(errOverflow)
+	}
+	quotient, remainder := bits.Div64(hi, lo, b)
+	negative := negA != negB
+	return mustFromMagnitude(round(quotient, remainder, b, negative, mode), negative)
+}
+
+// MulDiv 计算 d*mul/div，中间结果不舍入，只在最后按 mode 舍入一次，除数为 0 时 panic
+func (d Decimal) MulDiv(mul, div Decimal, mode RoundingMode) Decimal {
+	result, err := d.TryMulDiv(mul, div, mode)
+	if err != nil {
+		panic(errOverflow)
+	}
+	return result
+}
+
+// TryMulDiv 同 MulDiv，结果超出范围时返回 ErrOutOfRange，用于由用户输入决定大小的计算
+func (d Decimal) TryMulDiv(mul, div Decimal, mode RoundingMode) (Decimal, error) {
+	if div.units == 0 {
+		panic(errDivideByZero)
+	}
+	a, negA := d.abs()
+	b, negB := mul.abs()
+	c, negC := div.abs()
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		return Zero, ErrOutOfRange
+	}
+	quotient, remainder := bits.Div64(hi, lo, c)
+	negative := negA != negB != negC
+	if quotient == math.MaxUint64 && remainder != 0 {
+		return Zero, ErrOutOfRange
+	}
+	return fromMagnitude(round(quotient, remainder, c, negative, mode), negative)
+}
+
+// Sqrt 平方根，结果按 mode 舍入到 Scale 位，负数时 panic。
+// 在 128 位整数上对 d*10^Scale 二分求整数平方根，不经过浮点数
+func (d Decimal) Sqrt(mode RoundingMode) Decimal {
+	if d.units < 0 {
+		panic(errNegativeSqrt)
+	}
+	hi, lo := bits.Mul64(uint64(d.units), unit)
+	// 结果不超过 sqrt(MaxInt64 * 10^6) < 2^42
+	low, high := uint64(0), uint64(1)<<42
+	for low < high {
+		mid := low + (high-low+1)/2
+		sqHi, sqLo := bits.Mul64(mid, mid)
+		if sqHi < hi || (sqHi == hi && sqLo <= lo) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	root := low
+	_, sqLo := bits.Mul64(root, root)
+	remainder := lo - sqLo // 余数小于 2*root+1，低 64 位相减即可
+	if remainder != 0 {
+		up := false
+		switch mode {
+		case RoundUp, RoundCeiling:
+			up = true
+		case RoundHalfUp, RoundHalfEven:
+			// n > (root+0.5)^2 = root^2 + root + 0.25，余数为整数，等价于余数 > root；不会恰好一半
+			up = remainder > root
+		}
+		if up {
+			root++
+		}
+	}
+	return Decimal{units: int64(root)}
+}
+
+// Round 按 mode 舍入到 places 位小数（0 到 Scale）
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	if places >= Scale {
+		return d
+	}
+	if places < 0 {
+		places = 0
+	}
+	divisor := pow10(Scale - places)
+	magnitude, negative := d.abs()
+	quotient := round(magnitude/divisor, magnitude%divisor, divisor, negative, mode)
+	hi, lo := bits.Mul64(quotient, divisor)
+	if hi != 0 {
+		panic(errOverflow)
+	}
+	return mustFromMagnitude(lo, negative)
+}
+
+// Cmp 比较，返回 -1、0、1
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.units < other.units:
+		return -1
+	case d.units > other.units:
+		return 1
+	}
+	return 0
+}
+
+// Equal 是否相等
+func (d Decimal) Equal(other Decimal) bool {
+	return d.units == other.units
+}
+
+// LessThan 是否小于
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.units < other.units
+}
+
+// GreaterThan 是否大于
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.units > other.units
+}
+
+// Sign 符号，返回 -1、0、1
+func (d Decimal) Sign() int {
+	return d.Cmp(Zero)
+}
+
+// IsZero 是否为 0
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// IsPositive 是否大于 0
+func (d Decimal) IsPositive() bool {
+	return d.units > 0
+}
+
+// IsNegative 是否小于 0
+func (d Decimal) IsNegative() bool {
+	return d.units < 0
+}
+
+// Min 较小值
+func Min(a, b Decimal) Decimal {
+	if a.units < b.units {
+		return a
+	}
+	return b
+}
+
+// Max 较大值
+func Max(a, b Decimal) Decimal {
+	if a.units > b.units {
+		return a
+	}
+	return b
+}
+
+// Sum 求和
+func Sum(values ...Decimal) Decimal {
+	total := Zero
+	for _, value := range values {
+		total = total.Add(value)
+	}
+	return total
+}
+
+// Float64 转为浮点数，仅用于展示或计算百分比等不需要精确的场景
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / unit
+}
+
+// String 去掉末尾零的十进制表示，如 "1500"、"-0.25"
+func (d Decimal) String() string {
+	magnitude, negative := d.abs()
+	s := strconv.FormatUint(magnitude/unit, 10)
+	if frac := magnitude % unit; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%06d", frac), "0")
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// StringFixed 固定 Scale 位小数的十进制表示，如 "1500.000000"
+func (d Decimal) StringFixed() string {
+	magnitude, negative := d.abs()
+	s := fmt.Sprintf("%d.%06d", magnitude/unit, magnitude%unit)
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON 输出为 JSON 数字
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON 接受 JSON 数字或字符串，小数位超过 Scale 或绝对值超过 MaxAmount 时报错
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	} else if strings.ContainsAny(s, "eE") {
+		// 科学计数法先按浮点数解析，再转为最短十进制表示
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return ErrInvalid
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value 写入数据库时使用固定小数位的字符串，PostgreSQL NUMERIC 精确保存
+func (d Decimal) Value() (driver.Value, error) {
+	return d.StringFixed(), nil
+}
+
+// Scan 从数据库读取：PostgreSQL NUMERIC 返回字符串；SQLite 的 NUMERIC 亲和列返回整数或浮点数，
+// 浮点数在 15 位有效数字内（见 MaxAmount）按 Scale 位四舍五入后与写入值一致
+func (d *Decimal) Scan(value any) error {
+	var (
+		parsed Decimal
+		err    error
+	)
+	switch v := value.(type) {
+	case nil:
+		parsed = Zero
+	case int64:
+		parsed = FromInt(v)
+	case float64:
+		parsed, err = FromFloat(v, RoundHalfEven)
+	case []byte:
+		parsed, err = ParseRound(string(v), RoundHalfEven)
+	case string:
+		parsed, err = ParseRound(v, RoundHalfEven)
+	default:
+		return fmt.Errorf("cannot scan %T into decimal", value)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot scan %v into decimal: %w", value, err)
+	}
+	*d = parsed
+	return nil
+}
+
+// GormDataType 通用数据类型
+func (Decimal) GormDataType() string {
+	return "numeric"
+}
+
+// GormDBDataType PostgreSQL 使用 NUMERIC(38,6)，SQLite 使用 NUMERIC 亲和类型
+func (Decimal) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return fmt.Sprintf("numeric(38,%d)", Scale)
+	}
+	return "numeric"
+}
@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Icon    string      `xml:"icon,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Length int64  `xml:"length,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+	Content   atomText   `xml:"content"`
+}
+
+// atomTime Atom 使用 RFC 3339 时间
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Atom 编码为 Atom 1.0 (RFC 4287)
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: atomTime(f.LastModified()),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.FeedURL},
+			{Rel: "alternate", Href: f.HomeURL},
+		},
+		Icon:    f.IconURL,
+		Entries: make([]atomEntry, 0, len(f.Items)),
+	}
+	if f.Author.Name != "" {
+		doc.Author = &atomAuthor{Name: f.Author.Name, URI: f.Author.URL}
+	}
+
+	for i := range f.Items {
+		item := &f.Items[i]
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.title(),
+			Published: atomTime(item.Published),
+			Updated:   atomTime(item.updated()),
+			Links:     []atomLink{{Rel: "alternate", Href: item.URL}},
+			Content:   atomText{Type: "text", Body: item.Content},
+		}
+		for _, attachment := range item.Attachments {
+			entry.Links = append(entry.Links, atomLink{
+				Rel:    "enclosure",
+				Type:   attachment.MIMEType,
+				Href:   attachment.URL,
+				Length: attachment.Size,
+				Title:  attachment.Title,
+			})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,123 @@
+// Package feed 将用户的帖子渲染为 Atom、RSS 2.0 和 JSON Feed 1.1 订阅源
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 订阅源格式
+const (
+	FormatAtom = "atom"
+	FormatRSS  = "rss"
+	FormatJSON = "json"
+)
+
+// ContentTypes 各格式的响应类型
+var ContentTypes = map[string]string{
+	FormatAtom: "application/atom+xml; charset=utf-8",
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatJSON: "application/feed+json; charset=utf-8",
+}
+
+// maxTitleLength 条目标题取正文第一行，超出部分截断
+const maxTitleLength = 80
+
+// Feed 与格式无关的订阅源
+type Feed struct {
+	ID          string // 永久不变的唯一标识
+	Title       string
+	Description string
+	HomeURL     string // 对应的网页地址
+	FeedURL     string // 订阅源自身的地址
+	IconURL     string
+	Author      Author
+	Updated     time.Time // 为零值时取条目中最晚的更新时间
+	Items       []Item
+}
+
+// Author 作者
+type Author struct {
+	Name string
+	URL  string
+}
+
+// Item 订阅源条目
+type Item struct {
+	ID          string // 永久不变的唯一标识，帖子删除后不会被复用
+	URL         string
+	Title       string // 为空时取正文第一行
+	Content     string // 纯文本正文
+	Published   time.Time
+	Updated     time.Time
+	Attachments []Attachment
+}
+
+// Attachment 条目附件（图片、视频）
+type Attachment struct {
+	URL      string
+	MIMEType string
+	Size     int64
+	Title    string
+}
+
+// LastModified 订阅源的最后修改时间，精确到秒以便与 HTTP 日期比较
+func (f *Feed) LastModified() time.Time {
+	updated := f.Updated
+	for i := range f.Items {
+		if t := f.Items[i].updated(); t.After(updated) {
+			updated = t
+		}
+	}
+	return updated.UTC().Truncate(time.Second)
+}
+
+// ETag 根据格式、条目标识和更新时间生成弱校验值，条目增删或修改时都会变化
+func (f *Feed) ETag(format string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%d\n", format, f.ID, f.Title, f.Updated.Unix())
+	for i := range f.Items {
+		fmt.Fprintf(h, "%s\n%d\n", f.Items[i].ID, f.Items[i].updated().UnixNano())
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// Encode 按格式编码订阅源
+func (f *Feed) Encode(format string) ([]byte, error) {
+	switch format {
+	case FormatAtom:
+		return f.Atom()
+	case FormatRSS:
+		return f.RSS()
+	case FormatJSON:
+		return f.JSON()
+	default:
+		return nil, fmt.Errorf("unsupported feed format %q", format)
+	}
+}
+
+// updated 条目更新时间不早于发布时间
+func (i *Item) updated() time.Time {
+	if i.Updated.After(i.Published) {
+		return i.Updated
+	}
+	return i.Published
+}
+
+// title 条目标题，未设置时取正文第一行并截断
+func (i *Item) title() string {
+	if i.Title != "" {
+		return i.Title
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(i.Content), "\n")
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) > maxTitleLength {
+		runes := []rune(line)
+		line = strings.TrimSpace(string(runes[:maxTitleLength-1])) + "…"
+	}
+	return line
+}
@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Icon        string       `json:"icon,omitempty"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonAttachment struct {
+	URL         string `json:"url"`
+	MIMEType    string `json:"mime_type"`
+	Title       string `json:"title,omitempty"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+type jsonItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Attachments   []jsonAttachment `json:"attachments,omitempty"`
+}
+
+// JSON 编码为 JSON Feed 1.1，短帖子没有标题，只返回正文
+func (f *Feed) JSON() ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Icon:        f.IconURL,
+		Items:       make([]jsonItem, 0, len(f.Items)),
+	}
+	if f.Author.Name != "" {
+		doc.Authors = []jsonAuthor{{Name: f.Author.Name, URL: f.Author.URL}}
+	}
+
+	for i := range f.Items {
+		item := &f.Items[i]
+		entry := jsonItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentText:   item.Content,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.updated().UTC().Format(time.RFC3339),
+		}
+		for _, attachment := range item.Attachments {
+			entry.Attachments = append(entry.Attachments, jsonAttachment{
+				URL:         attachment.URL,
+				MIMEType:    attachment.MIMEType,
+				Title:       attachment.Title,
+				SizeInBytes: attachment.Size,
+			})
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"encoding/xml"
+	"html"
+	"strings"
+	"time"
+)
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"` // 推荐的自引用链接
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Image         *rssImage `xml:"image,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+// rssTime RSS 使用 RFC 822 时间（四位年份）
+func rssTime(t time.Time) string {
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// textToHTML RSS 的 description 按 HTML 解析，转义正文并保留换行
+func textToHTML(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
+
+// RSS 编码为 RSS 2.0，条目的 guid 使用与 Atom 相同的永久标识
+func (f *Feed) RSS() ([]byte, error) {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.HomeURL,
+		Description:   f.Description,
+		SelfLink:      atomLink{Rel: "self", Type: "application/rss+xml", Href: f.FeedURL},
+		LastBuildDate: rssTime(f.LastModified()),
+		Items:         make([]rssItem, 0, len(f.Items)),
+	}
+	if channel.Description == "" {
+		channel.Description = f.Title
+	}
+	if f.IconURL != "" {
+		channel.Image = &rssImage{URL: f.IconURL, Title: f.Title, Link: f.HomeURL}
+	}
+
+	for i := range f.Items {
+		item := &f.Items[i]
+		entry := rssItem{
+			Title:       item.title(),
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: "false", Value: item.ID},
+			PubDate:     rssTime(item.Published),
+			Description: textToHTML(item.Content),
+		}
+		// RSS 每个条目只允许一个附件
+		if len(item.Attachments) > 0 {
+			attachment := item.Attachments[0]
+			entry.Enclosure = &rssEnclosure{URL: attachment.URL, Length: attachment.Size, Type: attachment.MIMEType}
+		}
+		channel.Items = append(channel.Items, entry)
+	}
+
+	doc := rssDocument{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: channel}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,222 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 频道命名
+const (
+	TimelineChannel = "timeline" // 全站时间线
+)
+
+// NotificationsChannel 用户通知频道，仅本人可订阅
+func NotificationsChannel(userID uuid.UUID) string {
+	return "notifications:" + userID.String()
+}
+
+// UserChannel 用户发帖频道
+func UserChannel(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// StockChannel 股票价格频道
+func StockChannel(symbol string) string {
+	return "stock:" + strings.ToUpper(symbol)
+}
+
+// Event 推送事件，ID 格式为 "<epoch>-<seq>"，用于断线续传
+type Event struct {
+	ID      string          `json:"id"`
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+
+	seq uint64
+}
+
+// Config 推送中心配置
+type Config struct {
+	SubscriberBuffer  int           // 每个订阅者的缓冲区大小，写满视为慢消费者并断开
+	HistorySize       int           // 保留用于断线续传的历史事件数量
+	HeartbeatInterval time.Duration // 心跳间隔
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() Config {
+	return Config{
+		SubscriberBuffer:  64,
+		HistorySize:       1024,
+		HeartbeatInterval: 15 * time.Second,
+	}
+}
+
+// Hub 事件推送中心，服务发布事件，按频道分发给订阅者
+type Hub struct {
+	config Config
+	epoch  string
+
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription 一个客户端的订阅
+type Subscription struct {
+	hub      *Hub
+	channels map[string]bool
+	events   chan Event
+	lagged   bool
+}
+
+// New 创建推送中心
+func New(config Config) *Hub {
+	if config.SubscriberBuffer <= 0 {
+		config.SubscriberBuffer = DefaultConfig().SubscriberBuffer
+	}
+	if config.HistorySize <= 0 {
+		config.HistorySize = DefaultConfig().HistorySize
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = DefaultConfig().HeartbeatInterval
+	}
+
+	return &Hub{
+		config:      config,
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// HeartbeatInterval 返回心跳间隔
+func (h *Hub) HeartbeatInterval() time.Duration {
+	return h.config.HeartbeatInterval
+}
+
+// Publish 向频道发布事件
+func (h *Hub) Publish(channel, eventType string, data any) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := Event{
+		ID:      fmt.Sprintf("%s-%d", h.epoch, h.seq),
+		Channel: channel,
+		Type:    eventType,
+		Data:    payload,
+		seq:     h.seq,
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > h.config.HistorySize {
+		h.history = h.history[len(h.history)-h.config.HistorySize:]
+	}
+
+	for sub := range h.subscribers {
+		if !sub.channels[channel] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// 慢消费者：断开连接，客户端可带 Last-Event-ID 重连续传
+			sub.lagged = true
+			h.removeLocked(sub)
+		}
+	}
+
+	return event, nil
+}
+
+// Subscribe 订阅频道；lastEventID 非空时返回需要补发的历史事件
+// resumed 为 false 表示无法从 lastEventID 续传（服务已重启或历史已被淘汰），客户端需要重新拉取数据
+func (h *Hub) Subscribe(channels []string, lastEventID string) (sub *Subscription, replay []Event, resumed bool) {
+	sub = &Subscription{
+		hub:      h,
+		channels: make(map[string]bool, len(channels)),
+		events:   make(chan Event, h.config.SubscriberBuffer),
+	}
+	for _, channel := range channels {
+		sub.channels[channel] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	resumed = true
+	if lastEventID != "" {
+		lastSeq, ok := h.parseEventID(lastEventID)
+		if !ok || (len(h.history) > 0 && lastSeq+1 < h.history[0].seq) || lastSeq > h.seq {
+			resumed = false
+		} else {
+			for _, event := range h.history {
+				if event.seq > lastSeq && sub.channels[event.Channel] {
+					replay = append(replay, event)
+				}
+			}
+		}
+	}
+
+	h.subscribers[sub] = struct{}{}
+	return sub, replay, resumed
+}
+
+// SubscriberCount 当前订阅者数量
+func (h *Hub) SubscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// parseEventID 解析事件ID，epoch不一致时视为无法续传
+func (h *Hub) parseEventID(id string) (uint64, bool) {
+	epoch, seqStr, found := strings.Cut(id, "-")
+	if !found || epoch != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// removeLocked 移除订阅者并关闭其事件通道，调用方需持有锁
+func (h *Hub) removeLocked(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.events)
+}
+
+// Events 事件通道，被关闭表示订阅已结束（主动取消或因积压被断开）
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagged 订阅是否因消费过慢被断开
+func (s *Subscription) Lagged() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.lagged
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"time"
 	"yolo/database"
 	"yolo/routes"
 	"yolo/services"
@@ -16,23 +19,52 @@ func main() {
 	// Web3功能已完全停用
 	log.Println("Web3/Blockchain features are disabled")
 
-	// 自动迁移数据库表 - 仅迁移用户管理相关表
+	// 自动迁移数据库表
 	if err := database.AutoMigrate(); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	// 初始化服务 - 仅初始化用户管理相关服务
+	// 初始化服务
 	services.InitServices()
 
+	// 启动定时发布任务，多实例部署时可以同时运行
+	services.PostService.StartScheduler(context.Background(), envDuration("POST_SCHEDULER_INTERVAL", 10*time.Second))
+
+	// 定期重算热门话题和帖子热度分
+	services.RankingService.Start(context.Background(), envDuration("RANKING_INTERVAL", time.Minute))
+
+	// 向远程关注者投递活动，失败的按退避时间重试
+	services.FederationService.StartDeliveries(context.Background(), envDuration("FEDERATION_DELIVERY_INTERVAL", 30*time.Second))
+
+	// 定期检查账本是否平衡
+	services.LedgerService.StartChecks(context.Background(), envDuration("LEDGER_CHECK_INTERVAL", time.Hour))
+
+	// 成交后和定期检查止损、止盈条件单
+	services.ConditionalOrderService.Start(context.Background(), envDuration("CONDITIONAL_ORDER_INTERVAL", 30*time.Second))
+
+	// 定期更新每个用户当天的组合价值快照
+	services.PortfolioService.Start(context.Background(), envDuration("PORTFOLIO_SNAPSHOT_INTERVAL", time.Hour))
+
 	// 设置路由
 	router := routes.SetupRoutes()
 
 	// 启动服务器
 	log.Println("Starting YOLO API server on 0.0.0.0:8080")
-	log.Println("✅ Available features: User Authentication, User Management, Posts")
-	log.Println("❌ Disabled features: Stock Trading, Blockchain/Web3 transactions, User Holdings, Gift System")
+	log.Println("✅ Available features: User Authentication, User Management, Posts, Federation, Stocks, Ledger, Orders, Portfolios")
+	log.Println("❌ Disabled features: Blockchain/Web3 transactions")
 
 	if err := router.Run("0.0.0.0:8080"); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// envDuration 读取环境变量中的时间间隔，未设置或无效时使用默认值
+func envDuration(name string, def time.Duration) time.Duration {
+	if value := os.Getenv(name); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("Invalid %s %q, using default", name, value)
+	}
+	return def
+}
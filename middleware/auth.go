@@ -31,18 +31,60 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 验证JWT token
-		userID, err := utils.ValidateJWT(tokenString)
-		if err != nil {
+		authenticate(c, tokenString)
+	}
+}
+
+// StreamAuthMiddleware 实时推送连接的认证中间件
+// 浏览器的 EventSource 无法设置请求头，因此额外支持 access_token 查询参数
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			tokenString = c.Query("access_token")
+		}
+		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token",
+				"error": "Bearer token is required",
 			})
 			c.Abort()
 			return
 		}
 
-		// 将用户ID存储到上下文中
-		c.Set("userID", userID)
+		authenticate(c, tokenString)
+	}
+}
+
+// OptionalAuthMiddleware 可选认证中间件，用于公开路由识别已登录的查看者
+// 未携带或携带无效token时按未登录处理，不会拒绝请求
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			c.Next()
+			return
+		}
+
+		if userID, err := utils.ValidateJWT(tokenString); err == nil {
+			c.Set("userID", userID)
+		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// authenticate 验证JWT并将用户ID写入上下文
+func authenticate(c *gin.Context, tokenString string) {
+	// 验证JWT token
+	userID, err := utils.ValidateJWT(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid token",
+		})
+		c.Abort()
+		return
+	}
+
+	// 将用户ID存储到上下文中
+	c.Set("userID", userID)
+	c.Next()
+}
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"errors"
 	"time"
+	"yolo/decimal"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,22 +11,23 @@ import (
 
 // User 用户模型 - 仅保留基本用户信息
 type User struct {
-	ID           uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
-	Name         string    `json:"name" gorm:"not null;size:100"`                   // 用户显示名称
-	Username     string    `json:"username" gorm:"uniqueIndex;not null;size:50"`    // 用户名（用于登录）
-	Email        string    `json:"email" gorm:"uniqueIndex;not null;size:255"`      // 邮箱
-	PasswordHash string    `json:"-" gorm:"size:255"`                               // 密码哈希，Google用户可以为空
-	GoogleID     *string   `json:"google_id,omitempty" gorm:"uniqueIndex;size:255"` // Google用户ID
-	Avatar       *string   `json:"avatar,omitempty" gorm:"size:500"`                // 用户头像URL
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	Name           string     `json:"name" gorm:"not null;size:100"`                   // 用户显示名称
+	Username       string     `json:"username" gorm:"uniqueIndex;not null;size:50"`    // 用户名（用于登录）
+	Email          string     `json:"email" gorm:"uniqueIndex;not null;size:255"`      // 邮箱
+	PasswordHash   string     `json:"-" gorm:"size:255"`                               // 密码哈希，Google用户可以为空
+	GoogleID       *string    `json:"google_id,omitempty" gorm:"uniqueIndex;size:255"` // Google用户ID
+	Avatar         *string    `json:"avatar,omitempty" gorm:"size:500"`                // 用户头像URL
+	Role           string     `json:"role" gorm:"not null;size:20;default:'user'"`     // 用户角色 user/moderator
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`                       // 封禁截止时间，期间不能发帖
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 
 	// 关联关系 - 仅保留帖子关系
 	Posts []Post `json:"posts,omitempty" gorm:"foreignKey:UserID"`
 
 	// ==================== 以下字段已停用 ====================
 	// YoloStockValue float64   `json:"yoloStockValue" gorm:"default:0.00"`              // 用户股票价值
-	// Balance        float64   `json:"balance" gorm:"default:8000.00"`                  // 平台代币余额
 	// IsListed       bool      `json:"is_listed" gorm:"default:false"`                  // 是否已上市
 	// Stocks     []Stock       `json:"stocks,omitempty" gorm:"foreignKey:UserID"`
 	// Holdings   []UserHolding `json:"holdings,omitempty" gorm:"foreignKey:UserID"`
@@ -32,79 +35,686 @@ type User struct {
 	// SellTrades []Trade       `json:"sell_trades,omitempty" gorm:"foreignKey:SellerID"`
 }
 
+// 用户角色
+const (
+	UserRoleUser      = "user"      // 普通用户
+	UserRoleModerator = "moderator" // 审核员，可以处理举报
+)
+
+// 帖子类型
+const (
+	PostTypePost   = "post"   // 普通帖子
+	PostTypeRepost = "repost" // 转发（无内容，仅分享原帖）
+	PostTypeQuote  = "quote"  // 引用（新内容 + 嵌入原帖）
+)
+
+// 帖子发布状态
+const (
+	PostStatusPublished = "published" // 已发布
+	PostStatusDraft     = "draft"     // 草稿，仅作者可见
+	PostStatusScheduled = "scheduled" // 等待定时发布
+	PostStatusHeld      = "held"      // 被自动过滤拦截，等待审核
+)
+
+// 帖子可见范围
+const (
+	VisibilityPublic    = "public"    // 所有人可见
+	VisibilityFollowers = "followers" // 仅关注者可见
+	VisibilityOnlyMe    = "only_me"   // 仅自己可见
+	VisibilityHolders   = "holders"   // 仅持有作者股票的用户可见，仅创作者可用
+)
+
 // Post 帖子模型 - 保留
 type Post struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:char(36);primary_key;index:idx_posts_timestamp_id,priority:2;index:idx_posts_user_timestamp_id,priority:3"`
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_posts_user_repost;index:idx_posts_user_timestamp_id,priority:1"`
+	Content    string         `json:"content" gorm:"type:text;not null"`                                                                              // 帖子内容，转发时为空
+	RepostOfID *uuid.UUID     `json:"repost_of_id,omitempty" gorm:"type:char(36);uniqueIndex:idx_posts_user_repost"`                                  // 转发的原帖ID
+	QuoteOfID  *uuid.UUID     `json:"quote_of_id,omitempty" gorm:"type:char(36);index"`                                                               // 引用的原帖ID
+	Visibility string         `json:"visibility" gorm:"not null;size:20;default:'public';index"`                                                      // 可见范围
+	Status     string         `json:"status" gorm:"not null;size:20;default:'published';index:idx_posts_status_publish_at,priority:1"`                // 发布状态
+	PublishAt  *time.Time     `json:"publish_at,omitempty" gorm:"index:idx_posts_status_publish_at,priority:2"`                                       // 定时发布时间
+	HiddenAt   *time.Time     `json:"hidden_at,omitempty" gorm:"index"`                                                                               // 被审核员隐藏的时间，隐藏后对所有人不可见
+	Limited    bool           `json:"-" gorm:"not null;default:false"`                                                                                // 被自动过滤影子限流，只有作者本人能看到
+	Timestamp  time.Time      `json:"timestamp" gorm:"not null;index:idx_posts_timestamp_id,priority:1;index:idx_posts_user_timestamp_id,priority:2"` // 发布时间（草稿为创建时间），与id组成游标分页的排序键
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"` // 软删除，保证转发/引用在原帖删除后仍可渲染
+
+	// 关联关系
+	User     User         `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	RepostOf *Post        `json:"repost_of,omitempty" gorm:"foreignKey:RepostOfID"`
+	QuoteOf  *Post        `json:"quote_of,omitempty" gorm:"foreignKey:QuoteOfID"`
+	Entities []PostEntity `json:"entities,omitempty" gorm:"foreignKey:PostID"`
+	Media    []Media      `json:"media,omitempty" gorm:"foreignKey:PostID"`
+	Links    []PostLink   `json:"links,omitempty" gorm:"foreignKey:PostID"`
+	Poll     *Poll        `json:"poll,omitempty" gorm:"foreignKey:PostID"`
+}
+
+// Type 返回帖子类型（post/repost/quote）
+func (p *Post) Type() string {
+	switch {
+	case p.RepostOfID != nil:
+		return PostTypeRepost
+	case p.QuoteOfID != nil:
+		return PostTypeQuote
+	default:
+		return PostTypePost
+	}
+}
+
+// 媒体类型
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
+// 媒体处理状态
+const (
+	MediaStatusProcessing = "processing" // 已上传，等待后台处理
+	MediaStatusReady      = "ready"      // 处理完成
+	MediaStatusFailed     = "failed"     // 处理失败，不能再附加到帖子
+)
+
+// Media 帖子附件（图片或短视频），先上传再在发帖时附加
+type Media struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:char(36);not null;index"`  // 上传者
+	PostID       *uuid.UUID `json:"post_id,omitempty" gorm:"type:char(36);index"` // 附加到的帖子，未使用时为空
+	Position     int        `json:"position" gorm:"not null;default:0"`           // 在帖子中的顺序
+	Type         string     `json:"type" gorm:"not null;size:20"`
+	Status       string     `json:"status" gorm:"not null;size:20;index"`
+	ContentType  string     `json:"content_type" gorm:"not null;size:100"`
+	Size         int64      `json:"size" gorm:"not null"` // 原文件字节数
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	DurationMs   int64      `json:"duration_ms"` // 视频时长
+	AltText      string     `json:"alt_text" gorm:"size:1000"`
+	StorageKey   string     `json:"-" gorm:"not null;size:255"`
+	ThumbnailKey *string    `json:"-" gorm:"size:255"`
+	Blurhash     *string    `json:"blurhash,omitempty" gorm:"size:100"`
+	Error        *string    `json:"error,omitempty" gorm:"size:255"` // 处理失败原因
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// 链接预览抓取状态
+const (
+	LinkPreviewStatusPending = "pending" // 等待后台抓取
+	LinkPreviewStatusReady   = "ready"   // 已抓取到元数据
+	LinkPreviewStatusFailed  = "failed"  // 抓取失败或页面没有可展示的信息
+)
+
+// LinkPreview 按链接缓存的预览信息（OpenGraph / Twitter Card），多个帖子共用
+type LinkPreview struct {
+	URLHash     string     `json:"-" gorm:"primaryKey;size:64"` // 规范化链接的sha256
+	URL         string     `json:"url" gorm:"type:text;not null"`
+	Status      string     `json:"status" gorm:"not null;size:20;index"`
+	Title       string     `json:"title" gorm:"size:300"`
+	Description string     `json:"description" gorm:"size:1000"`
+	ImageURL    string     `json:"image_url" gorm:"type:text"`
+	SiteName    string     `json:"site_name" gorm:"size:200"`
+	Error       *string    `json:"error,omitempty" gorm:"size:255"` // 抓取失败原因
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`            // 最近一次抓取时间，用于判断缓存是否过期
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// PostLink 帖子内容中的链接，按出现顺序关联到链接预览
+type PostLink struct {
+	PostID   uuid.UUID `json:"post_id" gorm:"type:char(36);primaryKey"`
+	Position int       `json:"position" gorm:"primaryKey"`
+	URLHash  string    `json:"-" gorm:"size:64;not null;index"`
+
+	Preview LinkPreview `json:"preview" gorm:"foreignKey:URLHash;references:URLHash"`
+}
+
+// Poll 帖子附带的投票
+type Poll struct {
+	ID             uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	PostID         uuid.UUID `json:"post_id" gorm:"type:char(36);not null;uniqueIndex"`
+	MultipleChoice bool      `json:"multiple_choice" gorm:"not null;default:false"`
+	WeightByShares bool      `json:"weight_by_shares" gorm:"not null;default:false"` // 按投票人持有作者股票的数量计票，仅创作者可用
+	ClosesAt       time.Time `json:"closes_at" gorm:"not null"`
+	Voters         int64     `json:"voters" gorm:"not null;default:0"`       // 投票人数
+	TotalWeight    float64   `json:"total_weight" gorm:"not null;default:0"` // 总票数，不加权时等于投票人数
+	CreatedAt      time.Time `json:"created_at"`
+
+	Options []PollOption `json:"options" gorm:"foreignKey:PollID"`
+	Votes   []PollVote   `json:"votes,omitempty" gorm:"foreignKey:PollID"` // 仅预加载当前查看者的选择
+}
+
+// Closed 投票是否已截止
+func (p *Poll) Closed(now time.Time) bool {
+	return !now.Before(p.ClosesAt)
+}
+
+// PollOption 投票选项
+type PollOption struct {
+	ID       uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	PollID   uuid.UUID `json:"poll_id" gorm:"type:char(36);not null;index"`
+	Position int       `json:"position" gorm:"not null"`
+	Text     string    `json:"text" gorm:"not null;size:100"`
+	Votes    int64     `json:"votes" gorm:"not null;default:0"`  // 选择该项的人数
+	Weight   float64   `json:"weight" gorm:"not null;default:0"` // 加权票数
+}
+
+// PollBallot 用户的一次投票，每人每个投票只能投一次，多选时包含多个选项
+type PollBallot struct {
+	PollID    uuid.UUID `json:"poll_id" gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey"`
+	Weight    float64   `json:"weight" gorm:"not null"` // 投票时的权重，不加权时为1
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PollVote 投票选择的选项
+type PollVote struct {
+	PollID   uuid.UUID `json:"poll_id" gorm:"type:char(36);primaryKey"`
+	UserID   uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey"`
+	OptionID uuid.UUID `json:"option_id" gorm:"type:char(36);primaryKey"`
+}
+
+// BookmarkCollection 用户的收藏夹，仅自己可见
+type BookmarkCollection struct {
 	ID        uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);not null"`
-	Content   string    `json:"content" gorm:"type:text;not null"` // 帖子内容
-	Timestamp time.Time `json:"timestamp" gorm:"not null"`         // 发布时间
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_bookmark_collections_user_name"`
+	Name      string    `json:"name" gorm:"not null;size:50;uniqueIndex:idx_bookmark_collections_user_name"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+}
 
-	// 关联关系
-	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+// Bookmark 收藏的帖子（包括转发和引用），每个帖子只能收藏一次，可以放入一个收藏夹
+type Bookmark struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:char(36);primary_key;index:idx_bookmarks_user_created_id,priority:3"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_bookmarks_user_post;index:idx_bookmarks_user_created_id,priority:1"`
+	PostID       uuid.UUID  `json:"post_id" gorm:"type:char(36);not null;uniqueIndex:idx_bookmarks_user_post"`
+	CollectionID *uuid.UUID `json:"collection_id,omitempty" gorm:"type:char(36);index"`               // 所在收藏夹，为空时未分类
+	CreatedAt    time.Time  `json:"created_at" gorm:"index:idx_bookmarks_user_created_id,priority:2"` // 与id组成游标分页的排序键
 }
 
-// ==================== 以下模型已停用 ====================
-// 注释掉所有交易相关的模型，但保留代码以备将来需要时恢复
+// 联邦投递状态
+const (
+	DeliveryStatusPending   = "pending"   // 等待投递或重试
+	DeliveryStatusDelivered = "delivered" // 对方收件箱已接收
+	DeliveryStatusFailed    = "failed"    // 重试次数用尽
+)
 
-/*
-// Stock 股票/项目模型 - 已停用
+// ActorKey 用户的 ActivityPub 签名密钥，首次被联邦访问时生成
+type ActorKey struct {
+	UserID        uuid.UUID `json:"user_id" gorm:"type:char(36);primary_key"`
+	PublicKeyPEM  string    `json:"public_key_pem" gorm:"type:text;not null"`
+	PrivateKeyPEM string    `json:"-" gorm:"type:text;not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RemoteActor 其他实例上的参与者，缓存其收件箱和公钥
+type RemoteActor struct {
+	ID           uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	URI          string    `json:"uri" gorm:"size:500;not null;uniqueIndex"`    // 参与者ID
+	KeyID        string    `json:"key_id" gorm:"size:500;not null;uniqueIndex"` // 签名使用的 keyId
+	Username     string    `json:"username" gorm:"size:100"`
+	Domain       string    `json:"domain" gorm:"size:255;index"`
+	Inbox        string    `json:"inbox" gorm:"size:500;not null"`
+	SharedInbox  string    `json:"shared_inbox" gorm:"size:500"`
+	PublicKeyPEM string    `json:"public_key_pem" gorm:"type:text;not null"`
+	FetchedAt    time.Time `json:"fetched_at"` // 上次获取参与者文档的时间，过期后重新获取
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RemoteFollower 关注本站用户的远程参与者
+type RemoteFollower struct {
+	UserID        uuid.UUID   `json:"user_id" gorm:"type:char(36);primaryKey"`
+	RemoteActorID uuid.UUID   `json:"remote_actor_id" gorm:"type:char(36);primaryKey;index"`
+	ActivityID    string      `json:"activity_id" gorm:"size:500"` // Follow 活动的ID，Undo 时按它匹配
+	CreatedAt     time.Time   `json:"created_at"`
+	RemoteActor   RemoteActor `json:"remote_actor,omitempty" gorm:"foreignKey:RemoteActorID"`
+}
+
+// FederationDelivery 待投递到远程收件箱的活动，失败后按指数退避重试
+type FederationDelivery struct {
+	ID            uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	UserID        uuid.UUID `json:"user_id" gorm:"type:char(36);not null;index"` // 以哪个用户的身份签名
+	Inbox         string    `json:"inbox" gorm:"size:500;not null"`
+	Payload       string    `json:"payload" gorm:"type:text;not null"` // 序列化后的活动
+	Status        string    `json:"status" gorm:"size:20;not null;default:'pending';index:idx_federation_deliveries_due,priority:1"`
+	Attempts      int       `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"index:idx_federation_deliveries_due,priority:2"`
+	LastError     *string   `json:"last_error,omitempty" gorm:"size:255"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Follow 关注关系
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id" gorm:"type:char(36);primaryKey"`       // 关注者
+	FolloweeID uuid.UUID `json:"followee_id" gorm:"type:char(36);primaryKey;index"` // 被关注者
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// 帖子实体类型
+const (
+	EntityTypeHashtag = "hashtag" // #话题
+	EntityTypeMention = "mention" // @用户
+	EntityTypeCashtag = "cashtag" // $股票符号
+)
+
+// PostEntity 帖子中解析出的实体（话题、提及、股票符号）
+type PostEntity struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	PostID          uuid.UUID  `json:"post_id" gorm:"type:char(36);not null;index"`
+	Type            string     `json:"type" gorm:"not null;size:20;index:idx_post_entities_type_value"`
+	Value           string     `json:"value" gorm:"not null;size:100;index:idx_post_entities_type_value"` // 归一化后的值
+	StartOffset     int        `json:"start" gorm:"not null"`                                             // 字符偏移（含）
+	EndOffset       int        `json:"end" gorm:"not null"`                                               // 字符偏移（不含）
+	MentionedUserID *uuid.UUID `json:"mentioned_user_id,omitempty" gorm:"type:char(36);index"`            // 被提及的用户
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// PostScore 帖子热度分，由后台任务定期重算，用于时间线的热门排序
+type PostScore struct {
+	PostID     uuid.UUID `json:"post_id" gorm:"type:char(36);primary_key"`
+	Engagement float64   `json:"engagement" gorm:"not null;default:0"`  // 加权互动数（转发、引用、投票、收藏）
+	Score      float64   `json:"score" gorm:"not null;default:0;index"` // 热度分，越新、互动越多越高
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// 通知类型
+const (
+	NotificationTypeMention        = "mention"         // 被提及
+	NotificationTypeReply          = "reply"           // 帖子被回复
+	NotificationTypeReaction       = "reaction"        // 帖子收到互动
+	NotificationTypeFollow         = "follow"          // 新的关注者
+	NotificationTypeRepost         = "repost"          // 帖子被转发
+	NotificationTypeQuote          = "quote"           // 帖子被引用
+	NotificationTypeTradeFill      = "trade_fill"      // 交易成交
+	NotificationTypeModeration     = "moderation"      // 收到审核处理
+	NotificationTypeGift           = "gift"            // 收到赠送的 YOLO
+	NotificationTypeOrderTriggered = "order_triggered" // 条件单触发
+)
+
+// NotificationTypes 所有通知类型
+var NotificationTypes = []string{
+	NotificationTypeMention,
+	NotificationTypeReply,
+	NotificationTypeReaction,
+	NotificationTypeFollow,
+	NotificationTypeRepost,
+	NotificationTypeQuote,
+	NotificationTypeTradeFill,
+	NotificationTypeModeration,
+	NotificationTypeGift,
+	NotificationTypeOrderTriggered,
+}
+
+// Notification 用户通知
+type Notification struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:char(36);primary_key"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:char(36);not null;index:idx_notifications_user_created"` // 接收者
+	ActorID   *uuid.UUID     `json:"actor_id,omitempty" gorm:"type:char(36)"`                                    // 触发者
+	Type      string         `json:"type" gorm:"not null;size:20"`
+	PostID    *uuid.UUID     `json:"post_id,omitempty" gorm:"type:char(36)"` // 相关帖子
+	Data      map[string]any `json:"data,omitempty" gorm:"type:text;serializer:json"`
+	ReadAt    *time.Time     `json:"read_at,omitempty" gorm:"index"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index:idx_notifications_user_created"`
+
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// NotificationPreference 用户按通知类型的偏好设置，没有记录时默认全部开启
+type NotificationPreference struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey"`
+	Type      string    `json:"type" gorm:"size:20;primaryKey"`
+	Store     bool      `json:"store" gorm:"not null"`   // 是否保存到通知列表
+	Deliver   bool      `json:"deliver" gorm:"not null"` // 是否实时推送
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 举报对象类型
+const (
+	ReportTargetPost  = "post"
+	ReportTargetUser  = "user"
+	ReportTargetStock = "stock"
+)
+
+// 举报原因
+const (
+	ReportReasonSpam           = "spam"           // 垃圾信息
+	ReportReasonHarassment     = "harassment"     // 骚扰或霸凌
+	ReportReasonHateSpeech     = "hate_speech"    // 仇恨言论
+	ReportReasonViolence       = "violence"       // 暴力或威胁
+	ReportReasonScam           = "scam"           // 诈骗或操纵市场
+	ReportReasonMisinformation = "misinformation" // 虚假信息
+	ReportReasonImpersonation  = "impersonation"  // 冒充他人
+	ReportReasonOther          = "other"          // 其他，需要填写说明
+)
+
+// ReportReasons 所有举报原因
+var ReportReasons = []string{
+	ReportReasonSpam,
+	ReportReasonHarassment,
+	ReportReasonHateSpeech,
+	ReportReasonViolence,
+	ReportReasonScam,
+	ReportReasonMisinformation,
+	ReportReasonImpersonation,
+	ReportReasonOther,
+}
+
+// 举报处理状态
+const (
+	ReportStatusOpen      = "open"      // 等待处理
+	ReportStatusClaimed   = "claimed"   // 审核员已认领
+	ReportStatusResolved  = "resolved"  // 已处理
+	ReportStatusDismissed = "dismissed" // 已驳回
+)
+
+// 审核处理动作
+const (
+	ModerationActionHidePost    = "hide_post"    // 隐藏帖子
+	ModerationActionSuspendUser = "suspend_user" // 封禁用户
+	ModerationActionWarn        = "warn"         // 警告
+	ModerationActionDismiss     = "dismiss"      // 驳回举报，不做处理
+)
+
+// Report 用户举报
+type Report struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	ReporterID   *uuid.UUID `json:"reporter_id,omitempty" gorm:"type:char(36);index"`
+	TargetType   string     `json:"target_type" gorm:"not null;size:20;index:idx_reports_target,priority:1"`     // post/user/stock
+	TargetID     uuid.UUID  `json:"target_id" gorm:"type:char(36);not null;index:idx_reports_target,priority:2"` // 被举报的帖子/用户/股票
+	TargetUserID uuid.UUID  `json:"target_user_id" gorm:"type:char(36);not null;index"`                          // 被举报的用户（帖子作者、股票创作者）
+	Reason       string     `json:"reason" gorm:"not null;size:30"`                                              // 举报原因
+	Details      string     `json:"details" gorm:"type:text"`                                                    // 补充说明
+	Status       string     `json:"status" gorm:"not null;size:20;default:'open';index:idx_reports_status_created,priority:1"`
+	ModeratorID  *uuid.UUID `json:"moderator_id,omitempty" gorm:"type:char(36);index"` // 认领的审核员
+	ClaimedAt    *time.Time `json:"claimed_at,omitempty"`
+	ActionID     *uuid.UUID `json:"action_id,omitempty" gorm:"type:char(36)"` // 处理结果
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"index:idx_reports_status_created,priority:2"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	Reporter   *User `json:"reporter,omitempty" gorm:"foreignKey:ReporterID"`
+	TargetUser User  `json:"target_user,omitempty" gorm:"foreignKey:TargetUserID"`
+}
+
+// ModerationAction 审核处理记录，每次处理（包括驳回）都会记录，被举报用户可以查看
+type ModerationAction struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	ModeratorID  uuid.UUID  `json:"moderator_id" gorm:"type:char(36);not null;index"`
+	TargetUserID uuid.UUID  `json:"target_user_id" gorm:"type:char(36);not null;index:idx_moderation_actions_user_created,priority:1"`
+	TargetType   string     `json:"target_type" gorm:"not null;size:20"`
+	TargetID     uuid.UUID  `json:"target_id" gorm:"type:char(36);not null"`
+	Action       string     `json:"action" gorm:"not null;size:20"`
+	Reason       string     `json:"reason" gorm:"not null;size:30"` // 对应的举报原因
+	Note         string     `json:"note" gorm:"type:text"`          // 审核员说明，被处理用户可见
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`           // 封禁截止时间
+	CreatedAt    time.Time  `json:"created_at" gorm:"index:idx_moderation_actions_user_created,priority:2"`
+}
+
+// ==================== 股票与持仓模型 ====================
+// 用于识别创作者及其持有者，交易通过流动性池撮合
+
+// Stock 股票/项目模型，拥有股票的用户即为创作者
 type Stock struct {
-	ID          uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
-	UserID      uuid.UUID `json:"user_id" gorm:"type:char(36);not null"`
-	Name        string    `json:"name" gorm:"not null;size:100"`
-	Symbol      string    `json:"symbol" gorm:"uniqueIndex;not null;size:10"`
-	Image       *string   `json:"img,omitempty" gorm:"size:500"`
-	Status      string    `json:"status" gorm:"not null;size:20;default:'demo'"`
-	Price       float64   `json:"price" gorm:"default:1.00"`
-	DailyChange float64   `json:"dailyChange" gorm:"default:0.00"`
-	DailyVolume float64   `json:"dailyVolume" gorm:"default:0.00"`
-	MarketCap   float64   `json:"marketCap" gorm:"default:0.00"`
-	Owners      int       `json:"owners" gorm:"default:0"`
-	Supply      float64   `json:"supply" gorm:"default:1000000.00"`
-	Description *string   `json:"description,omitempty" gorm:"type:text"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	UserID      uuid.UUID       `json:"user_id" gorm:"type:char(36);not null;index"`
+	Name        string          `json:"name" gorm:"not null;size:100"`
+	Symbol      string          `json:"symbol" gorm:"uniqueIndex;not null;size:10"`
+	Category    string          `json:"category" gorm:"not null;size:30;default:'';index"` // 分类，为空时未分类
+	Image       *string         `json:"img,omitempty" gorm:"size:500"`
+	Status      string          `json:"status" gorm:"not null;size:20;default:'demo'"`
+	Price       decimal.Decimal `json:"price" gorm:"default:1.00"`
+	DailyChange float64         `json:"dailyChange" gorm:"default:0.00"` // 涨跌幅百分比
+	DailyVolume decimal.Decimal `json:"dailyVolume" gorm:"default:0.00"`
+	MarketCap   decimal.Decimal `json:"marketCap" gorm:"default:0.00"`
+	Owners      int             `json:"owners" gorm:"default:0"`
+	Supply      decimal.Decimal `json:"supply" gorm:"default:1000000.00"`
+	Description *string         `json:"description,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 
-	User      User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Holdings  []UserHolding `json:"holdings,omitempty" gorm:"foreignKey:StockID"`
-	Trades    []Trade       `json:"trades,omitempty" gorm:"foreignKey:StockID"`
-	ChartData []ChartData   `json:"chartData,omitempty" gorm:"foreignKey:StockID"`
+	User     User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Holdings []UserHolding `json:"holdings,omitempty" gorm:"foreignKey:StockID"`
+	// Trades    []Trade       `json:"trades,omitempty" gorm:"foreignKey:StockID"`
+	// ChartData []ChartData   `json:"chartData,omitempty" gorm:"foreignKey:StockID"`
 }
 
-// UserHolding 用户持仓模型 - 已停用
+// UserHolding 用户持仓模型
 type UserHolding struct {
-	ID        uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);not null"`
-	StockID   uuid.UUID `json:"stock_id" gorm:"type:char(36);not null"`
-	Quantity  float64   `json:"quantity" gorm:"default:0.00"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	UserID    uuid.UUID       `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_user_holdings_user_stock,priority:1"`
+	StockID   uuid.UUID       `json:"stock_id" gorm:"type:char(36);not null;index;uniqueIndex:idx_user_holdings_user_stock,priority:2"`
+	Quantity  decimal.Decimal `json:"quantity" gorm:"default:0.00"`
+	Locked    decimal.Decimal `json:"locked" gorm:"not null;default:0"` // 卖单冻结的股数，可卖出的是 Quantity - Locked
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
 
 	User  User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Stock Stock `json:"stock,omitempty" gorm:"foreignKey:StockID"`
 }
 
-// Trade 交易记录模型 - 已停用
-type Trade struct {
+// ErrLedgerImmutable 试图修改或删除已记账的凭证
+var ErrLedgerImmutable = errors.New("journal entries are immutable")
+
+// DefaultUserBalance 新用户开户时发放的平台代币(YOLO)
+var DefaultUserBalance = decimal.FromInt(8000)
+
+// 账本账户类型
+const (
+	LedgerAccountUser     = "user"     // 用户的 YOLO 余额
+	LedgerAccountPool     = "pool"     // 流动性池中的 YOLO 储备
+	LedgerAccountPlatform = "platform" // 平台账户：发放代币的金库、手续费收入等
+	LedgerAccountBook     = "book"     // 订单簿中买单冻结的 YOLO
+)
+
+// 平台账户代码
+const (
+	LedgerTreasuryCode = "platform:treasury" // 金库，所有发放的代币从这里借出，余额为负
+	LedgerFeesCode     = "platform:fees"     // 手续费收入
+)
+
+// 记账凭证类型
+const (
+	JournalTypeGrant = "grant" // 平台发放代币：开户赠送、流动性池注资
+	JournalTypeTrade = "trade" // 交易
+	JournalTypeGift  = "gift"  // 用户之间赠送
+	JournalTypeFee   = "fee"   // 手续费
+)
+
+// LedgerAccount 复式记账账户，Balance 是分录的快照，与分录在同一事务中更新
+type LedgerAccount struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	Code      string          `json:"code" gorm:"uniqueIndex;not null;size:60"` // user:<userId> / pool:<stockId> / book:<stockId> / platform:<name>
+	Type      string          `json:"type" gorm:"not null;size:20;index"`
+	OwnerID   *uuid.UUID      `json:"owner_id,omitempty" gorm:"type:char(36)"` // 用户ID或股票ID
+	Balance   decimal.Decimal `json:"balance" gorm:"not null;default:0"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// JournalEntry 记账凭证，写入后不可修改，所有分录金额之和为零
+type JournalEntry struct {
 	ID            uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
-	StockID       uuid.UUID  `json:"stock_id" gorm:"type:char(36);not null"`
-	BuyerID       uuid.UUID  `json:"buyer_id" gorm:"type:char(36);not null"`
-	SellerID      *uuid.UUID `json:"seller_id,omitempty" gorm:"type:char(36)"`
-	Type          string     `json:"type" gorm:"not null;size:10"`
-	Amount        float64    `json:"amount" gorm:"not null"`
-	Price         float64    `json:"price" gorm:"not null"`
-	TotalValue    float64    `json:"total_value" gorm:"not null"`
-	TransactionID string     `json:"transaction_id" gorm:"uniqueIndex;not null;size:100"`
-	Status        string     `json:"status" gorm:"not null;size:20;default:'completed'"`
-	CreatedAt     time.Time  `json:"created_at"`
+	Type          string     `json:"type" gorm:"not null;size:20"`
+	ReferenceType string     `json:"reference_type,omitempty" gorm:"size:20;index:idx_journal_entries_reference,priority:1"` // 关联的业务记录，如 trade
+	ReferenceID   *uuid.UUID `json:"reference_id,omitempty" gorm:"type:char(36);index:idx_journal_entries_reference,priority:2"`
+	Memo          string     `json:"memo" gorm:"size:200"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+
+	Postings []LedgerPosting `json:"postings,omitempty" gorm:"foreignKey:EntryID"`
+}
+
+// LedgerPosting 分录，正数记入账户余额，负数从账户余额扣除
+type LedgerPosting struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	EntryID   uuid.UUID       `json:"entry_id" gorm:"type:char(36);not null;index"`
+	AccountID uuid.UUID       `json:"account_id" gorm:"type:char(36);not null;index:idx_ledger_postings_account_created,priority:1"`
+	Amount    decimal.Decimal `json:"amount" gorm:"not null"`
+	CreatedAt time.Time       `json:"created_at" gorm:"index:idx_ledger_postings_account_created,priority:2"`
+
+	Entry   JournalEntry  `json:"entry,omitempty" gorm:"foreignKey:EntryID"`
+	Account LedgerAccount `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+}
+
+// LiquidityPool 股票的恒定乘积(x·y=k)流动性池，价格为 YoloReserve / StockReserve
+type LiquidityPool struct {
+	StockID      uuid.UUID       `json:"stock_id" gorm:"type:char(36);primary_key"`
+	YoloReserve  decimal.Decimal `json:"yolo_reserve" gorm:"not null"`
+	StockReserve decimal.Decimal `json:"stock_reserve" gorm:"not null"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+
+	Stock Stock `json:"stock,omitempty" gorm:"foreignKey:StockID"`
+}
+
+// 交易方向
+const (
+	TradeTypeBuy  = "buy"
+	TradeTypeSell = "sell"
+)
+
+// 交易状态
+const (
+	TradeStatusCompleted = "completed"
+)
+
+// Trade 成交记录，Type 为发起方的方向；买方或卖方为空时对手方是流动性池
+type Trade struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	StockID       uuid.UUID       `json:"stock_id" gorm:"type:char(36);not null;index:idx_trades_stock_created,priority:1"`
+	BuyerID       *uuid.UUID      `json:"buyer_id,omitempty" gorm:"type:char(36);index"`
+	SellerID      *uuid.UUID      `json:"seller_id,omitempty" gorm:"type:char(36);index"`
+	BuyOrderID    *uuid.UUID      `json:"buy_order_id,omitempty" gorm:"type:char(36);index"` // 直接与流动性池交易时为空
+	SellOrderID   *uuid.UUID      `json:"sell_order_id,omitempty" gorm:"type:char(36);index"`
+	Type          string          `json:"type" gorm:"not null;size:10"`
+	Amount        decimal.Decimal `json:"amount" gorm:"not null"`                              // 股数
+	Price         decimal.Decimal `json:"price" gorm:"not null"`                               // 成交均价
+	TotalValue    decimal.Decimal `json:"total_value" gorm:"not null"`                         // YOLO 金额
+	Fee           decimal.Decimal `json:"fee" gorm:"not null;default:0"`                       // 吃单方支付的 YOLO 手续费，买入时计入 TotalValue，卖出时已从中扣除
+	QuoteID       *uuid.UUID      `json:"quote_id,omitempty" gorm:"type:char(36);uniqueIndex"` // 按签名报价成交时为报价ID，每个报价只能成交一次
+	TransactionID string          `json:"transaction_id" gorm:"uniqueIndex;not null;size:100"`
+	Status        string          `json:"status" gorm:"not null;size:20;default:'completed'"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"index:idx_trades_stock_created,priority:2"`
 
 	Stock  Stock `json:"stock,omitempty" gorm:"foreignKey:StockID"`
-	Buyer  User  `json:"buyer,omitempty" gorm:"foreignKey:BuyerID"`
+	Buyer  *User `json:"buyer,omitempty" gorm:"foreignKey:BuyerID"`
 	Seller *User `json:"seller,omitempty" gorm:"foreignKey:SellerID"`
 }
 
+// 订单类型
+const (
+	OrderTypeLimit  = "limit"
+	OrderTypeMarket = "market"
+)
+
+// 订单有效期
+const (
+	TimeInForceGTC = "gtc" // 撤单前一直有效，未成交部分挂在订单簿上
+	TimeInForceIOC = "ioc" // 立即成交，未成交部分撤销
+	TimeInForceFOK = "fok" // 全部立即成交，否则整单拒绝
+)
+
+// 订单状态
+const (
+	OrderStatusOpen      = "open"      // 挂在订单簿上，可能已部分成交
+	OrderStatusFilled    = "filled"    // 全部成交
+	OrderStatusCancelled = "cancelled" // 已撤销，可能已部分成交
+)
+
+// Order 订单簿中的委托，按 价格优先、Sequence 先后 撮合
+// 挂单时买单按限价冻结 YOLO 到订单簿账户，卖单冻结持仓中的股份
+type Order struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	StockID     uuid.UUID       `json:"stock_id" gorm:"type:char(36);not null;uniqueIndex:idx_orders_stock_sequence,priority:1;index:idx_orders_book,priority:1"`
+	UserID      uuid.UUID       `json:"user_id" gorm:"type:char(36);not null;index"`
+	Side        string          `json:"side" gorm:"not null;size:10;index:idx_orders_book,priority:2"` // buy / sell
+	Type        string          `json:"type" gorm:"not null;size:10"`
+	TimeInForce string          `json:"time_in_force" gorm:"not null;size:10"`
+	Price       decimal.Decimal `json:"price" gorm:"not null;default:0;index:idx_orders_book,priority:4"` // 限价，市价单为 0
+	Quantity    decimal.Decimal `json:"quantity" gorm:"not null"`
+	Filled      decimal.Decimal `json:"filled" gorm:"not null;default:0"`
+	Reserved    decimal.Decimal `json:"reserved" gorm:"not null;default:0"` // 买单剩余部分冻结的 YOLO
+	Sequence    int64           `json:"sequence" gorm:"not null;uniqueIndex:idx_orders_stock_sequence,priority:2"`
+	Status      string          `json:"status" gorm:"not null;size:20;index:idx_orders_book,priority:3"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	Stock Stock `json:"stock,omitempty" gorm:"foreignKey:StockID"`
+	User  User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Remaining 未成交的股数
+func (o *Order) Remaining() decimal.Decimal {
+	return o.Quantity.Sub(o.Filled)
+}
+
+// 条件单类型
+const (
+	ConditionalKindStopLoss   = "stop_loss"   // 止损：卖出在价格跌到触发价时触发，买入在涨到触发价时触发
+	ConditionalKindTakeProfit = "take_profit" // 止盈：卖出在价格涨到触发价时触发，买入在跌到触发价时触发
+)
+
+// 条件单的触发价格来源
+const (
+	TriggerSourceLast = "last" // 最新成交价
+	TriggerSourceTWAP = "twap" // 最近一段时间的时间加权平均成交价
+)
+
+// 条件单状态
+const (
+	ConditionalStatusPending   = "pending"   // 等待触发
+	ConditionalStatusTriggered = "triggered" // 已触发并提交订单
+	ConditionalStatusCancelled = "cancelled" // 已撤销
+	ConditionalStatusFailed    = "failed"    // 已触发但订单被拒绝（余额或持仓不足等）
+)
+
+// ConditionalOrder 止损、止盈条件单，价格到达触发价后提交一笔市价或限价订单
+// 触发时在同一事务中把状态从 pending 改为 triggered 并下单，每张条件单只会触发一次
+type ConditionalOrder struct {
+	ID             uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	StockID        uuid.UUID       `json:"stock_id" gorm:"type:char(36);not null;index:idx_conditional_orders_pending,priority:1"`
+	UserID         uuid.UUID       `json:"user_id" gorm:"type:char(36);not null;index"`
+	Kind           string          `json:"kind" gorm:"not null;size:20"`
+	TriggerSource  string          `json:"trigger_source" gorm:"not null;size:10"`
+	TriggerPrice   decimal.Decimal `json:"trigger_price" gorm:"not null"`
+	TWAPWindow     int             `json:"twap_window" gorm:"column:twap_window;not null;default:0"` // TWAP 窗口秒数
+	Side           string          `json:"side" gorm:"not null;size:10"`                             // 触发后提交的订单
+	OrderType      string          `json:"order_type" gorm:"not null;size:10"`
+	TimeInForce    string          `json:"time_in_force" gorm:"not null;size:10"`
+	LimitPrice     decimal.Decimal `json:"limit_price" gorm:"not null;default:0"`
+	Quantity       decimal.Decimal `json:"quantity" gorm:"not null"`
+	Status         string          `json:"status" gorm:"not null;size:20;index:idx_conditional_orders_pending,priority:2"`
+	TriggeredPrice decimal.Decimal `json:"triggered_price" gorm:"not null;default:0"` // 触发时的价格
+	TriggeredAt    *time.Time      `json:"triggered_at"`
+	OrderID        *uuid.UUID      `json:"order_id" gorm:"type:char(36)"` // 触发后提交的订单
+	FailureReason  string          `json:"failure_reason" gorm:"size:255"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	Stock Stock `json:"stock,omitempty" gorm:"foreignKey:StockID"`
+	User  User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// PortfolioSnapshot 用户每日的投资组合价值，按 UTC 日期每天一行，当天内的快照覆盖同一行，日终后即为当天的收盘价值
+type PortfolioSnapshot struct {
+	ID            uuid.UUID       `json:"id" gorm:"type:char(36);primary_key"`
+	UserID        uuid.UUID       `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_portfolio_snapshots_user_date,priority:1"`
+	Date          string          `json:"date" gorm:"not null;size:10;uniqueIndex:idx_portfolio_snapshots_user_date,priority:2"` // 2006-01-02
+	Cash          decimal.Decimal `json:"cash" gorm:"not null;default:0"`                                                        // YOLO 余额
+	OpenOrders    decimal.Decimal `json:"open_orders" gorm:"not null;default:0"`                                                 // 挂单买单冻结的 YOLO
+	HoldingsValue decimal.Decimal `json:"holdings_value" gorm:"not null;default:0"`                                              // 持股按池中价格计算的市值
+	TotalValue    decimal.Decimal `json:"total_value" gorm:"not null;default:0"`
+	CostBasis     decimal.Decimal `json:"cost_basis" gorm:"not null;default:0"`
+	RealizedPnL   decimal.Decimal `json:"realized_pnl" gorm:"column:realized_pnl;not null;default:0"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl" gorm:"column:unrealized_pnl;not null;default:0"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// ==================== 以下模型已停用 ====================
+// 注释掉所有交易相关的模型，但保留代码以备将来需要时恢复
+
+/*
 // ChartData K线图数据模型 - 已停用
 type ChartData struct {
 	ID         uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
@@ -154,8 +764,83 @@ func (p *Post) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// ==================== 以下钩子函数已停用 ====================
-/*
+func (e *PostEntity) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+func (m *Media) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *Poll) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (o *PollOption) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+func (c *BookmarkCollection) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (b *Bookmark) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *RemoteActor) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (d *FederationDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *ModerationAction) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
 func (s *Stock) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == uuid.Nil {
 		s.ID = uuid.New()
@@ -177,6 +862,67 @@ func (t *Trade) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (o *Order) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+func (c *ConditionalOrder) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *PortfolioSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *LedgerAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *JournalEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *LedgerPosting) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeUpdate 记账凭证写入后不可修改，更正需要另记一笔冲销凭证
+func (e *JournalEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (e *JournalEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (p *LedgerPosting) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+func (p *LedgerPosting) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// ==================== 以下钩子函数已停用 ====================
+/*
 func (cd *ChartData) BeforeCreate(tx *gorm.DB) error {
 	if cd.ID == uuid.Nil {
 		cd.ID = uuid.New()
@@ -201,8 +947,86 @@ func (Post) TableName() string {
 	return "posts"
 }
 
-// ==================== 以下表名函数已停用 ====================
-/*
+func (PostEntity) TableName() string {
+	return "post_entities"
+}
+
+func (PostScore) TableName() string {
+	return "post_scores"
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+func (Follow) TableName() string {
+	return "follows"
+}
+
+func (Media) TableName() string {
+	return "media"
+}
+
+func (LinkPreview) TableName() string {
+	return "link_previews"
+}
+
+func (PostLink) TableName() string {
+	return "post_links"
+}
+
+func (Poll) TableName() string {
+	return "polls"
+}
+
+func (PollOption) TableName() string {
+	return "poll_options"
+}
+
+func (PollBallot) TableName() string {
+	return "poll_ballots"
+}
+
+func (PollVote) TableName() string {
+	return "poll_votes"
+}
+
+func (BookmarkCollection) TableName() string {
+	return "bookmark_collections"
+}
+
+func (Bookmark) TableName() string {
+	return "bookmarks"
+}
+
+func (ActorKey) TableName() string {
+	return "actor_keys"
+}
+
+func (RemoteActor) TableName() string {
+	return "remote_actors"
+}
+
+func (RemoteFollower) TableName() string {
+	return "remote_followers"
+}
+
+func (FederationDelivery) TableName() string {
+	return "federation_deliveries"
+}
+
+func (Report) TableName() string {
+	return "reports"
+}
+
+func (ModerationAction) TableName() string {
+	return "moderation_actions"
+}
+
 func (Stock) TableName() string {
 	return "stocks"
 }
@@ -211,10 +1035,40 @@ func (UserHolding) TableName() string {
 	return "user_holdings"
 }
 
+func (LedgerAccount) TableName() string {
+	return "ledger_accounts"
+}
+
+func (JournalEntry) TableName() string {
+	return "journal_entries"
+}
+
+func (LedgerPosting) TableName() string {
+	return "ledger_postings"
+}
+
+func (LiquidityPool) TableName() string {
+	return "liquidity_pools"
+}
+
 func (Trade) TableName() string {
 	return "trades"
 }
 
+func (Order) TableName() string {
+	return "orders"
+}
+
+func (ConditionalOrder) TableName() string {
+	return "conditional_orders"
+}
+
+func (PortfolioSnapshot) TableName() string {
+	return "portfolio_snapshots"
+}
+
+// ==================== 以下表名函数已停用 ====================
+/*
 func (ChartData) TableName() string {
 	return "chart_data"
 }
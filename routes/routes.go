@@ -19,14 +19,26 @@ func SetupRoutes() *gin.Engine {
 
 	// 健康检查
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok", "message": "YOLO API is running - User Management Only"})
+		c.JSON(200, gin.H{"status": "ok", "message": "YOLO API is running"})
 	})
 
+	// ActivityPub 联邦，地址由其他实例解析，放在 /api/v1 之外；未开启联邦时返回 404
+	router.GET("/.well-known/webfinger", controllers.WebFinger)
+	federation := router.Group("/ap")
+	{
+		federation.GET("/users/:username", controllers.GetActor)
+		federation.GET("/users/:username/outbox", controllers.GetOutbox)
+		federation.GET("/users/:username/followers", controllers.GetFollowersCollection)
+		federation.POST("/users/:username/inbox", controllers.PostInbox)
+		federation.GET("/posts/:postId", controllers.GetNote)
+	}
+
 	// API版本分组
 	v1 := router.Group("/api/v1")
 
-	// 公开路由（无需认证）
+	// 公开路由（无需认证，登录用户可看到更多可见范围的帖子）
 	public := v1.Group("/")
+	public.Use(middleware.OptionalAuthMiddleware())
 	{
 		// 用户认证相关
 		public.POST("/auth/register", controllers.Register)
@@ -36,9 +48,30 @@ func SetupRoutes() *gin.Engine {
 		// 公开的用户信息
 		public.GET("/users/:username", controllers.GetUserPublicInfo)
 		public.GET("/users/:username/posts", controllers.GetUserPosts)
+		public.GET("/users/:username/feed.atom", controllers.GetUserAtomFeed)
+		public.GET("/users/:username/feed.rss", controllers.GetUserRSSFeed)
+		public.GET("/users/:username/feed.json", controllers.GetUserJSONFeed)
+		public.GET("/users/:username/portfolio/history", controllers.GetUserPortfolioHistory)
 
 		// 公开的帖子信息（如果需要保留）
 		public.GET("/posts/timeline", controllers.GetTimeline)
+		public.GET("/posts/search", controllers.SearchPosts)
+		public.GET("/posts/:postId", controllers.GetPost)
+		public.GET("/posts/:postId/poll", controllers.GetPoll)
+
+		// 话题和股票符号讨论区
+		public.GET("/tags/:tag/posts", controllers.GetTagPosts)
+		public.GET("/symbols/:symbol/posts", controllers.GetSymbolPosts)
+		public.GET("/trending", controllers.GetTrending)
+
+		// 股票（STOCKS_ENABLED 关闭时返回 404）
+		public.GET("/stocks", controllers.GetStocks)
+		public.GET("/stocks/:symbol", controllers.GetStockDetail)
+		public.GET("/stocks/:symbol/quote", controllers.GetTradeQuote)
+		public.GET("/stocks/:symbol/orderbook", controllers.GetOrderBook)
+
+		// 媒体文件
+		public.GET("/media/files/*key", controllers.ServeMediaFile)
 	}
 
 	// 需要认证的路由
@@ -50,17 +83,72 @@ func SetupRoutes() *gin.Engine {
 		protected.GET("/user/profile", controllers.GetUserProfile)
 		protected.PUT("/user/profile", controllers.UpdateUserProfile)
 
+		protected.GET("/user/balance", controllers.GetUserBalance)
+		protected.GET("/user/ledger", controllers.GetUserLedger)
+		protected.GET("/user/portfolio", controllers.GetUserPortfolio)
+
+		// 发行和交易股票
+		protected.POST("/stocks", controllers.CreateStock)
+		protected.POST("/stocks/:symbol/quote", controllers.CreateQuote)
+		protected.POST("/stocks/:symbol/trade", controllers.TradeStock)
+		protected.POST("/stocks/:symbol/orders", controllers.PlaceOrder)
+		protected.GET("/user/orders", controllers.GetUserOrders)
+		protected.DELETE("/orders/:orderId", controllers.CancelOrder)
+		protected.POST("/stocks/:symbol/conditional-orders", controllers.CreateConditionalOrder)
+		protected.GET("/user/conditional-orders", controllers.GetUserConditionalOrders)
+		protected.DELETE("/conditional-orders/:conditionalOrderId", controllers.CancelConditionalOrder)
+
+		// 关注
+		protected.POST("/users/:username/follow", controllers.FollowUser)
+		protected.DELETE("/users/:username/follow", controllers.UnfollowUser)
+		protected.POST("/users/:username/gifts", controllers.SendGift)
+
 		// 帖子管理（如果需要保留）
 		protected.POST("/posts", controllers.CreatePost)
+		protected.DELETE("/posts/:postId", controllers.DeletePost)
+		protected.POST("/posts/:postId/repost", controllers.Repost)
+		protected.DELETE("/posts/:postId/repost", controllers.Unrepost)
+		protected.POST("/posts/:postId/poll/votes", controllers.VotePoll)
+
+		// 草稿与定时发布
+		protected.GET("/posts/drafts", controllers.GetDrafts)
+		protected.GET("/posts/scheduled", controllers.GetScheduledPosts)
+		protected.PUT("/posts/:postId", controllers.UpdateDraft)
+		protected.PUT("/posts/:postId/schedule", controllers.SchedulePost)
+		protected.DELETE("/posts/:postId/schedule", controllers.UnschedulePost)
+		protected.POST("/posts/:postId/publish", controllers.PublishPost)
+
+		// 收藏
+		protected.GET("/bookmarks", controllers.GetBookmarks)
+		protected.POST("/bookmarks", controllers.AddBookmark)
+		protected.DELETE("/bookmarks/:postId", controllers.RemoveBookmark)
+		protected.GET("/bookmarks/collections", controllers.GetBookmarkCollections)
+		protected.POST("/bookmarks/collections", controllers.CreateBookmarkCollection)
+		protected.PATCH("/bookmarks/collections/:collectionId", controllers.RenameBookmarkCollection)
+		protected.DELETE("/bookmarks/collections/:collectionId", controllers.DeleteBookmarkCollection)
+
+		// 媒体附件
+		protected.POST("/media", controllers.UploadMedia)
+		protected.GET("/media/:mediaId", controllers.GetMedia)
+		protected.PATCH("/media/:mediaId", controllers.UpdateMedia)
+
+		// 举报与审核
+		protected.POST("/reports", controllers.CreateReport)
+		protected.GET("/user/moderation-actions", controllers.GetModerationActions)
+		protected.GET("/moderation/reports", controllers.GetReports)
+		protected.POST("/moderation/reports/:reportId/claim", controllers.ClaimReport)
+		protected.POST("/moderation/reports/:reportId/resolve", controllers.ResolveReport)
+		protected.POST("/moderation/reports/:reportId/dismiss", controllers.DismissReport)
+
+		// 通知
+		protected.GET("/notifications", controllers.GetNotifications)
+		protected.GET("/notifications/unread-count", controllers.GetUnreadNotificationCount)
+		protected.POST("/notifications/read", controllers.MarkNotificationsRead)
+		protected.GET("/notifications/preferences", controllers.GetNotificationPreferences)
+		protected.PUT("/notifications/preferences", controllers.UpdateNotificationPreferences)
 
 		// ==================== 以下功能已停用 ====================
-		// 股票相关功能已停用
-		// protected.GET("/stocks", controllers.GetStocks)
-		// protected.GET("/stocks/:symbol", controllers.GetStockDetail)
-		// protected.POST("/stocks/trade", controllers.TradeStock)
-		
 		// 用户交易相关功能已停用
-		// protected.GET("/user/balance", controllers.GetUserBalance)
 		// protected.GET("/user/holdings", controllers.GetUserHoldings)
 		// protected.GET("/user/trades", controllers.GetUserTrades)
 
@@ -76,5 +164,8 @@ func SetupRoutes() *gin.Engine {
 		// protected.GET("/gifts/sent", controllers.GetSentGifts)
 	}
 
+	// 实时推送（SSE），EventSource 无法设置请求头，单独使用支持查询参数token的认证
+	v1.GET("/stream", middleware.StreamAuthMiddleware(), controllers.Stream)
+
 	return router
 }
@@ -15,11 +15,11 @@ export SKIP_WEB3_INIT=true
 
 # 运行所有测试
 echo "📋 Running all tests..."
-go test ./tests/... -v
+go test -tags sqlite_fts5 ./tests/... -v
 
 echo ""
 echo "📊 Running tests with coverage..."
-go test ./tests/... -v -coverprofile=coverage.out
+go test -tags sqlite_fts5 ./tests/... -v -coverprofile=coverage.out
 
 echo ""
 echo "📈 Generating coverage report..."
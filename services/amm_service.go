@@ -0,0 +1,500 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"yolo/database"
+	"yolo/decimal"
+	"yolo/hub"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreatorAllocation 发行时分配给创作者的股份比例，其余注入流动性池
+var CreatorAllocation = decimal.RequireFromString("0.35")
+
+// 交易相关错误
+var (
+	ErrPoolNotFound          = errors.New("liquidity pool not found")
+	ErrInvalidTradeType      = errors.New("type must be buy or sell")
+	ErrInvalidTradeAmount    = errors.New("amount must be positive")
+	ErrTradeTooSmall         = errors.New("trade amount is too small")
+	ErrInsufficientBalance   = errors.New("insufficient balance")
+	ErrInsufficientShares    = errors.New("insufficient shares")
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	ErrTradeAmountConflict   = errors.New("specify either amount or amount_out")
+	ErrSlippageExceeded      = errors.New("pool moved beyond the accepted tolerance")
+)
+
+// EventStockPrice 股票价格变动的实时事件类型
+const EventStockPrice = "stock.price"
+
+// TradeQuote 按当前流动性池计算的报价
+type TradeQuote struct {
+	Type        string          // buy / sell
+	AmountIn    decimal.Decimal // 买入时为支付的 YOLO（含手续费），卖出时为卖出的股数
+	AmountOut   decimal.Decimal // 买入时为得到的股数，卖出时为得到的 YOLO（已扣手续费）
+	Fee         decimal.Decimal // YOLO 手续费
+	Price       decimal.Decimal // 含手续费的成交均价（YOLO/股）
+	MidPrice    decimal.Decimal // 交易前的池中价格
+	PriceImpact decimal.Decimal // 不含手续费的成交均价偏离池中价格的百分比
+}
+
+// TradeOptions 与流动性池交易的参数，AmountIn 与 AmountOut 二选一
+type TradeOptions struct {
+	Type      string          // buy / sell
+	AmountIn  decimal.Decimal // 精确输入：买入时为支付的 YOLO，卖出时为卖出的股数
+	AmountOut decimal.Decimal // 精确输出：买入时为要得到的股数，卖出时为要得到的 YOLO，实际得到的可能因舍入略多
+	MinOut    decimal.Decimal // 精确输入时至少得到的数量，为 0 时不限制
+	MaxIn     decimal.Decimal // 精确输出时最多支付的数量，为 0 时不限制
+	QuoteID   string          // 签名报价，提供时交易方向、数量和容差都取自报价
+}
+
+// TradeResult 交易执行结果
+type TradeResult struct {
+	Trade    models.Trade
+	Quote    TradeQuote
+	Pool     models.LiquidityPool
+	Balance  decimal.Decimal // 交易后的 YOLO 余额
+	Holding  decimal.Decimal // 交易后的持股数
+	NewPrice decimal.Decimal // 交易后的池中价格
+}
+
+type ammService struct{}
+
+// poolPrice 池中价格
+func poolPrice(pool *models.LiquidityPool) decimal.Decimal {
+	if !pool.StockReserve.IsPositive() {
+		return decimal.Zero
+	}
+	return pool.YoloReserve.Div(pool.StockReserve, decimal.RoundHalfEven)
+}
+
+// normalizeTradeType 归一化交易方向
+func normalizeTradeType(tradeType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(tradeType)) {
+	case models.TradeTypeBuy:
+		return models.TradeTypeBuy, nil
+	case models.TradeTypeSell:
+		return models.TradeTypeSell, nil
+	}
+	return "", ErrInvalidTradeType
+}
+
+// tradeFeeRate 当前的手续费比例
+func tradeFeeRate() decimal.Decimal {
+	return StockService.Config().TradeFeeRate
+}
+
+// quote 按恒定乘积公式计算报价：输出 = 对手储备 × 输入 / (输入储备 + 输入)，
+// 只在最后向下舍入一次，多出的尾数留在池中，保证交易后 k 不减少。
+// 手续费按进出池子的 YOLO 金额收取并向上舍入：买入时支付的 YOLO 中 1/(1+费率) 进入池子，卖出时从池子付出的 YOLO 中扣除
+func quote(pool *models.LiquidityPool, tradeType string, amountIn, feeRate decimal.Decimal) (TradeQuote, error) {
+	if !amountIn.IsPositive() {
+		return TradeQuote{}, ErrInvalidTradeAmount
+	}
+	if !pool.YoloReserve.IsPositive() || !pool.StockReserve.IsPositive() {
+		return TradeQuote{}, ErrInsufficientLiquidity
+	}
+
+	reserveIn, reserveOut := pool.YoloReserve, pool.StockReserve
+	if tradeType == models.TradeTypeSell {
+		reserveIn, reserveOut = reserveOut, reserveIn
+	}
+	result := TradeQuote{Type: tradeType, AmountIn: amountIn, MidPrice: poolPrice(pool)}
+	poolIn := amountIn
+	if tradeType == models.TradeTypeBuy {
+		poolIn = amountIn.Div(decimal.FromInt(1).Add(feeRate), decimal.RoundDown)
+		result.Fee = amountIn.Sub(poolIn)
+	}
+	// 输入大到超出 Decimal 范围时按流动性不足拒绝，而不是溢出
+	after, err := reserveIn.TryAdd(poolIn)
+	if err != nil {
+		return TradeQuote{}, ErrInsufficientLiquidity
+	}
+	poolOut := reserveOut.MulDiv(poolIn, after, decimal.RoundDown)
+	if !poolOut.LessThan(reserveOut) {
+		return TradeQuote{}, ErrInsufficientLiquidity
+	}
+	result.AmountOut = poolOut
+	if tradeType == models.TradeTypeSell {
+		result.Fee = poolOut.Mul(feeRate, decimal.RoundUp)
+		result.AmountOut = poolOut.Sub(result.Fee)
+	}
+	if !result.AmountOut.IsPositive() {
+		return TradeQuote{}, ErrTradeTooSmall
+	}
+
+	// 按池中价格 reserveOut/reserveIn 能换到的数量，与实际换到的 poolOut 比较；
+	// 输入远大于池子时这些比值可能超出范围，同样按流动性不足拒绝
+	fair, err := poolIn.TryMulDiv(reserveOut, reserveIn, decimal.RoundHalfEven)
+	if err != nil {
+		return TradeQuote{}, ErrInsufficientLiquidity
+	}
+	one, hundred := decimal.FromInt(1), decimal.FromInt(100)
+	if tradeType == models.TradeTypeBuy {
+		result.Price, err = amountIn.TryMulDiv(one, result.AmountOut, decimal.RoundHalfEven)
+		if err == nil {
+			result.PriceImpact, err = fair.Sub(poolOut).TryMulDiv(hundred, poolOut, decimal.RoundHalfEven)
+		}
+	} else {
+		result.Price = result.AmountOut.Div(amountIn, decimal.RoundHalfEven)
+		result.PriceImpact = fair.Sub(poolOut).MulDiv(hundred, fair, decimal.RoundHalfEven)
+	}
+	if err != nil {
+		return TradeQuote{}, ErrInsufficientLiquidity
+	}
+	return result, nil
+}
+
+// quoteExactOut 按要得到的数量反推所需输入并向上舍入，再按精确输入报价，得到的数量不少于 amountOut；
+// 接近储备时所需输入超出范围，按流动性不足拒绝
+func quoteExactOut(pool *models.LiquidityPool, tradeType string, amountOut, feeRate decimal.Decimal) (TradeQuote, error) {
+	if !amountOut.IsPositive() {
+		return TradeQuote{}, ErrInvalidTradeAmount
+	}
+	x, y := pool.YoloReserve, pool.StockReserve
+	one := decimal.FromInt(1)
+	var amountIn decimal.Decimal
+	if tradeType == models.TradeTypeBuy {
+		if !amountOut.LessThan(y) {
+			return TradeQuote{}, ErrInsufficientLiquidity
+		}
+		cost, err := x.TryMulDiv(amountOut, y.Sub(amountOut), decimal.RoundUp)
+		if err == nil {
+			amountIn, err = cost.TryMulDiv(one.Add(feeRate), one, decimal.RoundUp)
+		}
+		if err != nil {
+			return TradeQuote{}, ErrInsufficientLiquidity
+		}
+	} else {
+		gross := amountOut.Div(one.Sub(feeRate), decimal.RoundUp)
+		if !gross.LessThan(x) {
+			return TradeQuote{}, ErrInsufficientLiquidity
+		}
+		var err error
+		if amountIn, err = y.TryMulDiv(gross, x.Sub(gross), decimal.RoundUp); err != nil {
+			return TradeQuote{}, ErrInsufficientLiquidity
+		}
+	}
+	return quote(pool, tradeType, amountIn, feeRate)
+}
+
+// openPoolAccount 锁定池账户，首次开户时由金库注入池中的 YOLO 储备，调用前需已锁定池子
+func openPoolAccount(tx *gorm.DB, pool *models.LiquidityPool) (*models.LedgerAccount, error) {
+	return openAccount(tx, poolAccountCode(pool.StockID), models.LedgerAccountPool, &pool.StockID, pool.YoloReserve)
+}
+
+// createPool 在发行股票的事务中创建流动性池并由金库注资，把创作者份额记入其持仓
+func createPool(tx *gorm.DB, stock *models.Stock) error {
+	creatorShares := stock.Supply.Mul(CreatorAllocation, decimal.RoundDown)
+	poolShares := stock.Supply.Sub(creatorShares)
+	pool := models.LiquidityPool{
+		StockID:      stock.ID,
+		YoloReserve:  stock.Price.Mul(poolShares, decimal.RoundHalfEven),
+		StockReserve: poolShares,
+	}
+	if err := tx.Create(&pool).Error; err != nil {
+		return err
+	}
+	if _, err := openPoolAccount(tx, &pool); err != nil {
+		return err
+	}
+	if !creatorShares.IsPositive() {
+		return nil
+	}
+	if err := tx.Create(&models.UserHolding{UserID: stock.UserID, StockID: stock.ID, Quantity: creatorShares}).Error; err != nil {
+		return err
+	}
+	stock.Owners = 1
+	return tx.Model(stock).Update("owners", 1).Error
+}
+
+// GetPool 获取股票的流动性池
+func (s *ammService) GetPool(symbol string) (*models.Stock, *models.LiquidityPool, error) {
+	stock, err := StockService.GetStockBySymbol(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pool models.LiquidityPool
+	if err := database.DB.Where("stock_id = ?", stock.ID).First(&pool).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrPoolNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+	return stock, &pool, nil
+}
+
+// Quote 按当前池子报价，不做任何修改
+func (s *ammService) Quote(symbol, tradeType string, amount decimal.Decimal) (*TradeQuote, error) {
+	tradeType, err := normalizeTradeType(tradeType)
+	if err != nil {
+		return nil, err
+	}
+	_, pool, err := s.GetPool(symbol)
+	if err != nil {
+		return nil, err
+	}
+	result, err := quote(pool, tradeType, amount, tradeFeeRate())
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// lockHolding 锁定用户持仓行，不存在时创建空持仓
+func lockHolding(tx *gorm.DB, userID, stockID uuid.UUID) (*models.UserHolding, error) {
+	var holding models.UserHolding
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND stock_id = ?", userID, stockID).First(&holding).Error
+	if err == nil {
+		return &holding, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	holding = models.UserHolding{UserID: userID, StockID: stockID}
+	if err := tx.Create(&holding).Error; err != nil {
+		return nil, err
+	}
+	return &holding, nil
+}
+
+// lockPool 锁定股票的流动性池行，同一股票的交易和撮合都在此串行
+func lockPool(tx *gorm.DB, stockID uuid.UUID) (*models.LiquidityPool, error) {
+	var pool models.LiquidityPool
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("stock_id = ?", stockID).First(&pool).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPoolNotFound
+		}
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// adjustHolding 增量更新持仓和冻结股数，带条件兜底：更新后可卖出的股数和冻结股数都不能为负
+func adjustHolding(tx *gorm.DB, holding *models.UserHolding, quantityDelta, lockedDelta decimal.Decimal) error {
+	update := tx.Model(&models.UserHolding{}).
+		Where("id = ? AND quantity >= locked + ? AND locked >= ?", holding.ID,
+			lockedDelta.Sub(quantityDelta), lockedDelta.Neg()).
+		Updates(map[string]any{
+			"quantity": incrementExpr("quantity", quantityDelta),
+			"locked":   incrementExpr("locked", lockedDelta),
+		})
+	if update.Error != nil {
+		return update.Error
+	}
+	if update.RowsAffected == 0 {
+		return ErrInsufficientShares
+	}
+	holding.Quantity = holding.Quantity.Add(quantityDelta)
+	holding.Locked = holding.Locked.Add(lockedDelta)
+	return nil
+}
+
+// updatePoolReserves 写回池子储备
+func updatePoolReserves(tx *gorm.DB, pool *models.LiquidityPool) error {
+	return tx.Model(pool).Updates(map[string]any{
+		"yolo_reserve":  pool.YoloReserve,
+		"stock_reserve": pool.StockReserve,
+	}).Error
+}
+
+// refreshStock 按池中价格更新股价、市值和持有人数，返回新的股价
+func refreshStock(tx *gorm.DB, stock *models.Stock, pool *models.LiquidityPool) (decimal.Decimal, error) {
+	var owners int64
+	if err := tx.Model(&models.UserHolding{}).Where("stock_id = ? AND quantity > 0", stock.ID).Count(&owners).Error; err != nil {
+		return decimal.Zero, err
+	}
+	price := poolPrice(pool)
+	err := tx.Model(&models.Stock{}).Where("id = ?", stock.ID).Updates(map[string]any{
+		"price":      price,
+		"market_cap": price.Mul(stock.Supply, decimal.RoundHalfEven),
+		"owners":     owners,
+	}).Error
+	return price, err
+}
+
+// normalizeTradeOptions 校验交易参数，使用签名报价时按报价补全
+func normalizeTradeOptions(userID uuid.UUID, stock *models.Stock, opts TradeOptions) (TradeOptions, *signedQuote, error) {
+	var signed *signedQuote
+	if opts.QuoteID != "" {
+		var err error
+		if signed, err = verifyQuote(opts.QuoteID, userID, stock.ID); err != nil {
+			return opts, nil, err
+		}
+		opts = TradeOptions{
+			Type:      signed.Type,
+			AmountIn:  signed.AmountIn,
+			AmountOut: signed.AmountOut,
+			MinOut:    signed.MinOut,
+			MaxIn:     signed.MaxIn,
+			QuoteID:   opts.QuoteID,
+		}
+	}
+
+	tradeType, err := normalizeTradeType(opts.Type)
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.Type = tradeType
+	if opts.AmountIn.IsNegative() || opts.AmountOut.IsNegative() || opts.MinOut.IsNegative() || opts.MaxIn.IsNegative() {
+		return opts, nil, ErrInvalidTradeAmount
+	}
+	if opts.AmountIn.IsPositive() == opts.AmountOut.IsPositive() {
+		if opts.AmountIn.IsZero() {
+			return opts, nil, ErrInvalidTradeAmount
+		}
+		return opts, nil, ErrTradeAmountConflict
+	}
+	return opts, signed, nil
+}
+
+// Trade 与流动性池交易：按精确输入或精确输出成交，成交前检查 min_out / max_in，超出容差时整笔拒绝。
+// 使用签名报价时报价只能成交一次。
+// 在同一事务中按 池子 → 账户（按代码排序）→ 持仓 的固定顺序加行锁，并发交易不会丢失更新或透支
+func (s *ammService) Trade(userID uuid.UUID, symbol string, opts TradeOptions) (*TradeResult, error) {
+	stock, err := StockService.GetStockBySymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	opts, signed, err := normalizeTradeOptions(userID, stock, opts)
+	if err != nil {
+		return nil, err
+	}
+	tradeType := opts.Type
+
+	result := &TradeResult{}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		pool, err := lockPool(tx, stock.ID)
+		if err != nil {
+			return err
+		}
+		if signed != nil {
+			var used int64
+			if err := tx.Model(&models.Trade{}).Where("quote_id = ?", signed.Nonce).Count(&used).Error; err != nil {
+				return err
+			}
+			if used > 0 {
+				return ErrQuoteUsed
+			}
+		}
+
+		var q TradeQuote
+		if opts.AmountIn.IsPositive() {
+			q, err = quote(pool, tradeType, opts.AmountIn, tradeFeeRate())
+		} else {
+			q, err = quoteExactOut(pool, tradeType, opts.AmountOut, tradeFeeRate())
+		}
+		if err != nil {
+			return err
+		}
+		if opts.MinOut.IsPositive() && q.AmountOut.LessThan(opts.MinOut) ||
+			opts.MaxIn.IsPositive() && q.AmountIn.GreaterThan(opts.MaxIn) {
+			return ErrSlippageExceeded
+		}
+		result.Quote = q
+
+		accounts, err := lockOrderAccounts(tx, pool, []uuid.UUID{userID}, false, true, q.Fee.IsPositive())
+		if err != nil {
+			return err
+		}
+		account, poolAccount := accounts[userAccountCode(userID)], accounts[poolAccountCode(stock.ID)]
+		holding, err := lockHolding(tx, userID, stock.ID)
+		if err != nil {
+			return err
+		}
+
+		trade := models.Trade{
+			ID:      uuid.New(),
+			StockID: stock.ID,
+			Type:    tradeType,
+			Fee:     q.Fee,
+			Status:  models.TradeStatusCompleted,
+		}
+		if signed != nil {
+			trade.QuoteID = &signed.Nonce
+		}
+		var balanceDelta, holdingDelta decimal.Decimal
+		if tradeType == models.TradeTypeBuy {
+			if account.Balance.LessThan(q.AmountIn) {
+				return ErrInsufficientBalance
+			}
+			pool.YoloReserve = pool.YoloReserve.Add(q.AmountIn.Sub(q.Fee))
+			pool.StockReserve = pool.StockReserve.Sub(q.AmountOut)
+			balanceDelta, holdingDelta = q.AmountIn.Neg(), q.AmountOut
+			trade.BuyerID = &userID
+			trade.Amount, trade.TotalValue = q.AmountOut, q.AmountIn
+		} else {
+			if holding.Quantity.Sub(holding.Locked).LessThan(q.AmountIn) {
+				return ErrInsufficientShares
+			}
+			pool.YoloReserve = pool.YoloReserve.Sub(q.AmountOut.Add(q.Fee))
+			pool.StockReserve = pool.StockReserve.Add(q.AmountIn)
+			balanceDelta, holdingDelta = q.AmountOut, q.AmountIn.Neg()
+			trade.SellerID = &userID
+			trade.Amount, trade.TotalValue = q.AmountIn, q.AmountOut
+		}
+		trade.Price = q.Price
+
+		if err := updatePoolReserves(tx, pool); err != nil {
+			return err
+		}
+		result.Pool = *pool
+
+		// YOLO 在用户、池账户和手续费账户之间记账，凭证号即成交记录的 TransactionID
+		entry := &models.JournalEntry{
+			Type:          models.JournalTypeTrade,
+			ReferenceType: "trade",
+			ReferenceID:   &trade.ID,
+			Memo:          tradeType + " " + stock.Symbol,
+		}
+		legs := []ledgerLeg{{account.ID, balanceDelta}, {poolAccount.ID, balanceDelta.Neg().Sub(q.Fee)}}
+		if q.Fee.IsPositive() {
+			legs = append(legs, ledgerLeg{accounts[models.LedgerFeesCode].ID, q.Fee})
+		}
+		if err := postEntry(tx, entry, legs...); err != nil {
+			return err
+		}
+		trade.TransactionID = entry.ID.String()
+		result.Balance = account.Balance.Add(balanceDelta)
+
+		// 增量更新并带条件兜底：即使行锁失效也不会覆盖并发修改或卖出挂单冻结的股份
+		if err := adjustHolding(tx, holding, holdingDelta, decimal.Zero); err != nil {
+			return err
+		}
+		result.Holding = holding.Quantity
+
+		if err := tx.Create(&trade).Error; err != nil {
+			return err
+		}
+		result.Trade = trade
+
+		result.NewPrice, err = refreshStock(tx, stock, pool)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPoolNotFound), errors.Is(err, ErrInvalidTradeAmount), errors.Is(err, ErrTradeTooSmall),
+			errors.Is(err, ErrInsufficientBalance), errors.Is(err, ErrInsufficientShares),
+			errors.Is(err, ErrInsufficientLiquidity), errors.Is(err, ErrSlippageExceeded), errors.Is(err, ErrQuoteUsed):
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to execute trade: %w", err)
+	}
+
+	publish(hub.StockChannel(stock.Symbol), EventStockPrice, map[string]any{
+		"symbol":       stock.Symbol,
+		"price":        result.NewPrice,
+		"yoloReserve":  result.Pool.YoloReserve,
+		"stockReserve": result.Pool.StockReserve,
+		"tradeId":      result.Trade.ID.String(),
+	})
+	notifyPriceUpdate(stock.ID)
+	return result, nil
+}
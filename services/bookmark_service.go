@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yolo/database"
+	"yolo/models"
+	"yolo/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 收藏夹限制
+const (
+	MaxBookmarkCollections       = 100
+	MaxBookmarkCollectionNameLen = 50
+)
+
+// 收藏相关错误
+var (
+	ErrBookmarkNotFound      = errors.New("bookmark not found")
+	ErrCollectionNotFound    = errors.New("bookmark collection not found")
+	ErrCollectionExists      = errors.New("a bookmark collection with this name already exists")
+	ErrInvalidCollectionName = fmt.Errorf("collection name must be 1 to %d characters", MaxBookmarkCollectionNameLen)
+	ErrTooManyCollections    = fmt.Errorf("at most %d bookmark collections are allowed", MaxBookmarkCollections)
+)
+
+// BookmarkCollectionSummary 收藏夹及其中的收藏数
+type BookmarkCollectionSummary struct {
+	models.BookmarkCollection
+	Count int64
+}
+
+// BookmarkItem 收藏及对应的帖子，帖子已删除或不再可见时 Post 为空
+type BookmarkItem struct {
+	models.Bookmark
+	Post *models.Post
+}
+
+// BookmarkPage 收藏游标分页结果
+type BookmarkPage struct {
+	Bookmarks  []BookmarkItem
+	NextCursor string // 更早收藏的一页，没有更多时为空
+	Total      *int64 // 仅在请求时统计
+}
+
+type bookmarkService struct{}
+
+// normalizeCollectionName 去掉首尾空白并检查长度
+func normalizeCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxBookmarkCollectionNameLen {
+		return "", ErrInvalidCollectionName
+	}
+	return name, nil
+}
+
+// getCollection 获取自己的收藏夹
+func (s *bookmarkService) getCollection(db *gorm.DB, userID, collectionID uuid.UUID) (*models.BookmarkCollection, error) {
+	var collection models.BookmarkCollection
+	if err := db.Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+		return nil, ErrCollectionNotFound
+	}
+	return &collection, nil
+}
+
+// checkCollectionName 同一用户的收藏夹名称不区分大小写不能重复
+func (s *bookmarkService) checkCollectionName(db *gorm.DB, userID uuid.UUID, name string, exclude uuid.UUID) error {
+	var count int64
+	db.Model(&models.BookmarkCollection{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, exclude).
+		Count(&count)
+	if count > 0 {
+		return ErrCollectionExists
+	}
+	return nil
+}
+
+// CreateCollection 创建收藏夹
+func (s *bookmarkService) CreateCollection(userID uuid.UUID, name string) (*models.BookmarkCollection, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &models.BookmarkCollection{UserID: userID, Name: name, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.BookmarkCollection{}).Where("user_id = ?", userID).Count(&count)
+		if count >= MaxBookmarkCollections {
+			return ErrTooManyCollections
+		}
+		if err := s.checkCollectionName(tx, userID, name, uuid.Nil); err != nil {
+			return err
+		}
+		return tx.Create(collection).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrTooManyCollections) || errors.Is(err, ErrCollectionExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create bookmark collection: %w", err)
+	}
+	return collection, nil
+}
+
+// RenameCollection 重命名收藏夹
+func (s *bookmarkService) RenameCollection(userID, collectionID uuid.UUID, name string) (*BookmarkCollectionSummary, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.getCollection(database.DB, userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCollectionName(database.DB, userID, name, collectionID); err != nil {
+		return nil, err
+	}
+
+	collection.Name = name
+	collection.UpdatedAt = time.Now()
+	if err := database.DB.Model(collection).Select("name", "updated_at").Updates(collection).Error; err != nil {
+		return nil, fmt.Errorf("failed to rename bookmark collection: %w", err)
+	}
+
+	summary := &BookmarkCollectionSummary{BookmarkCollection: *collection}
+	database.DB.Model(&models.Bookmark{}).Where("collection_id = ?", collectionID).Count(&summary.Count)
+	return summary, nil
+}
+
+// DeleteCollection 删除收藏夹，其中的收藏保留并变为未分类
+func (s *bookmarkService) DeleteCollection(userID, collectionID uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.getCollection(tx, userID, collectionID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Bookmark{}).
+			Where("user_id = ? AND collection_id = ?", userID, collectionID).
+			Update("collection_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to move bookmarks: %w", err)
+		}
+		if err := tx.Delete(&models.BookmarkCollection{}, "id = ?", collectionID).Error; err != nil {
+			return fmt.Errorf("failed to delete bookmark collection: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetCollections 获取自己的收藏夹及收藏数，按创建时间排序
+func (s *bookmarkService) GetCollections(userID uuid.UUID) ([]BookmarkCollectionSummary, error) {
+	var collections []models.BookmarkCollection
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&collections).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bookmark collections: %w", err)
+	}
+
+	var counts []struct {
+		CollectionID uuid.UUID
+		Count        int64
+	}
+	if err := database.DB.Model(&models.Bookmark{}).
+		Select("collection_id, COUNT(*) AS count").
+		Where("user_id = ? AND collection_id IS NOT NULL", userID).
+		Group("collection_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count bookmarks: %w", err)
+	}
+	countByCollection := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		countByCollection[count.CollectionID] = count.Count
+	}
+
+	summaries := make([]BookmarkCollectionSummary, 0, len(collections))
+	for _, collection := range collections {
+		summaries = append(summaries, BookmarkCollectionSummary{BookmarkCollection: collection, Count: countByCollection[collection.ID]})
+	}
+	return summaries, nil
+}
+
+// AddBookmark 收藏帖子，已收藏时移动到指定收藏夹（collectionID 为空时移出收藏夹）
+// 返回的 created 表示是否为新收藏
+func (s *bookmarkService) AddBookmark(userID, postID uuid.UUID, collectionID *uuid.UUID) (*models.Bookmark, bool, error) {
+	if !PostService.canView(database.DB, userID, postID) {
+		return nil, false, ErrPostNotFound
+	}
+	if collectionID != nil {
+		if _, err := s.getCollection(database.DB, userID, *collectionID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	bookmark := &models.Bookmark{UserID: userID, PostID: postID, CollectionID: collectionID, CreatedAt: time.Now()}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(bookmark)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to add bookmark: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return bookmark, true, nil
+	}
+
+	// 已收藏过，保留原收藏时间，只修改所在收藏夹
+	if err := database.DB.Model(&models.Bookmark{}).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Update("collection_id", collectionID).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to move bookmark: %w", err)
+	}
+	var existing models.Bookmark
+	if err := database.DB.Where("user_id = ? AND post_id = ?", userID, postID).First(&existing).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+	return &existing, false, nil
+}
+
+// RemoveBookmark 取消收藏，帖子已删除时同样可以取消
+func (s *bookmarkService) RemoveBookmark(userID, postID uuid.UUID) error {
+	result := database.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove bookmark: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// GetBookmarks 按收藏时间倒序分页获取收藏，collectionID 为空时返回全部收藏。
+// 已删除、被隐藏或不再可见的帖子仍保留收藏记录，Post 为空，由客户端显示为不可用
+func (s *bookmarkService) GetBookmarks(userID uuid.UUID, collectionID *uuid.UUID, encodedCursor string, limit int, withTotal bool) (*BookmarkPage, error) {
+	if collectionID != nil {
+		if _, err := s.getCollection(database.DB, userID, *collectionID); err != nil {
+			return nil, err
+		}
+	}
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if collectionID != nil {
+			db = db.Where("collection_id = ?", *collectionID)
+		}
+		return db
+	}
+
+	query := database.DB.Scopes(scope)
+	if encodedCursor != "" {
+		cursor, err := utils.DecodeCursor(encodedCursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		// 收藏列表只向更早的方向翻页
+		if cursor.Direction != utils.CursorNext {
+			return nil, fmt.Errorf("%w: bookmarks only support next cursors", ErrInvalidCursor)
+		}
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.Timestamp, cursor.Timestamp, cursor.ID)
+	}
+
+	// 多取一条用于判断是否还有更多
+	var bookmarks []models.Bookmark
+	if err := query.Order("created_at DESC").Order("id DESC").Limit(limit + 1).Find(&bookmarks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks: %w", err)
+	}
+	hasMore := len(bookmarks) > limit
+	if hasMore {
+		bookmarks = bookmarks[:limit]
+	}
+
+	postIDs := make([]uuid.UUID, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		postIDs = append(postIDs, bookmark.PostID)
+	}
+	var posts []models.Post
+	if len(postIDs) > 0 {
+		if err := withPostRelations(database.DB, userID).
+			Scopes(visiblePostsScope(userID)).
+			Where("posts.id IN ?", postIDs).
+			Find(&posts).Error; err != nil {
+			return nil, fmt.Errorf("failed to get bookmarked posts: %w", err)
+		}
+	}
+	postByID := make(map[uuid.UUID]*models.Post, len(posts))
+	for i := range posts {
+		postByID[posts[i].ID] = &posts[i]
+	}
+
+	page := &BookmarkPage{Bookmarks: make([]BookmarkItem, 0, len(bookmarks))}
+	for _, bookmark := range bookmarks {
+		page.Bookmarks = append(page.Bookmarks, BookmarkItem{Bookmark: bookmark, Post: postByID[bookmark.PostID]})
+	}
+	if hasMore {
+		last := bookmarks[len(bookmarks)-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID, utils.CursorNext)
+	}
+
+	if withTotal {
+		var total int64
+		if err := database.DB.Model(&models.Bookmark{}).Scopes(scope).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count bookmarks: %w", err)
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"yolo/database"
+	"yolo/decimal"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 条件单限制
+const (
+	DefaultTWAPWindow = 5 * time.Minute
+	MaxTWAPWindow     = 24 * time.Hour
+	// MaxPendingConditionalOrders 每个用户同时等待触发的条件单数量上限
+	MaxPendingConditionalOrders = 50
+)
+
+// 条件单相关错误
+var (
+	ErrInvalidConditionalKind       = errors.New("kind must be stop_loss or take_profit")
+	ErrInvalidTriggerSource         = errors.New("trigger_source must be last or twap")
+	ErrInvalidTriggerPrice          = errors.New("trigger_price must be positive")
+	ErrInvalidTWAPWindow            = errors.New("twap_window must be between 1 second and 24 hours")
+	ErrInvalidConditionalStatus     = errors.New("status must be pending, triggered, cancelled or failed")
+	ErrTriggerConditionMet          = errors.New("trigger condition is already met at the current price")
+	ErrTooManyConditionalOrders     = errors.New("too many pending conditional orders")
+	ErrConditionalOrderNotFound     = errors.New("conditional order not found")
+	ErrConditionalOrderNotPending   = errors.New("conditional order is no longer pending")
+	errConditionalOrderAlreadyTaken = errors.New("conditional order already taken")
+	errConditionalOrderPanicked     = errors.New("order could not be placed")
+)
+
+// ConditionalOrderOptions 创建条件单的参数，Order 为触发后提交的订单
+type ConditionalOrderOptions struct {
+	Kind          string // stop_loss / take_profit
+	TriggerSource string // last / twap，默认 last
+	TriggerPrice  decimal.Decimal
+	TWAPWindow    time.Duration // 仅 twap 使用，默认 5 分钟
+	Order         PlaceOrderOptions
+}
+
+type conditionalOrderService struct {
+	mu    sync.Mutex
+	dirty map[uuid.UUID]bool // 价格变动后等待检查的股票
+	wake  chan struct{}
+}
+
+// newConditionalOrderService 创建条件单服务
+func newConditionalOrderService() *conditionalOrderService {
+	return &conditionalOrderService{
+		dirty: make(map[uuid.UUID]bool),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// notifyPriceUpdate 股票有新成交后调用，由后台任务检查该股票的条件单
+func notifyPriceUpdate(stockID uuid.UUID) {
+	if s := ConditionalOrderService; s != nil {
+		s.mu.Lock()
+		s.dirty[stockID] = true
+		s.mu.Unlock()
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// conditionMet 价格是否到达触发价：卖出止损和买入止盈在价格不高于触发价时触发，其余在不低于触发价时触发
+func conditionMet(c *models.ConditionalOrder, price decimal.Decimal) bool {
+	rising := (c.Kind == models.ConditionalKindStopLoss) == (c.Side == models.TradeTypeBuy)
+	if rising {
+		return !price.LessThan(c.TriggerPrice)
+	}
+	return !price.GreaterThan(c.TriggerPrice)
+}
+
+// lastTradePrice 最新成交价，还没有成交时为股价
+func lastTradePrice(db *gorm.DB, stock *models.Stock) (decimal.Decimal, error) {
+	var trade models.Trade
+	err := db.Select("price").Where("stock_id = ?", stock.ID).Order("created_at DESC").First(&trade).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return stock.Price, nil
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return trade.Price, nil
+}
+
+// twapPrice 截至 now 的 window 内的时间加权平均成交价，每个成交价持续到下一笔成交；
+// 窗口开始时的价格取窗口前的最后一笔成交，窗口前没有成交时从窗口内第一笔成交算起，完全没有成交时为股价
+func twapPrice(db *gorm.DB, stock *models.Stock, window time.Duration, now time.Time) (decimal.Decimal, error) {
+	start := now.Add(-window)
+	var trades []models.Trade
+	if err := db.Select("price, created_at").Where("stock_id = ? AND created_at > ?", stock.ID, start).
+		Order("created_at ASC").Find(&trades).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	price, from := stock.Price, start
+	var before models.Trade
+	err := db.Select("price").Where("stock_id = ? AND created_at <= ?", stock.ID, start).Order("created_at DESC").First(&before).Error
+	switch {
+	case err == nil:
+		price = before.Price
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return decimal.Zero, err
+	case len(trades) == 0:
+		return stock.Price, nil
+	default:
+		price, from = trades[0].Price, trades[0].CreatedAt
+	}
+
+	total := now.Sub(from).Milliseconds()
+	if total <= 0 {
+		return trades[len(trades)-1].Price, nil
+	}
+	weighted := func(p decimal.Decimal, until time.Time) decimal.Decimal {
+		elapsed := max(until.Sub(from).Milliseconds(), 0)
+		return p.MulDiv(decimal.FromInt(elapsed), decimal.FromInt(total), decimal.RoundHalfEven)
+	}
+	sum := decimal.Zero
+	for _, trade := range trades {
+		sum = sum.Add(weighted(price, trade.CreatedAt))
+		price, from = trade.Price, trade.CreatedAt
+	}
+	return sum.Add(weighted(price, now)), nil
+}
+
+// triggerPrice 条件单当前用于判断的价格
+func triggerPrice(db *gorm.DB, c *models.ConditionalOrder, stock *models.Stock) (decimal.Decimal, error) {
+	if c.TriggerSource == models.TriggerSourceTWAP {
+		return twapPrice(db, stock, time.Duration(c.TWAPWindow)*time.Second, time.Now())
+	}
+	return lastTradePrice(db, stock)
+}
+
+// normalizeConditionalOptions 校验条件单参数并补全默认值，未指定订单类型时有限价为限价单，否则为市价单
+func normalizeConditionalOptions(opts ConditionalOrderOptions) (ConditionalOrderOptions, error) {
+	opts.Kind = strings.ToLower(strings.TrimSpace(opts.Kind))
+	if opts.Kind != models.ConditionalKindStopLoss && opts.Kind != models.ConditionalKindTakeProfit {
+		return opts, ErrInvalidConditionalKind
+	}
+	opts.TriggerSource = strings.ToLower(strings.TrimSpace(opts.TriggerSource))
+	switch opts.TriggerSource {
+	case "", models.TriggerSourceLast:
+		opts.TriggerSource = models.TriggerSourceLast
+		opts.TWAPWindow = 0
+	case models.TriggerSourceTWAP:
+		if opts.TWAPWindow == 0 {
+			opts.TWAPWindow = DefaultTWAPWindow
+		}
+		if opts.TWAPWindow < time.Second || opts.TWAPWindow > MaxTWAPWindow {
+			return opts, ErrInvalidTWAPWindow
+		}
+	default:
+		return opts, ErrInvalidTriggerSource
+	}
+	if !opts.TriggerPrice.IsPositive() {
+		return opts, ErrInvalidTriggerPrice
+	}
+
+	if strings.TrimSpace(opts.Order.Side) == "" {
+		opts.Order.Side = models.TradeTypeSell
+	}
+	if strings.TrimSpace(opts.Order.Type) == "" && opts.Order.Price.IsZero() {
+		opts.Order.Type = models.OrderTypeMarket
+	}
+	order, err := normalizeOrderOptions(opts.Order)
+	if err != nil {
+		return opts, err
+	}
+	opts.Order = order
+	return opts, nil
+}
+
+// CreateConditionalOrder 创建条件单。卖出条件单要求当前持有足够的股份，买入条件单要求余额足够支付
+// 限价（市价单按触发价）乘以数量，且市价买入不超过池中的股份；两者都不冻结，触发时再检查。
+// 创建时已经满足触发条件的会被拒绝，避免方向设反时立即成交
+func (s *conditionalOrderService) CreateConditionalOrder(userID uuid.UUID, symbol string, opts ConditionalOrderOptions) (*models.ConditionalOrder, error) {
+	opts, err := normalizeConditionalOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := StockService.GetStockBySymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending int64
+	if err := database.DB.Model(&models.ConditionalOrder{}).
+		Where("user_id = ? AND status = ?", userID, models.ConditionalStatusPending).Count(&pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to count conditional orders: %w", err)
+	}
+	if pending >= MaxPendingConditionalOrders {
+		return nil, ErrTooManyConditionalOrders
+	}
+	if opts.Order.Side == models.TradeTypeSell {
+		var holding models.UserHolding
+		err := database.DB.Where("user_id = ? AND stock_id = ?", userID, stock.ID).First(&holding).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get holding: %w", err)
+		}
+		if holding.Quantity.LessThan(opts.Order.Quantity) {
+			return nil, ErrInsufficientShares
+		}
+	} else if err := checkConditionalBuy(stock, userID, opts); err != nil {
+		return nil, err
+	}
+
+	order := &models.ConditionalOrder{
+		StockID:       stock.ID,
+		UserID:        userID,
+		Kind:          opts.Kind,
+		TriggerSource: opts.TriggerSource,
+		TriggerPrice:  opts.TriggerPrice,
+		TWAPWindow:    int(opts.TWAPWindow / time.Second),
+		Side:          opts.Order.Side,
+		OrderType:     opts.Order.Type,
+		TimeInForce:   opts.Order.TimeInForce,
+		LimitPrice:    opts.Order.Price,
+		Quantity:      opts.Order.Quantity,
+		Status:        models.ConditionalStatusPending,
+		Stock:         *stock,
+	}
+	price, err := triggerPrice(database.DB, order, stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trigger price: %w", err)
+	}
+	if conditionMet(order, price) {
+		return nil, ErrTriggerConditionMet
+	}
+	if err := database.DB.Omit("Stock", "User").Create(order).Error; err != nil {
+		return nil, fmt.Errorf("failed to create conditional order: %w", err)
+	}
+	return order, nil
+}
+
+// checkConditionalBuy 检查买入条件单的金额：余额不少于限价（市价单按触发价）乘以数量，市价买入的数量小于池中的股份
+func checkConditionalBuy(stock *models.Stock, userID uuid.UUID, opts ConditionalOrderOptions) error {
+	price := opts.Order.Price
+	if opts.Order.Type == models.OrderTypeMarket {
+		var pool models.LiquidityPool
+		err := database.DB.Where("stock_id = ?", stock.ID).First(&pool).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPoolNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get pool: %w", err)
+		}
+		if !opts.Order.Quantity.LessThan(pool.StockReserve) {
+			return ErrInsufficientLiquidity
+		}
+		price = opts.TriggerPrice
+	}
+	cost, err := price.TryMulDiv(opts.Order.Quantity, decimal.FromInt(1), decimal.RoundUp)
+	if err != nil {
+		return ErrOrderTooLarge
+	}
+	balance, err := LedgerService.GetBalance(userID)
+	if err != nil {
+		return err
+	}
+	if balance.LessThan(cost) {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// CancelConditionalOrder 撤销自己等待触发的条件单
+func (s *conditionalOrderService) CancelConditionalOrder(userID, id uuid.UUID) (*models.ConditionalOrder, error) {
+	if !StockService.Enabled() {
+		return nil, ErrStocksDisabled
+	}
+	result := database.DB.Model(&models.ConditionalOrder{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userID, models.ConditionalStatusPending).
+		Update("status", models.ConditionalStatusCancelled)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to cancel conditional order: %w", result.Error)
+	}
+
+	var order models.ConditionalOrder
+	if err := database.DB.Preload("Stock").Where("id = ? AND user_id = ?", id, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConditionalOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get conditional order: %w", err)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrConditionalOrderNotPending
+	}
+	return &order, nil
+}
+
+// GetUserConditionalOrders 获取用户的条件单，按创建时间倒序；status 为空时返回全部
+func (s *conditionalOrderService) GetUserConditionalOrders(userID uuid.UUID, status string, page, limit int) ([]models.ConditionalOrder, int64, error) {
+	if !StockService.Enabled() {
+		return nil, 0, ErrStocksDisabled
+	}
+	var orders []models.ConditionalOrder
+	var total int64
+
+	query := database.DB.Model(&models.ConditionalOrder{}).Where("user_id = ?", userID)
+	switch status {
+	case "":
+	case models.ConditionalStatusPending, models.ConditionalStatusTriggered,
+		models.ConditionalStatusCancelled, models.ConditionalStatusFailed:
+		query = query.Where("status = ?", status)
+	default:
+		return nil, 0, ErrInvalidConditionalStatus
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count conditional orders: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Stock").Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get conditional orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+// Evaluate 按最新价格检查股票等待触发的条件单，按创建顺序逐个触发，每次触发后重新取价，返回触发的数量。
+// 触发时先用 status = pending 条件更新认领再下单，两步在同一事务中，多实例或重启后重复检查也不会重复下单
+func (s *conditionalOrderService) Evaluate(stockID uuid.UUID) (int, error) {
+	if !StockService.Enabled() {
+		return 0, nil
+	}
+	var pending []models.ConditionalOrder
+	if err := database.DB.Preload("Stock").
+		Where("stock_id = ? AND status = ?", stockID, models.ConditionalStatusPending).
+		Order("created_at ASC, id ASC").Find(&pending).Error; err != nil {
+		return 0, fmt.Errorf("failed to get conditional orders: %w", err)
+	}
+
+	triggered := 0
+	prices := make(map[int]decimal.Decimal) // 按 TWAP 窗口缓存，0 为最新成交价
+	for i := range pending {
+		c := &pending[i]
+		price, ok := prices[c.TWAPWindow]
+		if !ok {
+			var err error
+			if price, err = triggerPrice(database.DB, c, &c.Stock); err != nil {
+				return triggered, fmt.Errorf("failed to get trigger price: %w", err)
+			}
+			prices[c.TWAPWindow] = price
+		}
+		if !conditionMet(c, price) {
+			continue
+		}
+		fired, err := s.trigger(c, price)
+		if err != nil {
+			return triggered, err
+		}
+		if fired {
+			triggered++
+			clear(prices)
+		}
+	}
+	return triggered, nil
+}
+
+// trigger 认领条件单并提交订单；订单被拒绝或下单时 panic 都标记为失败，不再重试。已被其他检查认领时返回 false
+func (s *conditionalOrderService) trigger(c *models.ConditionalOrder, price decimal.Decimal) (bool, error) {
+	opts := PlaceOrderOptions{
+		Side:        c.Side,
+		Type:        c.OrderType,
+		TimeInForce: c.TimeInForce,
+		Price:       c.LimitPrice,
+		Quantity:    c.Quantity,
+	}
+	now := time.Now()
+	claim := func(tx *gorm.DB, status, reason string) error {
+		result := tx.Model(&models.ConditionalOrder{}).
+			Where("id = ? AND status = ?", c.ID, models.ConditionalStatusPending).
+			Updates(map[string]any{
+				"status":          status,
+				"triggered_price": price,
+				"triggered_at":    now,
+				"failure_reason":  reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errConditionalOrderAlreadyTaken
+		}
+		c.Status, c.TriggeredPrice, c.TriggeredAt, c.FailureReason = status, price, &now, reason
+		return nil
+	}
+
+	result, err := func() (result *OrderResult, err error) {
+		// 下单时的 panic 只让这一个条件单失败，事务已由 gorm 回滚，不影响后台任务继续检查其他条件单
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Conditional order %s panicked while placing order: %v", c.ID, r)
+				err = fmt.Errorf("%w: %v", errConditionalOrderPanicked, r)
+			}
+		}()
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := claim(tx, models.ConditionalStatusTriggered, ""); err != nil {
+				return err
+			}
+			var err error
+			if result, err = placeOrder(tx, &c.Stock, c.UserID, opts); err != nil {
+				return err
+			}
+			c.OrderID = &result.Order.ID
+			return tx.Model(&models.ConditionalOrder{}).Where("id = ?", c.ID).Update("order_id", c.OrderID).Error
+		})
+		return result, err
+	}()
+	if isOrderRejection(err) || errors.Is(err, errConditionalOrderPanicked) {
+		result = nil
+		err = claim(database.DB, models.ConditionalStatusFailed, err.Error())
+	}
+	if errors.Is(err, errConditionalOrderAlreadyTaken) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to trigger conditional order %s: %w", c.ID, err)
+	}
+
+	data := map[string]any{
+		"conditionalOrderId": c.ID.String(),
+		"symbol":             c.Stock.Symbol,
+		"kind":               c.Kind,
+		"status":             c.Status,
+		"price":              price,
+	}
+	if result != nil {
+		publishOrderTrades(&c.Stock, result)
+		data["orderId"] = result.Order.ID.String()
+		data["filled"] = result.Order.Filled
+	} else {
+		data["reason"] = c.FailureReason
+	}
+	NotificationService.Notify(NotificationEvent{
+		UserID: c.UserID,
+		Type:   models.NotificationTypeOrderTriggered,
+		Data:   data,
+	})
+	return true, nil
+}
+
+// evaluateAll 检查所有有等待触发条件单的股票
+func (s *conditionalOrderService) evaluateAll() {
+	var stockIDs []uuid.UUID
+	if err := database.DB.Model(&models.ConditionalOrder{}).Distinct("stock_id").
+		Where("status = ?", models.ConditionalStatusPending).Pluck("stock_id", &stockIDs).Error; err != nil {
+		log.Printf("Failed to get conditional orders: %v", err)
+		return
+	}
+	s.evaluateStocks(stockIDs)
+}
+
+// evaluateStocks 逐个检查股票的条件单，失败只记录日志，下次检查时重试
+func (s *conditionalOrderService) evaluateStocks(stockIDs []uuid.UUID) {
+	for _, id := range stockIDs {
+		if _, err := s.Evaluate(id); err != nil {
+			log.Printf("Failed to evaluate conditional orders for stock %s: %v", id, err)
+		}
+	}
+}
+
+// Start 启动后台检查：每次成交后检查该股票的条件单，另外每隔 interval 检查全部条件单，
+// 覆盖启动前错过的价格变动和随时间变化的 TWAP
+func (s *conditionalOrderService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		s.evaluateAll()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.evaluateAll()
+			case <-s.wake:
+				s.mu.Lock()
+				stockIDs := make([]uuid.UUID, 0, len(s.dirty))
+				for id := range s.dirty {
+					stockIDs = append(stockIDs, id)
+				}
+				clear(s.dirty)
+				s.mu.Unlock()
+				s.evaluateStocks(stockIDs)
+			}
+		}
+	}()
+}
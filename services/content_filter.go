@@ -0,0 +1,361 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"yolo/models"
+	"yolo/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 过滤结果，按严重程度递增
+const (
+	FilterActionAllow  = "allow"  // 放行
+	FilterActionLimit  = "limit"  // 影子限流：正常返回，但只有作者能看到
+	FilterActionHold   = "hold"   // 暂不发布，进入审核队列
+	FilterActionReject = "reject" // 直接拒绝
+)
+
+// filterSeverity 过滤结果的严重程度，多条规则命中时取最严重的
+var filterSeverity = map[string]int{
+	FilterActionAllow:  0,
+	FilterActionLimit:  1,
+	FilterActionHold:   2,
+	FilterActionReject: 3,
+}
+
+// 过滤规则
+const (
+	FilterRuleRateLimit = "rate_limit" // 发帖频率限制
+	FilterRuleDuplicate = "duplicate"  // 近似重复内容
+	FilterRuleBlocklist = "blocklist"  // 屏蔽词或正则
+	FilterRuleLinks     = "links"      // 链接数量限制
+	FilterRuleProbation = "probation"  // 新账号观察期
+)
+
+// ErrPostRejected 帖子被自动过滤拒绝，具体原因见 FilterError
+var ErrPostRejected = errors.New("post rejected by content filter")
+
+// FilterVerdict 过滤结果
+type FilterVerdict struct {
+	Action     string
+	Rule       string        // 命中的规则，放行时为空
+	Message    string        // 可以返回给用户的说明，不包含命中的屏蔽词
+	RetryAfter time.Duration // 频率限制时多久之后可以重试
+}
+
+// FilterError 被过滤拒绝（或无法进入审核）时返回的错误
+type FilterError struct {
+	FilterVerdict
+}
+
+func (e *FilterError) Error() string {
+	return e.Message
+}
+
+func (e *FilterError) Unwrap() error {
+	return ErrPostRejected
+}
+
+// ContentFilterConfig 发帖过滤配置
+type ContentFilterConfig struct {
+	Enabled bool
+
+	RateLimit  int           // 窗口内最多发帖数（含转发），0为不限制
+	RateWindow time.Duration // 频率限制窗口
+
+	DuplicateWindow    time.Duration // 近似重复检测的回溯时间，0为不检测
+	DuplicateLookback  int           // 最多比较的近期帖子数（所有用户）
+	DuplicateThreshold float64       // 相似度达到该值视为重复
+	DuplicateMinLength int           // 归一化后短于该长度的内容不检测，避免误伤 "gm" 之类的短帖
+
+	Blocklist []string // 屏蔽词，忽略大小写；以 / 包围的按正则匹配
+
+	MaxLinks int // 单个帖子最多链接数，负数为不限制
+
+	ProbationPeriod    time.Duration // 新账号观察期，0为不启用
+	ProbationRateLimit int           // 观察期内的发帖频率限制，0时使用 RateLimit
+	ProbationMaxLinks  int           // 观察期内最多链接数，负数为不限制
+
+	Actions map[string]string // 各规则命中后的处理方式，未设置时使用默认值
+}
+
+// defaultFilterActions 各规则的默认处理方式
+var defaultFilterActions = map[string]string{
+	FilterRuleRateLimit: FilterActionReject,
+	FilterRuleDuplicate: FilterActionReject,
+	FilterRuleBlocklist: FilterActionHold,
+	FilterRuleLinks:     FilterActionReject,
+	FilterRuleProbation: FilterActionLimit,
+}
+
+// DefaultContentFilterConfig 默认过滤配置
+func DefaultContentFilterConfig() ContentFilterConfig {
+	return ContentFilterConfig{
+		Enabled:            true,
+		RateLimit:          10,
+		RateWindow:         time.Minute,
+		DuplicateWindow:    24 * time.Hour,
+		DuplicateLookback:  200,
+		DuplicateThreshold: 0.9,
+		DuplicateMinLength: 20,
+		MaxLinks:           3,
+		ProbationPeriod:    24 * time.Hour,
+		ProbationRateLimit: 3,
+		ProbationMaxLinks:  0,
+	}
+}
+
+// LoadContentFilterConfig 从环境变量读取过滤配置，未设置的项使用默认值
+func LoadContentFilterConfig() (ContentFilterConfig, error) {
+	cfg := DefaultContentFilterConfig()
+
+	var err error
+	if value := os.Getenv("CONTENT_FILTER_ENABLED"); value != "" {
+		if cfg.Enabled, err = strconv.ParseBool(value); err != nil {
+			return cfg, fmt.Errorf("invalid CONTENT_FILTER_ENABLED: %w", err)
+		}
+	}
+	for name, target := range map[string]*int{
+		"POST_RATE_LIMIT":           &cfg.RateLimit,
+		"POST_MAX_LINKS":            &cfg.MaxLinks,
+		"PROBATION_POST_RATE_LIMIT": &cfg.ProbationRateLimit,
+		"PROBATION_MAX_LINKS":       &cfg.ProbationMaxLinks,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				return cfg, fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+	for name, target := range map[string]*time.Duration{
+		"POST_RATE_WINDOW":      &cfg.RateWindow,
+		"DUPLICATE_POST_WINDOW": &cfg.DuplicateWindow,
+		"NEW_ACCOUNT_PROBATION": &cfg.ProbationPeriod,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if *target, err = time.ParseDuration(value); err != nil {
+				return cfg, fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+
+	// 屏蔽词文件每行一条，# 开头为注释
+	if path := os.Getenv("CONTENT_BLOCKLIST_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to open blocklist: %w", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				cfg.Blocklist = append(cfg.Blocklist, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return cfg, fmt.Errorf("failed to read blocklist: %w", err)
+		}
+	}
+
+	// 格式：blocklist=reject,probation=hold
+	if value := os.Getenv("CONTENT_FILTER_ACTIONS"); value != "" {
+		cfg.Actions = make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			rule, action, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return cfg, fmt.Errorf("invalid CONTENT_FILTER_ACTIONS entry %q", pair)
+			}
+			cfg.Actions[rule] = action
+		}
+	}
+
+	return cfg, nil
+}
+
+// contentFilter 发帖过滤流水线
+type contentFilter struct {
+	cfg       ContentFilterConfig
+	actions   map[string]string
+	blocklist []*regexp.Regexp
+}
+
+// asciiWordPattern 纯ASCII单词使用单词边界匹配，其他语言（如中文）按子串匹配
+var asciiWordPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// newContentFilter 校验配置并编译屏蔽词
+func newContentFilter(cfg ContentFilterConfig) (*contentFilter, error) {
+	f := &contentFilter{cfg: cfg, actions: make(map[string]string)}
+
+	for rule, action := range defaultFilterActions {
+		f.actions[rule] = action
+	}
+	for rule, action := range cfg.Actions {
+		if _, ok := defaultFilterActions[rule]; !ok {
+			return nil, fmt.Errorf("unknown content filter rule %q", rule)
+		}
+		if _, ok := filterSeverity[action]; !ok {
+			return nil, fmt.Errorf("unknown content filter action %q for %s", action, rule)
+		}
+		f.actions[rule] = action
+	}
+
+	for _, entry := range cfg.Blocklist {
+		pattern := entry
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			pattern = entry[1 : len(entry)-1]
+		} else if asciiWordPattern.MatchString(entry) {
+			pattern = `\b` + regexp.QuoteMeta(entry) + `\b`
+		} else {
+			pattern = regexp.QuoteMeta(entry)
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist entry %q: %w", entry, err)
+		}
+		f.blocklist = append(f.blocklist, re)
+	}
+
+	return f, nil
+}
+
+// SetContentFilterConfig 替换发帖过滤配置，配置无效时保持原配置
+func (s *postService) SetContentFilterConfig(cfg ContentFilterConfig) error {
+	filter, err := newContentFilter(cfg)
+	if err != nil {
+		return err
+	}
+	s.filter.Store(filter)
+	return nil
+}
+
+// verdict 生成规则命中结果
+func (f *contentFilter) verdict(rule, message string) FilterVerdict {
+	return FilterVerdict{Action: f.actions[rule], Rule: rule, Message: message}
+}
+
+// Check 依次执行各规则，返回最严重的结果；同样严重时以先执行的规则为准
+// content 为空（如转发）时只检查发帖频率；editing 为正在修改的帖子，修改时不计入频率且不与自身比较
+func (f *contentFilter) Check(db *gorm.DB, userID uuid.UUID, content string, editing *uuid.UUID) (FilterVerdict, error) {
+	result := FilterVerdict{Action: FilterActionAllow}
+	if f == nil || !f.cfg.Enabled {
+		return result, nil
+	}
+
+	var user models.User
+	if err := db.Select("id", "created_at").Where("id = ?", userID).First(&user).Error; err != nil {
+		return result, fmt.Errorf("failed to load user: %w", err)
+	}
+	now := time.Now()
+	probation := f.cfg.ProbationPeriod > 0 && now.Sub(user.CreatedAt) < f.cfg.ProbationPeriod
+
+	var verdicts []FilterVerdict
+	if editing == nil {
+		v, err := f.checkRate(db, userID, probation, now)
+		if err != nil {
+			return result, err
+		}
+		verdicts = append(verdicts, v...)
+	}
+	if strings.TrimSpace(content) != "" {
+		links := len(utils.ExtractLinks(content))
+		if f.cfg.MaxLinks >= 0 && links > f.cfg.MaxLinks {
+			verdicts = append(verdicts, f.verdict(FilterRuleLinks, fmt.Sprintf("posts can contain at most %d links", f.cfg.MaxLinks)))
+		}
+		for _, re := range f.blocklist {
+			if re.MatchString(content) {
+				verdicts = append(verdicts, f.verdict(FilterRuleBlocklist, "post contains blocked words"))
+				break
+			}
+		}
+		v, err := f.checkDuplicate(db, userID, content, editing, now)
+		if err != nil {
+			return result, err
+		}
+		verdicts = append(verdicts, v...)
+		if probation && f.cfg.ProbationMaxLinks >= 0 && links > f.cfg.ProbationMaxLinks {
+			verdicts = append(verdicts, f.verdict(FilterRuleProbation, "new accounts cannot post links yet"))
+		}
+	}
+
+	for _, v := range verdicts {
+		if filterSeverity[v.Action] > filterSeverity[result.Action] {
+			result = v
+		}
+	}
+	return result, nil
+}
+
+// checkRate 发帖频率限制，统计包括已删除的帖子，避免发了再删绕过限制
+func (f *contentFilter) checkRate(db *gorm.DB, userID uuid.UUID, probation bool, now time.Time) ([]FilterVerdict, error) {
+	limit := f.cfg.RateLimit
+	if probation && f.cfg.ProbationRateLimit > 0 {
+		limit = f.cfg.ProbationRateLimit
+	}
+	if limit <= 0 || f.cfg.RateWindow <= 0 {
+		return nil, nil
+	}
+
+	since := now.Add(-f.cfg.RateWindow)
+	var recent []time.Time
+	if err := db.Unscoped().Model(&models.Post{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("created_at", &recent).Error; err != nil {
+		return nil, fmt.Errorf("failed to check post rate: %w", err)
+	}
+	if len(recent) < limit {
+		return nil, nil
+	}
+
+	// 窗口内第 limit 新的帖子过期后即可再次发帖
+	retryAfter := recent[limit-1].Add(f.cfg.RateWindow).Sub(now)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	v := f.verdict(FilterRuleRateLimit, fmt.Sprintf("too many posts, at most %d per %s", limit, f.cfg.RateWindow))
+	v.RetryAfter = retryAfter
+	return []FilterVerdict{v}, nil
+}
+
+// checkDuplicate 与作者自己未删除的近期帖子比较，检测近似重复的内容。
+// 不与其他用户的帖子比较，避免拦截引用他人原话，也避免泄露他人私密、草稿或待审核帖子的内容
+func (f *contentFilter) checkDuplicate(db *gorm.DB, userID uuid.UUID, content string, editing *uuid.UUID, now time.Time) ([]FilterVerdict, error) {
+	if f.cfg.DuplicateWindow <= 0 || f.cfg.DuplicateLookback <= 0 {
+		return nil, nil
+	}
+	if len([]rune(utils.NormalizeForComparison(content))) < f.cfg.DuplicateMinLength {
+		return nil, nil
+	}
+
+	query := db.Model(&models.Post{}).
+		Where("user_id = ? AND created_at > ? AND content <> ''", userID, now.Add(-f.cfg.DuplicateWindow))
+	if editing != nil {
+		query = query.Where("id <> ?", *editing)
+	}
+
+	var recent []string
+	if err := query.
+		Order("created_at DESC").
+		Limit(f.cfg.DuplicateLookback).
+		Pluck("content", &recent).Error; err != nil {
+		return nil, fmt.Errorf("failed to check duplicate posts: %w", err)
+	}
+
+	for _, other := range recent {
+		if utils.TextSimilarity(content, other) >= f.cfg.DuplicateThreshold {
+			return []FilterVerdict{f.verdict(FilterRuleDuplicate, "post is too similar to a recent post")}, nil
+		}
+	}
+	return nil, nil
+}
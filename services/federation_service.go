@@ -0,0 +1,737 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"yolo/activitypub"
+	"yolo/database"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 联邦限制
+const (
+	MaxInboxBodyBytes          = 1 << 20          // 收件箱请求体上限
+	federationOutboxSize       = 20               // 发件箱只展示最新的帖子
+	remoteActorTTL             = 24 * time.Hour   // 远程参与者缓存一天，过期后校验签名时重新获取
+	remoteActorRefetchInterval = time.Minute      // 签名校验失败时重新获取公钥的最小间隔，防止被刷
+	federationDeliveryBatch    = 100              // 每轮最多投递的活动数
+	federationDeliveryLease    = 5 * time.Minute  // 投递中的活动推迟下次尝试，避免多实例重复投递
+	maxFederationRetryDelay    = 12 * time.Hour   // 重试间隔上限
+	federationFetchTimeout     = 10 * time.Second // 获取远程参与者的超时
+	maxFederationErrorLen      = 255
+)
+
+// 联邦相关错误
+var (
+	ErrFederationDisabled = errors.New("federation is disabled")
+	ErrActorNotFound      = errors.New("actor not found")
+	ErrInvalidActivity    = errors.New("invalid activity")
+	ErrActorMismatch      = errors.New("activity actor does not match the signing key")
+)
+
+// FederationConfig 联邦配置
+type FederationConfig struct {
+	Enabled     bool
+	BaseURL     string        // 对外访问地址，参与者和帖子的ID都基于它生成，例如 https://yolo.example
+	MaxAttempts int           // 投递的最大尝试次数，用尽后放弃
+	RetryDelay  time.Duration // 第一次重试的等待时间，之后每次翻倍
+	Client      activitypub.ClientConfig
+}
+
+// DefaultFederationConfig 默认联邦配置，默认关闭
+func DefaultFederationConfig() FederationConfig {
+	return FederationConfig{
+		MaxAttempts: 8,
+		RetryDelay:  time.Minute,
+		Client: activitypub.ClientConfig{
+			Timeout:   federationFetchTimeout,
+			UserAgent: "YOLO-Federation/1.0",
+		},
+	}
+}
+
+// LoadFederationConfig 从环境变量读取联邦配置，开启时必须设置 FEDERATION_BASE_URL
+func LoadFederationConfig() (FederationConfig, error) {
+	cfg := DefaultFederationConfig()
+
+	var err error
+	if value := os.Getenv("FEDERATION_ENABLED"); value != "" {
+		if cfg.Enabled, err = strconv.ParseBool(value); err != nil {
+			return cfg, fmt.Errorf("invalid FEDERATION_ENABLED: %w", err)
+		}
+	}
+	cfg.BaseURL = os.Getenv("FEDERATION_BASE_URL")
+	if value := os.Getenv("FEDERATION_MAX_ATTEMPTS"); value != "" {
+		if cfg.MaxAttempts, err = strconv.Atoi(value); err != nil || cfg.MaxAttempts < 1 {
+			return cfg, fmt.Errorf("invalid FEDERATION_MAX_ATTEMPTS: %q", value)
+		}
+	}
+	if value := os.Getenv("FEDERATION_TIMEOUT"); value != "" {
+		if cfg.Client.Timeout, err = time.ParseDuration(value); err != nil {
+			return cfg, fmt.Errorf("invalid FEDERATION_TIMEOUT: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// federationState 当前生效的配置和客户端，整体替换
+type federationState struct {
+	config FederationConfig
+	client *activitypub.Client
+	domain string // WebFinger 使用的域名，即 BaseURL 的 host
+}
+
+type federationService struct {
+	state atomic.Pointer[federationState]
+	wake  chan struct{} // 有新活动入队时唤醒投递协程
+}
+
+// newFederationService 创建联邦服务
+func newFederationService(cfg FederationConfig) (*federationService, error) {
+	s := &federationService{wake: make(chan struct{}, 1)}
+	if err := s.SetConfig(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetConfig 替换联邦配置
+func (s *federationService) SetConfig(cfg FederationConfig) error {
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	state := &federationState{config: cfg, client: activitypub.NewClient(cfg.Client)}
+	if cfg.Enabled {
+		base, err := url.Parse(cfg.BaseURL)
+		if err != nil || base.Host == "" || (base.Scheme != "https" && base.Scheme != "http") || base.Path != "" {
+			return fmt.Errorf("invalid federation base url %q", cfg.BaseURL)
+		}
+		state.domain = base.Host
+	}
+	if cfg.MaxAttempts < 1 {
+		state.config.MaxAttempts = 1
+	}
+	s.state.Store(state)
+	return nil
+}
+
+// Enabled 是否开启联邦
+func (s *federationService) Enabled() bool {
+	return s.state.Load().config.Enabled
+}
+
+// enabledState 返回开启时的配置
+func (s *federationService) enabledState() (*federationState, error) {
+	state := s.state.Load()
+	if !state.config.Enabled {
+		return nil, ErrFederationDisabled
+	}
+	return state, nil
+}
+
+// actorURI 用户的参与者ID，收件箱等地址都在它之下
+func (st *federationState) actorURI(username string) string {
+	return st.config.BaseURL + "/ap/users/" + url.PathEscape(username)
+}
+
+// postURI 帖子的 Note ID
+func (st *federationState) postURI(postID uuid.UUID) string {
+	return st.config.BaseURL + "/ap/posts/" + postID.String()
+}
+
+// localUser 按用户名查找本站用户
+func localUser(username string) (*models.User, error) {
+	user, err := UserService.GetUserByUsername(username)
+	if err != nil {
+		return nil, ErrActorNotFound
+	}
+	return user, nil
+}
+
+// actorKey 获取用户的签名密钥，没有时生成一个
+func (s *federationService) actorKey(userID uuid.UUID) (*models.ActorKey, error) {
+	var key models.ActorKey
+	err := database.DB.Where("user_id = ?", userID).First(&key).Error
+	if err == nil {
+		return &key, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load actor key: %w", err)
+	}
+
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	key = models.ActorKey{UserID: userID, PublicKeyPEM: publicPEM, PrivateKeyPEM: privatePEM}
+	// 并发生成时以先写入的为准
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to save actor key: %w", err)
+	}
+	if err := database.DB.Where("user_id = ?", userID).First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to load actor key: %w", err)
+	}
+	return &key, nil
+}
+
+// WebFinger 解析 acct:username@domain 或参与者ID，返回参与者地址
+func (s *federationService) WebFinger(resource string) (*activitypub.WebFinger, error) {
+	state, err := s.enabledState()
+	if err != nil {
+		return nil, err
+	}
+
+	var username string
+	if account, ok := strings.CutPrefix(resource, "acct:"); ok {
+		name, domain, found := strings.Cut(account, "@")
+		if !found || !strings.EqualFold(domain, state.domain) {
+			return nil, ErrActorNotFound
+		}
+		username = name
+	} else if name, ok := strings.CutPrefix(resource, state.config.BaseURL+"/ap/users/"); ok {
+		username = name
+	} else {
+		return nil, ErrActorNotFound
+	}
+
+	user, err := localUser(username)
+	if err != nil {
+		return nil, err
+	}
+	actor := state.actorURI(user.Username)
+	return &activitypub.WebFinger{
+		Subject: "acct:" + user.Username + "@" + state.domain,
+		Aliases: []string{actor},
+		Links:   []activitypub.WebFingerLink{{Rel: "self", Type: activitypub.ContentType, Href: actor}},
+	}, nil
+}
+
+// GetActor 获取用户的参与者文档
+func (s *federationService) GetActor(username string) (*activitypub.Actor, error) {
+	state, err := s.enabledState()
+	if err != nil {
+		return nil, err
+	}
+	user, err := localUser(username)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.actorKey(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	id := state.actorURI(user.Username)
+	actor := &activitypub.Actor{
+		Context:           activitypub.DefaultContext,
+		ID:                id,
+		Type:              activitypub.TypePerson,
+		PreferredUsername: user.Username,
+		Name:              user.Name,
+		URL:               id,
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		PublicKey: activitypub.PublicKey{
+			ID:           id + "#main-key",
+			Owner:        id,
+			PublicKeyPEM: key.PublicKeyPEM,
+		},
+	}
+	if user.Avatar != nil && *user.Avatar != "" {
+		avatar := *user.Avatar
+		if strings.HasPrefix(avatar, "/") {
+			avatar = state.config.BaseURL + avatar
+		}
+		actor.Icon = &activitypub.Image{Type: "Image", URL: avatar}
+	}
+	return actor, nil
+}
+
+// GetOutbox 获取用户的发件箱，包含最新公开帖子的 Create 活动，转发不联邦
+func (s *federationService) GetOutbox(username string) (*activitypub.OrderedCollection, error) {
+	state, err := s.enabledState()
+	if err != nil {
+		return nil, err
+	}
+	user, err := localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	query := func() *gorm.DB {
+		return database.DB.Model(&models.Post{}).
+			Scopes(visiblePostsScope(uuid.Nil)).
+			Where("posts.user_id = ? AND posts.repost_of_id IS NULL", user.ID)
+	}
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count posts: %w", err)
+	}
+	var posts []models.Post
+	if err := query().Order("posts.timestamp DESC, posts.id DESC").Limit(federationOutboxSize).Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+
+	items := make([]any, 0, len(posts))
+	for i := range posts {
+		activity, err := state.createActivity(&posts[i], user.Username)
+		if err != nil {
+			return nil, err
+		}
+		activity.Context = nil
+		items = append(items, activity)
+	}
+	return &activitypub.OrderedCollection{
+		Context:      activitypub.ActivityStreamsContext,
+		ID:           state.actorURI(user.Username) + "/outbox",
+		Type:         activitypub.TypeOrderedCollection,
+		TotalItems:   total,
+		OrderedItems: items,
+	}, nil
+}
+
+// GetFollowers 获取用户的关注者集合，只公开数量
+func (s *federationService) GetFollowers(username string) (*activitypub.OrderedCollection, error) {
+	state, err := s.enabledState()
+	if err != nil {
+		return nil, err
+	}
+	user, err := localUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	followers, _ := UserService.CountFollows(user.ID)
+	var remote int64
+	database.DB.Model(&models.RemoteFollower{}).Where("user_id = ?", user.ID).Count(&remote)
+	return &activitypub.OrderedCollection{
+		Context:    activitypub.ActivityStreamsContext,
+		ID:         state.actorURI(user.Username) + "/followers",
+		Type:       activitypub.TypeOrderedCollection,
+		TotalItems: followers + remote,
+	}, nil
+}
+
+// GetNote 获取公开帖子的 Note 对象
+func (s *federationService) GetNote(postID uuid.UUID) (*activitypub.Note, error) {
+	state, err := s.enabledState()
+	if err != nil {
+		return nil, err
+	}
+	post, err := PostService.GetPostByID(uuid.Nil, postID)
+	if err != nil || post.RepostOfID != nil {
+		return nil, ErrPostNotFound
+	}
+	note := state.note(post, post.User.Username)
+	note.Context = activitypub.ActivityStreamsContext
+	return note, nil
+}
+
+// noteContent 把纯文本正文转为 HTML，引用的帖子以链接附在末尾
+func (st *federationState) noteContent(post *models.Post) string {
+	content := "<p>" + strings.ReplaceAll(html.EscapeString(post.Content), "\n", "<br>") + "</p>"
+	if post.QuoteOfID != nil {
+		quote := html.EscapeString(st.postURI(*post.QuoteOfID))
+		content += `<p>RE: <a href="` + quote + `">` + quote + `</a></p>`
+	}
+	return content
+}
+
+// note 帖子对应的 Note，发给所有人并抄送关注者
+func (st *federationState) note(post *models.Post, username string) *activitypub.Note {
+	actor := st.actorURI(username)
+	id := st.postURI(post.ID)
+	return &activitypub.Note{
+		ID:           id,
+		Type:         activitypub.TypeNote,
+		AttributedTo: actor,
+		Content:      st.noteContent(post),
+		Published:    post.Timestamp.UTC().Format(time.RFC3339),
+		URL:          id,
+		To:           []string{activitypub.PublicCollection},
+		CC:           []string{actor + "/followers"},
+	}
+}
+
+// createActivity 发布帖子的 Create 活动
+func (st *federationState) createActivity(post *models.Post, username string) (*activitypub.Activity, error) {
+	note := st.note(post, username)
+	activity, err := activitypub.NewActivity(note.ID+"/activity", activitypub.TypeCreate, note.AttributedTo, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode activity: %w", err)
+	}
+	activity.Published = note.Published
+	activity.To = note.To
+	activity.CC = note.CC
+	return activity, nil
+}
+
+// deleteActivity 删除帖子的 Delete 活动，对象为 Tombstone
+func (st *federationState) deleteActivity(post *models.Post, username string) (*activitypub.Activity, error) {
+	id := st.postURI(post.ID)
+	activity, err := activitypub.NewActivity(id+"#delete", activitypub.TypeDelete, st.actorURI(username),
+		activitypub.Note{ID: id, Type: activitypub.TypeTombstone})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode activity: %w", err)
+	}
+	activity.To = []string{activitypub.PublicCollection}
+	return activity, nil
+}
+
+// HandleInbox 处理投递到用户收件箱的活动。请求必须带有效的 HTTP Signature，
+// 且活动的 actor 必须是签名公钥的所有者。目前处理 Follow 和 Undo(Follow)，其他活动忽略
+func (s *federationService) HandleInbox(username string, r *http.Request, body []byte) error {
+	state, err := s.enabledState()
+	if err != nil {
+		return err
+	}
+	user, err := localUser(username)
+	if err != nil {
+		return err
+	}
+	owner, err := s.verifySignature(state, r, body)
+	if err != nil {
+		return err
+	}
+
+	var activity activitypub.Activity
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Type == "" {
+		return ErrInvalidActivity
+	}
+	if activity.Actor != owner {
+		return ErrActorMismatch
+	}
+	var remote models.RemoteActor
+	if err := database.DB.Where("uri = ?", owner).First(&remote).Error; err != nil {
+		return fmt.Errorf("failed to load remote actor: %w", err)
+	}
+
+	actor := state.actorURI(user.Username)
+	switch activity.Type {
+	case activitypub.TypeFollow:
+		if activity.ObjectID() != actor {
+			return ErrInvalidActivity
+		}
+		return s.acceptFollow(state, user, &remote, &activity)
+	case activitypub.TypeUndo:
+		query := database.DB.Where("user_id = ? AND remote_actor_id = ?", user.ID, remote.ID)
+		if inner, ok := activity.InnerActivity(); ok {
+			if inner.Type != activitypub.TypeFollow || inner.ObjectID() != actor {
+				return nil
+			}
+		} else {
+			// object 只给出ID时按 Follow 活动ID匹配
+			query = query.Where("activity_id = ?", activity.ObjectID())
+		}
+		if err := query.Delete(&models.RemoteFollower{}).Error; err != nil {
+			return fmt.Errorf("failed to remove follower: %w", err)
+		}
+	}
+	return nil
+}
+
+// acceptFollow 记录远程关注者并回复 Accept
+func (s *federationService) acceptFollow(state *federationState, user *models.User, remote *models.RemoteActor, follow *activitypub.Activity) error {
+	follower := models.RemoteFollower{UserID: user.ID, RemoteActorID: remote.ID, ActivityID: follow.ID}
+	follow.Context = nil
+	actor := state.actorURI(user.Username)
+	accept, err := activitypub.NewActivity(actor+"#accepts/"+uuid.NewString(), activitypub.TypeAccept, actor, follow)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %w", err)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "remote_actor_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"activity_id"}),
+		}).Create(&follower).Error; err != nil {
+			return fmt.Errorf("failed to save follower: %w", err)
+		}
+		return s.enqueue(tx, user.ID, []string{remote.Inbox}, accept)
+	})
+	if err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// verifySignature 校验请求签名并返回签名者的参与者ID。
+// 缓存的公钥校验失败时重新获取一次参与者，兼容对方轮换密钥
+func (s *federationService) verifySignature(state *federationState, r *http.Request, body []byte) (string, error) {
+	resolver := func(refresh bool) activitypub.KeyResolver {
+		return func(keyID string) (*rsa.PublicKey, string, error) {
+			remote, err := s.remoteActorByKey(state, keyID, refresh)
+			if err != nil {
+				return nil, "", err
+			}
+			key, err := activitypub.ParsePublicKey(remote.PublicKeyPEM)
+			if err != nil {
+				return nil, "", err
+			}
+			return key, remote.URI, nil
+		}
+	}
+
+	now := time.Now()
+	owner, err := activitypub.VerifyRequest(r, body, now, resolver(false))
+	if errors.Is(err, activitypub.ErrInvalidSignature) {
+		owner, err = activitypub.VerifyRequest(r, body, now, resolver(true))
+	}
+	return owner, err
+}
+
+// remoteActorByKey 按 keyId 查找远程参与者，缓存过期或需要刷新时重新获取参与者文档
+func (s *federationService) remoteActorByKey(state *federationState, keyID string, refresh bool) (*models.RemoteActor, error) {
+	var cached models.RemoteActor
+	found := database.DB.Where("key_id = ?", keyID).First(&cached).Error == nil
+	if found {
+		age := time.Since(cached.FetchedAt)
+		if (!refresh && age < remoteActorTTL) || (refresh && age < remoteActorRefetchInterval) {
+			return &cached, nil
+		}
+	}
+
+	actorURL, err := url.Parse(keyID)
+	if err != nil {
+		return nil, activitypub.ErrInvalidURL
+	}
+	actorURL.Fragment = ""
+	ctx, cancel := context.WithTimeout(context.Background(), federationFetchTimeout)
+	defer cancel()
+	actor, err := state.client.FetchActor(ctx, actorURL.String())
+	if err != nil {
+		// 对方暂时不可用时继续使用旧的公钥
+		if found && !refresh {
+			return &cached, nil
+		}
+		return nil, err
+	}
+	if actor.PublicKey.ID != keyID {
+		return nil, fmt.Errorf("key %s not found on actor %s", keyID, actor.ID)
+	}
+
+	id, _ := url.Parse(actor.ID)
+	sharedInbox := ""
+	if actor.Endpoints != nil {
+		sharedInbox = actor.Endpoints.SharedInbox
+	}
+	remote := models.RemoteActor{
+		URI:          actor.ID,
+		KeyID:        keyID,
+		Username:     actor.PreferredUsername,
+		Domain:       id.Host,
+		Inbox:        actor.Inbox,
+		SharedInbox:  sharedInbox,
+		PublicKeyPEM: actor.PublicKey.PublicKeyPEM,
+		FetchedAt:    time.Now(),
+	}
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uri"}},
+		DoUpdates: clause.AssignmentColumns([]string{"key_id", "username", "domain", "inbox", "shared_inbox", "public_key_pem", "fetched_at"}),
+	}).Create(&remote).Error; err != nil {
+		return nil, fmt.Errorf("failed to save remote actor: %w", err)
+	}
+	if err := database.DB.Where("uri = ?", actor.ID).First(&remote).Error; err != nil {
+		return nil, fmt.Errorf("failed to load remote actor: %w", err)
+	}
+	return &remote, nil
+}
+
+// federatePost 把帖子的发布和删除投递给作者的远程关注者，同一实例只投递一次共享收件箱。
+// 由 publishPostEvent 调用，因此只会处理公开、已发布且未被限流的帖子；转发不联邦
+func (s *federationService) federatePost(eventType string, post *models.Post) {
+	state := s.state.Load()
+	if !state.config.Enabled || post.RepostOfID != nil {
+		return
+	}
+
+	var inboxes []string
+	database.DB.Model(&models.RemoteFollower{}).
+		Joins("JOIN remote_actors ON remote_actors.id = remote_followers.remote_actor_id").
+		Where("remote_followers.user_id = ?", post.UserID).
+		Distinct().
+		Pluck("COALESCE(NULLIF(remote_actors.shared_inbox, ''), remote_actors.inbox)", &inboxes)
+	if len(inboxes) == 0 {
+		return
+	}
+	user, err := UserService.GetUserByID(post.UserID)
+	if err != nil {
+		return
+	}
+
+	var activity *activitypub.Activity
+	switch eventType {
+	case EventPostCreated:
+		activity, err = state.createActivity(post, user.Username)
+	case EventPostDeleted:
+		activity, err = state.deleteActivity(post, user.Username)
+	default:
+		return
+	}
+	if err == nil {
+		err = s.enqueue(database.DB, user.ID, inboxes, activity)
+	}
+	if err != nil {
+		log.Printf("Failed to federate post %s: %v", post.ID, err)
+		return
+	}
+	s.notify()
+}
+
+// enqueue 为每个收件箱创建一条待投递记录
+func (s *federationService) enqueue(db *gorm.DB, userID uuid.UUID, inboxes []string, activity *activitypub.Activity) error {
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity: %w", err)
+	}
+	now := time.Now()
+	deliveries := make([]models.FederationDelivery, 0, len(inboxes))
+	for _, inbox := range inboxes {
+		deliveries = append(deliveries, models.FederationDelivery{
+			UserID:        userID,
+			Inbox:         inbox,
+			Payload:       string(payload),
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := db.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to queue deliveries: %w", err)
+	}
+	return nil
+}
+
+// notify 唤醒投递协程，不阻塞
+func (s *federationService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// retryDelay 第 attempts 次失败后的等待时间
+func (st *federationState) retryDelay(attempts int) time.Duration {
+	delay := st.config.RetryDelay
+	for i := 1; i < attempts && delay < maxFederationRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxFederationRetryDelay)
+}
+
+// DeliverDue 投递到期的活动，返回成功投递的数量。
+// 失败后按指数退避重试，达到最大尝试次数后标记为失败
+func (s *federationService) DeliverDue(now time.Time) (int, error) {
+	state := s.state.Load()
+	if !state.config.Enabled {
+		return 0, nil
+	}
+
+	var due []models.FederationDelivery
+	if err := database.DB.
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryStatusPending, now).
+		Order("next_attempt_at").
+		Limit(federationDeliveryBatch).
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	delivered := 0
+	keys := make(map[uuid.UUID]*rsa.PrivateKey)
+	for i := range due {
+		delivery := &due[i]
+		// 通过带条件的 UPDATE 抢占投递权，多个实例同时处理时只有一个会成功
+		claimed := database.DB.Model(&models.FederationDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ?", delivery.ID, models.DeliveryStatusPending, delivery.Attempts, now).
+			Update("next_attempt_at", now.Add(federationDeliveryLease))
+		if claimed.Error != nil || claimed.RowsAffected == 0 {
+			continue
+		}
+
+		err := s.deliver(state, delivery, keys)
+		attempts := delivery.Attempts + 1
+		updates := map[string]any{"attempts": attempts}
+		switch {
+		case err == nil:
+			updates["status"] = models.DeliveryStatusDelivered
+			updates["last_error"] = nil
+			delivered++
+		case attempts >= state.config.MaxAttempts:
+			updates["status"] = models.DeliveryStatusFailed
+			updates["last_error"] = truncateError(err)
+		default:
+			updates["next_attempt_at"] = now.Add(state.retryDelay(attempts))
+			updates["last_error"] = truncateError(err)
+		}
+		if err := database.DB.Model(&models.FederationDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+			log.Printf("Failed to update delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return delivered, nil
+}
+
+// deliver 以发送者的密钥签名并投递一条活动
+func (s *federationService) deliver(state *federationState, delivery *models.FederationDelivery, keys map[uuid.UUID]*rsa.PrivateKey) error {
+	key, ok := keys[delivery.UserID]
+	if !ok {
+		actorKey, err := s.actorKey(delivery.UserID)
+		if err != nil {
+			return err
+		}
+		if key, err = activitypub.ParsePrivateKey(actorKey.PrivateKeyPEM); err != nil {
+			return err
+		}
+		keys[delivery.UserID] = key
+	}
+	user, err := UserService.GetUserByID(delivery.UserID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), state.config.Client.Timeout)
+	defer cancel()
+	return state.client.Deliver(ctx, delivery.Inbox, state.actorURI(user.Username)+"#main-key", key, []byte(delivery.Payload))
+}
+
+// truncateError 截断错误信息以便存入数据库
+func truncateError(err error) string {
+	message := err.Error()
+	if len(message) > maxFederationErrorLen {
+		message = message[:maxFederationErrorLen]
+	}
+	return message
+}
+
+// StartDeliveries 启动后台投递，按间隔检查到期的活动，有新活动入队时立即投递
+func (s *federationService) StartDeliveries(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.DeliverDue(time.Now()); err != nil {
+				log.Printf("Failed to deliver activities: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yolo/database"
+	"yolo/decimal"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 账本限制
+const (
+	MaxGiftMemoLen = 200
+)
+
+// 账本相关错误
+var (
+	ErrUnbalancedEntry  = errors.New("journal entry does not balance")
+	ErrInvalidGift      = errors.New("gift amount must be positive")
+	ErrGiftToSelf       = errors.New("cannot gift to yourself")
+	ErrGiftMemoTooLong  = errors.New("gift memo is too long")
+	ErrRecipientMissing = errors.New("recipient not found")
+)
+
+// ledgerLeg 一条待记账的分录
+type ledgerLeg struct {
+	AccountID uuid.UUID
+	Amount    decimal.Decimal
+}
+
+// LedgerReport 账本一致性检查结果
+type LedgerReport struct {
+	Entries            int64
+	Accounts           int64
+	Total              decimal.Decimal // 所有账户余额之和，应为 0
+	UnbalancedEntries  []uuid.UUID     // 分录之和不为 0 的凭证
+	MismatchedAccounts []string        // 余额快照与分录之和不一致的账户
+	MismatchedPools    []string        // 流动性池储备与池账户余额不一致的账户
+	MismatchedBooks    []string        // 订单簿账户余额与买单冻结金额之和不一致的账户
+}
+
+// Balanced 账本是否平衡
+func (r *LedgerReport) Balanced() bool {
+	return r.Total.IsZero() && len(r.UnbalancedEntries) == 0 &&
+		len(r.MismatchedAccounts) == 0 && len(r.MismatchedPools) == 0 && len(r.MismatchedBooks) == 0
+}
+
+type ledgerService struct{}
+
+// userAccountCode 用户账户代码
+func userAccountCode(userID uuid.UUID) string {
+	return models.LedgerAccountUser + ":" + userID.String()
+}
+
+// poolAccountCode 流动性池账户代码
+func poolAccountCode(stockID uuid.UUID) string {
+	return models.LedgerAccountPool + ":" + stockID.String()
+}
+
+// openAccount 锁定账户，不存在时创建；只有本次创建了账户时才从金库发放 opening，并发开户也只发放一次
+func openAccount(tx *gorm.DB, code, accountType string, ownerID *uuid.UUID, opening decimal.Decimal) (*models.LedgerAccount, error) {
+	account := models.LedgerAccount{Code: code, Type: accountType, OwnerID: ownerID}
+	created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account)
+	if created.Error != nil {
+		return nil, created.Error
+	}
+	if created.RowsAffected == 1 && !opening.IsZero() {
+		treasury, err := openAccount(tx, models.LedgerTreasuryCode, models.LedgerAccountPlatform, nil, decimal.Zero)
+		if err != nil {
+			return nil, err
+		}
+		entry := &models.JournalEntry{Type: models.JournalTypeGrant, Memo: "opening balance for " + code}
+		if err := postEntry(tx, entry, ledgerLeg{treasury.ID, opening.Neg()}, ledgerLeg{account.ID, opening}); err != nil {
+			return nil, err
+		}
+	}
+
+	var locked models.LedgerAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&locked).Error; err != nil {
+		return nil, err
+	}
+	return &locked, nil
+}
+
+// openFeesAccount 锁定手续费收入账户
+func openFeesAccount(tx *gorm.DB) (*models.LedgerAccount, error) {
+	return openAccount(tx, models.LedgerFeesCode, models.LedgerAccountPlatform, nil, decimal.Zero)
+}
+
+// openUserAccount 锁定用户账户，首次开户时发放初始余额
+func openUserAccount(tx *gorm.DB, userID uuid.UUID) (*models.LedgerAccount, error) {
+	return openAccount(tx, userAccountCode(userID), models.LedgerAccountUser, &userID, models.DefaultUserBalance)
+}
+
+// postEntry 写入记账凭证和分录并更新账户余额快照，必须在事务中调用
+// 分录之和必须为 0；只有平台账户可以为负，用户和池账户余额不足时返回 ErrInsufficientBalance
+func postEntry(tx *gorm.DB, entry *models.JournalEntry, legs ...ledgerLeg) error {
+	if len(legs) < 2 {
+		return ErrUnbalancedEntry
+	}
+	var sum decimal.Decimal
+	for _, leg := range legs {
+		sum = sum.Add(leg.Amount)
+	}
+	if !sum.IsZero() {
+		return ErrUnbalancedEntry
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+	postings := make([]models.LedgerPosting, 0, len(legs))
+	for _, leg := range legs {
+		postings = append(postings, models.LedgerPosting{
+			EntryID:   entry.ID,
+			AccountID: leg.AccountID,
+			Amount:    leg.Amount,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	if err := tx.Create(&postings).Error; err != nil {
+		return err
+	}
+	entry.Postings = postings
+
+	for _, leg := range legs {
+		update := tx.Model(&models.LedgerAccount{}).
+			Where("id = ? AND (type = ? OR balance >= ?)", leg.AccountID, models.LedgerAccountPlatform, decimal.Max(leg.Amount.Neg(), decimal.Zero)).
+			Update("balance", incrementExpr("balance", leg.Amount))
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrInsufficientBalance
+		}
+	}
+	return nil
+}
+
+// incrementExpr 列增量更新表达式。SQLite 的 NUMERIC 列按浮点数相加，每次更新后舍入到 Scale 位，
+// 误差不会随更新次数累积；PostgreSQL 的 NUMERIC(38,6) 上 ROUND 不改变结果
+func incrementExpr(column string, delta decimal.Decimal) clause.Expr {
+	return gorm.Expr(fmt.Sprintf("ROUND(%s + ?, %d)", column, decimal.Scale), delta)
+}
+
+// GetBalance 获取用户的 YOLO 余额，未开户的用户返回开户时将发放的金额
+func (s *ledgerService) GetBalance(userID uuid.UUID) (decimal.Decimal, error) {
+	return userBalance(database.DB, userID)
+}
+
+// userBalance 在给定的连接或事务中读取用户的 YOLO 余额，不加锁
+func userBalance(db *gorm.DB, userID uuid.UUID) (decimal.Decimal, error) {
+	var account models.LedgerAccount
+	err := db.Where("code = ?", userAccountCode(userID)).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultUserBalance, nil
+	}
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return account.Balance, nil
+}
+
+// GetStatement 获取用户账户的分录明细，按时间倒序
+func (s *ledgerService) GetStatement(userID uuid.UUID, page, limit int) ([]models.LedgerPosting, int64, error) {
+	var postings []models.LedgerPosting
+	var total int64
+
+	var account models.LedgerAccount
+	err := database.DB.Where("code = ?", userAccountCode(userID)).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return postings, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	query := database.DB.Model(&models.LedgerPosting{}).Where("account_id = ?", account.ID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count postings: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	err = query.Preload("Entry").Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&postings).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get statement: %w", err)
+	}
+	return postings, total, nil
+}
+
+// Gift 向其他用户赠送 YOLO
+func (s *ledgerService) Gift(senderID uuid.UUID, recipientUsername string, amount decimal.Decimal, memo string) (*models.JournalEntry, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidGift
+	}
+	memo = strings.TrimSpace(memo)
+	if utf8.RuneCountInString(memo) > MaxGiftMemoLen {
+		return nil, ErrGiftMemoTooLong
+	}
+	recipient, err := UserService.GetUserByUsername(recipientUsername)
+	if err != nil {
+		return nil, ErrRecipientMissing
+	}
+	if recipient.ID == senderID {
+		return nil, ErrGiftToSelf
+	}
+
+	entry := &models.JournalEntry{Type: models.JournalTypeGift, Memo: memo}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// 按账户代码顺序加锁，互相赠送时不会死锁
+		ids := []uuid.UUID{senderID, recipient.ID}
+		if userAccountCode(ids[1]) < userAccountCode(ids[0]) {
+			ids[0], ids[1] = ids[1], ids[0]
+		}
+		accounts := make(map[uuid.UUID]*models.LedgerAccount, 2)
+		for _, id := range ids {
+			account, err := openUserAccount(tx, id)
+			if err != nil {
+				return err
+			}
+			accounts[id] = account
+		}
+		if accounts[senderID].Balance.LessThan(amount) {
+			return ErrInsufficientBalance
+		}
+		return postEntry(tx, entry,
+			ledgerLeg{accounts[senderID].ID, amount.Neg()},
+			ledgerLeg{accounts[recipient.ID].ID, amount})
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientBalance) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to send gift: %w", err)
+	}
+
+	NotificationService.Notify(NotificationEvent{
+		UserID:  recipient.ID,
+		ActorID: &senderID,
+		Type:    models.NotificationTypeGift,
+		Data:    map[string]any{"amount": amount, "memo": memo},
+	})
+	return entry, nil
+}
+
+// CheckInvariants 检查账本：每张凭证借贷平衡、所有账户余额之和为 0、
+// 余额快照等于分录之和、流动性池的 YOLO 储备等于池账户余额、订单簿账户余额等于挂单买单的冻结金额。
+// SQLite 的 NUMERIC 列按浮点数求和，不能精确比较，因此逐行读出 Decimal 在 Go 中求和，要求严格等于 0
+func (s *ledgerService) CheckInvariants() (*LedgerReport, error) {
+	report := &LedgerReport{}
+	db := database.DB
+
+	if err := db.Model(&models.JournalEntry{}).Count(&report.Entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to count entries: %w", err)
+	}
+	var accounts []models.LedgerAccount
+	if err := db.Order("code").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load accounts: %w", err)
+	}
+	report.Accounts = int64(len(accounts))
+
+	entrySums := make(map[uuid.UUID]decimal.Decimal)
+	accountSums := make(map[uuid.UUID]decimal.Decimal)
+	rows, err := db.Model(&models.LedgerPosting{}).Select("entry_id, account_id, amount").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load postings: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var posting models.LedgerPosting
+		if err := rows.Scan(&posting.EntryID, &posting.AccountID, &posting.Amount); err != nil {
+			return nil, fmt.Errorf("failed to load postings: %w", err)
+		}
+		entrySums[posting.EntryID] = entrySums[posting.EntryID].Add(posting.Amount)
+		accountSums[posting.AccountID] = accountSums[posting.AccountID].Add(posting.Amount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load postings: %w", err)
+	}
+
+	for id, sum := range entrySums {
+		if !sum.IsZero() {
+			report.UnbalancedEntries = append(report.UnbalancedEntries, id)
+		}
+	}
+	// 没有分录的凭证同样视为不平衡
+	var empty []uuid.UUID
+	err = db.Model(&models.JournalEntry{}).
+		Where("NOT EXISTS (SELECT 1 FROM ledger_postings WHERE ledger_postings.entry_id = journal_entries.id)").
+		Pluck("id", &empty).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check entries: %w", err)
+	}
+	report.UnbalancedEntries = append(report.UnbalancedEntries, empty...)
+
+	var pools []models.LiquidityPool
+	if err := db.Find(&pools).Error; err != nil {
+		return nil, fmt.Errorf("failed to check pools: %w", err)
+	}
+	reserves := make(map[uuid.UUID]decimal.Decimal, len(pools))
+	for _, pool := range pools {
+		reserves[pool.StockID] = pool.YoloReserve
+	}
+
+	var orders []models.Order
+	err = db.Select("stock_id, reserved").
+		Where("side = ? AND status = ?", models.TradeTypeBuy, models.OrderStatusOpen).
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check order books: %w", err)
+	}
+	reserved := make(map[uuid.UUID]decimal.Decimal)
+	for _, order := range orders {
+		reserved[order.StockID] = reserved[order.StockID].Add(order.Reserved)
+	}
+
+	for _, account := range accounts {
+		report.Total = report.Total.Add(account.Balance)
+		if account.Balance != accountSums[account.ID] {
+			report.MismatchedAccounts = append(report.MismatchedAccounts, account.Code)
+		}
+		if account.OwnerID == nil {
+			continue
+		}
+		switch account.Type {
+		case models.LedgerAccountPool:
+			if reserve, ok := reserves[*account.OwnerID]; ok && account.Balance != reserve {
+				report.MismatchedPools = append(report.MismatchedPools, account.Code)
+			}
+		case models.LedgerAccountBook:
+			if account.Balance != reserved[*account.OwnerID] {
+				report.MismatchedBooks = append(report.MismatchedBooks, account.Code)
+			}
+		}
+	}
+	return report, nil
+}
+
+// StartChecks 定期检查账本一致性，发现问题时记录日志
+func (s *ledgerService) StartChecks(ctx context.Context, interval time.Duration) {
+	run := func() {
+		report, err := s.CheckInvariants()
+		if err != nil {
+			log.Printf("Failed to check ledger: %v", err)
+			return
+		}
+		if !report.Balanced() {
+			log.Printf("Ledger out of balance: total=%s unbalanced_entries=%v mismatched_accounts=%v mismatched_pools=%v mismatched_books=%v",
+				report.Total, report.UnbalancedEntries, report.MismatchedAccounts, report.MismatchedPools, report.MismatchedBooks)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
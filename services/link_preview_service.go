@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"yolo/database"
+	"yolo/models"
+	"yolo/unfurl"
+	"yolo/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 链接预览限制
+const (
+	MaxPostLinkPreviews    = 4              // 每个帖子最多展示的链接预览数
+	linkPreviewTTL         = 24 * time.Hour // 成功的预览缓存一天，过期后再次被引用时刷新
+	linkPreviewRetryDelay  = time.Hour      // 抓取失败的链接一小时内不再重试
+	linkPreviewQueueSize   = 256
+	maxLinkPreviewErrorLen = 255
+)
+
+// LinkPreviewConfig 链接预览配置
+type LinkPreviewConfig struct {
+	Enabled bool
+	Fetch   unfurl.Config
+}
+
+// DefaultLinkPreviewConfig 默认链接预览配置
+func DefaultLinkPreviewConfig() LinkPreviewConfig {
+	return LinkPreviewConfig{Enabled: true, Fetch: unfurl.DefaultConfig()}
+}
+
+// LoadLinkPreviewConfig 从环境变量读取链接预览配置，未设置的项使用默认值
+func LoadLinkPreviewConfig() (LinkPreviewConfig, error) {
+	cfg := DefaultLinkPreviewConfig()
+
+	var err error
+	if value := os.Getenv("LINK_PREVIEW_ENABLED"); value != "" {
+		if cfg.Enabled, err = strconv.ParseBool(value); err != nil {
+			return cfg, fmt.Errorf("invalid LINK_PREVIEW_ENABLED: %w", err)
+		}
+	}
+	if value := os.Getenv("LINK_PREVIEW_TIMEOUT"); value != "" {
+		if cfg.Fetch.Timeout, err = time.ParseDuration(value); err != nil {
+			return cfg, fmt.Errorf("invalid LINK_PREVIEW_TIMEOUT: %w", err)
+		}
+	}
+	if value := os.Getenv("LINK_PREVIEW_MAX_BYTES"); value != "" {
+		if cfg.Fetch.MaxBodyBytes, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid LINK_PREVIEW_MAX_BYTES: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// linkPreviewState 当前生效的配置和抓取器，整体替换
+type linkPreviewState struct {
+	config  LinkPreviewConfig
+	fetcher *unfurl.Fetcher
+}
+
+type linkPreviewService struct {
+	state    atomic.Pointer[linkPreviewState]
+	queue    chan string
+	inflight sync.Map // 已在队列中的链接，避免同一链接被重复抓取
+}
+
+// newLinkPreviewService 创建链接预览服务
+func newLinkPreviewService(cfg LinkPreviewConfig) *linkPreviewService {
+	s := &linkPreviewService{queue: make(chan string, linkPreviewQueueSize)}
+	s.SetConfig(cfg)
+	return s
+}
+
+// SetConfig 替换链接预览配置
+func (s *linkPreviewService) SetConfig(cfg LinkPreviewConfig) {
+	s.state.Store(&linkPreviewState{config: cfg, fetcher: unfurl.New(cfg.Fetch)})
+}
+
+// Start 启动后台抓取协程，并重新排队上次退出时未抓取的链接
+func (s *linkPreviewService) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for hash := range s.queue {
+				s.Process(hash)
+			}
+		}()
+	}
+
+	if !s.state.Load().config.Enabled {
+		return
+	}
+	var pending []string
+	database.DB.Model(&models.LinkPreview{}).Where("status = ?", models.LinkPreviewStatusPending).Pluck("url_hash", &pending)
+	for _, hash := range pending {
+		s.enqueue(hash)
+	}
+}
+
+// enqueue 加入抓取队列，队列满时异步等待，不阻塞发帖请求
+func (s *linkPreviewService) enqueue(hash string) {
+	if _, queued := s.inflight.LoadOrStore(hash, struct{}{}); queued {
+		return
+	}
+	select {
+	case s.queue <- hash:
+	default:
+		go func() { s.queue <- hash }()
+	}
+}
+
+// hashURL 链接预览的缓存键
+func hashURL(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// attachToPost 在事务中重新提取帖子内容里的链接，只保留前 MaxPostLinkPreviews 个不同的链接
+func (s *linkPreviewService) attachToPost(tx *gorm.DB, postID uuid.UUID, content string) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostLink{}).Error; err != nil {
+		return err
+	}
+	if !s.state.Load().config.Enabled {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var links []models.PostLink
+	for _, raw := range utils.ExtractLinks(content) {
+		url, err := unfurl.NormalizeURL(raw)
+		if err != nil {
+			continue
+		}
+		hash := hashURL(url)
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		preview := models.LinkPreview{URLHash: hash, URL: url, Status: models.LinkPreviewStatusPending}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&preview).Error; err != nil {
+			return err
+		}
+		links = append(links, models.PostLink{PostID: postID, Position: len(links), URLHash: hash})
+		if len(links) == MaxPostLinkPreviews {
+			break
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.Create(&links).Error
+}
+
+// staleScope 需要（重新）抓取的预览：未抓取、缓存过期或失败后已过重试间隔
+func staleScope(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"link_previews.status = ? OR (link_previews.status = ? AND link_previews.fetched_at < ?) OR (link_previews.status = ? AND link_previews.fetched_at < ?)",
+			models.LinkPreviewStatusPending,
+			models.LinkPreviewStatusReady, now.Add(-linkPreviewTTL),
+			models.LinkPreviewStatusFailed, now.Add(-linkPreviewRetryDelay),
+		)
+	}
+}
+
+// fetchForPost 帖子保存后将需要抓取的链接加入队列
+func (s *linkPreviewService) fetchForPost(postID uuid.UUID) {
+	if !s.state.Load().config.Enabled {
+		return
+	}
+	var hashes []string
+	if err := database.DB.Model(&models.LinkPreview{}).
+		Joins("JOIN post_links ON post_links.url_hash = link_previews.url_hash").
+		Where("post_links.post_id = ?", postID).
+		Scopes(staleScope(time.Now())).
+		Pluck("link_previews.url_hash", &hashes).Error; err != nil {
+		log.Printf("Failed to load links of post %s: %v", postID, err)
+		return
+	}
+	for _, hash := range hashes {
+		s.enqueue(hash)
+	}
+}
+
+// Process 抓取链接元数据。刷新过期缓存失败时保留旧的预览，等下次过期再试
+func (s *linkPreviewService) Process(hash string) {
+	defer s.inflight.Delete(hash)
+
+	var preview models.LinkPreview
+	if err := database.DB.Scopes(staleScope(time.Now())).Where("url_hash = ?", hash).First(&preview).Error; err != nil {
+		return // 已被删除或已是最新
+	}
+
+	state := s.state.Load()
+	ctx, cancel := context.WithTimeout(context.Background(), state.config.Fetch.Timeout)
+	defer cancel()
+	meta, err := state.fetcher.Fetch(ctx, preview.URL)
+	if err == nil && meta.Empty() {
+		err = fmt.Errorf("page has no preview metadata")
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"fetched_at": now, "updated_at": now}
+	if err != nil {
+		if preview.Status != models.LinkPreviewStatusReady {
+			message := err.Error()
+			if len(message) > maxLinkPreviewErrorLen {
+				message = message[:maxLinkPreviewErrorLen]
+			}
+			updates["status"] = models.LinkPreviewStatusFailed
+			updates["error"] = message
+		}
+	} else {
+		updates["status"] = models.LinkPreviewStatusReady
+		updates["title"] = meta.Title
+		updates["description"] = meta.Description
+		updates["image_url"] = meta.ImageURL
+		updates["site_name"] = meta.SiteName
+		updates["error"] = nil
+	}
+
+	if err := database.DB.Model(&models.LinkPreview{}).Where("url_hash = ?", hash).Updates(updates).Error; err != nil {
+		log.Printf("Failed to save link preview %s: %v", preview.URL, err)
+	}
+}
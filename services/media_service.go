@@ -0,0 +1,320 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"yolo/database"
+	"yolo/models"
+	"yolo/storage"
+	"yolo/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 媒体附件限制
+const (
+	MaxPostMedia     = 4                // 每个帖子最多的附件数
+	MaxImageBytes    = 10 << 20         // 图片最大10MB
+	MaxVideoBytes    = 50 << 20         // 视频最大50MB
+	MaxVideoDuration = 60 * time.Second // 仅支持短视频
+	maxImagePixels   = 50_000_000       // 防止解压炸弹
+	thumbnailMaxSize = 400
+	blurhashSample   = 32
+	mediaQueueSize   = 256
+)
+
+// 媒体相关错误
+var (
+	ErrUnsupportedMedia = errors.New("unsupported media type, only jpeg/png/gif images and mp4 videos are allowed")
+	ErrMediaTooLarge    = errors.New("media file is too large")
+	ErrMediaNotFound    = errors.New("media not found")
+	ErrTooManyMedia     = fmt.Errorf("a post can have at most %d attachments", MaxPostMedia)
+	ErrMediaUnavailable = errors.New("media is already attached to a post or failed processing")
+)
+
+// mediaFormat 按文件内容识别出的格式
+type mediaFormat struct {
+	Type     string
+	Ext      string
+	MaxBytes int64
+}
+
+// supportedMediaFormats 支持的格式，按 http.DetectContentType 的结果匹配，不信任客户端声明的类型
+var supportedMediaFormats = map[string]mediaFormat{
+	"image/jpeg": {models.MediaTypeImage, ".jpg", MaxImageBytes},
+	"image/png":  {models.MediaTypeImage, ".png", MaxImageBytes},
+	"image/gif":  {models.MediaTypeImage, ".gif", MaxImageBytes},
+	"video/mp4":  {models.MediaTypeVideo, ".mp4", MaxVideoBytes},
+}
+
+type mediaService struct {
+	storage storage.Storage
+	queue   chan uuid.UUID
+}
+
+// newMediaService 创建媒体服务
+func newMediaService(store storage.Storage) *mediaService {
+	return &mediaService{
+		storage: store,
+		queue:   make(chan uuid.UUID, mediaQueueSize),
+	}
+}
+
+// Start 启动后台处理协程，并重新排队上次退出时未处理完的媒体
+func (s *mediaService) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range s.queue {
+				s.Process(id)
+			}
+		}()
+	}
+
+	var pending []uuid.UUID
+	database.DB.Model(&models.Media{}).Where("status = ?", models.MediaStatusProcessing).Pluck("id", &pending)
+	for _, id := range pending {
+		s.enqueue(id)
+	}
+}
+
+// enqueue 加入处理队列，队列满时异步等待，不阻塞上传请求
+func (s *mediaService) enqueue(id uuid.UUID) {
+	select {
+	case s.queue <- id:
+	default:
+		go func() { s.queue <- id }()
+	}
+}
+
+// Storage 返回媒体文件存储
+func (s *mediaService) Storage() storage.Storage {
+	return s.storage
+}
+
+// URL 返回文件访问地址
+func (s *mediaService) URL(key string) string {
+	return s.storage.URL(key)
+}
+
+// sizeLimitReader 超过大小限制时返回 ErrMediaTooLarge
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrMediaTooLarge
+	}
+	return n, err
+}
+
+// Upload 保存上传的文件并加入后台处理队列，返回处理中状态的媒体记录
+func (s *mediaService) Upload(ctx context.Context, userID uuid.UUID, r io.Reader, altText string) (*models.Media, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrUnsupportedMedia
+		}
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	format, ok := supportedMediaFormats[contentType]
+	if !ok {
+		return nil, ErrUnsupportedMedia
+	}
+
+	media := &models.Media{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Type:        format.Type,
+		Status:      models.MediaStatusProcessing,
+		ContentType: contentType,
+		AltText:     altText,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	media.StorageKey = "media/" + media.ID.String() + format.Ext
+
+	body := &sizeLimitReader{r: io.MultiReader(bytes.NewReader(head), r), remaining: format.MaxBytes}
+	if err := s.storage.Put(ctx, media.StorageKey, body, contentType); err != nil {
+		if errors.Is(err, ErrMediaTooLarge) {
+			return nil, ErrMediaTooLarge
+		}
+		return nil, fmt.Errorf("failed to store media: %w", err)
+	}
+	media.Size = body.read
+
+	if err := database.DB.Create(media).Error; err != nil {
+		s.storage.Delete(ctx, media.StorageKey)
+		return nil, fmt.Errorf("failed to create media: %w", err)
+	}
+
+	s.enqueue(media.ID)
+	return media, nil
+}
+
+// GetMedia 获取自己上传的媒体
+func (s *mediaService) GetMedia(userID, mediaID uuid.UUID) (*models.Media, error) {
+	var media models.Media
+	if err := database.DB.Where("id = ? AND user_id = ?", mediaID, userID).First(&media).Error; err != nil {
+		return nil, ErrMediaNotFound
+	}
+	return &media, nil
+}
+
+// UpdateAltText 更新替代文本，附加到帖子后仍可修改
+func (s *mediaService) UpdateAltText(userID, mediaID uuid.UUID, altText string) (*models.Media, error) {
+	media, err := s.GetMedia(userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	media.AltText = altText
+	media.UpdatedAt = time.Now()
+	if err := database.DB.Model(media).Select("alt_text", "updated_at").Updates(media).Error; err != nil {
+		return nil, fmt.Errorf("failed to update media: %w", err)
+	}
+	return media, nil
+}
+
+// Process 处理媒体：读取尺寸，图片生成缩略图和blurhash，视频校验时长
+func (s *mediaService) Process(mediaID uuid.UUID) {
+	var media models.Media
+	if err := database.DB.Where("id = ?", mediaID).First(&media).Error; err != nil || media.Status != models.MediaStatusProcessing {
+		return
+	}
+
+	var err error
+	if media.Type == models.MediaTypeVideo {
+		err = s.processVideo(&media)
+	} else {
+		err = s.processImage(&media)
+	}
+
+	media.Status = models.MediaStatusReady
+	if err != nil {
+		log.Printf("Failed to process media %s: %v", media.ID, err)
+		message := err.Error()
+		media.Status = models.MediaStatusFailed
+		media.Error = &message
+	}
+	media.UpdatedAt = time.Now()
+
+	if err := database.DB.Model(&media).
+		Select("status", "width", "height", "duration_ms", "thumbnail_key", "blurhash", "error", "updated_at").
+		Updates(&media).Error; err != nil {
+		log.Printf("Failed to save processed media %s: %v", media.ID, err)
+	}
+}
+
+// readOriginal 读取原文件
+func (s *mediaService) readOriginal(media *models.Media) ([]byte, error) {
+	file, err := s.storage.Open(context.Background(), media.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media: %w", err)
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// processImage 处理图片
+func (s *mediaService) processImage(media *models.Media) error {
+	data, err := s.readOriginal(media)
+	if err != nil {
+		return err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid image: %w", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return fmt.Errorf("image dimensions are too large")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid image: %w", err)
+	}
+	media.Width, media.Height = config.Width, config.Height
+
+	thumbnail := utils.ResizeToFit(img, thumbnailMaxSize, thumbnailMaxSize)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80}); err != nil {
+		return fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	thumbnailKey := "media/" + media.ID.String() + "_thumb.jpg"
+	if err := s.storage.Put(context.Background(), thumbnailKey, &buf, "image/jpeg"); err != nil {
+		return err
+	}
+	media.ThumbnailKey = &thumbnailKey
+
+	hash, err := utils.EncodeBlurhash(utils.ResizeToFit(thumbnail, blurhashSample, blurhashSample), 4, 3)
+	if err != nil {
+		return fmt.Errorf("failed to compute blurhash: %w", err)
+	}
+	media.Blurhash = &hash
+	return nil
+}
+
+// processVideo 处理视频，只读取文件头；纯Go无法解码视频帧，因此不生成缩略图
+func (s *mediaService) processVideo(media *models.Media) error {
+	data, err := s.readOriginal(media)
+	if err != nil {
+		return err
+	}
+
+	info, err := utils.ProbeMP4(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if info.Width == 0 || info.Height == 0 {
+		return fmt.Errorf("video track not found")
+	}
+	if info.Duration > MaxVideoDuration {
+		return fmt.Errorf("video is longer than %s", MaxVideoDuration)
+	}
+
+	media.Width, media.Height = info.Width, info.Height
+	media.DurationMs = info.Duration.Milliseconds()
+	return nil
+}
+
+// attachToPost 在发帖事务中将媒体按顺序附加到帖子
+func (s *mediaService) attachToPost(tx *gorm.DB, userID, postID uuid.UUID, mediaIDs []uuid.UUID) error {
+	for position, mediaID := range mediaIDs {
+		result := tx.Model(&models.Media{}).
+			Where("id = ? AND user_id = ? AND post_id IS NULL AND status <> ?", mediaID, userID, models.MediaStatusFailed).
+			Updates(map[string]any{"post_id": postID, "position": position})
+		if result.Error != nil {
+			return fmt.Errorf("failed to attach media: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			tx.Model(&models.Media{}).Where("id = ? AND user_id = ?", mediaID, userID).Count(&count)
+			if count == 0 {
+				return ErrMediaNotFound
+			}
+			return ErrMediaUnavailable
+		}
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yolo/database"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 封禁时长
+const (
+	DefaultSuspension = 7 * 24 * time.Hour
+	MaxSuspension     = 365 * 24 * time.Hour
+)
+
+// 举报与审核相关错误
+var (
+	ErrNotModerator            = errors.New("moderator role required")
+	ErrInvalidReportReason     = errors.New("invalid report reason, details are required for other")
+	ErrReportTargetNotFound    = errors.New("report target not found")
+	ErrCannotReportSelf        = errors.New("cannot report yourself")
+	ErrAlreadyReported         = errors.New("you already have an open report for this target")
+	ErrReportNotFound          = errors.New("report not found")
+	ErrReportClaimed           = errors.New("report is claimed by another moderator")
+	ErrReportClosed            = errors.New("report is already closed")
+	ErrInvalidModerationAction = errors.New("moderation action does not apply to this target")
+	ErrUserSuspended           = errors.New("account is suspended")
+)
+
+// openReportStatuses 尚未处理完的举报状态
+var openReportStatuses = []string{models.ReportStatusOpen, models.ReportStatusClaimed}
+
+type moderationService struct{}
+
+// checkNotSuspended 封禁期间的用户不能发帖或转发
+func checkNotSuspended(userID uuid.UUID) error {
+	var count int64
+	database.DB.Model(&models.User{}).
+		Where("id = ? AND suspended_until > ?", userID, time.Now()).
+		Count(&count)
+	if count > 0 {
+		return ErrUserSuspended
+	}
+	return nil
+}
+
+// requireModerator 检查用户是否为审核员
+func requireModerator(userID uuid.UUID) error {
+	var count int64
+	database.DB.Model(&models.User{}).
+		Where("id = ? AND role = ?", userID, models.UserRoleModerator).
+		Count(&count)
+	if count == 0 {
+		return ErrNotModerator
+	}
+	return nil
+}
+
+// isValidReportReason 检查举报原因是否受支持
+func isValidReportReason(reason string) bool {
+	for _, r := range models.ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveReportTarget 返回被举报对象所属的用户；举报人看不到的帖子视为不存在
+func resolveReportTarget(reporterID uuid.UUID, targetType string, targetID uuid.UUID) (uuid.UUID, error) {
+	switch targetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := database.DB.Scopes(visiblePostsScope(reporterID)).Where("id = ?", targetID).First(&post).Error; err != nil {
+			return uuid.Nil, ErrReportTargetNotFound
+		}
+		return post.UserID, nil
+	case models.ReportTargetUser:
+		var user models.User
+		if err := database.DB.Select("id").Where("id = ?", targetID).First(&user).Error; err != nil {
+			return uuid.Nil, ErrReportTargetNotFound
+		}
+		return user.ID, nil
+	case models.ReportTargetStock:
+		var stock models.Stock
+		if err := database.DB.Where("id = ?", targetID).First(&stock).Error; err != nil {
+			return uuid.Nil, ErrReportTargetNotFound
+		}
+		return stock.UserID, nil
+	}
+	return uuid.Nil, ErrReportTargetNotFound
+}
+
+// CreateReportOptions 举报参数
+type CreateReportOptions struct {
+	TargetType string
+	TargetID   uuid.UUID
+	Reason     string
+	Details    string
+}
+
+// CreateReport 举报帖子、用户或股票，同一举报人对同一对象只能有一个未处理的举报
+func (s *moderationService) CreateReport(reporterID uuid.UUID, opts CreateReportOptions) (*models.Report, error) {
+	details := strings.TrimSpace(opts.Details)
+	if !isValidReportReason(opts.Reason) || (opts.Reason == models.ReportReasonOther && details == "") {
+		return nil, ErrInvalidReportReason
+	}
+
+	targetUserID, err := resolveReportTarget(reporterID, opts.TargetType, opts.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if targetUserID == reporterID {
+		return nil, ErrCannotReportSelf
+	}
+
+	var count int64
+	database.DB.Model(&models.Report{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status IN ?",
+			reporterID, opts.TargetType, opts.TargetID, openReportStatuses).
+		Count(&count)
+	if count > 0 {
+		return nil, ErrAlreadyReported
+	}
+
+	report := &models.Report{
+		ID:           uuid.New(),
+		ReporterID:   &reporterID,
+		TargetType:   opts.TargetType,
+		TargetID:     opts.TargetID,
+		TargetUserID: targetUserID,
+		Reason:       opts.Reason,
+		Details:      details,
+		Status:       models.ReportStatusOpen,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := database.DB.Create(report).Error; err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+	return report, nil
+}
+
+// holdForReview 在发帖事务中为被自动过滤拦截的帖子创建举报，没有举报人
+func (s *moderationService) holdForReview(tx *gorm.DB, post *models.Post, verdict FilterVerdict) error {
+	report := &models.Report{
+		ID:           uuid.New(),
+		TargetType:   models.ReportTargetPost,
+		TargetID:     post.ID,
+		TargetUserID: post.UserID,
+		Reason:       models.ReportReasonSpam,
+		Details:      fmt.Sprintf("held by %s filter: %s", verdict.Rule, verdict.Message),
+		Status:       models.ReportStatusOpen,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := tx.Create(report).Error; err != nil {
+		return fmt.Errorf("failed to hold post for review: %w", err)
+	}
+	return nil
+}
+
+// GetReports 审核队列：未处理的举报按时间先后排列，已关闭的按最近排列
+func (s *moderationService) GetReports(moderatorID uuid.UUID, status string, page, limit int) ([]models.Report, int64, error) {
+	if err := requireModerator(moderatorID); err != nil {
+		return nil, 0, err
+	}
+
+	var reports []models.Report
+	var total int64
+
+	query := database.DB.Model(&models.Report{}).Where("status = ?", status)
+	query.Count(&total)
+
+	order := "created_at ASC"
+	if status == models.ReportStatusResolved || status == models.ReportStatusDismissed {
+		order = "closed_at DESC"
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Reporter").Preload("TargetUser").
+		Order(order).
+		Offset(offset).
+		Limit(limit).
+		Find(&reports).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get reports: %w", err)
+	}
+
+	return reports, total, nil
+}
+
+// getReport 获取举报及关联用户
+func getReport(db *gorm.DB, reportID uuid.UUID) (*models.Report, error) {
+	var report models.Report
+	if err := db.Preload("Reporter").Preload("TargetUser").Where("id = ?", reportID).First(&report).Error; err != nil {
+		return nil, ErrReportNotFound
+	}
+	return &report, nil
+}
+
+// claimError 返回无法认领或处理举报的原因
+func claimError(report *models.Report, moderatorID uuid.UUID) error {
+	switch {
+	case report.Status == models.ReportStatusResolved || report.Status == models.ReportStatusDismissed:
+		return ErrReportClosed
+	case report.ModeratorID != nil && *report.ModeratorID != moderatorID:
+		return ErrReportClaimed
+	}
+	return nil
+}
+
+// ClaimReport 认领举报，避免多个审核员重复处理；重复认领自己的举报直接返回
+func (s *moderationService) ClaimReport(moderatorID, reportID uuid.UUID) (*models.Report, error) {
+	if err := requireModerator(moderatorID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := database.DB.Model(&models.Report{}).
+		Where("id = ? AND status = ?", reportID, models.ReportStatusOpen).
+		Updates(map[string]any{
+			"status":       models.ReportStatusClaimed,
+			"moderator_id": moderatorID,
+			"claimed_at":   now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim report: %w", result.Error)
+	}
+
+	report, err := getReport(database.DB, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		if err := claimError(report, moderatorID); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// ResolveOptions 处理举报的参数
+type ResolveOptions struct {
+	Action   string        // hide_post/suspend_user/warn
+	Note     string        // 给被处理用户的说明
+	Duration time.Duration // 封禁时长，为0时使用默认值
+}
+
+// ResolveReport 处理举报：执行处理动作并记录，同一对象的其他未认领举报一并关闭
+func (s *moderationService) ResolveReport(moderatorID, reportID uuid.UUID, opts ResolveOptions) (*models.ModerationAction, error) {
+	if opts.Action == models.ModerationActionDismiss {
+		return nil, ErrInvalidModerationAction
+	}
+	return s.decide(moderatorID, reportID, opts)
+}
+
+// DismissReport 驳回举报，不做处理但同样记录
+func (s *moderationService) DismissReport(moderatorID, reportID uuid.UUID, note string) (*models.ModerationAction, error) {
+	return s.decide(moderatorID, reportID, ResolveOptions{Action: models.ModerationActionDismiss, Note: note})
+}
+
+// decide 在一个事务中执行处理动作、记录处理结果并关闭举报
+// 未认领的举报可以直接处理，已被其他审核员认领的返回 ErrReportClaimed
+// 被自动过滤拦截的帖子在驳回后发布，其他处理方式下保持不发布
+func (s *moderationService) decide(moderatorID, reportID uuid.UUID, opts ResolveOptions) (*models.ModerationAction, error) {
+	if err := requireModerator(moderatorID); err != nil {
+		return nil, err
+	}
+
+	var action *models.ModerationAction
+	var hiddenPost *models.Post
+	var heldPostID *uuid.UUID
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		report, err := getReport(tx, reportID)
+		if err != nil {
+			return err
+		}
+		if err := claimError(report, moderatorID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		action = &models.ModerationAction{
+			ID:           uuid.New(),
+			ModeratorID:  moderatorID,
+			TargetUserID: report.TargetUserID,
+			TargetType:   report.TargetType,
+			TargetID:     report.TargetID,
+			Action:       opts.Action,
+			Reason:       report.Reason,
+			Note:         strings.TrimSpace(opts.Note),
+			CreatedAt:    now,
+		}
+
+		switch opts.Action {
+		case models.ModerationActionHidePost:
+			if report.TargetType != models.ReportTargetPost {
+				return ErrInvalidModerationAction
+			}
+			var post models.Post
+			if err := tx.Unscoped().Where("id = ?", report.TargetID).First(&post).Error; err != nil {
+				return ErrReportTargetNotFound
+			}
+			if err := tx.Unscoped().Model(&post).Update("hidden_at", now).Error; err != nil {
+				return fmt.Errorf("failed to hide post: %w", err)
+			}
+			hiddenPost = &post
+		case models.ModerationActionSuspendUser:
+			duration := opts.Duration
+			if duration <= 0 {
+				duration = DefaultSuspension
+			}
+			if duration > MaxSuspension {
+				return ErrInvalidModerationAction
+			}
+			until := now.Add(duration)
+			action.ExpiresAt = &until
+			// 已有更长的封禁时不缩短
+			if err := tx.Model(&models.User{}).
+				Where("id = ? AND (suspended_until IS NULL OR suspended_until < ?)", report.TargetUserID, until).
+				Update("suspended_until", until).Error; err != nil {
+				return fmt.Errorf("failed to suspend user: %w", err)
+			}
+		case models.ModerationActionWarn, models.ModerationActionDismiss:
+		default:
+			return ErrInvalidModerationAction
+		}
+
+		if err := tx.Create(action).Error; err != nil {
+			return fmt.Errorf("failed to record moderation action: %w", err)
+		}
+
+		status := models.ReportStatusResolved
+		if opts.Action == models.ModerationActionDismiss {
+			status = models.ReportStatusDismissed
+		}
+		closed := map[string]any{
+			"status":       status,
+			"moderator_id": moderatorID,
+			"action_id":    action.ID,
+			"closed_at":    now,
+			"updated_at":   now,
+		}
+
+		// 带状态条件的更新，防止与其他审核员的认领或处理并发
+		result := tx.Model(&models.Report{}).
+			Where("id = ? AND status IN ? AND (moderator_id IS NULL OR moderator_id = ?)", reportID, openReportStatuses, moderatorID).
+			Updates(closed)
+		if result.Error != nil {
+			return fmt.Errorf("failed to close report: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrReportClaimed
+		}
+
+		if report.TargetType == models.ReportTargetPost && opts.Action == models.ModerationActionDismiss {
+			var count int64
+			tx.Model(&models.Post{}).Where("id = ? AND status = ?", report.TargetID, models.PostStatusHeld).Count(&count)
+			if count > 0 {
+				heldPostID = &report.TargetID
+			}
+		}
+
+		// 处理结果适用于同一对象的其他未认领举报，驳回只针对当前举报
+		if opts.Action != models.ModerationActionDismiss {
+			if err := tx.Model(&models.Report{}).
+				Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, models.ReportStatusOpen).
+				Updates(closed).Error; err != nil {
+				return fmt.Errorf("failed to close duplicate reports: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if hiddenPost != nil {
+		publishPostEvent(EventPostDeleted, hiddenPost)
+	}
+	if heldPostID != nil {
+		if _, err := PostService.publish(database.DB, *heldPostID, []string{models.PostStatusHeld}); err != nil {
+			return nil, err
+		}
+	}
+	s.notifyAction(action)
+	return action, nil
+}
+
+// notifyAction 通知被处理的用户，驳回不通知
+func (s *moderationService) notifyAction(action *models.ModerationAction) {
+	if action.Action == models.ModerationActionDismiss {
+		return
+	}
+
+	data := map[string]any{
+		"actionId":   action.ID.String(),
+		"action":     action.Action,
+		"targetType": action.TargetType,
+		"targetId":   action.TargetID.String(),
+		"reason":     action.Reason,
+	}
+	event := NotificationEvent{
+		UserID: action.TargetUserID,
+		Type:   models.NotificationTypeModeration,
+		Data:   data,
+	}
+	if action.TargetType == models.ReportTargetPost {
+		event.PostID = &action.TargetID
+	}
+	NotificationService.Notify(event)
+}
+
+// GetActionsForUser 获取针对某个用户的全部审核处理记录
+func (s *moderationService) GetActionsForUser(userID uuid.UUID, page, limit int) ([]models.ModerationAction, int64, error) {
+	var actions []models.ModerationAction
+	var total int64
+
+	query := database.DB.Model(&models.ModerationAction{}).Where("target_user_id = ?", userID)
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&actions).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get moderation actions: %w", err)
+	}
+
+	return actions, total, nil
+}
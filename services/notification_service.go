@@ -0,0 +1,230 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"yolo/database"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidNotificationType 不支持的通知类型
+var ErrInvalidNotificationType = errors.New("invalid notification type")
+
+// NotificationEvent 其他服务向通知服务发出的事件
+type NotificationEvent struct {
+	UserID  uuid.UUID  // 接收者
+	ActorID *uuid.UUID // 触发者，为接收者本人时不产生通知
+	Type    string
+	PostID  *uuid.UUID
+	Data    map[string]any
+}
+
+// NotificationDeliverer 通知实时投递函数（例如推送到在线客户端）
+type NotificationDeliverer func(notification *models.Notification)
+
+// NotificationPreferenceSetting 单个通知类型的偏好
+type NotificationPreferenceSetting struct {
+	Type    string `json:"type"`
+	Store   bool   `json:"store"`
+	Deliver bool   `json:"deliver"`
+}
+
+type notificationService struct {
+	mu         sync.RWMutex
+	deliverers []NotificationDeliverer
+}
+
+// isValidNotificationType 检查通知类型是否受支持
+func isValidNotificationType(notificationType string) bool {
+	for _, t := range models.NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterDeliverer 注册实时投递函数
+func (s *notificationService) RegisterDeliverer(deliverer NotificationDeliverer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliverers = append(s.deliverers, deliverer)
+}
+
+// getPreference 获取用户对某类通知的偏好，未设置时默认保存并推送
+func (s *notificationService) getPreference(userID uuid.UUID, notificationType string) NotificationPreferenceSetting {
+	setting := NotificationPreferenceSetting{Type: notificationType, Store: true, Deliver: true}
+
+	var pref models.NotificationPreference
+	err := database.DB.Where("user_id = ? AND type = ?", userID, notificationType).First(&pref).Error
+	if err == nil {
+		setting.Store = pref.Store
+		setting.Deliver = pref.Deliver
+	}
+	return setting
+}
+
+// Emit 发出通知事件，根据接收者的偏好决定是否保存和实时推送
+func (s *notificationService) Emit(event NotificationEvent) (*models.Notification, error) {
+	if !isValidNotificationType(event.Type) {
+		return nil, ErrInvalidNotificationType
+	}
+	if event.ActorID != nil && *event.ActorID == event.UserID {
+		return nil, nil
+	}
+
+	pref := s.getPreference(event.UserID, event.Type)
+	if !pref.Store && !pref.Deliver {
+		return nil, nil
+	}
+
+	notification := &models.Notification{
+		ID:        uuid.New(),
+		UserID:    event.UserID,
+		ActorID:   event.ActorID,
+		Type:      event.Type,
+		PostID:    event.PostID,
+		Data:      event.Data,
+		CreatedAt: time.Now(),
+	}
+
+	if pref.Store {
+		if err := database.DB.Create(notification).Error; err != nil {
+			return nil, fmt.Errorf("failed to create notification: %w", err)
+		}
+	}
+
+	if pref.Deliver {
+		s.mu.RLock()
+		deliverers := s.deliverers
+		s.mu.RUnlock()
+		for _, deliver := range deliverers {
+			deliver(notification)
+		}
+	}
+
+	return notification, nil
+}
+
+// Notify 发出通知事件，失败只记录日志，不影响调用方主流程
+func (s *notificationService) Notify(event NotificationEvent) {
+	if _, err := s.Emit(event); err != nil {
+		log.Printf("Failed to emit %s notification to %s: %v", event.Type, event.UserID, err)
+	}
+}
+
+// GetNotifications 获取用户通知列表
+func (s *notificationService) GetNotifications(userID uuid.UUID, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	// 计算总数
+	query.Count(&total)
+
+	// 分页查询
+	offset := (page - 1) * limit
+	if err := query.Preload("Actor").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+// CountUnread 统计未读通知数量
+func (s *notificationService) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead 将指定通知标记为已读，ids为空时标记全部
+func (s *notificationService) MarkRead(userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	query := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	result := query.Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetPreferences 获取用户所有通知类型的偏好
+func (s *notificationService) GetPreferences(userID uuid.UUID) ([]NotificationPreferenceSetting, error) {
+	var prefs []models.NotificationPreference
+	if err := database.DB.Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	stored := make(map[string]models.NotificationPreference, len(prefs))
+	for _, pref := range prefs {
+		stored[pref.Type] = pref
+	}
+
+	settings := make([]NotificationPreferenceSetting, 0, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		setting := NotificationPreferenceSetting{Type: t, Store: true, Deliver: true}
+		if pref, ok := stored[t]; ok {
+			setting.Store = pref.Store
+			setting.Deliver = pref.Deliver
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// UpdatePreferences 更新用户通知偏好
+func (s *notificationService) UpdatePreferences(userID uuid.UUID, settings []NotificationPreferenceSetting) ([]NotificationPreferenceSetting, error) {
+	for _, setting := range settings {
+		if !isValidNotificationType(setting.Type) {
+			return nil, ErrInvalidNotificationType
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, setting := range settings {
+			pref := models.NotificationPreference{
+				UserID:    userID,
+				Type:      setting.Type,
+				Store:     setting.Store,
+				Deliver:   setting.Deliver,
+				UpdatedAt: time.Now(),
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+				DoUpdates: clause.AssignmentColumns([]string{"store", "deliver", "updated_at"}),
+			}).Create(&pref).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+
+	return s.GetPreferences(userID)
+}
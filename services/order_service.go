@@ -0,0 +1,750 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"yolo/database"
+	"yolo/decimal"
+	"yolo/hub"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 订单簿限制
+const (
+	DefaultOrderBookDepth = 20
+	MaxOrderBookDepth     = 100
+	// orderMatchBatch 撮合时每批读取的对手挂单数
+	orderMatchBatch = 100
+)
+
+// 订单相关错误
+var (
+	ErrInvalidOrderType     = errors.New("type must be limit or market")
+	ErrInvalidTimeInForce   = errors.New("time_in_force must be gtc, ioc or fok, and market orders cannot be gtc")
+	ErrInvalidOrderPrice    = errors.New("limit orders need a positive price")
+	ErrInvalidOrderQuantity = errors.New("quantity must be positive")
+	ErrOrderTooLarge        = errors.New("order value is too large")
+	ErrInvalidOrderStatus   = errors.New("status must be open, filled or cancelled")
+	ErrOrderNotFillable     = errors.New("fill-or-kill order cannot be filled completely")
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrOrderNotOpen         = errors.New("order is no longer open")
+)
+
+// PlaceOrderOptions 下单参数，Quantity 为股数
+type PlaceOrderOptions struct {
+	Side        string          // buy / sell
+	Type        string          // limit / market，默认 limit
+	TimeInForce string          // gtc / ioc / fok，限价单默认 gtc，市价单默认 ioc
+	Price       decimal.Decimal // 限价，市价单忽略
+	Quantity    decimal.Decimal
+}
+
+// OrderResult 下单结果
+type OrderResult struct {
+	Order    models.Order
+	Trades   []models.Trade // 按成交顺序，先订单簿后流动性池
+	NewPrice decimal.Decimal
+}
+
+// OrderBookLevel 订单簿中的一个价位
+type OrderBookLevel struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+	Orders   int64
+}
+
+// OrderBook 订单簿快照，买盘按价格从高到低，卖盘从低到高
+type OrderBook struct {
+	Bids     []OrderBookLevel
+	Asks     []OrderBookLevel
+	MidPrice decimal.Decimal // 流动性池价格
+}
+
+// orderFill 撮合计划中的一笔成交，maker 为空时对手方是流动性池
+type orderFill struct {
+	maker    *models.Order
+	quantity decimal.Decimal
+	price    decimal.Decimal
+	value    decimal.Decimal // 吃单方支付或得到的 YOLO，含手续费
+	fee      decimal.Decimal // 与流动性池成交时的手续费
+}
+
+type orderService struct{}
+
+// bookAccountCode 订单簿冻结账户代码
+func bookAccountCode(stockID uuid.UUID) string {
+	return models.LedgerAccountBook + ":" + stockID.String()
+}
+
+// openBookAccount 锁定股票的订单簿冻结账户，不存在时创建
+func openBookAccount(tx *gorm.DB, stockID uuid.UUID) (*models.LedgerAccount, error) {
+	return openAccount(tx, bookAccountCode(stockID), models.LedgerAccountBook, &stockID, decimal.Zero)
+}
+
+// normalizeOrderOptions 校验下单参数并补全默认值
+func normalizeOrderOptions(opts PlaceOrderOptions) (PlaceOrderOptions, error) {
+	side, err := normalizeTradeType(opts.Side)
+	if err != nil {
+		return opts, err
+	}
+	opts.Side = side
+
+	opts.Type = strings.ToLower(strings.TrimSpace(opts.Type))
+	opts.TimeInForce = strings.ToLower(strings.TrimSpace(opts.TimeInForce))
+	switch opts.Type {
+	case "", models.OrderTypeLimit:
+		opts.Type = models.OrderTypeLimit
+		if opts.TimeInForce == "" {
+			opts.TimeInForce = models.TimeInForceGTC
+		}
+		if !opts.Price.IsPositive() {
+			return opts, ErrInvalidOrderPrice
+		}
+	case models.OrderTypeMarket:
+		if opts.TimeInForce == "" {
+			opts.TimeInForce = models.TimeInForceIOC
+		}
+		if opts.TimeInForce == models.TimeInForceGTC {
+			return opts, ErrInvalidTimeInForce
+		}
+		opts.Price = decimal.Zero
+	default:
+		return opts, ErrInvalidOrderType
+	}
+	switch opts.TimeInForce {
+	case models.TimeInForceGTC, models.TimeInForceIOC, models.TimeInForceFOK:
+	default:
+		return opts, ErrInvalidTimeInForce
+	}
+
+	if !opts.Quantity.IsPositive() {
+		return opts, ErrInvalidOrderQuantity
+	}
+	if opts.Type == models.OrderTypeLimit {
+		// 挂单按限价向上舍入冻结，先确认金额不会溢出
+		if _, err := opts.Price.TryMulDiv(opts.Quantity, decimal.FromInt(1), decimal.RoundUp); err != nil {
+			return opts, ErrOrderTooLarge
+		}
+		if opts.Price.Mul(opts.Quantity, decimal.RoundDown).IsZero() {
+			return opts, ErrTradeTooSmall
+		}
+	}
+	return opts, nil
+}
+
+// ammFillable 在限价内可以与流动性池成交的股数，不超过 remaining；limit 为 0 表示市价。
+// 限价按含手续费的边际价格计算：买入后 x'·(1+fee)/y' 不高于限价、卖出后 x'·(1-fee)/y' 不低于限价，
+// 由 x'·y' = k 得 y' = √(k·(1±fee) / limit)
+func ammFillable(pool *models.LiquidityPool, side string, limit, remaining, feeRate decimal.Decimal) decimal.Decimal {
+	x, y := pool.YoloReserve, pool.StockReserve
+	if !x.IsPositive() || !y.IsPositive() || !remaining.IsPositive() {
+		return decimal.Zero
+	}
+	if limit.IsZero() {
+		return remaining
+	}
+	one := decimal.FromInt(1)
+	// k/limit 超出范围说明限价极低：买入无法成交，卖出可以全部成交
+	if side == models.TradeTypeBuy {
+		squared, err := x.Mul(one.Add(feeRate), decimal.RoundCeiling).TryMulDiv(y, limit, decimal.RoundCeiling)
+		if err != nil {
+			return decimal.Zero
+		}
+		reserve := squared.Sqrt(decimal.RoundCeiling)
+		if !reserve.LessThan(y) {
+			return decimal.Zero
+		}
+		return decimal.Min(remaining, y.Sub(reserve))
+	}
+	squared, err := x.Mul(one.Sub(feeRate), decimal.RoundFloor).TryMulDiv(y, limit, decimal.RoundFloor)
+	if err != nil {
+		return remaining
+	}
+	reserve := squared.Sqrt(decimal.RoundFloor)
+	if !reserve.GreaterThan(y) {
+		return decimal.Zero
+	}
+	return decimal.Min(remaining, reserve.Sub(y))
+}
+
+// ammAffordable 用 budget 最多能从池中买入的股数，总小于池中储备 y。
+// 支付 c·(1+fee)，成本和手续费两次向上舍入最多多付一个最小单位，因此要求 c ≤ (budget - 1)/(1+fee)，
+// 由 c = x·s/(y-s) 得 s ≤ y·c/(x+c)
+func ammAffordable(pool *models.LiquidityPool, budget, feeRate decimal.Decimal) decimal.Decimal {
+	spendable := budget.Sub(decimal.FromUnits(1)).Div(decimal.FromInt(1).Add(feeRate), decimal.RoundDown)
+	if !spendable.IsPositive() {
+		return decimal.Zero
+	}
+	return pool.StockReserve.MulDiv(spendable, pool.YoloReserve.Add(spendable), decimal.RoundDown)
+}
+
+// planAMMFill 计算与流动性池的成交：买入按股数反推需支付的 YOLO 并向上舍入，卖出沿用 quote 向下舍入，
+// 手续费与直接和池子交易相同。买入不超过 budget 能支付的数量；舍入使含手续费的成交均价超出限价时按比例减少股数
+func planAMMFill(pool *models.LiquidityPool, side string, limit, remaining, feeRate, budget decimal.Decimal) (*orderFill, error) {
+	if !pool.YoloReserve.IsPositive() || !pool.StockReserve.IsPositive() {
+		return nil, nil
+	}
+	shares := ammFillable(pool, side, limit, remaining, feeRate)
+	if !shares.IsPositive() {
+		return nil, nil
+	}
+	if side == models.TradeTypeBuy {
+		shares = decimal.Min(shares, ammAffordable(pool, budget, feeRate))
+		if !shares.IsPositive() {
+			return nil, ErrInsufficientBalance
+		}
+	}
+
+	for shares.IsPositive() {
+		fill := &orderFill{quantity: shares}
+		var next decimal.Decimal
+		if side == models.TradeTypeBuy {
+			cost, err := pool.YoloReserve.TryMulDiv(shares, pool.StockReserve.Sub(shares), decimal.RoundUp)
+			if err != nil {
+				return nil, ErrInsufficientLiquidity
+			}
+			fill.fee = cost.Mul(feeRate, decimal.RoundUp)
+			fill.value = cost.Add(fill.fee)
+			allowed := limit.Mul(shares, decimal.RoundUp)
+			if limit.IsZero() || !fill.value.GreaterThan(allowed) {
+				fill.price = fill.value.Div(shares, decimal.RoundHalfEven)
+				return fill, nil
+			}
+			next = shares.MulDiv(allowed, fill.value, decimal.RoundDown)
+		} else {
+			q, err := quote(pool, side, shares, feeRate)
+			if err != nil {
+				return nil, nil
+			}
+			fill.value, fill.fee = q.AmountOut, q.Fee
+			required := limit.Mul(shares, decimal.RoundDown)
+			if !fill.value.LessThan(required) {
+				fill.price = fill.value.Div(shares, decimal.RoundHalfEven)
+				return fill, nil
+			}
+			next = shares.MulDiv(fill.value, required, decimal.RoundDown)
+		}
+		shares = decimal.Min(next, shares.Sub(decimal.FromUnits(1)))
+	}
+	return nil, nil
+}
+
+// lockOrderAccounts 按账户代码顺序锁定成交涉及的账户，多方成交时不会互相死锁
+func lockOrderAccounts(tx *gorm.DB, pool *models.LiquidityPool, userIDs []uuid.UUID, needBook, needPool, needFees bool) (map[string]*models.LedgerAccount, error) {
+	openers := make(map[string]func() (*models.LedgerAccount, error), len(userIDs)+3)
+	for _, id := range userIDs {
+		openers[userAccountCode(id)] = func() (*models.LedgerAccount, error) { return openUserAccount(tx, id) }
+	}
+	if needBook {
+		openers[bookAccountCode(pool.StockID)] = func() (*models.LedgerAccount, error) { return openBookAccount(tx, pool.StockID) }
+	}
+	if needPool {
+		openers[poolAccountCode(pool.StockID)] = func() (*models.LedgerAccount, error) { return openPoolAccount(tx, pool) }
+	}
+	if needFees {
+		openers[models.LedgerFeesCode] = func() (*models.LedgerAccount, error) { return openFeesAccount(tx) }
+	}
+
+	codes := make([]string, 0, len(openers))
+	for code := range openers {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	accounts := make(map[string]*models.LedgerAccount, len(codes))
+	for _, code := range codes {
+		account, err := openers[code]()
+		if err != nil {
+			return nil, err
+		}
+		accounts[code] = account
+	}
+	return accounts, nil
+}
+
+// lockOrderHoldings 按用户ID顺序锁定撮合涉及的持仓
+func lockOrderHoldings(tx *gorm.DB, stockID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]*models.UserHolding, error) {
+	holdings := make(map[uuid.UUID]*models.UserHolding, len(userIDs))
+	for _, id := range userIDs {
+		holding, err := lockHolding(tx, id, stockID)
+		if err != nil {
+			return nil, err
+		}
+		holdings[id] = holding
+	}
+	return holdings, nil
+}
+
+// releaseOrder 撤销挂单：买单冻结的 YOLO 退回用户，卖单解冻股份
+func releaseOrder(tx *gorm.DB, order *models.Order, book, account *models.LedgerAccount, holding *models.UserHolding) error {
+	if order.Side == models.TradeTypeBuy && order.Reserved.IsPositive() {
+		entry := &models.JournalEntry{
+			Type:          models.JournalTypeTrade,
+			ReferenceType: "order",
+			ReferenceID:   &order.ID,
+			Memo:          "release buy order",
+		}
+		if err := postEntry(tx, entry,
+			ledgerLeg{book.ID, order.Reserved.Neg()},
+			ledgerLeg{account.ID, order.Reserved}); err != nil {
+			return err
+		}
+	}
+	if order.Side == models.TradeTypeSell {
+		if err := adjustHolding(tx, holding, decimal.Zero, order.Remaining().Neg()); err != nil {
+			return err
+		}
+	}
+	order.Reserved = decimal.Zero
+	order.Status = models.OrderStatusCancelled
+	return tx.Model(order).Updates(map[string]any{
+		"reserved": order.Reserved,
+		"status":   order.Status,
+	}).Error
+}
+
+// isOrderRejection 是否为订单本身被拒绝的错误（而不是数据库等临时故障）
+func isOrderRejection(err error) bool {
+	return errors.Is(err, ErrPoolNotFound) || errors.Is(err, ErrOrderNotFillable) ||
+		errors.Is(err, ErrInsufficientBalance) || errors.Is(err, ErrInsufficientShares) ||
+		errors.Is(err, ErrInsufficientLiquidity)
+}
+
+// PlaceOrder 下单并立即撮合：先按价格优先、时间优先与订单簿中的挂单成交，成交价为挂单价；
+// 剩余部分在限价内与流动性池成交，仍未成交的部分 GTC 挂单、IOC 撤销，FOK 不能全部成交时整单拒绝。
+// 撮合到自己的挂单时撤销该挂单而不成交。同一股票的撮合在池子行锁下串行，结果只取决于下单顺序
+func (s *orderService) PlaceOrder(userID uuid.UUID, symbol string, opts PlaceOrderOptions) (*OrderResult, error) {
+	opts, err := normalizeOrderOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := StockService.GetStockBySymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *OrderResult
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result, err = placeOrder(tx, stock, userID, opts)
+		return err
+	})
+	if err != nil {
+		if isOrderRejection(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+	publishOrderTrades(stock, result)
+	return result, nil
+}
+
+// publishOrderTrades 订单有成交时推送价格变动，并重新检查该股票的条件单
+func publishOrderTrades(stock *models.Stock, result *OrderResult) {
+	if len(result.Trades) == 0 {
+		return
+	}
+	last := result.Trades[len(result.Trades)-1]
+	publish(hub.StockChannel(stock.Symbol), EventStockPrice, map[string]any{
+		"symbol":    stock.Symbol,
+		"price":     result.NewPrice,
+		"lastPrice": last.Price,
+		"tradeId":   last.ID.String(),
+	})
+	notifyPriceUpdate(stock.ID)
+}
+
+// placeOrder 在事务中撮合一笔已校验的订单，调用方负责提交后推送
+func placeOrder(tx *gorm.DB, stock *models.Stock, userID uuid.UUID, opts PlaceOrderOptions) (*OrderResult, error) {
+	result := &OrderResult{}
+	pool, err := lockPool(tx, stock.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sequence int64
+	if err := tx.Model(&models.Order{}).Where("stock_id = ?", stock.ID).
+		Select("COALESCE(MAX(sequence), 0)").Row().Scan(&sequence); err != nil {
+		return nil, err
+	}
+	order := &result.Order
+	*order = models.Order{
+		ID:          uuid.New(),
+		StockID:     stock.ID,
+		UserID:      userID,
+		Side:        opts.Side,
+		Type:        opts.Type,
+		TimeInForce: opts.TimeInForce,
+		Price:       opts.Price,
+		Quantity:    opts.Quantity,
+		Sequence:    sequence + 1,
+		Status:      models.OrderStatusOpen,
+	}
+
+	// 撮合计划：只读取对手挂单，确定成交对象后再统一加锁执行
+	makerSide, priceOrder := models.TradeTypeSell, "price ASC"
+	if opts.Side == models.TradeTypeSell {
+		makerSide, priceOrder = models.TradeTypeBuy, "price DESC"
+	}
+	var fills []orderFill
+	var selfMatched []*models.Order
+	remaining := opts.Quantity
+	for offset := 0; remaining.IsPositive(); offset += orderMatchBatch {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("stock_id = ? AND side = ? AND status = ?", stock.ID, makerSide, models.OrderStatusOpen)
+		if opts.Type == models.OrderTypeLimit {
+			if opts.Side == models.TradeTypeBuy {
+				query = query.Where("price <= ?", opts.Price)
+			} else {
+				query = query.Where("price >= ?", opts.Price)
+			}
+		}
+		var makers []models.Order
+		if err := query.Order(priceOrder).Order("sequence ASC").Offset(offset).Limit(orderMatchBatch).Find(&makers).Error; err != nil {
+			return nil, err
+		}
+		for i := range makers {
+			maker := &makers[i]
+			if !remaining.IsPositive() {
+				break
+			}
+			if maker.UserID == userID {
+				selfMatched = append(selfMatched, maker)
+				continue
+			}
+			quantity := decimal.Min(remaining, maker.Remaining())
+			fills = append(fills, orderFill{
+				maker:    maker,
+				quantity: quantity,
+				price:    maker.Price,
+				value:    maker.Price.Mul(quantity, decimal.RoundDown),
+			})
+			remaining = remaining.Sub(quantity)
+		}
+		if len(makers) < orderMatchBatch {
+			break
+		}
+	}
+	var budget decimal.Decimal
+	if opts.Side == models.TradeTypeBuy {
+		if budget, err = userBalance(tx, userID); err != nil {
+			return nil, err
+		}
+		for _, fill := range fills {
+			budget = budget.Sub(fill.value)
+		}
+	}
+	ammFill, err := planAMMFill(pool, opts.Side, opts.Price, remaining, tradeFeeRate(), budget)
+	if err != nil {
+		return nil, err
+	}
+	if ammFill != nil {
+		remaining = remaining.Sub(ammFill.quantity)
+	}
+	if opts.TimeInForce == models.TimeInForceFOK && remaining.IsPositive() {
+		return nil, ErrOrderNotFillable
+	}
+	rests := opts.TimeInForce == models.TimeInForceGTC && remaining.IsPositive()
+
+	// 锁定账户和持仓
+	users := map[uuid.UUID]bool{userID: true}
+	needBook := rests && opts.Side == models.TradeTypeBuy
+	for _, maker := range selfMatched {
+		needBook = needBook || maker.Side == models.TradeTypeBuy
+	}
+	for _, fill := range fills {
+		users[fill.maker.UserID] = true
+		needBook = needBook || fill.maker.Side == models.TradeTypeBuy
+	}
+	userIDs := make([]uuid.UUID, 0, len(users))
+	for id := range users {
+		userIDs = append(userIDs, id)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i].String() < userIDs[j].String() })
+	accounts, err := lockOrderAccounts(tx, pool, userIDs, needBook, ammFill != nil, ammFill != nil && ammFill.fee.IsPositive())
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := lockOrderHoldings(tx, stock.ID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	book := accounts[bookAccountCode(stock.ID)]
+	taker := accounts[userAccountCode(userID)]
+	if opts.Side == models.TradeTypeSell {
+		if holding := holdings[userID]; holding.Quantity.Sub(holding.Locked).LessThan(opts.Quantity) {
+			return nil, ErrInsufficientShares
+		}
+	}
+
+	for _, maker := range selfMatched {
+		if err := releaseOrder(tx, maker, book, taker, holdings[userID]); err != nil {
+			return nil, err
+		}
+	}
+
+	// 与挂单成交
+	for _, fill := range fills {
+		maker := fill.maker
+		trade := models.Trade{
+			ID:         uuid.New(),
+			StockID:    stock.ID,
+			Type:       opts.Side,
+			Amount:     fill.quantity,
+			Price:      fill.price,
+			TotalValue: fill.value,
+			Status:     models.TradeStatusCompleted,
+		}
+		makerAccount := accounts[userAccountCode(maker.UserID)]
+		var legs []ledgerLeg
+		if opts.Side == models.TradeTypeBuy {
+			trade.BuyerID, trade.SellerID = &userID, &maker.UserID
+			trade.BuyOrderID, trade.SellOrderID = &order.ID, &maker.ID
+			legs = []ledgerLeg{{taker.ID, fill.value.Neg()}, {makerAccount.ID, fill.value}}
+			if err := adjustHolding(tx, holdings[maker.UserID], fill.quantity.Neg(), fill.quantity.Neg()); err != nil {
+				return nil, err
+			}
+			if err := adjustHolding(tx, holdings[userID], fill.quantity, decimal.Zero); err != nil {
+				return nil, err
+			}
+		} else {
+			trade.BuyerID, trade.SellerID = &maker.UserID, &userID
+			trade.BuyOrderID, trade.SellOrderID = &maker.ID, &order.ID
+			// 买单按限价向上舍入冻结，成交后重新计算剩余部分的冻结额，多出的零头退回
+			reserve := maker.Price.Mul(maker.Remaining().Sub(fill.quantity), decimal.RoundUp)
+			refund := maker.Reserved.Sub(fill.value).Sub(reserve)
+			legs = []ledgerLeg{{book.ID, fill.value.Add(refund).Neg()}, {taker.ID, fill.value}}
+			if refund.IsPositive() {
+				legs = append(legs, ledgerLeg{makerAccount.ID, refund})
+			}
+			maker.Reserved = reserve
+			if err := adjustHolding(tx, holdings[userID], fill.quantity.Neg(), decimal.Zero); err != nil {
+				return nil, err
+			}
+			if err := adjustHolding(tx, holdings[maker.UserID], fill.quantity, decimal.Zero); err != nil {
+				return nil, err
+			}
+		}
+		entry := &models.JournalEntry{
+			Type:          models.JournalTypeTrade,
+			ReferenceType: "trade",
+			ReferenceID:   &trade.ID,
+			Memo:          opts.Side + " " + stock.Symbol,
+		}
+		if err := postEntry(tx, entry, legs...); err != nil {
+			return nil, err
+		}
+		trade.TransactionID = entry.ID.String()
+		if err := tx.Create(&trade).Error; err != nil {
+			return nil, err
+		}
+		result.Trades = append(result.Trades, trade)
+
+		maker.Filled = maker.Filled.Add(fill.quantity)
+		if !maker.Remaining().IsPositive() {
+			maker.Status = models.OrderStatusFilled
+		}
+		if err := tx.Model(maker).Updates(map[string]any{
+			"filled":   maker.Filled,
+			"reserved": maker.Reserved,
+			"status":   maker.Status,
+		}).Error; err != nil {
+			return nil, err
+		}
+		order.Filled = order.Filled.Add(fill.quantity)
+	}
+
+	// 剩余部分与流动性池成交
+	if ammFill != nil {
+		trade := models.Trade{
+			ID:         uuid.New(),
+			StockID:    stock.ID,
+			Type:       opts.Side,
+			Amount:     ammFill.quantity,
+			Price:      ammFill.price,
+			TotalValue: ammFill.value,
+			Fee:        ammFill.fee,
+			Status:     models.TradeStatusCompleted,
+		}
+		balanceDelta, holdingDelta := ammFill.value.Neg(), ammFill.quantity
+		if opts.Side == models.TradeTypeBuy {
+			trade.BuyerID, trade.BuyOrderID = &userID, &order.ID
+			pool.YoloReserve = pool.YoloReserve.Add(ammFill.value.Sub(ammFill.fee))
+			pool.StockReserve = pool.StockReserve.Sub(ammFill.quantity)
+		} else {
+			trade.SellerID, trade.SellOrderID = &userID, &order.ID
+			balanceDelta, holdingDelta = ammFill.value, ammFill.quantity.Neg()
+			pool.YoloReserve = pool.YoloReserve.Sub(ammFill.value.Add(ammFill.fee))
+			pool.StockReserve = pool.StockReserve.Add(ammFill.quantity)
+		}
+		if err := updatePoolReserves(tx, pool); err != nil {
+			return nil, err
+		}
+		entry := &models.JournalEntry{
+			Type:          models.JournalTypeTrade,
+			ReferenceType: "trade",
+			ReferenceID:   &trade.ID,
+			Memo:          opts.Side + " " + stock.Symbol,
+		}
+		legs := []ledgerLeg{
+			{taker.ID, balanceDelta},
+			{accounts[poolAccountCode(stock.ID)].ID, balanceDelta.Neg().Sub(ammFill.fee)},
+		}
+		if ammFill.fee.IsPositive() {
+			legs = append(legs, ledgerLeg{accounts[models.LedgerFeesCode].ID, ammFill.fee})
+		}
+		if err := postEntry(tx, entry, legs...); err != nil {
+			return nil, err
+		}
+		trade.TransactionID = entry.ID.String()
+		if err := adjustHolding(tx, holdings[userID], holdingDelta, decimal.Zero); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&trade).Error; err != nil {
+			return nil, err
+		}
+		result.Trades = append(result.Trades, trade)
+		order.Filled = order.Filled.Add(ammFill.quantity)
+	}
+
+	// 未成交部分：GTC 挂单并冻结，其余撤销
+	switch {
+	case !order.Remaining().IsPositive():
+		order.Status = models.OrderStatusFilled
+	case rests && opts.Side == models.TradeTypeBuy:
+		order.Reserved = order.Price.Mul(order.Remaining(), decimal.RoundUp)
+		entry := &models.JournalEntry{
+			Type:          models.JournalTypeTrade,
+			ReferenceType: "order",
+			ReferenceID:   &order.ID,
+			Memo:          "reserve buy order",
+		}
+		if err := postEntry(tx, entry,
+			ledgerLeg{taker.ID, order.Reserved.Neg()},
+			ledgerLeg{book.ID, order.Reserved}); err != nil {
+			return nil, err
+		}
+	case rests:
+		if err := adjustHolding(tx, holdings[userID], decimal.Zero, order.Remaining()); err != nil {
+			return nil, err
+		}
+	default:
+		order.Status = models.OrderStatusCancelled
+	}
+	if err := tx.Create(order).Error; err != nil {
+		return nil, err
+	}
+
+	result.NewPrice, err = refreshStock(tx, stock, pool)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CancelOrder 撤销自己的挂单
+func (s *orderService) CancelOrder(userID, orderID uuid.UUID) (*models.Order, error) {
+	if !StockService.Enabled() {
+		return nil, ErrStocksDisabled
+	}
+	var order models.Order
+	if err := database.DB.Preload("Stock").Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		pool, err := lockPool(tx, order.StockID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", order.ID).First(&order).Error; err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusOpen {
+			return ErrOrderNotOpen
+		}
+		accounts, err := lockOrderAccounts(tx, pool, []uuid.UUID{userID}, order.Side == models.TradeTypeBuy, false, false)
+		if err != nil {
+			return err
+		}
+		holding, err := lockHolding(tx, userID, order.StockID)
+		if err != nil {
+			return err
+		}
+		return releaseOrder(tx, &order, accounts[bookAccountCode(order.StockID)], accounts[userAccountCode(userID)], holding)
+	})
+	if err != nil {
+		if errors.Is(err, ErrOrderNotOpen) || errors.Is(err, ErrPoolNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to cancel order: %w", err)
+	}
+	return &order, nil
+}
+
+// GetOrderBook 获取订单簿中按价位聚合的挂单
+func (s *orderService) GetOrderBook(symbol string, depth int) (*OrderBook, error) {
+	stock, pool, err := AMMService.GetPool(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if depth <= 0 {
+		depth = DefaultOrderBookDepth
+	}
+	depth = min(depth, MaxOrderBookDepth)
+
+	book := &OrderBook{MidPrice: poolPrice(pool)}
+	for _, side := range []struct {
+		name   string
+		order  string
+		levels *[]OrderBookLevel
+	}{
+		{models.TradeTypeBuy, "price DESC", &book.Bids},
+		{models.TradeTypeSell, "price ASC", &book.Asks},
+	} {
+		err := database.DB.Model(&models.Order{}).
+			Select("price, SUM(quantity - filled) AS quantity, COUNT(*) AS orders").
+			Where("stock_id = ? AND side = ? AND status = ?", stock.ID, side.name, models.OrderStatusOpen).
+			Group("price").Order(side.order).Limit(depth).
+			Scan(side.levels).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order book: %w", err)
+		}
+	}
+	return book, nil
+}
+
+// GetUserOrders 获取用户的订单，按下单时间倒序；status 为空时返回全部
+func (s *orderService) GetUserOrders(userID uuid.UUID, status string, page, limit int) ([]models.Order, int64, error) {
+	if !StockService.Enabled() {
+		return nil, 0, ErrStocksDisabled
+	}
+	var orders []models.Order
+	var total int64
+
+	query := database.DB.Model(&models.Order{}).Where("user_id = ?", userID)
+	switch status {
+	case "":
+	case models.OrderStatusOpen, models.OrderStatusFilled, models.OrderStatusCancelled:
+		query = query.Where("status = ?", status)
+	default:
+		return nil, 0, ErrInvalidOrderStatus
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Stock").Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get orders: %w", err)
+	}
+	return orders, total, nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yolo/database"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 投票限制
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 4
+	MaxPollOptionLength = 100
+	MinPollDuration     = 5 * time.Minute
+	MaxPollDuration     = 7 * 24 * time.Hour
+)
+
+// 投票相关错误
+var (
+	ErrInvalidPollOptions    = fmt.Errorf("a poll needs %d to %d distinct options of at most %d characters", MinPollOptions, MaxPollOptions, MaxPollOptionLength)
+	ErrInvalidPollDuration   = fmt.Errorf("a poll must close between %s and %s after it is published", MinPollDuration, MaxPollDuration)
+	ErrWeightedPollForbidden = errors.New("only creators can weight polls by shares")
+	ErrPollNotFound          = errors.New("poll not found")
+	ErrPollClosed            = errors.New("poll is closed")
+	ErrAlreadyVoted          = errors.New("already voted in this poll")
+	ErrInvalidPollChoice     = errors.New("invalid poll choice")
+	ErrNoSharesToVote        = errors.New("this poll is weighted by shares, only holders of the creator's stock can vote")
+)
+
+// NewPoll 发帖时附带的投票
+type NewPoll struct {
+	Options        []string
+	ClosesAt       time.Time
+	MultipleChoice bool
+	WeightByShares bool // 按持有作者股票的数量计票，仅创作者可用
+}
+
+type pollService struct{}
+
+// validatePoll 检查投票选项和截止时间，截止时间从帖子发布时间（定时帖子为定时时间）起算
+func validatePoll(userID uuid.UUID, poll *NewPoll, publishAt time.Time) ([]string, error) {
+	if len(poll.Options) < MinPollOptions || len(poll.Options) > MaxPollOptions {
+		return nil, ErrInvalidPollOptions
+	}
+	seen := make(map[string]bool)
+	options := make([]string, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		key := strings.ToLower(option)
+		if option == "" || utf8.RuneCountInString(option) > MaxPollOptionLength || seen[key] {
+			return nil, ErrInvalidPollOptions
+		}
+		seen[key] = true
+		options = append(options, option)
+	}
+
+	duration := poll.ClosesAt.Sub(publishAt)
+	if duration < MinPollDuration || duration > MaxPollDuration {
+		return nil, ErrInvalidPollDuration
+	}
+	if poll.WeightByShares && !UserService.IsCreator(userID) {
+		return nil, ErrWeightedPollForbidden
+	}
+	return options, nil
+}
+
+// createForPost 在发帖事务中创建投票
+func (s *pollService) createForPost(tx *gorm.DB, postID uuid.UUID, poll *NewPoll, options []string) error {
+	record := &models.Poll{
+		PostID:         postID,
+		MultipleChoice: poll.MultipleChoice,
+		WeightByShares: poll.WeightByShares,
+		ClosesAt:       poll.ClosesAt,
+		CreatedAt:      time.Now(),
+	}
+	for i, text := range options {
+		record.Options = append(record.Options, models.PollOption{Position: i, Text: text})
+	}
+	return tx.Create(record).Error
+}
+
+// sharesHeld 投票人持有的作者股票数量
+func sharesHeld(db *gorm.DB, userID, creatorID uuid.UUID) (float64, error) {
+	var shares float64
+	err := db.Model(&models.UserHolding{}).
+		Joins("JOIN stocks ON stocks.id = user_holdings.stock_id").
+		Where("stocks.user_id = ? AND user_holdings.user_id = ?", creatorID, userID).
+		Select("COALESCE(SUM(user_holdings.quantity), 0)").
+		Scan(&shares).Error
+	return shares, err
+}
+
+// Vote 为帖子中的投票投票，每人只能投一次，多选时一次提交全部选项。
+// 按股份加权的投票以投票时的持仓计票，之后买卖不影响已投的票
+func (s *pollService) Vote(userID, postID uuid.UUID, optionIDs []uuid.UUID) (*models.Poll, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Scopes(visiblePostsScope(userID)).Where("posts.id = ?", postID).First(&post).Error; err != nil {
+			return ErrPostNotFound
+		}
+		var poll models.Poll
+		if err := tx.Preload("Options").Where("post_id = ?", postID).First(&poll).Error; err != nil {
+			return ErrPollNotFound
+		}
+		if poll.Closed(time.Now()) {
+			return ErrPollClosed
+		}
+
+		chosen := make(map[uuid.UUID]bool)
+		for _, id := range optionIDs {
+			chosen[id] = true
+		}
+		if len(chosen) == 0 || len(chosen) != len(optionIDs) || (!poll.MultipleChoice && len(chosen) > 1) {
+			return ErrInvalidPollChoice
+		}
+		valid := 0
+		for _, option := range poll.Options {
+			if chosen[option.ID] {
+				valid++
+			}
+		}
+		if valid != len(chosen) {
+			return ErrInvalidPollChoice
+		}
+
+		weight := 1.0
+		if poll.WeightByShares {
+			shares, err := sharesHeld(tx, userID, post.UserID)
+			if err != nil {
+				return err
+			}
+			if shares <= 0 {
+				return ErrNoSharesToVote
+			}
+			weight = shares
+		}
+
+		// 主键冲突说明已经投过票
+		ballot := models.PollBallot{PollID: poll.ID, UserID: userID, Weight: weight, CreatedAt: time.Now()}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ballot)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyVoted
+		}
+
+		votes := make([]models.PollVote, 0, len(optionIDs))
+		for _, id := range optionIDs {
+			votes = append(votes, models.PollVote{PollID: poll.ID, UserID: userID, OptionID: id})
+		}
+		if err := tx.Create(&votes).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PollOption{}).Where("id IN ?", optionIDs).Updates(map[string]interface{}{
+			"votes":  gorm.Expr("votes + 1"),
+			"weight": gorm.Expr("weight + ?", weight),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Poll{}).Where("id = ?", poll.ID).Updates(map[string]interface{}{
+			"voters":       gorm.Expr("voters + 1"),
+			"total_weight": gorm.Expr("total_weight + ?", weight),
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrPostNotFound) || errors.Is(err, ErrPollNotFound) || errors.Is(err, ErrPollClosed) ||
+			errors.Is(err, ErrInvalidPollChoice) || errors.Is(err, ErrNoSharesToVote) || errors.Is(err, ErrAlreadyVoted) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to vote: %w", err)
+	}
+
+	return s.GetPoll(userID, postID)
+}
+
+// withPollRelations 预加载投票选项和查看者自己的选择
+func withPollRelations(db *gorm.DB, prefix string, viewerID uuid.UUID) *gorm.DB {
+	return db.Preload(prefix+"Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload(prefix+"Votes", "user_id = ?", viewerID)
+}
+
+// GetPoll 获取查看者可见的帖子中的投票
+func (s *pollService) GetPoll(viewerID, postID uuid.UUID) (*models.Poll, error) {
+	if !PostService.canView(database.DB, viewerID, postID) {
+		return nil, ErrPostNotFound
+	}
+	var poll models.Poll
+	if err := withPollRelations(database.DB, "", viewerID).Where("post_id = ?", postID).First(&poll).Error; err != nil {
+		return nil, ErrPollNotFound
+	}
+	return &poll, nil
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+	"yolo/database"
+	"yolo/decimal"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 组合价值历史的查询范围
+const (
+	DefaultPortfolioHistoryDays = 30
+	MaxPortfolioHistoryDays     = 366
+)
+
+// ErrInvalidPortfolioDays 历史天数超出范围
+var ErrInvalidPortfolioDays = errors.New("days must be between 1 and 366")
+
+// PortfolioLot 按先进先出剩余的买入批次
+type PortfolioLot struct {
+	Quantity   decimal.Decimal // 剩余股数
+	Cost       decimal.Decimal // 剩余股数的成本（YOLO，含买入手续费）
+	Price      decimal.Decimal // 买入均价
+	AcquiredAt time.Time
+}
+
+// PortfolioPosition 单支股票的持仓估值
+type PortfolioPosition struct {
+	Stock         models.Stock
+	Quantity      decimal.Decimal // 持股数，含卖单冻结的股数
+	Locked        decimal.Decimal
+	CostBasis     decimal.Decimal // 剩余批次的成本之和
+	AverageCost   decimal.Decimal
+	MidPrice      decimal.Decimal // 流动性池中价格
+	Value         decimal.Decimal // 按池中价格计算的市值
+	RealizedPnL   decimal.Decimal // 卖出所得减去按先进先出卖出批次的成本
+	UnrealizedPnL decimal.Decimal // 市值减去成本
+	Allocation    float64         // 占组合总价值的百分比
+	Lots          []PortfolioLot
+}
+
+// Portfolio 用户的投资组合，总价值 = YOLO 余额 + 挂单冻结的 YOLO + 持股市值
+type Portfolio struct {
+	Positions      []PortfolioPosition // 持股数大于 0 的持仓，按市值从高到低
+	Cash           decimal.Decimal
+	OpenOrders     decimal.Decimal
+	HoldingsValue  decimal.Decimal
+	TotalValue     decimal.Decimal
+	CostBasis      decimal.Decimal
+	RealizedPnL    decimal.Decimal // 含已清仓股票的已实现盈亏
+	UnrealizedPnL  decimal.Decimal
+	CashAllocation float64 // YOLO 余额和挂单冻结部分占总价值的百分比
+}
+
+type portfolioService struct{}
+
+// replayLots 按时间顺序重放用户在一支股票上的成交，得到先进先出的剩余批次和已实现盈亏。
+// 发行时分配给创作者的股份没有成交记录，持股数与成交净买入之差按零成本、发行时间的首个批次计入
+func replayLots(holding *models.UserHolding, trades []models.Trade) ([]PortfolioLot, decimal.Decimal) {
+	userID := holding.UserID
+	net := decimal.Zero
+	for _, trade := range trades {
+		if trade.BuyerID != nil && *trade.BuyerID == userID {
+			net = net.Add(trade.Amount)
+		} else {
+			net = net.Sub(trade.Amount)
+		}
+	}
+
+	var lots []PortfolioLot
+	if seed := holding.Quantity.Sub(net); seed.IsPositive() {
+		lots = append(lots, PortfolioLot{Quantity: seed, AcquiredAt: holding.Stock.CreatedAt})
+	}
+	realized := decimal.Zero
+	for _, trade := range trades {
+		if trade.BuyerID != nil && *trade.BuyerID == userID {
+			lots = append(lots, PortfolioLot{
+				Quantity:   trade.Amount,
+				Cost:       trade.TotalValue,
+				Price:      trade.TotalValue.Div(trade.Amount, decimal.RoundHalfEven),
+				AcquiredAt: trade.CreatedAt,
+			})
+			continue
+		}
+
+		realized = realized.Add(trade.TotalValue)
+		remaining := trade.Amount
+		for len(lots) > 0 && remaining.IsPositive() {
+			lot := &lots[0]
+			if !remaining.LessThan(lot.Quantity) {
+				realized = realized.Sub(lot.Cost)
+				remaining = remaining.Sub(lot.Quantity)
+				lots = lots[1:]
+				continue
+			}
+			cost := lot.Cost.MulDiv(remaining, lot.Quantity, decimal.RoundHalfEven)
+			realized = realized.Sub(cost)
+			lot.Cost = lot.Cost.Sub(cost)
+			lot.Quantity = lot.Quantity.Sub(remaining)
+			remaining = decimal.Zero
+		}
+	}
+	return lots, realized
+}
+
+// percentOf 计算占比百分比
+func percentOf(value, total decimal.Decimal) float64 {
+	if !total.IsPositive() {
+		return 0
+	}
+	return value.Float64() / total.Float64() * 100
+}
+
+// GetPortfolio 计算用户的投资组合：每支股票的先进先出批次、平均成本、按池中价格的市值、已实现和未实现盈亏及占比
+func (s *portfolioService) GetPortfolio(userID uuid.UUID) (*Portfolio, error) {
+	if !StockService.Enabled() {
+		return nil, ErrStocksDisabled
+	}
+	return readPortfolio(userID)
+}
+
+// readPortfolio 在一个只读事务中计算投资组合，持仓、成交、池子、余额和挂单都来自同一时刻，
+// 估值时不会混入计算过程中新发生的成交。PostgreSQL 默认的读已提交每条语句取一次快照，因此使用可重复读
+func readPortfolio(userID uuid.UUID) (*Portfolio, error) {
+	var opts *sql.TxOptions
+	if database.DB.Dialector.Name() == "postgres" {
+		opts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+	var portfolio *Portfolio
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		portfolio, err = buildPortfolio(tx, userID)
+		return err
+	}, opts)
+	return portfolio, err
+}
+
+// buildPortfolio 读取持仓、成交、池子和余额计算投资组合，所有查询都使用 db
+func buildPortfolio(db *gorm.DB, userID uuid.UUID) (*Portfolio, error) {
+	var holdings []models.UserHolding
+	if err := db.Preload("Stock").Where("user_id = ?", userID).Find(&holdings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+	var trades []models.Trade
+	err := db.Where("buyer_id = ? OR seller_id = ?", userID, userID).
+		Order("created_at ASC, id ASC").Find(&trades).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trades: %w", err)
+	}
+	tradesByStock := make(map[uuid.UUID][]models.Trade)
+	for _, trade := range trades {
+		tradesByStock[trade.StockID] = append(tradesByStock[trade.StockID], trade)
+	}
+
+	stockIDs := make([]uuid.UUID, 0, len(holdings))
+	for _, holding := range holdings {
+		stockIDs = append(stockIDs, holding.StockID)
+	}
+	prices := make(map[uuid.UUID]decimal.Decimal, len(stockIDs))
+	if len(stockIDs) > 0 {
+		var pools []models.LiquidityPool
+		if err := db.Where("stock_id IN ?", stockIDs).Find(&pools).Error; err != nil {
+			return nil, fmt.Errorf("failed to get pools: %w", err)
+		}
+		for i := range pools {
+			prices[pools[i].StockID] = poolPrice(&pools[i])
+		}
+	}
+
+	portfolio := &Portfolio{}
+	if portfolio.Cash, err = userBalance(db, userID); err != nil {
+		return nil, err
+	}
+	err = db.Model(&models.Order{}).
+		Where("user_id = ? AND side = ? AND status = ?", userID, models.TradeTypeBuy, models.OrderStatusOpen).
+		Select("COALESCE(SUM(reserved), 0)").Row().Scan(&portfolio.OpenOrders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum open orders: %w", err)
+	}
+
+	for i := range holdings {
+		holding := &holdings[i]
+		lots, realized := replayLots(holding, tradesByStock[holding.StockID])
+		portfolio.RealizedPnL = portfolio.RealizedPnL.Add(realized)
+		if !holding.Quantity.IsPositive() {
+			continue
+		}
+
+		position := PortfolioPosition{
+			Stock:       holding.Stock,
+			Quantity:    holding.Quantity,
+			Locked:      holding.Locked,
+			MidPrice:    prices[holding.StockID],
+			RealizedPnL: realized,
+			Lots:        lots,
+		}
+		for _, lot := range lots {
+			position.CostBasis = position.CostBasis.Add(lot.Cost)
+		}
+		position.AverageCost = position.CostBasis.Div(position.Quantity, decimal.RoundHalfEven)
+		position.Value = position.Quantity.Mul(position.MidPrice, decimal.RoundHalfEven)
+		position.UnrealizedPnL = position.Value.Sub(position.CostBasis)
+		portfolio.Positions = append(portfolio.Positions, position)
+
+		portfolio.HoldingsValue = portfolio.HoldingsValue.Add(position.Value)
+		portfolio.CostBasis = portfolio.CostBasis.Add(position.CostBasis)
+		portfolio.UnrealizedPnL = portfolio.UnrealizedPnL.Add(position.UnrealizedPnL)
+	}
+
+	portfolio.TotalValue = decimal.Sum(portfolio.Cash, portfolio.OpenOrders, portfolio.HoldingsValue)
+	for i := range portfolio.Positions {
+		portfolio.Positions[i].Allocation = percentOf(portfolio.Positions[i].Value, portfolio.TotalValue)
+	}
+	portfolio.CashAllocation = percentOf(portfolio.Cash.Add(portfolio.OpenOrders), portfolio.TotalValue)
+	sort.SliceStable(portfolio.Positions, func(i, j int) bool {
+		return portfolio.Positions[i].Value.GreaterThan(portfolio.Positions[j].Value)
+	})
+	return portfolio, nil
+}
+
+// snapshotDate 快照按 UTC 日期记录
+func snapshotDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// Snapshot 记录用户当天的组合价值，同一天再次记录时覆盖
+func (s *portfolioService) Snapshot(userID uuid.UUID, now time.Time) (*models.PortfolioSnapshot, error) {
+	portfolio, err := readPortfolio(userID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := models.PortfolioSnapshot{
+		UserID:        userID,
+		Date:          snapshotDate(now),
+		Cash:          portfolio.Cash,
+		OpenOrders:    portfolio.OpenOrders,
+		HoldingsValue: portfolio.HoldingsValue,
+		TotalValue:    portfolio.TotalValue,
+		CostBasis:     portfolio.CostBasis,
+		RealizedPnL:   portfolio.RealizedPnL,
+		UnrealizedPnL: portfolio.UnrealizedPnL,
+		UpdatedAt:     now,
+	}
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cash", "open_orders", "holdings_value", "total_value",
+			"cost_basis", "realized_pnl", "unrealized_pnl", "updated_at"}),
+	}).Create(&snapshot).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save portfolio snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// SnapshotAll 为开过 YOLO 账户或持有股份的用户记录当天的组合价值，返回记录的用户数
+func (s *portfolioService) SnapshotAll(now time.Time) (int, error) {
+	if !StockService.Enabled() {
+		return 0, nil
+	}
+	var accountUsers, holdingUsers []uuid.UUID
+	err := database.DB.Model(&models.LedgerAccount{}).
+		Where("type = ? AND owner_id IS NOT NULL", models.LedgerAccountUser).
+		Pluck("owner_id", &accountUsers).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	err = database.DB.Model(&models.UserHolding{}).
+		Where("quantity > 0").Distinct().Pluck("user_id", &holdingUsers).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list holders: %w", err)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(accountUsers)+len(holdingUsers))
+	count := 0
+	for _, userID := range append(accountUsers, holdingUsers...) {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if _, err := s.Snapshot(userID, now); err != nil {
+			log.Printf("Failed to snapshot portfolio for user %s: %v", userID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// GetHistory 获取用户最近若干天的组合价值，按日期从早到晚
+func (s *portfolioService) GetHistory(userID uuid.UUID, days int) ([]models.PortfolioSnapshot, error) {
+	if !StockService.Enabled() {
+		return nil, ErrStocksDisabled
+	}
+	if days == 0 {
+		days = DefaultPortfolioHistoryDays
+	}
+	if days < 1 || days > MaxPortfolioHistoryDays {
+		return nil, ErrInvalidPortfolioDays
+	}
+
+	since := snapshotDate(time.Now().AddDate(0, 0, 1-days))
+	var snapshots []models.PortfolioSnapshot
+	err := database.DB.Where("user_id = ? AND date >= ?", userID, since).Order("date ASC").Find(&snapshots).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio history: %w", err)
+	}
+	return snapshots, nil
+}
+
+// Start 启动组合价值快照的后台任务，每隔 interval 更新当天的快照
+func (s *portfolioService) Start(ctx context.Context, interval time.Duration) {
+	run := func() {
+		if _, err := s.SnapshotAll(time.Now()); err != nil {
+			log.Printf("Failed to snapshot portfolios: %v", err)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"yolo/decimal"
+
+	"github.com/google/uuid"
+)
+
+// 报价容差
+var (
+	DefaultQuoteSlippage = decimal.RequireFromString("0.005")
+	MaxQuoteSlippage     = decimal.RequireFromString("0.5")
+)
+
+// 报价相关错误
+var (
+	ErrInvalidSlippage = errors.New("slippage must be between 0 and 0.5")
+	ErrQuoteInvalid    = errors.New("quote is invalid")
+	ErrQuoteExpired    = errors.New("quote has expired")
+	ErrQuoteUsed       = errors.New("quote has already been used")
+)
+
+// QuoteOptions 签名报价的参数，AmountIn 与 AmountOut 二选一，含义同 TradeOptions
+type QuoteOptions struct {
+	Type      string
+	AmountIn  decimal.Decimal
+	AmountOut decimal.Decimal
+	Slippage  decimal.Decimal // 可接受的偏离比例，为 0 时使用默认的 0.5%
+}
+
+// SignedQuote 带签名ID的报价，按 ID 成交时输出不少于 MinOut（精确输入）或输入不多于 MaxIn（精确输出）
+type SignedQuote struct {
+	ID        string
+	Quote     TradeQuote
+	FeeRate   decimal.Decimal
+	Slippage  decimal.Decimal
+	MinOut    decimal.Decimal
+	MaxIn     decimal.Decimal
+	ExpiresAt time.Time
+}
+
+// signedQuote 报价ID中签名的内容，字段与 TradeOptions 对应；Nonce 记录在成交上，保证每个报价只成交一次
+type signedQuote struct {
+	Nonce     uuid.UUID       `json:"n"`
+	UserID    uuid.UUID       `json:"u"`
+	StockID   uuid.UUID       `json:"s"`
+	Type      string          `json:"t"`
+	AmountIn  decimal.Decimal `json:"i"`
+	AmountOut decimal.Decimal `json:"o"`
+	MinOut    decimal.Decimal `json:"m"`
+	MaxIn     decimal.Decimal `json:"x"`
+	ExpiresAt int64           `json:"e"` // 毫秒时间戳
+}
+
+// quoteSignature 用报价密钥计算 HMAC-SHA256 签名
+func quoteSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, StockService.Config().QuoteSecret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// signQuote 生成报价ID：base64url(内容).base64url(签名)
+func signQuote(q *signedQuote) (string, error) {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(quoteSignature(payload)), nil
+}
+
+// verifyQuote 校验报价ID的签名、所属用户、股票和有效期
+func verifyQuote(id string, userID, stockID uuid.UUID) (*signedQuote, error) {
+	payload, signature, ok := strings.Cut(id, ".")
+	if !ok {
+		return nil, ErrQuoteInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, quoteSignature(payload)) {
+		return nil, ErrQuoteInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrQuoteInvalid
+	}
+	var q signedQuote
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, ErrQuoteInvalid
+	}
+	if q.UserID != userID || q.StockID != stockID {
+		return nil, ErrQuoteInvalid
+	}
+	if time.Now().UnixMilli() > q.ExpiresAt {
+		return nil, ErrQuoteExpired
+	}
+	return &q, nil
+}
+
+// CreateQuote 按当前池子报价并签名。报价绑定用户和股票，在有效期内按 ID 成交一次，
+// 成交时池子变动导致超出容差则拒绝
+func (s *ammService) CreateQuote(userID uuid.UUID, symbol string, opts QuoteOptions) (*SignedQuote, error) {
+	tradeType, err := normalizeTradeType(opts.Type)
+	if err != nil {
+		return nil, err
+	}
+	slippage := opts.Slippage
+	if slippage.IsZero() {
+		slippage = DefaultQuoteSlippage
+	}
+	if slippage.IsNegative() || slippage.GreaterThan(MaxQuoteSlippage) {
+		return nil, ErrInvalidSlippage
+	}
+	if opts.AmountIn.IsNegative() || opts.AmountOut.IsNegative() {
+		return nil, ErrInvalidTradeAmount
+	}
+	if opts.AmountIn.IsPositive() && opts.AmountOut.IsPositive() {
+		return nil, ErrTradeAmountConflict
+	}
+
+	stock, pool, err := s.GetPool(symbol)
+	if err != nil {
+		return nil, err
+	}
+	cfg := StockService.Config()
+	result := &SignedQuote{FeeRate: cfg.TradeFeeRate, Slippage: slippage, ExpiresAt: time.Now().Add(cfg.QuoteTTL)}
+	signed := &signedQuote{
+		Nonce:     uuid.New(),
+		UserID:    userID,
+		StockID:   stock.ID,
+		Type:      tradeType,
+		ExpiresAt: result.ExpiresAt.UnixMilli(),
+	}
+	one := decimal.FromInt(1)
+	if opts.AmountOut.IsPositive() {
+		if result.Quote, err = quoteExactOut(pool, tradeType, opts.AmountOut, cfg.TradeFeeRate); err != nil {
+			return nil, err
+		}
+		result.MaxIn = result.Quote.AmountIn.Mul(one.Add(slippage), decimal.RoundUp)
+		signed.AmountOut, signed.MaxIn = opts.AmountOut, result.MaxIn
+	} else {
+		if result.Quote, err = quote(pool, tradeType, opts.AmountIn, cfg.TradeFeeRate); err != nil {
+			return nil, err
+		}
+		result.MinOut = result.Quote.AmountOut.Mul(one.Sub(slippage), decimal.RoundDown)
+		signed.AmountIn, signed.MinOut = opts.AmountIn, result.MinOut
+	}
+
+	if result.ID, err = signQuote(signed); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+	"yolo/database"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrendingWindow 热门话题的统计窗口，窗口内的帖子按半衰期衰减计分
+type TrendingWindow struct {
+	Name     string
+	Length   time.Duration
+	HalfLife time.Duration
+}
+
+// TrendingWindows 支持的统计窗口，第一个为默认窗口
+var TrendingWindows = []TrendingWindow{
+	{Name: "24h", Length: 24 * time.Hour, HalfLife: 6 * time.Hour},
+	{Name: "1h", Length: time.Hour, HalfLife: 15 * time.Minute},
+	{Name: "7d", Length: 7 * 24 * time.Hour, HalfLife: 48 * time.Hour},
+}
+
+// 热度计算参数
+const (
+	MaxTrendingTopics  = 50 // 每个窗口每种类型保留的话题数
+	MinTrendingAuthors = 2  // 至少有这么多不同作者讨论才能上榜，避免单人刷榜
+
+	// 互动权重
+	hotWeightRepost   = 2.0
+	hotWeightQuote    = 3.0
+	hotWeightVote     = 1.0
+	hotWeightBookmark = 1.0
+
+	// HotScoreWindow 只重算这段时间内发布的帖子，更早的帖子热度分保持不变
+	HotScoreWindow = 72 * time.Hour
+	// hotScoreScale 发布时间每晚这么多秒，热度分加1，相当于互动数多10倍
+	hotScoreScale = 45000.0
+	hotBatchSize  = 500
+)
+
+// ErrInvalidTrendingWindow 不支持的统计窗口
+var ErrInvalidTrendingWindow = errors.New("invalid trending window")
+
+// hotEpoch 热度分的时间零点
+var hotEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TrendingTopic 热门话题或股票符号
+type TrendingTopic struct {
+	Type    string // hashtag/cashtag
+	Value   string // 归一化后的值
+	Score   float64
+	Authors int // 窗口内讨论的不同作者数
+	Posts   int // 窗口内的帖子数
+}
+
+// TrendingSnapshot 某次计算出的各窗口热门话题
+type TrendingSnapshot struct {
+	ComputedAt time.Time
+	Windows    map[string][]TrendingTopic
+}
+
+type rankingService struct {
+	trending atomic.Pointer[TrendingSnapshot]
+}
+
+// findTrendingWindow 按名称查找统计窗口，名称为空时返回默认窗口
+func findTrendingWindow(name string) (TrendingWindow, error) {
+	if name == "" {
+		return TrendingWindows[0], nil
+	}
+	for _, window := range TrendingWindows {
+		if window.Name == name {
+			return window, nil
+		}
+	}
+	return TrendingWindow{}, ErrInvalidTrendingWindow
+}
+
+// HotScore 根据互动数和发布时间计算热度分。
+// 时间项与当前时间无关，所以只有互动数变化时才需要重算，旧帖子自然沉底
+func HotScore(engagement float64, publishedAt time.Time) float64 {
+	return math.Log10(1+math.Max(engagement, 0)) + publishedAt.Sub(hotEpoch).Seconds()/hotScoreScale
+}
+
+// RefreshTrending 重新计算各窗口的热门话题。
+// 只统计公开且未被隐藏或限流的帖子，每个作者在同一话题下只按最近一条帖子计分
+func (s *rankingService) RefreshTrending(now time.Time) (*TrendingSnapshot, error) {
+	longest := time.Duration(0)
+	for _, window := range TrendingWindows {
+		if window.Length > longest {
+			longest = window.Length
+		}
+	}
+
+	var rows []struct {
+		Type      string
+		Value     string
+		PostID    uuid.UUID
+		UserID    uuid.UUID
+		Timestamp time.Time
+	}
+	if err := database.DB.Model(&models.PostEntity{}).
+		Distinct("post_entities.type", "post_entities.value", "post_entities.post_id", "posts.user_id", "posts.timestamp").
+		Joins("JOIN posts ON posts.id = post_entities.post_id").
+		Where("post_entities.type IN ?", []string{models.EntityTypeHashtag, models.EntityTypeCashtag}).
+		Where("posts.status = ? AND posts.visibility = ? AND posts.hidden_at IS NULL AND posts.limited = ? AND posts.deleted_at IS NULL",
+			models.PostStatusPublished, models.VisibilityPublic, false).
+		Where("posts.timestamp > ? AND posts.timestamp <= ?", now.Add(-longest), now).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get topic mentions: %w", err)
+	}
+
+	type topicKey struct{ Type, Value string }
+	snapshot := &TrendingSnapshot{ComputedAt: now, Windows: make(map[string][]TrendingTopic, len(TrendingWindows))}
+	for _, window := range TrendingWindows {
+		since := now.Add(-window.Length)
+		// 每个作者在每个话题下取权重最高（即最近）的一条
+		authorWeights := make(map[topicKey]map[uuid.UUID]float64)
+		posts := make(map[topicKey]int)
+		for _, row := range rows {
+			if !row.Timestamp.After(since) {
+				continue
+			}
+			key := topicKey{row.Type, row.Value}
+			weight := math.Exp2(-now.Sub(row.Timestamp).Seconds() / window.HalfLife.Seconds())
+			if authorWeights[key] == nil {
+				authorWeights[key] = make(map[uuid.UUID]float64)
+			}
+			if weight > authorWeights[key][row.UserID] {
+				authorWeights[key][row.UserID] = weight
+			}
+			posts[key]++
+		}
+
+		topics := make([]TrendingTopic, 0, len(authorWeights))
+		for key, weights := range authorWeights {
+			if len(weights) < MinTrendingAuthors {
+				continue
+			}
+			topic := TrendingTopic{Type: key.Type, Value: key.Value, Authors: len(weights), Posts: posts[key]}
+			for _, weight := range weights {
+				topic.Score += weight
+			}
+			topics = append(topics, topic)
+		}
+		sort.Slice(topics, func(i, j int) bool {
+			if topics[i].Score != topics[j].Score {
+				return topics[i].Score > topics[j].Score
+			}
+			return topics[i].Value < topics[j].Value
+		})
+
+		kept := make([]TrendingTopic, 0, len(topics))
+		perType := make(map[string]int)
+		for _, topic := range topics {
+			if perType[topic.Type] < MaxTrendingTopics {
+				perType[topic.Type]++
+				kept = append(kept, topic)
+			}
+		}
+		snapshot.Windows[window.Name] = kept
+	}
+
+	s.trending.Store(snapshot)
+	return snapshot, nil
+}
+
+// GetTrending 获取最近一次计算的热门话题，entityType 为空时返回话题和股票符号。
+// 后台任务尚未运行时当场计算一次
+func (s *rankingService) GetTrending(windowName, entityType string, limit int) ([]TrendingTopic, time.Time, error) {
+	window, err := findTrendingWindow(windowName)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	snapshot := s.trending.Load()
+	if snapshot == nil {
+		if snapshot, err = s.RefreshTrending(time.Now()); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+
+	topics := make([]TrendingTopic, 0, limit)
+	for _, topic := range snapshot.Windows[window.Name] {
+		if len(topics) >= limit {
+			break
+		}
+		if entityType == "" || topic.Type == entityType {
+			topics = append(topics, topic)
+		}
+	}
+	return topics, snapshot.ComputedAt, nil
+}
+
+// countEngagement 按帖子分组累加某种互动的加权次数
+func countEngagement(engagement map[uuid.UUID]float64, query *gorm.DB, weight float64) error {
+	var counts []struct {
+		PostID uuid.UUID
+		Count  float64
+	}
+	if err := query.Scan(&counts).Error; err != nil {
+		return err
+	}
+	for _, count := range counts {
+		engagement[count.PostID] += count.Count * weight
+	}
+	return nil
+}
+
+// RefreshHotScores 重算最近 HotScoreWindow 内发布的帖子的热度分，返回更新的帖子数。
+// 只有被隐藏或限流之外的转发和引用才计入互动，转发本身不参与排序
+func (s *rankingService) RefreshHotScores(now time.Time) (int, error) {
+	var posts []struct {
+		ID        uuid.UUID
+		Timestamp time.Time
+	}
+	if err := database.DB.Model(&models.Post{}).
+		Select("id", "timestamp").
+		Where("status = ? AND repost_of_id IS NULL AND timestamp > ? AND timestamp <= ?",
+			models.PostStatusPublished, now.Add(-HotScoreWindow), now).
+		Scan(&posts).Error; err != nil {
+		return 0, fmt.Errorf("failed to get recent posts: %w", err)
+	}
+
+	updated := 0
+	for start := 0; start < len(posts); start += hotBatchSize {
+		batch := posts[start:min(start+hotBatchSize, len(posts))]
+		ids := make([]uuid.UUID, 0, len(batch))
+		for _, post := range batch {
+			ids = append(ids, post.ID)
+		}
+
+		engagement := make(map[uuid.UUID]float64, len(batch))
+		sharing := func(column string) *gorm.DB {
+			return database.DB.Model(&models.Post{}).
+				Select(column+" AS post_id, COUNT(*) AS count").
+				Where(column+" IN ? AND status = ? AND hidden_at IS NULL AND limited = ?", ids, models.PostStatusPublished, false).
+				Group(column)
+		}
+		if err := countEngagement(engagement, sharing("repost_of_id"), hotWeightRepost); err != nil {
+			return updated, fmt.Errorf("failed to count reposts: %w", err)
+		}
+		if err := countEngagement(engagement, sharing("quote_of_id"), hotWeightQuote); err != nil {
+			return updated, fmt.Errorf("failed to count quotes: %w", err)
+		}
+		if err := countEngagement(engagement, database.DB.Model(&models.Poll{}).
+			Select("post_id, voters AS count").
+			Where("post_id IN ?", ids), hotWeightVote); err != nil {
+			return updated, fmt.Errorf("failed to count poll votes: %w", err)
+		}
+		if err := countEngagement(engagement, database.DB.Model(&models.Bookmark{}).
+			Select("post_id, COUNT(*) AS count").
+			Where("post_id IN ?", ids).
+			Group("post_id"), hotWeightBookmark); err != nil {
+			return updated, fmt.Errorf("failed to count bookmarks: %w", err)
+		}
+
+		scores := make([]models.PostScore, 0, len(batch))
+		for _, post := range batch {
+			scores = append(scores, models.PostScore{
+				PostID:     post.ID,
+				Engagement: engagement[post.ID],
+				Score:      HotScore(engagement[post.ID], post.Timestamp),
+				UpdatedAt:  now,
+			})
+		}
+		if err := database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "post_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"engagement", "score", "updated_at"}),
+		}).Create(&scores).Error; err != nil {
+			return updated, fmt.Errorf("failed to save hot scores: %w", err)
+		}
+		updated += len(scores)
+	}
+	return updated, nil
+}
+
+// GetHotPosts 按热度分获取查看者可见的帖子，页码分页。
+// 只包含已计算过热度分的帖子，新发布的帖子在下一次后台重算后出现
+func (s *rankingService) GetHotPosts(viewerID uuid.UUID, page, limit int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var total int64
+	visible := visiblePostsScope(viewerID)
+
+	database.DB.Model(&models.Post{}).
+		Joins("JOIN post_scores ON post_scores.post_id = posts.id").
+		Scopes(visible).
+		Count(&total)
+
+	offset := (page - 1) * limit
+	if err := withPostRelations(database.DB, viewerID).
+		Joins("JOIN post_scores ON post_scores.post_id = posts.id").
+		Scopes(visible).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "post_scores.score DESC, posts.timestamp DESC, posts.id DESC"}}).
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get hot posts: %w", err)
+	}
+
+	return posts, total, nil
+}
+
+// Start 启动热门话题和热度分的后台计算，启动时立即计算一次
+func (s *rankingService) Start(ctx context.Context, interval time.Duration) {
+	run := func() {
+		now := time.Now()
+		if _, err := s.RefreshTrending(now); err != nil {
+			log.Printf("Failed to refresh trending topics: %v", err)
+		}
+		if _, err := s.RefreshHotScores(now); err != nil {
+			log.Printf("Failed to refresh hot scores: %v", err)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"log"
+	"yolo/hub"
+	"yolo/models"
+)
+
+// 实时推送事件类型
+const (
+	EventPostCreated  = "post.created"
+	EventPostDeleted  = "post.deleted"
+	EventNotification = "notification"
+)
+
+// publish 发布实时事件，失败只记录日志
+func publish(channel, eventType string, data any) {
+	if RealtimeHub == nil {
+		return
+	}
+	if _, err := RealtimeHub.Publish(channel, eventType, data); err != nil {
+		log.Printf("Failed to publish %s event to %s: %v", eventType, channel, err)
+	}
+}
+
+// publishPostEvent 向全站时间线和作者频道推送帖子事件
+// 只推送ID，客户端按需拉取完整帖子，避免在推送中泄露用户私密字段
+// 频道不区分订阅者，因此非公开、未发布或被限流的帖子不推送，也不投递给远程关注者
+func publishPostEvent(eventType string, post *models.Post) {
+	if post.Visibility != models.VisibilityPublic || post.Status != models.PostStatusPublished || post.Limited {
+		return
+	}
+	data := map[string]any{
+		"postId": post.ID.String(),
+		"userId": post.UserID.String(),
+		"type":   post.Type(),
+	}
+	publish(hub.TimelineChannel, eventType, data)
+	publish(hub.UserChannel(post.UserID), eventType, data)
+	if FederationService != nil {
+		FederationService.federatePost(eventType, post)
+	}
+}
+
+// publishNotification 将通知推送到接收者的通知频道
+func publishNotification(notification *models.Notification) {
+	data := map[string]any{
+		"id":        notification.ID.String(),
+		"type":      notification.Type,
+		"data":      notification.Data,
+		"createdAt": notification.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if notification.ActorID != nil {
+		data["actorId"] = notification.ActorID.String()
+	}
+	if notification.PostID != nil {
+		data["postId"] = notification.PostID.String()
+	}
+	publish(hub.NotificationsChannel(notification.UserID), EventNotification, data)
+}
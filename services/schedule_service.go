@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"yolo/database"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxScheduleAhead 最多可以提前多久定时发布
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// scheduledBatchSize 每轮最多发布的定时帖子数
+const scheduledBatchSize = 100
+
+// 草稿与定时发布相关错误
+var (
+	ErrInvalidSchedule = errors.New("publish_at must be in the future and within one year, and cannot be combined with draft")
+	ErrPostPublished   = errors.New("post is already published")
+	ErrPostHeld        = errors.New("post is held for review")
+)
+
+// unpublishedStatuses 可以编辑、定时和发布的状态
+var unpublishedStatuses = []string{models.PostStatusDraft, models.PostStatusScheduled}
+
+// validatePublishAt 校验定时发布时间
+func validatePublishAt(publishAt time.Time) error {
+	now := time.Now()
+	if !publishAt.After(now) || publishAt.After(now.Add(maxScheduleAhead)) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// UpdateDraftOptions 修改草稿或定时帖子的参数，为nil的字段保持不变
+type UpdateDraftOptions struct {
+	Content    *string
+	Visibility *string
+}
+
+// getUnpublishedPost 获取作者未发布的帖子，已发布时返回 ErrPostPublished
+func (s *postService) getUnpublishedPost(userID, postID uuid.UUID) (*models.Post, error) {
+	post, err := s.getOwnPost(userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	switch post.Status {
+	case models.PostStatusDraft, models.PostStatusScheduled:
+		return post, nil
+	case models.PostStatusHeld:
+		return nil, ErrPostHeld
+	}
+	return nil, ErrPostPublished
+}
+
+// updateUnpublished 只在帖子仍未发布时更新，避免与定时发布并发执行时修改已发布的帖子
+func updateUnpublished(tx *gorm.DB, userID, postID uuid.UUID, updates map[string]any) error {
+	updates["updated_at"] = time.Now()
+	result := tx.Model(&models.Post{}).
+		Where("id = ? AND user_id = ? AND status IN ?", postID, userID, unpublishedStatuses).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update post: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostPublished
+	}
+	return nil
+}
+
+// UpdateDraft 修改草稿或定时帖子的内容和可见范围，内容变化时重新解析实体
+func (s *postService) UpdateDraft(userID, postID uuid.UUID, opts UpdateDraftOptions) (*models.Post, error) {
+	post, err := s.getUnpublishedPost(userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	if opts.Visibility != nil {
+		if err := validateVisibility(userID, *opts.Visibility); err != nil {
+			return nil, err
+		}
+		updates["visibility"] = *opts.Visibility
+	}
+	if opts.Content != nil {
+		if strings.TrimSpace(*opts.Content) == "" && len(post.Media) == 0 {
+			return nil, ErrEmptyPost
+		}
+		// 修改内容同样经过过滤；草稿无法进入审核，需要审核的内容直接拒绝
+		verdict, err := s.filter.Load().Check(database.DB, userID, *opts.Content, &postID)
+		if err != nil {
+			return nil, err
+		}
+		switch verdict.Action {
+		case FilterActionReject, FilterActionHold:
+			return nil, &FilterError{verdict}
+		case FilterActionLimit:
+			updates["limited"] = true
+		}
+		updates["content"] = *opts.Content
+		post.Content = *opts.Content
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateUnpublished(tx, userID, postID, updates); err != nil {
+			return err
+		}
+		if opts.Content == nil {
+			return nil
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostEntity{}).Error; err != nil {
+			return err
+		}
+		if err := LinkPreviewService.attachToPost(tx, postID, post.Content); err != nil {
+			return err
+		}
+		return s.saveEntities(tx, post)
+	}); err != nil {
+		return nil, err
+	}
+	if opts.Content != nil {
+		LinkPreviewService.fetchForPost(postID)
+	}
+
+	return s.getOwnPost(userID, postID)
+}
+
+// SchedulePost 设置或修改定时发布时间，草稿会变为定时帖子
+func (s *postService) SchedulePost(userID, postID uuid.UUID, publishAt time.Time) (*models.Post, error) {
+	if err := validatePublishAt(publishAt); err != nil {
+		return nil, err
+	}
+	if _, err := s.getUnpublishedPost(userID, postID); err != nil {
+		return nil, err
+	}
+
+	if err := updateUnpublished(database.DB, userID, postID, map[string]any{
+		"status":     models.PostStatusScheduled,
+		"publish_at": publishAt,
+		"timestamp":  publishAt,
+	}); err != nil {
+		return nil, err
+	}
+	return s.getOwnPost(userID, postID)
+}
+
+// UnschedulePost 取消定时发布，帖子变回草稿
+func (s *postService) UnschedulePost(userID, postID uuid.UUID) (*models.Post, error) {
+	if _, err := s.getUnpublishedPost(userID, postID); err != nil {
+		return nil, err
+	}
+
+	if err := updateUnpublished(database.DB, userID, postID, map[string]any{
+		"status":     models.PostStatusDraft,
+		"publish_at": nil,
+	}); err != nil {
+		return nil, err
+	}
+	return s.getOwnPost(userID, postID)
+}
+
+// PublishNow 立即发布草稿或定时帖子
+func (s *postService) PublishNow(userID, postID uuid.UUID) (*models.Post, error) {
+	if err := checkNotSuspended(userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.getUnpublishedPost(userID, postID); err != nil {
+		return nil, err
+	}
+
+	published, err := s.publish(database.DB.Where("user_id = ?", userID), postID, unpublishedStatuses)
+	if err != nil {
+		return nil, err
+	}
+	if !published {
+		return nil, ErrPostPublished
+	}
+	return s.getOwnPost(userID, postID)
+}
+
+// publish 将帖子标记为已发布并把 Timestamp 设为实际发布时间，然后推送事件和通知
+// 通过带状态条件的 UPDATE 抢占发布权，多个实例同时处理同一帖子时只有一个会成功
+func (s *postService) publish(db *gorm.DB, postID uuid.UUID, fromStatuses []string) (bool, error) {
+	now := time.Now()
+	result := db.Model(&models.Post{}).
+		Where("id = ? AND status IN ?", postID, fromStatuses).
+		Updates(map[string]any{
+			"status":     models.PostStatusPublished,
+			"timestamp":  now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to publish post: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var post models.Post
+	if err := database.DB.Select("user_id").Where("id = ?", postID).First(&post).Error; err != nil {
+		return true, nil
+	}
+	if loaded, err := s.getOwnPost(post.UserID, postID); err == nil {
+		s.announce(loaded)
+	}
+	return true, nil
+}
+
+// PublishDuePosts 发布所有到期的定时帖子，返回本次由当前实例发布的数量
+func (s *postService) PublishDuePosts(now time.Time) (int, error) {
+	published := 0
+	for {
+		var ids []uuid.UUID
+		if err := database.DB.Model(&models.Post{}).
+			Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, now).
+			Order("publish_at ASC").
+			Limit(scheduledBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return published, fmt.Errorf("failed to get scheduled posts: %w", err)
+		}
+
+		for _, id := range ids {
+			ok, err := s.publish(database.DB, id, []string{models.PostStatusScheduled})
+			if err != nil {
+				return published, err
+			}
+			if ok {
+				published++
+			}
+		}
+
+		if len(ids) < scheduledBatchSize {
+			return published, nil
+		}
+	}
+}
+
+// StartScheduler 启动定时发布任务，启动时立即补发停机期间到期的帖子
+// 发布状态保存在数据库中，可以同时在多个实例上运行
+func (s *postService) StartScheduler(ctx context.Context, interval time.Duration) {
+	run := func() {
+		if count, err := s.PublishDuePosts(time.Now()); err != nil {
+			log.Printf("Failed to publish scheduled posts: %v", err)
+		} else if count > 0 {
+			log.Printf("Published %d scheduled posts", count)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// GetUnpublishedPosts 获取作者的草稿或定时帖子；草稿按最近修改排序，定时帖子按发布时间排序
+func (s *postService) GetUnpublishedPosts(userID uuid.UUID, status string, page, limit int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var total int64
+
+	query := database.DB.Model(&models.Post{}).Where("user_id = ? AND status = ?", userID, status)
+	query.Count(&total)
+
+	order := "updated_at DESC"
+	if status == models.PostStatusScheduled {
+		order = "publish_at ASC"
+	}
+
+	offset := (page - 1) * limit
+	if err := withPostRelations(database.DB, userID).
+		Where("user_id = ? AND status = ?", userID, status).
+		Order(order).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get %s posts: %w", status, err)
+	}
+
+	return posts, total, nil
+}
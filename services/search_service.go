@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yolo/database"
+	"yolo/models"
+	"yolo/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 搜索结果排序方式
+const (
+	SearchSortRelevance = "relevance" // 按相关度，相同时按时间倒序
+	SearchSortRecent    = "recent"    // 按时间倒序
+)
+
+// 搜索相关错误
+var (
+	ErrEmptySearchQuery  = errors.New("search query must contain at least one word")
+	ErrInvalidSearchSort = errors.New("sort must be relevance or recent")
+	ErrInvalidDateRange  = errors.New("since must be earlier than until")
+)
+
+// SearchPostsOptions 帖子搜索条件，除关键词外都是可选的
+type SearchPostsOptions struct {
+	Query  string     // 关键词，双引号包围的为短语
+	Author string     // 作者用户名
+	Tag    string     // 归一化后的话题
+	Since  *time.Time // 发布时间下限（含）
+	Until  *time.Time // 发布时间上限（不含）
+	Sort   string     // 为空时按相关度
+	Page   int
+	Limit  int
+}
+
+// searchMatch 按当前数据库的全文搜索实现生成匹配条件和相关度排序
+type searchMatch struct {
+	scope func(db *gorm.DB) *gorm.DB
+	rank  *clause.Expr // 相关度排序表达式（越相关越靠前），不支持时为空
+}
+
+// buildSearchMatch 生成关键词匹配条件
+func buildSearchMatch(query utils.SearchQuery) searchMatch {
+	switch database.SearchBackend {
+	case database.SearchBackendPostgres:
+		// 词已只含字母数字，可以直接拼成 tsquery
+		var parts []string
+		for _, term := range query.Terms {
+			parts = append(parts, "'"+term+"'")
+		}
+		for _, phrase := range query.Phrases {
+			parts = append(parts, "('"+strings.Join(phrase, "' <-> '")+"')")
+		}
+		tsquery := strings.Join(parts, " & ")
+		return searchMatch{
+			scope: func(db *gorm.DB) *gorm.DB {
+				return db.Where("posts.search_vector @@ to_tsquery('simple', ?)", tsquery)
+			},
+			rank: &clause.Expr{SQL: "ts_rank(posts.search_vector, to_tsquery('simple', ?)) DESC", Vars: []interface{}{tsquery}},
+		}
+
+	case database.SearchBackendFTS5:
+		var parts []string
+		for _, term := range query.Terms {
+			parts = append(parts, `"`+term+`"`)
+		}
+		for _, phrase := range query.Phrases {
+			parts = append(parts, `"`+strings.Join(phrase, " ")+`"`)
+		}
+		match := strings.Join(parts, " ")
+		return searchMatch{
+			scope: func(db *gorm.DB) *gorm.DB {
+				return db.Joins("JOIN (SELECT post_id, bm25(posts_fts) AS search_rank FROM posts_fts WHERE posts_fts MATCH ?) AS search_matches ON search_matches.post_id = posts.id", match)
+			},
+			rank: &clause.Expr{SQL: "search_matches.search_rank ASC"},
+		}
+
+	default:
+		return searchMatch{
+			scope: func(db *gorm.DB) *gorm.DB {
+				for _, term := range query.Terms {
+					db = db.Where("LOWER(posts.content) LIKE ?", "%"+term+"%")
+				}
+				for _, phrase := range query.Phrases {
+					db = db.Where("LOWER(posts.content) LIKE ?", "%"+strings.Join(phrase, " ")+"%")
+				}
+				return db
+			},
+		}
+	}
+}
+
+// SearchPosts 全文搜索查看者可见的帖子，返回当前页和总数
+func (s *postService) SearchPosts(viewerID uuid.UUID, opts SearchPostsOptions) ([]models.Post, int64, error) {
+	query := utils.ParseSearchQuery(opts.Query)
+	if query.Empty() {
+		return nil, 0, ErrEmptySearchQuery
+	}
+	if opts.Sort == "" {
+		opts.Sort = SearchSortRelevance
+	}
+	if opts.Sort != SearchSortRelevance && opts.Sort != SearchSortRecent {
+		return nil, 0, ErrInvalidSearchSort
+	}
+	if opts.Since != nil && opts.Until != nil && !opts.Since.Before(*opts.Until) {
+		return nil, 0, ErrInvalidDateRange
+	}
+
+	match := buildSearchMatch(query)
+	filters := func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(match.scope)
+		if opts.Author != "" {
+			db = db.Where("posts.user_id IN (?)", database.DB.Model(&models.User{}).Select("id").Where("username = ?", opts.Author))
+		}
+		if opts.Tag != "" {
+			db = db.Where("posts.id IN (?)", database.DB.Model(&models.PostEntity{}).Select("post_id").
+				Where("type = ? AND value = ?", models.EntityTypeHashtag, opts.Tag))
+		}
+		if opts.Since != nil {
+			db = db.Where("posts.timestamp >= ?", *opts.Since)
+		}
+		if opts.Until != nil {
+			db = db.Where("posts.timestamp < ?", *opts.Until)
+		}
+		return db
+	}
+	visible := visiblePostsScope(viewerID)
+
+	var total int64
+	if err := database.DB.Model(&models.Post{}).Scopes(visible, filters).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	// 排序表达式会覆盖后续追加的排序列，因此时间和ID的次级排序写在同一个表达式里
+	order := clause.Expr{SQL: "posts.timestamp DESC, posts.id DESC"}
+	if opts.Sort == SearchSortRelevance && match.rank != nil {
+		order = clause.Expr{SQL: match.rank.SQL + ", " + order.SQL, Vars: match.rank.Vars}
+	}
+
+	var posts []models.Post
+	offset := (opts.Page - 1) * opts.Limit
+	if err := withPostRelations(database.DB, viewerID).
+		Scopes(visible, filters).
+		Order(clause.OrderBy{Expression: order}).
+		Offset(offset).
+		Limit(opts.Limit).
+		Find(&posts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to search posts: %w", err)
+	}
+
+	return posts, total, nil
+}
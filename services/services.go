@@ -1,40 +1,115 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 	"yolo/database"
+	"yolo/hub"
 	"yolo/models"
+	"yolo/storage"
+	"yolo/utils"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// 全局服务实例 - 仅保留用户管理相关服务
+// 全局服务实例
 var (
-	UserService *userService
-	PostService *postService
+	UserService             *userService
+	PostService             *postService
+	NotificationService     *notificationService
+	MediaService            *mediaService
+	LinkPreviewService      *linkPreviewService
+	PollService             *pollService
+	BookmarkService         *bookmarkService
+	RankingService          *rankingService
+	FederationService       *federationService
+	StockService            *stockService
+	AMMService              *ammService
+	OrderService            *orderService
+	ConditionalOrderService *conditionalOrderService
+	PortfolioService        *portfolioService
+	LedgerService           *ledgerService
+	ModerationService       *moderationService
+	RealtimeHub             *hub.Hub
 	// ==================== 以下服务已停用 ====================
-	// StockService     *stockService
 	// HoldingService   *holdingService
 	// ChartDataService *chartDataService
 	// TradeService     *tradeService
 	// GiftService      *giftService
 )
 
-// InitServices 初始化服务 - 仅初始化用户管理相关服务
+// InitServices 初始化服务
 func InitServices() {
 	UserService = &userService{}
 	PostService = &postService{}
+	filterConfig, err := LoadContentFilterConfig()
+	if err == nil {
+		err = PostService.SetContentFilterConfig(filterConfig)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize content filter: %v", err)
+	}
+	NotificationService = &notificationService{}
+	ModerationService = &moderationService{}
+	PollService = &pollService{}
+	BookmarkService = &bookmarkService{}
+	RankingService = &rankingService{}
+	RealtimeHub = hub.New(hub.DefaultConfig())
+	NotificationService.RegisterDeliverer(publishNotification)
+
+	// 媒体文件默认存储在本地磁盘，通过 /api/v1/media/files 对外提供
+	mediaDir := os.Getenv("MEDIA_STORAGE_DIR")
+	if mediaDir == "" {
+		mediaDir = "uploads"
+	}
+	mediaBaseURL := os.Getenv("MEDIA_BASE_URL")
+	if mediaBaseURL == "" {
+		mediaBaseURL = "/api/v1/media/files"
+	}
+	mediaStorage, err := storage.NewLocal(mediaDir, mediaBaseURL)
+	if err != nil {
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+	MediaService = newMediaService(mediaStorage)
+	MediaService.Start(2)
+	linkPreviewConfig, err := LoadLinkPreviewConfig()
+	if err != nil {
+		log.Fatalf("Failed to initialize link previews: %v", err)
+	}
+	LinkPreviewService = newLinkPreviewService(linkPreviewConfig)
+	LinkPreviewService.Start(2)
+	stockConfig, err := LoadStockConfig()
+	if err != nil {
+		log.Fatalf("Failed to initialize stocks: %v", err)
+	}
+	StockService = newStockService(stockConfig)
+	AMMService = &ammService{}
+	OrderService = &orderService{}
+	ConditionalOrderService = newConditionalOrderService()
+	PortfolioService = &portfolioService{}
+	LedgerService = &ledgerService{}
+	federationConfig, err := LoadFederationConfig()
+	if err == nil {
+		FederationService, err = newFederationService(federationConfig)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize federation: %v", err)
+	}
 	// ==================== 以下服务已停用 ====================
-	// StockService = &stockService{}
 	// HoldingService = &holdingService{}
 	// ChartDataService = &chartDataService{}
 	// TradeService = &tradeService{}
 	// GiftService = &giftService{}
 
-	log.Println("User management services initialized successfully")
+	log.Println("Services initialized successfully")
 }
 
 // ==================== User Service ====================
@@ -171,6 +246,66 @@ func (s *userService) UpdateUserProfile(userID uuid.UUID, name, email string) (*
 	return &user, nil
 }
 
+// 关注相关错误
+var ErrCannotFollowSelf = errors.New("cannot follow yourself")
+
+// Follow 关注用户，重复关注不报错，仅首次关注时通知对方
+func (s *userService) Follow(followerID, followeeID uuid.UUID) error {
+	if followerID == followeeID {
+		return ErrCannotFollowSelf
+	}
+
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now(),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to follow user: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		NotificationService.Notify(NotificationEvent{
+			UserID:  followeeID,
+			ActorID: &followerID,
+			Type:    models.NotificationTypeFollow,
+		})
+	}
+	return nil
+}
+
+// Unfollow 取消关注
+func (s *userService) Unfollow(followerID, followeeID uuid.UUID) error {
+	if err := database.DB.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.Follow{}).Error; err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+	return nil
+}
+
+// IsFollowing 是否已关注
+func (s *userService) IsFollowing(followerID, followeeID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count)
+	return count > 0
+}
+
+// CountFollows 获取关注者数量和关注数量
+func (s *userService) CountFollows(userID uuid.UUID) (followers, following int64) {
+	database.DB.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&followers)
+	database.DB.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&following)
+	return followers, following
+}
+
+// IsCreator 是否为创作者（已发行股票）
+func (s *userService) IsCreator(userID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.Stock{}).Where("user_id = ?", userID).Count(&count)
+	return count > 0
+}
+
 // ==================== 以下用户功能已停用 ====================
 /*
 // ListUser 上市用户 - 已停用
@@ -184,42 +319,589 @@ func (s *userService) ListUser(userID uuid.UUID) error {
 
 // ==================== Post Service ====================
 
-type postService struct{}
+// 帖子相关错误
+var (
+	ErrPostNotFound    = errors.New("post not found")
+	ErrPostForbidden   = errors.New("not allowed to modify this post")
+	ErrAlreadyReposted = errors.New("post already reposted")
+	ErrRepostNotFound  = errors.New("repost not found")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+
+	ErrInvalidVisibility    = errors.New("invalid visibility")
+	ErrHoldersOnlyForbidden = errors.New("only creators can publish holder-only posts")
+	ErrPostNotShareable     = errors.New("only public posts can be reposted or quoted")
+	ErrEmptyPost            = errors.New("post content or media is required")
+)
+
+type postService struct {
+	filter atomic.Pointer[contentFilter]
+}
+
+// validateVisibility 检查可见范围是否合法，仅创作者可以发布持有者可见的帖子
+func validateVisibility(userID uuid.UUID, visibility string) error {
+	switch visibility {
+	case models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityOnlyMe:
+		return nil
+	case models.VisibilityHolders:
+		if !UserService.IsCreator(userID) {
+			return ErrHoldersOnlyForbidden
+		}
+		return nil
+	}
+	return ErrInvalidVisibility
+}
 
-// CreatePost 创建新帖子
+// visiblePostsScope 按查看者过滤已发布的帖子，viewerID 为 uuid.Nil 表示未登录，只能看到公开帖子
+// 草稿、定时帖子和被审核员隐藏的帖子对包括作者在内的所有人都不可见，作者通过草稿接口单独查看草稿
+// 被影子限流的帖子只有作者本人能看到
+func visiblePostsScope(viewerID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("posts.status = ? AND posts.hidden_at IS NULL", models.PostStatusPublished)
+		if viewerID == uuid.Nil {
+			return db.Where("posts.visibility = ? AND posts.limited = ?", models.VisibilityPublic, false)
+		}
+		db = db.Where("(posts.limited = ? OR posts.user_id = ?)", false, viewerID)
+		return db.Where(
+			"(posts.visibility = ? OR posts.user_id = ?"+
+				" OR (posts.visibility = ? AND EXISTS (SELECT 1 FROM follows WHERE follows.followee_id = posts.user_id AND follows.follower_id = ?))"+
+				" OR (posts.visibility = ? AND EXISTS (SELECT 1 FROM user_holdings JOIN stocks ON stocks.id = user_holdings.stock_id"+
+				" WHERE stocks.user_id = posts.user_id AND user_holdings.user_id = ? AND user_holdings.quantity > 0)))",
+			models.VisibilityPublic, viewerID,
+			models.VisibilityFollowers, viewerID,
+			models.VisibilityHolders, viewerID,
+		)
+	}
+}
+
+// withPostRelations 预加载帖子展示所需的关联（作者、转发/引用的原帖及其作者）
+// 原帖被软删除或对查看者不可见时对应关联为nil，由调用方渲染为不可用
+func withPostRelations(db *gorm.DB, viewerID uuid.UUID) *gorm.DB {
+	orderEntities := func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_offset ASC")
+	}
+	orderByPosition := func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}
+	visible := visiblePostsScope(viewerID)
+	db = withPollRelations(db.Preload("Poll"), "Poll.", viewerID)
+	db = withPollRelations(db.Preload("RepostOf.Poll"), "RepostOf.Poll.", viewerID)
+	db = withPollRelations(db.Preload("QuoteOf.Poll"), "QuoteOf.Poll.", viewerID)
+	return db.Preload("User").
+		Preload("Entities", orderEntities).
+		Preload("Media", orderByPosition).
+		Preload("Links", orderByPosition).
+		Preload("Links.Preview").
+		Preload("RepostOf", visible).
+		Preload("RepostOf.User").
+		Preload("RepostOf.Entities", orderEntities).
+		Preload("RepostOf.Media", orderByPosition).
+		Preload("RepostOf.Links", orderByPosition).
+		Preload("RepostOf.Links.Preview").
+		Preload("RepostOf.QuoteOf", visible).
+		Preload("RepostOf.QuoteOf.User").
+		Preload("QuoteOf", visible).
+		Preload("QuoteOf.User").
+		Preload("QuoteOf.Entities", orderEntities).
+		Preload("QuoteOf.Media", orderByPosition).
+		Preload("QuoteOf.Links", orderByPosition).
+		Preload("QuoteOf.Links.Preview")
+}
+
+// canView 查看者能否看到指定帖子
+func (s *postService) canView(db *gorm.DB, viewerID, postID uuid.UUID) bool {
+	var count int64
+	db.Model(&models.Post{}).Scopes(visiblePostsScope(viewerID)).Where("posts.id = ?", postID).Count(&count)
+	return count > 0
+}
+
+// createPostWithEntities 在事务中创建帖子并保存解析出的话题、提及和股票符号
+func (s *postService) createPostWithEntities(tx *gorm.DB, post *models.Post) error {
+	if err := tx.Create(post).Error; err != nil {
+		return err
+	}
+	return s.saveEntities(tx, post)
+}
+
+// saveEntities 解析并保存帖子内容中的实体
+func (s *postService) saveEntities(tx *gorm.DB, post *models.Post) error {
+	parsed := utils.ExtractEntities(post.Content)
+	if len(parsed) == 0 {
+		return nil
+	}
+
+	// 仅保留能匹配到用户的提及
+	var usernames []string
+	for _, entity := range parsed {
+		if entity.Type == models.EntityTypeMention {
+			usernames = append(usernames, entity.Value)
+		}
+	}
+	mentioned := make(map[string]uuid.UUID)
+	if len(usernames) > 0 {
+		var users []models.User
+		if err := tx.Select("id", "username").Where("username IN ?", usernames).Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			mentioned[user.Username] = user.ID
+		}
+	}
+
+	var entities []models.PostEntity
+	for _, entity := range parsed {
+		record := models.PostEntity{
+			PostID:      post.ID,
+			Type:        entity.Type,
+			Value:       entity.Value,
+			StartOffset: entity.Start,
+			EndOffset:   entity.End,
+			CreatedAt:   post.CreatedAt,
+		}
+		if entity.Type == models.EntityTypeMention {
+			userID, ok := mentioned[entity.Value]
+			if !ok {
+				continue
+			}
+			record.MentionedUserID = &userID
+		}
+		entities = append(entities, record)
+	}
+	if len(entities) == 0 {
+		return nil
+	}
+	return tx.Create(&entities).Error
+}
+
+// resolveOriginalPost 获取可被转发/引用的原帖，转发贴会被解析为其原帖
+func (s *postService) resolveOriginalPost(tx *gorm.DB, postID uuid.UUID) (*models.Post, error) {
+	var post models.Post
+	if err := tx.Where("id = ?", postID).First(&post).Error; err != nil {
+		return nil, ErrPostNotFound
+	}
+	if post.RepostOfID != nil {
+		return s.resolveOriginalPost(tx, *post.RepostOfID)
+	}
+	return &post, nil
+}
+
+// resolveShareablePost 获取可被转发/引用的原帖，只有公开帖子可以分享
+func (s *postService) resolveShareablePost(tx *gorm.DB, userID, postID uuid.UUID) (*models.Post, error) {
+	original, err := s.resolveOriginalPost(tx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Visibility != models.VisibilityPublic || original.Status != models.PostStatusPublished {
+		// 看不到的帖子视为不存在，避免泄露其存在
+		if !s.canView(tx, userID, original.ID) {
+			return nil, ErrPostNotFound
+		}
+		return nil, ErrPostNotShareable
+	}
+	return original, nil
+}
+
+// loadAndNotify 加载作者新建的帖子，已发布时推送事件并发送通知；草稿和定时帖子在发布时再通知
+func (s *postService) loadAndNotify(authorID, postID uuid.UUID) (*models.Post, error) {
+	post, err := s.getOwnPost(authorID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Status == models.PostStatusPublished {
+		s.announce(post)
+	}
+	return post, nil
+}
+
+// getOwnPost 获取作者自己的帖子（包括草稿和定时帖子）
+func (s *postService) getOwnPost(authorID, postID uuid.UUID) (*models.Post, error) {
+	var post models.Post
+	if err := withPostRelations(database.DB, authorID).
+		Where("posts.id = ? AND posts.user_id = ?", postID, authorID).
+		First(&post).Error; err != nil {
+		return nil, ErrPostNotFound
+	}
+	return &post, nil
+}
+
+// announce 帖子发布后推送实时事件，并通知被提及、被转发或被引用的用户
+// 被提及的用户看不到该帖子时不会收到通知
+func (s *postService) announce(post *models.Post) {
+	if post.Limited {
+		return
+	}
+	publishPostEvent(EventPostCreated, post)
+
+	notified := make(map[uuid.UUID]bool)
+	for _, entity := range post.Entities {
+		if entity.Type != models.EntityTypeMention || entity.MentionedUserID == nil || notified[*entity.MentionedUserID] {
+			continue
+		}
+		if !s.canView(database.DB, *entity.MentionedUserID, post.ID) {
+			continue
+		}
+		notified[*entity.MentionedUserID] = true
+		NotificationService.Notify(NotificationEvent{
+			UserID:  *entity.MentionedUserID,
+			ActorID: &post.UserID,
+			Type:    models.NotificationTypeMention,
+			PostID:  &post.ID,
+		})
+	}
+	if post.RepostOf != nil {
+		NotificationService.Notify(NotificationEvent{
+			UserID:  post.RepostOf.UserID,
+			ActorID: &post.UserID,
+			Type:    models.NotificationTypeRepost,
+			PostID:  &post.RepostOf.ID,
+		})
+	}
+	if post.QuoteOf != nil {
+		NotificationService.Notify(NotificationEvent{
+			UserID:  post.QuoteOf.UserID,
+			ActorID: &post.UserID,
+			Type:    models.NotificationTypeQuote,
+			PostID:  &post.ID,
+		})
+	}
+}
+
+// CreatePostOptions 创建帖子的参数
+type CreatePostOptions struct {
+	Content     string
+	Visibility  string      // 为空时默认公开
+	QuotePostID *uuid.UUID  // 引用的帖子
+	MediaIDs    []uuid.UUID // 已上传的媒体，按顺序附加，最多 MaxPostMedia 个
+	Draft       bool        // 保存为草稿，仅作者可见
+	PublishAt   *time.Time  // 定时发布时间，不能与草稿同时使用
+	Poll        *NewPoll    // 附带的投票
+}
+
+// CreatePost 创建新的公开帖子
 func (s *postService) CreatePost(userID uuid.UUID, content string) (*models.Post, error) {
+	return s.CreatePostWithOptions(userID, CreatePostOptions{Content: content})
+}
+
+// CreateQuotePost 创建公开的引用帖子（新内容 + 嵌入原帖）
+func (s *postService) CreateQuotePost(userID uuid.UUID, content string, quotedPostID uuid.UUID) (*models.Post, error) {
+	return s.CreatePostWithOptions(userID, CreatePostOptions{Content: content, QuotePostID: &quotedPostID})
+}
+
+// CreatePostWithOptions 按参数创建帖子
+func (s *postService) CreatePostWithOptions(userID uuid.UUID, opts CreatePostOptions) (*models.Post, error) {
+	if err := checkNotSuspended(userID); err != nil {
+		return nil, err
+	}
+	visibility := opts.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+	if err := validateVisibility(userID, visibility); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(opts.Content) == "" && len(opts.MediaIDs) == 0 {
+		return nil, ErrEmptyPost
+	}
+	if len(opts.MediaIDs) > MaxPostMedia {
+		return nil, ErrTooManyMedia
+	}
+
+	verdict, err := s.filter.Load().Check(database.DB, userID, opts.Content, nil)
+	if err != nil {
+		return nil, err
+	}
+	if verdict.Action == FilterActionReject {
+		return nil, &FilterError{verdict}
+	}
+
 	post := &models.Post{
-		UserID:    userID,
-		Content:   content,
-		Timestamp: time.Now(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserID:     userID,
+		Content:    opts.Content,
+		Visibility: visibility,
+		Status:     models.PostStatusPublished,
+		Timestamp:  time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	switch {
+	case opts.Draft && opts.PublishAt != nil:
+		return nil, ErrInvalidSchedule
+	case opts.Draft:
+		post.Status = models.PostStatusDraft
+	case opts.PublishAt != nil:
+		if err := validatePublishAt(*opts.PublishAt); err != nil {
+			return nil, err
+		}
+		post.Status = models.PostStatusScheduled
+		post.PublishAt = opts.PublishAt
+		post.Timestamp = *opts.PublishAt
+	}
+
+	// 草稿的投票截止时间从保存时起算
+	var pollOptions []string
+	if opts.Poll != nil {
+		if pollOptions, err = validatePoll(userID, opts.Poll, post.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	switch verdict.Action {
+	case FilterActionHold:
+		post.Status = models.PostStatusHeld
+		post.PublishAt = nil
+	case FilterActionLimit:
+		post.Limited = true
 	}
 
-	if err := database.DB.Create(post).Error; err != nil {
+	if opts.QuotePostID != nil {
+		original, err := s.resolveShareablePost(database.DB, userID, *opts.QuotePostID)
+		if err != nil {
+			return nil, err
+		}
+		post.QuoteOfID = &original.ID
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.createPostWithEntities(tx, post); err != nil {
+			return err
+		}
+		if err := MediaService.attachToPost(tx, userID, post.ID, opts.MediaIDs); err != nil {
+			return err
+		}
+		if err := LinkPreviewService.attachToPost(tx, post.ID, post.Content); err != nil {
+			return err
+		}
+		if opts.Poll != nil {
+			if err := PollService.createForPost(tx, post.ID, opts.Poll, pollOptions); err != nil {
+				return err
+			}
+		}
+		if post.Status == models.PostStatusHeld {
+			return ModerationService.holdForReview(tx, post, verdict)
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, ErrMediaNotFound) || errors.Is(err, ErrMediaUnavailable) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
+	LinkPreviewService.fetchForPost(post.ID)
 
-	// 预加载用户信息
-	if err := database.DB.Preload("User").First(post, post.ID).Error; err != nil {
-		return nil, fmt.Errorf("failed to load post with user: %w", err)
+	return s.loadAndNotify(userID, post.ID)
+}
+
+// Repost 转发帖子，同一用户对同一原帖只能转发一次
+func (s *postService) Repost(userID, postID uuid.UUID) (*models.Post, error) {
+	if err := checkNotSuspended(userID); err != nil {
+		return nil, err
+	}
+	// 转发没有内容，只会命中频率限制
+	verdict, err := s.filter.Load().Check(database.DB, userID, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if verdict.Action == FilterActionReject || verdict.Action == FilterActionHold {
+		return nil, &FilterError{verdict}
 	}
 
-	return post, nil
+	var repost *models.Post
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		original, err := s.resolveShareablePost(tx, userID, postID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		tx.Model(&models.Post{}).
+			Where("user_id = ? AND repost_of_id = ?", userID, original.ID).
+			Count(&count)
+		if count > 0 {
+			return ErrAlreadyReposted
+		}
+
+		repost = &models.Post{
+			UserID:     userID,
+			RepostOfID: &original.ID,
+			Visibility: models.VisibilityPublic,
+			Status:     models.PostStatusPublished,
+			Limited:    verdict.Action == FilterActionLimit,
+			Timestamp:  time.Now(),
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		if err := tx.Create(repost).Error; err != nil {
+			return fmt.Errorf("failed to create repost: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loadAndNotify(userID, repost.ID)
 }
 
-// GetTimeline 获取时间线帖子
-func (s *postService) GetTimeline(page, limit int) ([]models.Post, int64, error) {
+// Unrepost 取消转发，转发记录直接物理删除以便之后重新转发
+func (s *postService) Unrepost(userID, postID uuid.UUID) error {
+	original, err := s.resolveOriginalPost(database.DB.Unscoped(), postID)
+	if err != nil {
+		return err
+	}
+
+	var repost models.Post
+	if err := database.DB.Where("user_id = ? AND repost_of_id = ?", userID, original.ID).First(&repost).Error; err != nil {
+		return ErrRepostNotFound
+	}
+	if err := database.DB.Unscoped().Delete(&repost).Error; err != nil {
+		return fmt.Errorf("failed to delete repost: %w", err)
+	}
+
+	publishPostEvent(EventPostDeleted, &repost)
+	return nil
+}
+
+// DeletePost 删除帖子（软删除），仅作者本人可删除
+func (s *postService) DeletePost(userID, postID uuid.UUID) error {
+	var post models.Post
+	if err := database.DB.Where("id = ?", postID).First(&post).Error; err != nil {
+		return ErrPostNotFound
+	}
+	if post.UserID != userID {
+		return ErrPostForbidden
+	}
+
+	// 转发记录没有自身内容，直接物理删除
+	db := database.DB
+	if post.RepostOfID != nil {
+		db = db.Unscoped()
+	}
+	if err := db.Delete(&post).Error; err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+
+	publishPostEvent(EventPostDeleted, &post)
+	return nil
+}
+
+// GetPostByID 获取查看者可见的帖子，不可见时与不存在一样返回 ErrPostNotFound
+func (s *postService) GetPostByID(viewerID, postID uuid.UUID) (*models.Post, error) {
+	var post models.Post
+	if err := withPostRelations(database.DB, viewerID).
+		Scopes(visiblePostsScope(viewerID)).
+		Where("posts.id = ?", postID).
+		First(&post).Error; err != nil {
+		return nil, ErrPostNotFound
+	}
+	return &post, nil
+}
+
+// PostCursorPage 帖子游标分页结果
+type PostCursorPage struct {
+	Posts      []models.Post
+	NextCursor string // 更旧一页的游标，没有更多时为空
+	PrevCursor string // 更新一页的游标，可用于拉取新帖子
+	Total      *int64 // 仅在请求时统计
+}
+
+// paginatePosts 基于 (timestamp, id) 的键集分页，避免 OFFSET 扫描以及新帖插入导致的重复/遗漏
+// 结果只包含查看者可见的帖子
+func (s *postService) paginatePosts(viewerID uuid.UUID, scope func(db *gorm.DB) *gorm.DB, encodedCursor string, limit int, withTotal bool) (*PostCursorPage, error) {
+	direction := utils.CursorNext
+	visible := visiblePostsScope(viewerID)
+	query := withPostRelations(database.DB, viewerID).Scopes(scope, visible)
+
+	if encodedCursor != "" {
+		cursor, err := utils.DecodeCursor(encodedCursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		direction = cursor.Direction
+		if direction == utils.CursorNext {
+			query = query.Where("(timestamp < ? OR (timestamp = ? AND id < ?))", cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		} else {
+			query = query.Where("(timestamp > ? OR (timestamp = ? AND id > ?))", cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		}
+	}
+
+	if direction == utils.CursorNext {
+		query = query.Order("timestamp DESC").Order("id DESC")
+	} else {
+		query = query.Order("timestamp ASC").Order("id ASC")
+	}
+
+	// 多取一条用于判断是否还有更多
+	var posts []models.Post
+	if err := query.Limit(limit + 1).Find(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+
+	// 向前翻页时按时间倒序返回
+	if direction == utils.CursorPrev {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+
+	page := &PostCursorPage{Posts: posts}
+	if len(posts) > 0 {
+		first, last := posts[0], posts[len(posts)-1]
+		page.PrevCursor = utils.EncodeCursor(first.Timestamp, first.ID, utils.CursorPrev)
+		if direction == utils.CursorPrev || hasMore {
+			page.NextCursor = utils.EncodeCursor(last.Timestamp, last.ID, utils.CursorNext)
+		}
+	} else if direction == utils.CursorPrev {
+		// 暂无更新的内容，保留原游标供客户端继续轮询
+		page.PrevCursor = encodedCursor
+	}
+
+	if withTotal {
+		var total int64
+		if err := database.DB.Model(&models.Post{}).Scopes(scope, visible).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count posts: %w", err)
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// GetTimelineByCursor 使用游标分页获取时间线
+func (s *postService) GetTimelineByCursor(viewerID uuid.UUID, cursor string, limit int, withTotal bool) (*PostCursorPage, error) {
+	return s.paginatePosts(viewerID, func(db *gorm.DB) *gorm.DB {
+		return db
+	}, cursor, limit, withTotal)
+}
+
+// GetUserPostsByCursor 使用游标分页获取用户的帖子
+func (s *postService) GetUserPostsByCursor(viewerID, userID uuid.UUID, cursor string, limit int, withTotal bool) (*PostCursorPage, error) {
+	return s.paginatePosts(viewerID, func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
+	}, cursor, limit, withTotal)
+}
+
+// GetTimeline 获取时间线帖子（包含转发和引用），页码分页，保留以兼容旧客户端
+func (s *postService) GetTimeline(viewerID uuid.UUID, page, limit int) ([]models.Post, int64, error) {
 	var posts []models.Post
 	var total int64
+	visible := visiblePostsScope(viewerID)
 
 	// 计算总数
-	database.DB.Model(&models.Post{}).Count(&total)
+	if err := database.DB.Model(&models.Post{}).Scopes(visible).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+	}
 
 	// 分页查询
 	offset := (page - 1) * limit
-	if err := database.DB.Preload("User").
+	if err := withPostRelations(database.DB, viewerID).
+		Scopes(visible).
 		Order("timestamp DESC").
+		Order("id DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&posts).Error; err != nil {
@@ -229,19 +911,24 @@ func (s *postService) GetTimeline(page, limit int) ([]models.Post, int64, error)
 	return posts, total, nil
 }
 
-// GetUserPosts 获取用户的帖子
-func (s *postService) GetUserPosts(userID uuid.UUID, page, limit int) ([]models.Post, int64, error) {
+// GetUserPosts 获取用户的帖子（包含该用户的转发和引用），页码分页，保留以兼容旧客户端
+func (s *postService) GetUserPosts(viewerID, userID uuid.UUID, page, limit int) ([]models.Post, int64, error) {
 	var posts []models.Post
 	var total int64
+	visible := visiblePostsScope(viewerID)
 
 	// 计算总数
-	database.DB.Model(&models.Post{}).Where("user_id = ?", userID).Count(&total)
+	if err := database.DB.Model(&models.Post{}).Scopes(visible).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+	}
 
 	// 分页查询
 	offset := (page - 1) * limit
-	if err := database.DB.Preload("User").
+	if err := withPostRelations(database.DB, viewerID).
+		Scopes(visible).
 		Where("user_id = ?", userID).
 		Order("timestamp DESC").
+		Order("id DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&posts).Error; err != nil {
@@ -251,9 +938,39 @@ func (s *postService) GetUserPosts(userID uuid.UUID, page, limit int) ([]models.
 	return posts, total, nil
 }
 
+// GetPostsByEntity 获取包含指定话题/股票符号的帖子
+func (s *postService) GetPostsByEntity(viewerID uuid.UUID, entityType, value string, page, limit int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var total int64
+	visible := visiblePostsScope(viewerID)
+
+	postIDs := database.DB.Model(&models.PostEntity{}).
+		Select("post_id").
+		Where("type = ? AND value = ?", entityType, value)
+
+	// 计算总数
+	if err := database.DB.Model(&models.Post{}).Scopes(visible).Where("id IN (?)", postIDs).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count %s posts: %w", entityType, err)
+	}
+
+	// 分页查询
+	offset := (page - 1) * limit
+	if err := withPostRelations(database.DB, viewerID).
+		Scopes(visible).
+		Where("id IN (?)", postIDs).
+		Order("timestamp DESC").
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get %s posts: %w", entityType, err)
+	}
+
+	return posts, total, nil
+}
+
 // ==================== 以下服务已全部停用 ====================
 /*
-// ==================== Stock Service ====================
 // ==================== Holding Service ====================
 // ==================== Chart Data Service ====================
 // ==================== Trade Service ====================
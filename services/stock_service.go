@@ -0,0 +1,327 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+	"yolo/database"
+	"yolo/decimal"
+	"yolo/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 股票限制
+const (
+	MaxStockNameLen        = 100
+	MaxStockDescriptionLen = 2000
+)
+
+// 发行参数
+var (
+	DefaultStockSupply = decimal.FromInt(1000000)
+	DefaultStockPrice  = decimal.FromInt(1) // 发行价，流动性池按此价格注入 YOLO
+)
+
+// 股票相关错误
+var (
+	ErrStocksDisabled       = errors.New("stocks are disabled")
+	ErrStockNotFound        = errors.New("stock not found")
+	ErrInvalidSymbol        = errors.New("symbol must be 1-10 letters or digits and start with a letter")
+	ErrSymbolReserved       = errors.New("symbol is reserved")
+	ErrSymbolExists         = errors.New("symbol already exists")
+	ErrInvalidStockName     = errors.New("name must be 1-100 characters")
+	ErrInvalidStockSupply   = errors.New("supply must be positive")
+	ErrStockDescriptionLong = errors.New("description is too long")
+	ErrInvalidCategory      = errors.New("invalid category")
+	ErrInvalidStockSort     = errors.New("invalid sort")
+)
+
+// StockCategories 可选的股票分类
+var StockCategories = []string{
+	"art", "gaming", "memberships", "music", "pfps", "photography",
+	"domain_names", "sports_collectibles", "virtual_worlds",
+}
+
+// DefaultReservedSymbols 默认保留的股票符号：平台代币、常见法币和加密货币以及容易混淆的词
+var DefaultReservedSymbols = []string{
+	"YOLO", "INJ", "USD", "USDT", "USDC", "EUR", "CNY", "BTC", "ETH",
+	"ADMIN", "ROOT", "SYSTEM", "API", "NULL", "NONE",
+}
+
+// symbolPattern 股票符号格式，与帖子中 $SYMBOL 的解析规则一致
+var symbolPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}$`)
+
+// stockSortColumns 列表支持的排序字段，键与响应中的字段名一致
+var stockSortColumns = map[string]string{
+	"createdAt":   "created_at",
+	"price":       "price",
+	"dailyChange": "daily_change",
+	"dailyVolume": "daily_volume",
+	"marketCap":   "market_cap",
+	"owners":      "owners",
+	"name":        "name",
+	"symbol":      "symbol",
+}
+
+// MaxTradeFeeRate 手续费比例上限
+var MaxTradeFeeRate = decimal.RequireFromString("0.1")
+
+// StockConfig 股票功能配置
+type StockConfig struct {
+	Enabled         bool
+	ReservedSymbols []string
+	TradeFeeRate    decimal.Decimal // 与流动性池成交时按 YOLO 金额收取的手续费比例，默认不收取
+	QuoteTTL        time.Duration   // 签名报价的有效期
+	QuoteSecret     []byte          // 签名报价的密钥，多实例部署时需要一致；为空时启动时随机生成
+}
+
+// DefaultStockConfig 默认股票配置，默认关闭
+func DefaultStockConfig() StockConfig {
+	return StockConfig{ReservedSymbols: DefaultReservedSymbols, QuoteTTL: 15 * time.Second}
+}
+
+// LoadStockConfig 从环境变量读取股票配置，STOCK_RESERVED_SYMBOLS 追加在默认保留符号之后
+func LoadStockConfig() (StockConfig, error) {
+	cfg := DefaultStockConfig()
+
+	var err error
+	if value := os.Getenv("STOCKS_ENABLED"); value != "" {
+		if cfg.Enabled, err = strconv.ParseBool(value); err != nil {
+			return cfg, fmt.Errorf("invalid STOCKS_ENABLED: %w", err)
+		}
+	}
+	if value := os.Getenv("STOCK_TRADE_FEE_RATE"); value != "" {
+		if cfg.TradeFeeRate, err = decimal.Parse(value); err != nil {
+			return cfg, fmt.Errorf("invalid STOCK_TRADE_FEE_RATE: %w", err)
+		}
+		if cfg.TradeFeeRate.IsNegative() || cfg.TradeFeeRate.GreaterThan(MaxTradeFeeRate) {
+			return cfg, fmt.Errorf("invalid STOCK_TRADE_FEE_RATE: must be between 0 and %s", MaxTradeFeeRate)
+		}
+	}
+	if value := os.Getenv("STOCK_QUOTE_TTL"); value != "" {
+		if cfg.QuoteTTL, err = time.ParseDuration(value); err != nil || cfg.QuoteTTL <= 0 {
+			return cfg, fmt.Errorf("invalid STOCK_QUOTE_TTL: %q", value)
+		}
+	}
+	if value := os.Getenv("STOCK_QUOTE_SECRET"); value != "" {
+		cfg.QuoteSecret = []byte(value)
+	}
+	if value := os.Getenv("STOCK_RESERVED_SYMBOLS"); value != "" {
+		reserved := append([]string{}, cfg.ReservedSymbols...)
+		for _, symbol := range strings.Split(value, ",") {
+			if symbol = strings.TrimSpace(symbol); symbol != "" {
+				reserved = append(reserved, symbol)
+			}
+		}
+		cfg.ReservedSymbols = reserved
+	}
+	return cfg, nil
+}
+
+// stockState 当前生效的配置，整体替换
+type stockState struct {
+	config   StockConfig
+	reserved map[string]bool
+}
+
+type stockService struct {
+	state atomic.Pointer[stockState]
+}
+
+// newStockService 创建股票服务
+func newStockService(cfg StockConfig) *stockService {
+	s := &stockService{}
+	s.SetConfig(cfg)
+	return s
+}
+
+// SetConfig 替换股票配置，未设置报价密钥时随机生成
+func (s *stockService) SetConfig(cfg StockConfig) {
+	reserved := make(map[string]bool, len(cfg.ReservedSymbols))
+	for _, symbol := range cfg.ReservedSymbols {
+		reserved[strings.ToUpper(symbol)] = true
+	}
+	if len(cfg.QuoteSecret) == 0 {
+		cfg.QuoteSecret = make([]byte, 32)
+		if _, err := rand.Read(cfg.QuoteSecret); err != nil {
+			panic(fmt.Sprintf("failed to generate quote secret: %v", err))
+		}
+	}
+	s.state.Store(&stockState{config: cfg, reserved: reserved})
+}
+
+// Config 当前的股票配置
+func (s *stockService) Config() StockConfig {
+	return s.state.Load().config
+}
+
+// Enabled 是否开启股票功能
+func (s *stockService) Enabled() bool {
+	return s.state.Load().config.Enabled
+}
+
+// NormalizeSymbol 归一化股票符号（去掉前缀$并转大写）
+func NormalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(symbol), "$"))
+}
+
+// NormalizeCategory 归一化分类，"Domain Names" 与 "domain_names" 等价
+func NormalizeCategory(category string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(category)), " ", "_")
+}
+
+// isStockCategory 是否为可选的分类
+func isStockCategory(category string) bool {
+	for _, c := range StockCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateStockOptions 创建股票的参数
+type CreateStockOptions struct {
+	Symbol      string
+	Name        string
+	Category    string
+	Supply      decimal.Decimal // 为0时使用默认发行量
+	Description string
+	Image       string
+}
+
+// CreateStock 创建股票并注入流动性池，符号归一化为大写且不能与已有或保留的符号重复
+func (s *stockService) CreateStock(userID uuid.UUID, opts CreateStockOptions) (*models.Stock, error) {
+	state := s.state.Load()
+	if !state.config.Enabled {
+		return nil, ErrStocksDisabled
+	}
+
+	symbol := NormalizeSymbol(opts.Symbol)
+	if !symbolPattern.MatchString(symbol) {
+		return nil, ErrInvalidSymbol
+	}
+	if state.reserved[symbol] {
+		return nil, ErrSymbolReserved
+	}
+	name := strings.TrimSpace(opts.Name)
+	if name == "" || utf8.RuneCountInString(name) > MaxStockNameLen {
+		return nil, ErrInvalidStockName
+	}
+	category := NormalizeCategory(opts.Category)
+	if category != "" && !isStockCategory(category) {
+		return nil, ErrInvalidCategory
+	}
+	supply := opts.Supply
+	if supply.IsZero() {
+		supply = DefaultStockSupply
+	}
+	if supply.IsNegative() {
+		return nil, ErrInvalidStockSupply
+	}
+	if utf8.RuneCountInString(opts.Description) > MaxStockDescriptionLen {
+		return nil, ErrStockDescriptionLong
+	}
+
+	stock := &models.Stock{
+		UserID:    userID,
+		Name:      name,
+		Symbol:    symbol,
+		Category:  category,
+		Price:     DefaultStockPrice,
+		MarketCap: DefaultStockPrice.Mul(supply, decimal.RoundHalfEven),
+		Supply:    supply,
+	}
+	if description := strings.TrimSpace(opts.Description); description != "" {
+		stock.Description = &description
+	}
+	if image := strings.TrimSpace(opts.Image); image != "" {
+		stock.Image = &image
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Stock{}).Where("symbol = ?", symbol).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSymbolExists
+		}
+		if err := tx.Create(stock).Error; err != nil {
+			return err
+		}
+		return createPool(tx, stock)
+	})
+	if err != nil {
+		if errors.Is(err, ErrSymbolExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create stock: %w", err)
+	}
+	return stock, nil
+}
+
+// StockListOptions 股票列表筛选和排序
+type StockListOptions struct {
+	Category string // 为空或 all 时不筛选
+	SortBy   string // 见 stockSortColumns，默认 createdAt
+	Order    string // asc / desc，默认 desc
+}
+
+// GetStocks 获取股票列表
+func (s *stockService) GetStocks(opts StockListOptions) ([]models.Stock, error) {
+	if !s.Enabled() {
+		return nil, ErrStocksDisabled
+	}
+
+	query := database.DB.Model(&models.Stock{})
+	if category := NormalizeCategory(opts.Category); category != "" && category != "all" {
+		if !isStockCategory(category) {
+			return nil, ErrInvalidCategory
+		}
+		query = query.Where("category = ?", category)
+	}
+
+	column := "created_at"
+	if opts.SortBy != "" {
+		var ok bool
+		if column, ok = stockSortColumns[opts.SortBy]; !ok {
+			return nil, ErrInvalidStockSort
+		}
+	}
+	direction := "DESC"
+	switch strings.ToLower(opts.Order) {
+	case "", "desc":
+	case "asc":
+		direction = "ASC"
+	default:
+		return nil, ErrInvalidStockSort
+	}
+
+	var stocks []models.Stock
+	if err := query.Order(column + " " + direction + ", id " + direction).Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stocks: %w", err)
+	}
+	return stocks, nil
+}
+
+// GetStockBySymbol 根据符号获取股票，不区分大小写
+func (s *stockService) GetStockBySymbol(symbol string) (*models.Stock, error) {
+	if !s.Enabled() {
+		return nil, ErrStocksDisabled
+	}
+	var stock models.Stock
+	if err := database.DB.Preload("User").Where("symbol = ?", NormalizeSymbol(symbol)).First(&stock).Error; err != nil {
+		return nil, ErrStockNotFound
+	}
+	return &stock, nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound 文件不存在
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidKey 非法的文件key
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Storage 媒体文件存储抽象，可替换为本地磁盘、对象存储等实现
+type Storage interface {
+	// Put 写入文件，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Open 读取文件，不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// URL 返回客户端访问文件的地址
+	URL(key string) string
+}
+
+// CleanKey 校验并规范化文件key，拒绝绝对路径和目录穿越
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,313 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/hub"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// AMMTestSuite 流动性池交易测试套件
+type AMMTestSuite struct {
+	suite.Suite
+	router  *gin.Engine
+	db      *gorm.DB
+	creator *models.User
+	trader  *models.User
+	stock   *models.Stock
+}
+
+// SetupSuite 测试套件初始化
+func (suite *AMMTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *AMMTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *AMMTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	services.StockService.SetConfig(cfg)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.trader, err = services.UserService.CreateUser("Tina", "tina", "tina@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stock, err = services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "SAM", Name: "Sam"})
+	suite.Require().NoError(err)
+}
+
+// pool 当前流动性池
+func (suite *AMMTestSuite) pool() models.LiquidityPool {
+	var pool models.LiquidityPool
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	return pool
+}
+
+// holding 用户持股数
+func (suite *AMMTestSuite) holding(userID uuid.UUID) decimal.Decimal {
+	var quantity decimal.Decimal
+	suite.Require().NoError(suite.db.Model(&models.UserHolding{}).Where("user_id = ? AND stock_id = ?", userID, suite.stock.ID).
+		Select("COALESCE(SUM(quantity), 0)").Row().Scan(&quantity))
+	return quantity
+}
+
+// TestPoolCreatedWithStock 测试发行股票时按发行价注入流动性池并分配创作者份额
+func (suite *AMMTestSuite) TestPoolCreatedWithStock() {
+	pool := suite.pool()
+	assert.Equal(suite.T(), decimal.FromInt(650000), pool.StockReserve)
+	assert.Equal(suite.T(), decimal.FromInt(650000), pool.YoloReserve)
+	assert.Equal(suite.T(), decimal.FromInt(350000), suite.holding(suite.creator.ID))
+
+	detail, err := services.StockService.GetStockBySymbol("SAM")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), decimal.FromInt(1), detail.Price)
+	assert.Equal(suite.T(), decimal.FromInt(1000000), detail.MarketCap)
+	assert.Equal(suite.T(), 1, detail.Owners)
+}
+
+// TestQuote 测试报价按恒定乘积计算且不修改池子
+func (suite *AMMTestSuite) TestQuote() {
+	w := performRequest(suite.router, "GET", "/api/v1/stocks/sam/quote?type=buy&amount=6500", nil, suite.trader)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var quote controllers.TradeQuoteResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &quote))
+	// 650000 × 6500 / 656500，向下舍入到 6 位小数
+	assert.Equal(suite.T(), decimal.RequireFromString("6435.643564"), quote.AmountOut)
+	assert.Equal(suite.T(), decimal.RequireFromString("1.01"), quote.Price)
+	assert.Equal(suite.T(), decimal.FromInt(1), quote.MidPrice)
+	assert.Equal(suite.T(), decimal.FromInt(1), quote.PriceImpact)
+
+	sell, err := services.AMMService.Quote("SAM", "SELL", decimal.FromInt(6500))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), decimal.RequireFromString("6435.643564"), sell.AmountOut)
+	assert.Equal(suite.T(), decimal.RequireFromString("0.990099"), sell.PriceImpact)
+	assert.Equal(suite.T(), decimal.FromInt(650000), suite.pool().YoloReserve)
+
+	for _, query := range []string{"?type=hold&amount=1", "?type=buy&amount=-1", "?type=buy&amount=abc", "?type=buy&amount=0.0000001"} {
+		w = performRequest(suite.router, "GET", "/api/v1/stocks/SAM/quote"+query, nil, suite.trader)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// TestBuyAndSell 测试买入卖出同时更新池子、余额、持仓、成交记录和股价
+func (suite *AMMTestSuite) TestBuyAndSell() {
+	sub, _, _ := services.RealtimeHub.Subscribe([]string{hub.StockChannel("SAM")}, "")
+	defer sub.Close()
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"type": "buy", "amount": 6500}, suite.trader)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var bought controllers.TradeResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &bought))
+	assert.Equal(suite.T(), "SAM", bought.Symbol)
+	assert.Equal(suite.T(), decimal.RequireFromString("6435.643564"), bought.Shares)
+	assert.Equal(suite.T(), decimal.FromInt(1500), bought.Balance)
+	// 656500 / 643564.356436
+	assert.Equal(suite.T(), decimal.RequireFromString("1.0201"), bought.NewPrice)
+	assert.Equal(suite.T(), bought.Shares, suite.holding(suite.trader.ID))
+
+	event := <-sub.Events()
+	assert.Equal(suite.T(), services.EventStockPrice, event.Type)
+
+	pool := suite.pool()
+	assert.Equal(suite.T(), decimal.FromInt(656500), pool.YoloReserve)
+	assert.Equal(suite.T(), decimal.FromInt(650000).Sub(bought.Shares), pool.StockReserve)
+
+	detail, err := services.StockService.GetStockBySymbol("SAM")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, detail.Owners)
+	assert.Equal(suite.T(), bought.NewPrice, detail.Price)
+	assert.Equal(suite.T(), decimal.FromInt(1020100), detail.MarketCap)
+
+	var trade models.Trade
+	suite.Require().NoError(suite.db.Where("id = ?", bought.ID).First(&trade).Error)
+	suite.Require().NotNil(trade.BuyerID)
+	assert.Equal(suite.T(), suite.trader.ID, *trade.BuyerID)
+	assert.Nil(suite.T(), trade.SellerID)
+
+	// 余额和持仓不足时拒绝
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/SAM/trade", map[string]interface{}{"type": "buy", "amount": 1500.01}, suite.trader)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/SAM/trade", map[string]interface{}{"type": "sell", "amount": bought.Shares.Add(decimal.FromInt(1))}, suite.trader)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// 全部卖回，没有手续费，取回的 YOLO 只因向下舍入少一个最小单位，零头留在池中
+	result, err := services.AMMService.Trade(suite.trader.ID, "SAM", services.TradeOptions{Type: "sell", AmountIn: bought.Shares})
+	suite.Require().NoError(err)
+	assert.True(suite.T(), result.Holding.IsZero())
+	assert.Equal(suite.T(), decimal.RequireFromString("7999.999999"), result.Balance)
+	assert.Equal(suite.T(), decimal.RequireFromString("650000.000001"), result.Pool.YoloReserve)
+	suite.Require().NotNil(result.Trade.SellerID)
+	assert.Nil(suite.T(), result.Trade.BuyerID)
+
+	detail, err = services.StockService.GetStockBySymbol("SAM")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, detail.Owners)
+
+	w = performRequest(suite.router, "GET", "/api/v1/user/balance", nil, suite.trader)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"balance":`)
+}
+
+// TestInterleavedTradesConserveTotals 测试多个 goroutine 交替买卖后 YOLO 和股份总量守恒，k 不减少。
+// 测试库为单连接，事务依次执行，只验证结果不变量；多个连接同时争用见 ConcurrencyTestSuite
+func (suite *AMMTestSuite) TestInterleavedTradesConserveTotals() {
+	traders := []*models.User{suite.trader}
+	for i := 0; i < 7; i++ {
+		user, err := services.UserService.CreateUser(fmt.Sprintf("User %d", i), fmt.Sprintf("user%d", i), fmt.Sprintf("user%d@example.com", i), "password123")
+		suite.Require().NoError(err)
+		traders = append(traders, user)
+	}
+	before := suite.pool()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for _, user := range traders {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(userID uuid.UUID, i int) {
+				defer wg.Done()
+				var err error
+				if i%3 == 2 {
+					_, err = services.AMMService.Trade(userID, "SAM", services.TradeOptions{Type: "sell", AmountIn: decimal.FromInt(50)})
+				} else {
+					_, err = services.AMMService.Trade(userID, "SAM", services.TradeOptions{Type: "buy", AmountIn: decimal.FromInt(120)})
+				}
+				if err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}(user.ID, i)
+		}
+	}
+	wg.Wait()
+
+	var trades int64
+	suite.db.Model(&models.Trade{}).Count(&trades)
+	assert.Equal(suite.T(), int64(succeeded), trades)
+	assert.Greater(suite.T(), succeeded, 0)
+
+	after := suite.pool()
+	var balances []models.LedgerAccount
+	suite.Require().NoError(suite.db.Where("type = ?", models.LedgerAccountUser).Find(&balances).Error)
+	assert.Len(suite.T(), balances, len(traders))
+	totalYolo := after.YoloReserve
+	for _, balance := range balances {
+		assert.False(suite.T(), balance.Balance.IsNegative())
+		totalYolo = totalYolo.Add(balance.Balance)
+	}
+	assert.Equal(suite.T(), before.YoloReserve.Add(models.DefaultUserBalance.MulInt(int64(len(traders)))), totalYolo)
+
+	assertLedgerBalanced(suite.T())
+
+	var totalShares decimal.Decimal
+	suite.Require().NoError(suite.db.Model(&models.UserHolding{}).Where("stock_id = ?", suite.stock.ID).
+		Select("COALESCE(SUM(quantity), 0)").Row().Scan(&totalShares))
+	assert.Equal(suite.T(), suite.stock.Supply, totalShares.Add(after.StockReserve))
+	k := after.YoloReserve.Mul(after.StockReserve, decimal.RoundDown)
+	assert.False(suite.T(), k.LessThan(before.YoloReserve.Mul(before.StockReserve, decimal.RoundDown)))
+}
+
+// TestInterleavedBuysCannotOverdraw 测试同一用户多次买入合计超过余额时，超出的部分被拒绝
+func (suite *AMMTestSuite) TestInterleavedBuysCannotOverdraw() {
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := services.AMMService.Trade(suite.trader.ID, "SAM", services.TradeOptions{Type: "buy", AmountIn: decimal.FromInt(1000)})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(suite.T(), err, services.ErrInsufficientBalance)
+		}
+	}
+	assert.Equal(suite.T(), 8, succeeded)
+
+	balance, err := services.LedgerService.GetBalance(suite.trader.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), balance.IsZero())
+	assert.Equal(suite.T(), decimal.FromInt(658000), suite.pool().YoloReserve)
+}
+
+// TestOversizedAmountsRejected 测试超过 decimal.MaxAmount 的数量在请求中即被拒绝，不会进入报价计算
+func (suite *AMMTestSuite) TestOversizedAmountsRejected() {
+	_, err := services.AMMService.Trade(suite.trader.ID, "SAM", services.TradeOptions{Type: "buy", AmountIn: decimal.FromInt(7000)})
+	suite.Require().NoError(err)
+	suite.Require().True(suite.pool().YoloReserve.GreaterThan(suite.pool().StockReserve))
+
+	for _, amount := range []string{"1000000000", "9000000000000", "9223372000000"} {
+		w := performRequest(suite.router, "GET", "/api/v1/stocks/SAM/quote?type=sell&amount="+amount, nil, suite.trader)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "%s: %s", amount, w.Body.String())
+		assert.Contains(suite.T(), w.Body.String(), decimal.ErrOutOfRange.Error())
+		w = performRequest(suite.router, "POST", "/api/v1/stocks/SAM/orders", map[string]interface{}{
+			"side": "sell", "type": "market", "quantity": amount,
+		}, suite.trader)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "%s: %s", amount, w.Body.String())
+	}
+	w := performRequest(suite.router, "GET", "/api/v1/stocks/SAM/quote?type=sell&amount=999999999.999999", nil, suite.trader)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+}
+
+// TestTradeWithoutPool 测试没有流动性池的股票无法交易
+func (suite *AMMTestSuite) TestTradeWithoutPool() {
+	stock := models.Stock{UserID: suite.creator.ID, Name: "Legacy", Symbol: "OLD"}
+	suite.Require().NoError(suite.db.Create(&stock).Error)
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/OLD/trade", map[string]interface{}{"type": "buy", "amount": 10}, suite.trader)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = performRequest(suite.router, "GET", "/api/v1/stocks/OLD/quote?type=buy&amount=10", nil, suite.trader)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestTradingDisabled 测试股票功能关闭时交易接口返回 404
+func (suite *AMMTestSuite) TestTradingDisabled() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/SAM/trade", map[string]interface{}{"type": "buy", "amount": 10}, suite.trader)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = performRequest(suite.router, "GET", "/api/v1/stocks/SAM/quote?type=buy&amount=10", nil, suite.trader)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.True(suite.T(), suite.holding(suite.trader.ID).IsZero())
+}
+
+// TestAMMSuite 运行流动性池交易测试套件
+func TestAMMSuite(t *testing.T) {
+	suite.Run(t, new(AMMTestSuite))
+}
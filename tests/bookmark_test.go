@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// BookmarkTestSuite 收藏测试套件
+type BookmarkTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *BookmarkTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *BookmarkTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *BookmarkTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// bookmark 收藏帖子，collectionID为空时不放入收藏夹
+func (suite *BookmarkTestSuite) bookmark(user *models.User, postID string, collectionID string) *httptest.ResponseRecorder {
+	body := map[string]interface{}{"post_id": postID}
+	if collectionID != "" {
+		body["collection_id"] = collectionID
+	}
+	return performRequest(suite.router, "POST", "/api/v1/bookmarks", body, user)
+}
+
+// createCollection 创建收藏夹
+func (suite *BookmarkTestSuite) createCollection(user *models.User, name string) controllers.BookmarkCollectionResponse {
+	w := performRequest(suite.router, "POST", "/api/v1/bookmarks/collections", map[string]string{"name": name}, user)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var collection controllers.BookmarkCollectionResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &collection))
+	return collection
+}
+
+// list 获取收藏列表
+func (suite *BookmarkTestSuite) list(user *models.User, params url.Values) controllers.BookmarksResponse {
+	w := performRequest(suite.router, "GET", "/api/v1/bookmarks?"+params.Encode(), nil, user)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var response controllers.BookmarksResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// collections 获取收藏夹列表
+func (suite *BookmarkTestSuite) collections(user *models.User) []controllers.BookmarkCollectionResponse {
+	w := performRequest(suite.router, "GET", "/api/v1/bookmarks/collections", nil, user)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var response struct {
+		Collections []controllers.BookmarkCollectionResponse `json:"collections"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Collections
+}
+
+// TestAddAndRemove 测试收藏、重复收藏和取消收藏
+func (suite *BookmarkTestSuite) TestAddAndRemove() {
+	post, err := services.PostService.CreatePost(suite.bob.ID, "worth reading later")
+	suite.Require().NoError(err)
+
+	w := suite.bookmark(suite.alice, post.ID.String(), "")
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var created controllers.BookmarkResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(suite.T(), post.ID.String(), created.PostID)
+	assert.Nil(suite.T(), created.CollectionID)
+
+	// 重复收藏不会产生新记录
+	w = suite.bookmark(suite.alice, post.ID.String(), "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	response := suite.list(suite.alice, nil)
+	suite.Require().Len(response.Bookmarks, 1)
+	suite.Require().NotNil(response.Bookmarks[0].Post)
+	suite.Require().NotNil(response.Bookmarks[0].Post.Post)
+	assert.Equal(suite.T(), "worth reading later", response.Bookmarks[0].Post.Post.Content)
+
+	// 收藏是私密的
+	assert.Empty(suite.T(), suite.list(suite.bob, nil).Bookmarks)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/bookmarks/"+post.ID.String(), nil, suite.alice)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = performRequest(suite.router, "DELETE", "/api/v1/bookmarks/"+post.ID.String(), nil, suite.alice)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.Empty(suite.T(), suite.list(suite.alice, nil).Bookmarks)
+}
+
+// TestCannotBookmarkInvisiblePost 测试不能收藏看不到的帖子
+func (suite *BookmarkTestSuite) TestCannotBookmarkInvisiblePost() {
+	post, err := services.PostService.CreatePostWithOptions(suite.bob.ID, services.CreatePostOptions{
+		Content:    "followers only",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.bookmark(suite.alice, post.ID.String(), "").Code)
+	suite.Require().NoError(services.UserService.Follow(suite.alice.ID, suite.bob.ID))
+	assert.Equal(suite.T(), http.StatusCreated, suite.bookmark(suite.alice, post.ID.String(), "").Code)
+}
+
+// TestCursorPagination 测试按收藏时间倒序的游标分页
+func (suite *BookmarkTestSuite) TestCursorPagination() {
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		post, err := services.PostService.CreatePost(suite.bob.ID, fmt.Sprintf("post %d", i))
+		suite.Require().NoError(err)
+		suite.Require().Equal(http.StatusCreated, suite.bookmark(suite.alice, post.ID.String(), "").Code)
+		suite.db.Model(&models.Bookmark{}).Where("post_id = ?", post.ID).Update("created_at", now.Add(time.Duration(i)*time.Minute))
+	}
+
+	var contents []string
+	params := url.Values{"cursor": {""}, "limit": {"2"}, "includeTotal": {"true"}}
+	for pages := 0; pages < 5; pages++ {
+		response := suite.list(suite.alice, params)
+		suite.Require().NotNil(response.PageInfo.TotalPosts)
+		assert.Equal(suite.T(), int64(5), *response.PageInfo.TotalPosts)
+		for _, bookmark := range response.Bookmarks {
+			contents = append(contents, bookmark.Post.Post.Content)
+		}
+		if response.PageInfo.NextCursor == nil {
+			break
+		}
+		params.Set("cursor", *response.PageInfo.NextCursor)
+	}
+	assert.Equal(suite.T(), []string{"post 4", "post 3", "post 2", "post 1", "post 0"}, contents)
+
+	w := performRequest(suite.router, "GET", "/api/v1/bookmarks?cursor=garbage", nil, suite.alice)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestDeletedPostsStayListed 测试原帖删除或不再可见后收藏仍然保留并标记为不可用
+func (suite *BookmarkTestSuite) TestDeletedPostsStayListed() {
+	kept, err := services.PostService.CreatePost(suite.bob.ID, "still here")
+	suite.Require().NoError(err)
+	deleted, err := services.PostService.CreatePost(suite.bob.ID, "going away")
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusCreated, suite.bookmark(suite.alice, kept.ID.String(), "").Code)
+	suite.Require().Equal(http.StatusCreated, suite.bookmark(suite.alice, deleted.ID.String(), "").Code)
+
+	suite.Require().NoError(services.PostService.DeletePost(suite.bob.ID, deleted.ID))
+
+	response := suite.list(suite.alice, nil)
+	suite.Require().Len(response.Bookmarks, 2)
+	byPost := make(map[string]controllers.BookmarkResponse)
+	for _, bookmark := range response.Bookmarks {
+		byPost[bookmark.PostID] = bookmark
+	}
+	assert.True(suite.T(), byPost[deleted.ID.String()].Post.Unavailable)
+	assert.Nil(suite.T(), byPost[deleted.ID.String()].Post.Post)
+	assert.False(suite.T(), byPost[kept.ID.String()].Post.Unavailable)
+
+	// 已删除帖子的收藏仍可以取消
+	w := performRequest(suite.router, "DELETE", "/api/v1/bookmarks/"+deleted.ID.String(), nil, suite.alice)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+// TestCollections 测试收藏夹的创建、筛选、重命名和删除
+func (suite *BookmarkTestSuite) TestCollections() {
+	reading := suite.createCollection(suite.alice, "Reading")
+	ideas := suite.createCollection(suite.alice, "Ideas")
+
+	first, err := services.PostService.CreatePost(suite.bob.ID, "long read")
+	suite.Require().NoError(err)
+	second, err := services.PostService.CreatePost(suite.bob.ID, "trade idea")
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusCreated, suite.bookmark(suite.alice, first.ID.String(), reading.ID).Code)
+	suite.Require().Equal(http.StatusCreated, suite.bookmark(suite.alice, second.ID.String(), reading.ID).Code)
+
+	// 再次收藏会移动到新的收藏夹
+	suite.Require().Equal(http.StatusOK, suite.bookmark(suite.alice, second.ID.String(), ideas.ID).Code)
+	inIdeas := suite.list(suite.alice, url.Values{"collection_id": {ideas.ID}})
+	suite.Require().Len(inIdeas.Bookmarks, 1)
+	assert.Equal(suite.T(), second.ID.String(), inIdeas.Bookmarks[0].PostID)
+	assert.Len(suite.T(), suite.list(suite.alice, nil).Bookmarks, 2)
+
+	collections := suite.collections(suite.alice)
+	suite.Require().Len(collections, 2)
+	assert.Equal(suite.T(), "Reading", collections[0].Name)
+	assert.Equal(suite.T(), int64(1), collections[0].Count)
+
+	// 名称不区分大小写不能重复
+	w := performRequest(suite.router, "POST", "/api/v1/bookmarks/collections", map[string]string{"name": "reading"}, suite.alice)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = performRequest(suite.router, "PATCH", "/api/v1/bookmarks/collections/"+ideas.ID, map[string]string{"name": "READING"}, suite.alice)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = performRequest(suite.router, "PATCH", "/api/v1/bookmarks/collections/"+ideas.ID, map[string]string{"name": "Trade ideas"}, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var renamed controllers.BookmarkCollectionResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &renamed))
+	assert.Equal(suite.T(), "Trade ideas", renamed.Name)
+	assert.Equal(suite.T(), int64(1), renamed.Count)
+
+	// 不能使用别人的收藏夹
+	other, err := services.PostService.CreatePost(suite.alice.ID, "mine")
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.bookmark(suite.bob, other.ID.String(), reading.ID).Code)
+	w = performRequest(suite.router, "GET", "/api/v1/bookmarks?collection_id="+reading.ID, nil, suite.bob)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// 删除收藏夹后其中的收藏变为未分类
+	w = performRequest(suite.router, "DELETE", "/api/v1/bookmarks/collections/"+reading.ID, nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	all := suite.list(suite.alice, nil)
+	suite.Require().Len(all.Bookmarks, 2)
+	for _, bookmark := range all.Bookmarks {
+		if bookmark.PostID == first.ID.String() {
+			assert.Nil(suite.T(), bookmark.CollectionID)
+		}
+	}
+	assert.Len(suite.T(), suite.collections(suite.alice), 1)
+}
+
+// TestInvalidCollectionName 测试非法的收藏夹名称
+func (suite *BookmarkTestSuite) TestInvalidCollectionName() {
+	for _, name := range []string{"   ", strings.Repeat("a", services.MaxBookmarkCollectionNameLen+1)} {
+		w := performRequest(suite.router, "POST", "/api/v1/bookmarks/collections", map[string]string{"name": name}, suite.alice)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+// TestBookmarkTestSuite 运行收藏测试套件
+func TestBookmarkTestSuite(t *testing.T) {
+	suite.Run(t, new(BookmarkTestSuite))
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ConcurrencyTestSuite 多连接并发交易测试套件。
+// 与其他套件的单连接内存库不同，这里的事务在多个连接上同时执行：PostgreSQL 上覆盖池子和账户的行锁、
+// 持仓和余额的条件更新；SQLite 文件库上写事务整库互斥，覆盖多连接下的等待和事务边界
+type ConcurrencyTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	creator *models.User
+	traders []*models.User
+	stock   *models.Stock
+}
+
+// SetupSuite 测试套件初始化
+func (suite *ConcurrencyTestSuite) SetupSuite() {
+	db, err := SetupConcurrentTestEnvironment(suite.T().TempDir())
+	suite.Require().NoError(err)
+
+	suite.db = db
+}
+
+// TearDownSuite 测试套件清理
+func (suite *ConcurrencyTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备：一只带流动性池的股票，每个交易者开户并持有一些股份
+func (suite *ConcurrencyTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	cfg.TradeFeeRate = decimal.RequireFromString("0.003")
+	services.StockService.SetConfig(cfg)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stock, err = services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "SAM", Name: "Sam"})
+	suite.Require().NoError(err)
+
+	suite.traders = nil
+	for i := 0; i < 8; i++ {
+		user, err := services.UserService.CreateUser(fmt.Sprintf("User %d", i), fmt.Sprintf("user%d", i), fmt.Sprintf("user%d@example.com", i), "password123")
+		suite.Require().NoError(err)
+		// 先开户，之后金库余额不再变化
+		_, err = services.LedgerService.Gift(user.ID, "sam", decimal.FromInt(1), "")
+		suite.Require().NoError(err)
+		_, err = services.AMMService.Trade(user.ID, "SAM", services.TradeOptions{Type: "buy", AmountIn: decimal.FromInt(1000)})
+		suite.Require().NoError(err)
+		suite.traders = append(suite.traders, user)
+	}
+}
+
+// snapshot 除金库外所有账户的 YOLO 合计和所有持仓加池中的股份合计
+func (suite *ConcurrencyTestSuite) snapshot() (yolo, shares decimal.Decimal) {
+	var accounts []models.LedgerAccount
+	suite.Require().NoError(suite.db.Where("code <> ?", models.LedgerTreasuryCode).Find(&accounts).Error)
+	for _, account := range accounts {
+		yolo = yolo.Add(account.Balance)
+	}
+	var holdings []models.UserHolding
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).Find(&holdings).Error)
+	for _, holding := range holdings {
+		shares = shares.Add(holding.Quantity)
+	}
+	var pool models.LiquidityPool
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	return yolo, shares.Add(pool.StockReserve)
+}
+
+// runTrader 随机执行一组池子交易、挂单、撤单和赠送，业务上的拒绝之外的错误都记入 unexpected
+func (suite *ConcurrencyTestSuite) runTrader(user *models.User, seed int64, record func(error)) {
+	rng := rand.New(rand.NewSource(seed))
+	var open []uuid.UUID
+	for i := 0; i < 25; i++ {
+		var err error
+		amount := decimal.FromUnits(rng.Int63n(3000_000000) + 1)
+		price := decimal.FromUnits(900000 + rng.Int63n(300000))
+		switch rng.Intn(6) {
+		case 0:
+			_, err = services.AMMService.Trade(user.ID, "SAM", services.TradeOptions{Type: "buy", AmountIn: amount})
+		case 1:
+			_, err = services.AMMService.Trade(user.ID, "SAM", services.TradeOptions{Type: "sell", AmountIn: amount})
+		case 2, 3:
+			side := "buy"
+			if rng.Intn(2) == 1 {
+				side = "sell"
+			}
+			var result *services.OrderResult
+			result, err = services.OrderService.PlaceOrder(user.ID, "SAM", services.PlaceOrderOptions{Side: side, Price: price, Quantity: amount})
+			if err == nil && result.Order.Status == models.OrderStatusOpen {
+				open = append(open, result.Order.ID)
+			}
+		case 4:
+			if len(open) > 0 {
+				n := rng.Intn(len(open))
+				_, err = services.OrderService.CancelOrder(user.ID, open[n])
+				open = append(open[:n], open[n+1:]...)
+			}
+		case 5:
+			recipient := suite.traders[rng.Intn(len(suite.traders))]
+			if recipient.ID != user.ID {
+				_, err = services.LedgerService.Gift(user.ID, recipient.Username, amount, "")
+			}
+		}
+		record(err)
+	}
+}
+
+// TestConcurrentTradingConservesTotals 测试多个连接同时交易、挂单、撤单和赠送后，
+// YOLO 和股份总量守恒，没有账户透支，冻结与挂单一致，k 不减少
+func (suite *ConcurrencyTestSuite) TestConcurrentTradingConservesTotals() {
+	yoloBefore, sharesBefore := suite.snapshot()
+	var poolBefore models.LiquidityPool
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&poolBefore).Error)
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		succeeded  int
+		rejected   int
+		unexpected []error
+	)
+	record := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, services.ErrInsufficientShares),
+			errors.Is(err, services.ErrInsufficientLiquidity), errors.Is(err, services.ErrTradeTooSmall),
+			errors.Is(err, services.ErrOrderNotOpen):
+			rejected++
+		default:
+			unexpected = append(unexpected, err)
+		}
+	}
+	for i, user := range suite.traders {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(user *models.User, seed int64) {
+				defer wg.Done()
+				suite.runTrader(user, seed, record)
+			}(user, int64(i*2+j))
+		}
+	}
+	wg.Wait()
+
+	suite.Require().Empty(unexpected)
+	sqlDB, err := suite.db.DB()
+	suite.Require().NoError(err)
+	assert.Greater(suite.T(), sqlDB.Stats().OpenConnections, 1)
+	// 随机数量超出余额或持仓的操作会被拒绝，两类结果都要出现，否则没有测到争用下的兜底条件
+	assert.Greater(suite.T(), succeeded, 100)
+	assert.Greater(suite.T(), rejected, 0)
+
+	yoloAfter, sharesAfter := suite.snapshot()
+	assert.Equal(suite.T(), yoloBefore, yoloAfter)
+	assert.Equal(suite.T(), sharesBefore, sharesAfter)
+	assert.Equal(suite.T(), suite.stock.Supply, sharesAfter)
+
+	var accounts []models.LedgerAccount
+	suite.Require().NoError(suite.db.Where("type = ?", models.LedgerAccountUser).Find(&accounts).Error)
+	for _, account := range accounts {
+		assert.False(suite.T(), account.Balance.IsNegative(), account.Code)
+	}
+
+	var holdings []models.UserHolding
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).Find(&holdings).Error)
+	for _, holding := range holdings {
+		var orders []models.Order
+		suite.Require().NoError(suite.db.Where("user_id = ? AND side = ? AND status = ?", holding.UserID, "sell", models.OrderStatusOpen).Find(&orders).Error)
+		locked := decimal.Zero
+		for _, order := range orders {
+			locked = locked.Add(order.Remaining())
+		}
+		assert.Equal(suite.T(), locked, holding.Locked, holding.UserID.String())
+		assert.False(suite.T(), holding.Locked.IsNegative())
+		assert.False(suite.T(), holding.Quantity.LessThan(holding.Locked))
+	}
+
+	var pool models.LiquidityPool
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	k := pool.YoloReserve.Mul(pool.StockReserve, decimal.RoundDown)
+	assert.False(suite.T(), k.LessThan(poolBefore.YoloReserve.Mul(poolBefore.StockReserve, decimal.RoundDown)))
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestConcurrencySuite 运行多连接并发交易测试套件
+func TestConcurrencySuite(t *testing.T) {
+	suite.Run(t, new(ConcurrencyTestSuite))
+}
@@ -0,0 +1,333 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ConditionalOrderTestSuite 止损、止盈条件单测试套件
+type ConditionalOrderTestSuite struct {
+	suite.Suite
+	router  *gin.Engine
+	db      *gorm.DB
+	creator *models.User
+	bob     *models.User
+	stock   *models.Stock
+}
+
+// SetupSuite 测试套件初始化
+func (suite *ConditionalOrderTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *ConditionalOrderTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *ConditionalOrderTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	services.StockService.SetConfig(cfg)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stock, err = services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "SAM", Name: "Sam"})
+	suite.Require().NoError(err)
+}
+
+// create 创建条件单，必须成功
+func (suite *ConditionalOrderTestSuite) create(user *models.User, body map[string]interface{}) controllers.ConditionalOrderResponse {
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/conditional-orders", body, user)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var response controllers.ConditionalOrderResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// reload 重新读取条件单
+func (suite *ConditionalOrderTestSuite) reload(id string) models.ConditionalOrder {
+	var order models.ConditionalOrder
+	suite.Require().NoError(suite.db.Where("id = ?", id).First(&order).Error)
+	return order
+}
+
+// recordTrade 直接写入一笔成交记录，用于构造价格历史
+func (suite *ConditionalOrderTestSuite) recordTrade(price string, at time.Time) *models.Trade {
+	trade := &models.Trade{
+		StockID:       suite.stock.ID,
+		Type:          models.TradeTypeBuy,
+		Amount:        decimal.FromInt(1),
+		Price:         decimal.RequireFromString(price),
+		TotalValue:    decimal.RequireFromString(price),
+		TransactionID: uuid.New().String(),
+		Status:        models.TradeStatusCompleted,
+		CreatedAt:     at,
+	}
+	suite.Require().NoError(suite.db.Create(trade).Error)
+	return trade
+}
+
+// evaluate 检查条件单，返回触发数量
+func (suite *ConditionalOrderTestSuite) evaluate() int {
+	triggered, err := services.ConditionalOrderService.Evaluate(suite.stock.ID)
+	suite.Require().NoError(err)
+	return triggered
+}
+
+// TestStopLossTriggersOnLastPrice 测试最新成交价跌破触发价后提交市价卖单，只触发一次
+func (suite *ConditionalOrderTestSuite) TestStopLossTriggersOnLastPrice() {
+	// 方向设反时当前价格已满足条件，拒绝创建
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/conditional-orders", map[string]interface{}{
+		"kind": "stop_loss", "trigger_price": "1.1", "quantity": 1000,
+	}, suite.creator)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/conditional-orders", map[string]interface{}{
+		"kind": "stop_loss", "trigger_price": "0.9", "quantity": 1000,
+	}, suite.bob)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "selling shares the user does not hold")
+
+	created := suite.create(suite.creator, map[string]interface{}{
+		"kind": "stop_loss", "trigger_price": "0.9", "quantity": 1000,
+	})
+	assert.Equal(suite.T(), models.ConditionalStatusPending, created.Status)
+	assert.Equal(suite.T(), models.TradeTypeSell, created.Side)
+	assert.Equal(suite.T(), models.OrderTypeMarket, created.OrderType)
+	assert.Equal(suite.T(), models.TimeInForceIOC, created.TimeInForce)
+	assert.Equal(suite.T(), 0, suite.evaluate())
+
+	// Bob 向池子卖出，成交均价约 0.8667，低于触发价
+	suite.Require().NoError(suite.db.Create(&models.UserHolding{UserID: suite.bob.ID, StockID: suite.stock.ID, Quantity: decimal.FromInt(100000)}).Error)
+	_, err := services.AMMService.Trade(suite.bob.ID, "SAM", services.TradeOptions{Type: models.TradeTypeSell, AmountIn: decimal.FromInt(100000)})
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), 1, suite.evaluate())
+	assert.Equal(suite.T(), 0, suite.evaluate(), "a triggered order must not fire again")
+
+	order := suite.reload(created.ID)
+	assert.Equal(suite.T(), models.ConditionalStatusTriggered, order.Status)
+	assert.True(suite.T(), order.TriggeredPrice.LessThan(decimal.RequireFromString("0.9")))
+	suite.Require().NotNil(order.OrderID)
+	var placed models.Order
+	suite.Require().NoError(suite.db.Where("id = ?", order.OrderID).First(&placed).Error)
+	assert.Equal(suite.T(), models.OrderStatusFilled, placed.Status)
+	assert.Equal(suite.T(), suite.creator.ID, placed.UserID)
+
+	var holding models.UserHolding
+	suite.db.Where("user_id = ? AND stock_id = ?", suite.creator.ID, suite.stock.ID).First(&holding)
+	assert.Equal(suite.T(), decimal.FromInt(349000), holding.Quantity)
+
+	var notifications int64
+	suite.db.Model(&models.Notification{}).
+		Where("user_id = ? AND type = ?", suite.creator.ID, models.NotificationTypeOrderTriggered).Count(&notifications)
+	assert.Equal(suite.T(), int64(1), notifications)
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestTakeProfitUsesTWAP 测试按 TWAP 触发时短暂的价格尖峰不会触发
+func (suite *ConditionalOrderTestSuite) TestTakeProfitUsesTWAP() {
+	created := suite.create(suite.creator, map[string]interface{}{
+		"kind": "take_profit", "trigger_price": "1.5", "trigger_source": "twap", "twap_window": 300,
+		"order_type": "limit", "limit_price": "1.2", "quantity": 500,
+	})
+	assert.Equal(suite.T(), 300, created.TWAPWindow)
+	assert.Equal(suite.T(), models.TimeInForceGTC, created.TimeInForce)
+	last := suite.create(suite.creator, map[string]interface{}{
+		"kind": "take_profit", "trigger_price": "1.5", "quantity": 500,
+	})
+
+	now := time.Now()
+	suite.recordTrade("1", now.Add(-10*time.Minute))
+	spike := suite.recordTrade("2.2", now.Add(-30*time.Second))
+
+	// 最近 30 秒才到 2.2，TWAP 约为 1.14，只有按最新成交价的条件单触发
+	assert.Equal(suite.T(), 1, suite.evaluate())
+	assert.Equal(suite.T(), models.ConditionalStatusTriggered, suite.reload(last.ID).Status)
+	assert.Equal(suite.T(), models.ConditionalStatusPending, suite.reload(created.ID).Status)
+
+	// 价格在 2.2 停留一半窗口后 TWAP 约为 1.6
+	suite.Require().NoError(suite.db.Model(spike).Update("created_at", now.Add(-150*time.Second)).Error)
+	assert.Equal(suite.T(), 1, suite.evaluate())
+	order := suite.reload(created.ID)
+	assert.Equal(suite.T(), models.ConditionalStatusTriggered, order.Status)
+	assert.InDelta(suite.T(), 1.6, order.TriggeredPrice.Float64(), 0.01)
+	var placed models.Order
+	suite.Require().NoError(suite.db.Where("id = ?", order.OrderID).First(&placed).Error)
+	assert.Equal(suite.T(), models.OrderTypeLimit, placed.Type)
+	assert.Equal(suite.T(), decimal.RequireFromString("1.2"), placed.Price)
+}
+
+// TestRejectedOrderMarksFailed 测试触发后订单被拒绝时标记为失败且不再重试
+func (suite *ConditionalOrderTestSuite) TestRejectedOrderMarksFailed() {
+	created := suite.create(suite.creator, map[string]interface{}{
+		"kind": "take_profit", "trigger_price": "1.5", "quantity": 1000,
+	})
+	// 创建后卖光了持仓
+	suite.Require().NoError(suite.db.Model(&models.UserHolding{}).
+		Where("user_id = ? AND stock_id = ?", suite.creator.ID, suite.stock.ID).Update("quantity", decimal.Zero).Error)
+	suite.recordTrade("2", time.Now())
+
+	assert.Equal(suite.T(), 1, suite.evaluate())
+	assert.Equal(suite.T(), 0, suite.evaluate())
+	order := suite.reload(created.ID)
+	assert.Equal(suite.T(), models.ConditionalStatusFailed, order.Status)
+	assert.Nil(suite.T(), order.OrderID)
+	assert.Contains(suite.T(), order.FailureReason, services.ErrInsufficientShares.Error())
+
+	var orders int64
+	suite.db.Model(&models.Order{}).Count(&orders)
+	assert.Equal(suite.T(), int64(0), orders)
+}
+
+// TestBuyOrdersAreBounded 测试买入条件单创建时检查余额和池中股份
+func (suite *ConditionalOrderTestSuite) TestBuyOrdersAreBounded() {
+	for _, body := range []map[string]interface{}{
+		{"kind": "stop_loss", "side": "buy", "trigger_price": "1.1", "quantity": 1000000},
+		{"kind": "stop_loss", "side": "buy", "trigger_price": "1.1", "quantity": 10000},
+		{"kind": "stop_loss", "side": "buy", "trigger_price": "1.1", "order_type": "limit", "limit_price": "1.2", "quantity": 10000},
+		{"kind": "stop_loss", "side": "buy", "trigger_price": "1.1", "order_type": "limit", "limit_price": "1000000", "quantity": 10000000},
+	} {
+		w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/conditional-orders", body, suite.bob)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "%v: %s", body, w.Body.String())
+	}
+	created := suite.create(suite.bob, map[string]interface{}{
+		"kind": "stop_loss", "side": "buy", "trigger_price": "1.1", "quantity": 5000,
+	})
+	assert.Equal(suite.T(), models.TradeTypeBuy, created.Side)
+}
+
+// TestPanicDuringTriggerMarksFailed 测试下单时 panic 只让该条件单失败，后台检查继续处理其他条件单
+func (suite *ConditionalOrderTestSuite) TestPanicDuringTriggerMarksFailed() {
+	healthy := suite.create(suite.creator, map[string]interface{}{
+		"kind": "take_profit", "trigger_price": "1.5", "order_type": "limit", "limit_price": "3", "quantity": 100,
+	})
+	// 绕过创建时的检查写入一张挂单冻结金额会溢出的买单
+	broken := &models.ConditionalOrder{
+		StockID:       suite.stock.ID,
+		UserID:        suite.bob.ID,
+		Kind:          models.ConditionalKindTakeProfit,
+		TriggerSource: models.TriggerSourceLast,
+		TriggerPrice:  decimal.FromInt(2),
+		Side:          models.TradeTypeBuy,
+		OrderType:     models.OrderTypeLimit,
+		TimeInForce:   models.TimeInForceGTC,
+		LimitPrice:    decimal.FromInt(1000000),
+		Quantity:      decimal.FromInt(10000000),
+		Status:        models.ConditionalStatusPending,
+	}
+	suite.Require().NoError(suite.db.Omit("Stock", "User").Create(broken).Error)
+	suite.recordTrade("1.8", time.Now())
+
+	assert.Equal(suite.T(), 2, suite.evaluate())
+	assert.Equal(suite.T(), models.ConditionalStatusTriggered, suite.reload(healthy.ID).Status)
+	order := suite.reload(broken.ID.String())
+	assert.Equal(suite.T(), models.ConditionalStatusFailed, order.Status)
+	assert.Nil(suite.T(), order.OrderID)
+	assert.NotEmpty(suite.T(), order.FailureReason)
+
+	var orders int64
+	suite.db.Model(&models.Order{}).Where("user_id = ?", suite.bob.ID).Count(&orders)
+	assert.Equal(suite.T(), int64(0), orders)
+	balance, err := services.LedgerService.GetBalance(suite.bob.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.DefaultUserBalance, balance)
+	assertLedgerBalanced(suite.T())
+}
+
+// TestRepeatedEvaluateSubmitsOnce 测试多个 goroutine 重复检查时每张条件单只提交一笔订单，
+// 依赖的是按状态条件更新的认领，而不是行锁
+func (suite *ConditionalOrderTestSuite) TestRepeatedEvaluateSubmitsOnce() {
+	// 触发后挂出高价卖单，不会成交改变价格
+	for i := 0; i < 3; i++ {
+		suite.create(suite.creator, map[string]interface{}{
+			"kind": "take_profit", "trigger_price": "1.5", "order_type": "limit", "limit_price": "3", "quantity": 100,
+		})
+	}
+	suite.recordTrade("2", time.Now())
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			triggered, err := services.ConditionalOrderService.Evaluate(suite.stock.ID)
+			assert.NoError(suite.T(), err)
+			mu.Lock()
+			total += triggered
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), 3, total)
+	var orders int64
+	suite.db.Model(&models.Order{}).Where("user_id = ?", suite.creator.ID).Count(&orders)
+	assert.Equal(suite.T(), int64(3), orders)
+}
+
+// TestCancelAndList 测试撤销和查询条件单
+func (suite *ConditionalOrderTestSuite) TestCancelAndList() {
+	created := suite.create(suite.creator, map[string]interface{}{
+		"kind": "stop_loss", "trigger_price": "0.5", "quantity": 10,
+	})
+
+	w := performRequest(suite.router, "GET", "/api/v1/user/conditional-orders?status=pending", nil, suite.creator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var list controllers.ConditionalOrderListResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &list))
+	suite.Require().Len(list.ConditionalOrders, 1)
+	assert.Equal(suite.T(), "SAM", list.ConditionalOrders[0].Symbol)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/conditional-orders/"+created.ID, nil, suite.bob)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = performRequest(suite.router, "DELETE", "/api/v1/conditional-orders/"+created.ID, nil, suite.creator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var cancelled controllers.ConditionalOrderResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &cancelled))
+	assert.Equal(suite.T(), models.ConditionalStatusCancelled, cancelled.Status)
+	w = performRequest(suite.router, "DELETE", "/api/v1/conditional-orders/"+created.ID, nil, suite.creator)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// 撤销后价格到达触发价也不会触发
+	suite.recordTrade("0.4", time.Now())
+	assert.Equal(suite.T(), 0, suite.evaluate())
+
+	w = performRequest(suite.router, "GET", "/api/v1/user/conditional-orders?status=bogus", nil, suite.creator)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/conditional-orders", map[string]interface{}{
+		"kind": "stop_loss", "trigger_price": "0.5", "trigger_source": "twap", "twap_window": 90000, "quantity": 10,
+	}, suite.creator)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestConditionalOrderTestSuite(t *testing.T) {
+	suite.Run(t, new(ConditionalOrderTestSuite))
+}
@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ContentFilterTestSuite 发帖过滤测试套件
+type ContentFilterTestSuite struct {
+	suite.Suite
+	router    *gin.Engine
+	db        *gorm.DB
+	author    *models.User
+	reader    *models.User
+	moderator *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *ContentFilterTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *ContentFilterTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备：账号已过观察期，使用默认过滤配置
+func (suite *ContentFilterTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.author, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.reader, err = services.UserService.CreateUser("Rae", "rae", "rae@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.moderator, err = services.UserService.CreateUser("Mod", "mod", "mod@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(suite.moderator).Update("role", models.UserRoleModerator).Error)
+
+	suite.db.Model(&models.User{}).Where("1 = 1").Update("created_at", time.Now().Add(-30*24*time.Hour))
+	suite.configure(func(cfg *services.ContentFilterConfig) {})
+}
+
+// configure 在默认配置基础上修改过滤配置
+func (suite *ContentFilterTestSuite) configure(modify func(cfg *services.ContentFilterConfig)) {
+	cfg := services.DefaultContentFilterConfig()
+	cfg.Blocklist = []string{"scamcoin", "/free\\s+money/", "诈骗"}
+	modify(&cfg)
+	suite.Require().NoError(services.PostService.SetContentFilterConfig(cfg))
+}
+
+// post 以指定用户身份发帖
+func (suite *ContentFilterTestSuite) post(user *models.User, content string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"content": content})
+	req := createAuthenticatedRequest("POST", "/api/v1/posts", body, user.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// rule 返回过滤错误中的规则名
+func (suite *ContentFilterTestSuite) rule(w *httptest.ResponseRecorder) string {
+	var response struct {
+		Rule string `json:"rule"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Rule
+}
+
+// TestRateLimit 测试发帖频率限制，转发同样计数
+func (suite *ContentFilterTestSuite) TestRateLimit() {
+	suite.configure(func(cfg *services.ContentFilterConfig) {
+		cfg.RateLimit = 3
+		cfg.RateWindow = time.Minute
+	})
+
+	original, err := services.PostService.CreatePost(suite.reader.ID, "something worth sharing")
+	suite.Require().NoError(err)
+	_, err = services.PostService.Repost(suite.author.ID, original.ID)
+	suite.Require().NoError(err)
+
+	for _, content := range []string{"first", "second"} {
+		w := suite.post(suite.author, content)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w := suite.post(suite.author, "third")
+	suite.Require().Equal(http.StatusTooManyRequests, w.Code)
+	assert.Equal(suite.T(), services.FilterRuleRateLimit, suite.rule(w))
+	assert.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
+
+	// 删除帖子不会腾出名额
+	var post models.Post
+	suite.Require().NoError(suite.db.Where("user_id = ? AND content = ?", suite.author.ID, "first").First(&post).Error)
+	suite.Require().NoError(services.PostService.DeletePost(suite.author.ID, post.ID))
+	w = suite.post(suite.author, "third")
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	// 窗口过去后恢复
+	suite.db.Model(&models.Post{}).Unscoped().Where("user_id = ?", suite.author.ID).Update("created_at", time.Now().Add(-2*time.Minute))
+	w = suite.post(suite.author, "third")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+// TestDuplicateAndLinks 测试近似重复内容和链接数量限制
+func (suite *ContentFilterTestSuite) TestDuplicateAndLinks() {
+	w := suite.post(suite.author, "Check out this amazing opportunity right now")
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	// 同一作者发布几乎相同的内容会被拦截
+	w = suite.post(suite.author, "check out this AMAZING opportunity, right now!!")
+	suite.Require().Equal(http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(suite.T(), services.FilterRuleDuplicate, suite.rule(w))
+
+	// 其他用户引用相同的内容不受影响
+	w = suite.post(suite.reader, "check out this AMAZING opportunity, right now!!")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	// 删除后不再参与比较
+	var post models.Post
+	suite.Require().NoError(suite.db.Where("user_id = ?", suite.author.ID).First(&post).Error)
+	suite.Require().NoError(services.PostService.DeletePost(suite.author.ID, post.ID))
+	w = suite.post(suite.author, "Check out this amazing opportunity right now")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	// 短内容不参与重复检测
+	for i := 0; i < 2; i++ {
+		w = suite.post(suite.author, "gm")
+		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	}
+
+	w = suite.post(suite.author, "https://a.io https://b.io https://c.io https://d.io")
+	suite.Require().Equal(http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(suite.T(), services.FilterRuleLinks, suite.rule(w))
+}
+
+// TestBlocklist_HeldForReview 测试命中屏蔽词的帖子进入审核，驳回后发布
+func (suite *ContentFilterTestSuite) TestBlocklist_HeldForReview() {
+	w := suite.post(suite.author, "the scamcoinage era is over")
+	suite.Require().Equal(http.StatusCreated, w.Code, "blocked words match whole words only")
+
+	w = suite.post(suite.author, "FREE   money for @rae")
+	suite.Require().Equal(http.StatusAccepted, w.Code, w.Body.String())
+	var held controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &held))
+	assert.Equal(suite.T(), models.PostStatusHeld, held.Status)
+
+	w = suite.post(suite.author, "这是诈骗")
+	suite.Require().Equal(http.StatusAccepted, w.Code)
+
+	// 等待审核的帖子不可见，也不会发送提及通知
+	posts, _, err := services.PostService.GetTimeline(suite.reader.ID, 1, 10)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), posts, 1)
+	var mentions int64
+	suite.db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeMention).Count(&mentions)
+	assert.Equal(suite.T(), int64(0), mentions)
+
+	reports, total, err := services.ModerationService.GetReports(suite.moderator.ID, models.ReportStatusOpen, 1, 10)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(2), total)
+	assert.Nil(suite.T(), reports[0].ReporterID)
+	assert.Equal(suite.T(), held.ID, reports[0].TargetID.String())
+
+	req := createAuthenticatedRequest("GET", "/api/v1/moderation/reports", nil, suite.moderator.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	var queue controllers.ReportsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &queue))
+	assert.True(suite.T(), queue.Reports[0].Automatic)
+	assert.Nil(suite.T(), queue.Reports[0].Reporter)
+
+	// 驳回后发布，另一个被隐藏后保持不可见
+	_, err = services.ModerationService.DismissReport(suite.moderator.ID, reports[0].ID, "false positive")
+	suite.Require().NoError(err)
+	_, err = services.ModerationService.ResolveReport(suite.moderator.ID, reports[1].ID, services.ResolveOptions{Action: models.ModerationActionHidePost})
+	suite.Require().NoError(err)
+
+	posts, _, err = services.PostService.GetTimeline(suite.reader.ID, 1, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(posts, 2)
+	assert.Equal(suite.T(), held.ID, posts[0].ID.String())
+	suite.db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeMention).Count(&mentions)
+	assert.Equal(suite.T(), int64(1), mentions)
+
+	// 草稿修改同样经过过滤
+	draft, err := services.PostService.CreatePostWithOptions(suite.author.ID, services.CreatePostOptions{Content: "draft", Draft: true})
+	suite.Require().NoError(err)
+	content := "free money"
+	_, err = services.PostService.UpdateDraft(suite.author.ID, draft.ID, services.UpdateDraftOptions{Content: &content})
+	assert.ErrorIs(suite.T(), err, services.ErrPostRejected)
+}
+
+// TestProbation_ShadowLimited 测试新账号在观察期内发链接会被影子限流
+func (suite *ContentFilterTestSuite) TestProbation_ShadowLimited() {
+	suite.db.Model(suite.author).Update("created_at", time.Now())
+
+	w := suite.post(suite.author, "my new project https://example.com @rae")
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	assert.NotContains(suite.T(), w.Body.String(), "limited")
+
+	// 作者本人正常可见，其他人看不到，也不会收到提及通知
+	req := createAuthenticatedRequest("GET", "/api/v1/posts/"+post.ID, nil, suite.author.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	req = createAuthenticatedRequest("GET", "/api/v1/posts/"+post.ID, nil, suite.reader.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	var mentions int64
+	suite.db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeMention).Count(&mentions)
+	assert.Equal(suite.T(), int64(0), mentions)
+
+	// 观察期内的发帖频率限制更严格
+	for i := 0; i < 2; i++ {
+		w = suite.post(suite.author, "hello")
+		suite.Require().Equal(http.StatusCreated, w.Code)
+	}
+	w = suite.post(suite.author, "hello")
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	// 没有链接的帖子不受影响
+	posts, _, err := services.PostService.GetTimeline(suite.reader.ID, 1, 10)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), posts, 2)
+}
+
+// TestConfig_Validation 测试过滤配置校验
+func (suite *ContentFilterTestSuite) TestConfig_Validation() {
+	cfg := services.DefaultContentFilterConfig()
+	cfg.Blocklist = []string{"/([a-z/"}
+	assert.Error(suite.T(), services.PostService.SetContentFilterConfig(cfg))
+
+	cfg = services.DefaultContentFilterConfig()
+	cfg.Actions = map[string]string{services.FilterRuleLinks: "explode"}
+	assert.Error(suite.T(), services.PostService.SetContentFilterConfig(cfg))
+
+	cfg.Actions = map[string]string{services.FilterRuleLinks: services.FilterActionHold}
+	suite.Require().NoError(services.PostService.SetContentFilterConfig(cfg))
+	w := suite.post(suite.author, "https://a.io https://b.io https://c.io https://d.io")
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+}
+
+// TestContentFilterTestSuite 运行发帖过滤测试套件
+func TestContentFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(ContentFilterTestSuite))
+}
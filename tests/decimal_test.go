@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"yolo/decimal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecimalParse 测试解析和格式化
+func TestDecimalParse(t *testing.T) {
+	for input, expected := range map[string]string{
+		"0":           "0",
+		"1.50":        "1.5",
+		"-0.000001":   "-0.000001",
+		"+12.345678":  "12.345678",
+		".5":          "0.5",
+		"7.":          "7",
+		"-0":          "0",
+		"1000000.000": "1000000",
+	} {
+		d, err := decimal.Parse(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, d.String(), input)
+		}
+	}
+	assert.Equal(t, "1.500000", decimal.RequireFromString("1.5").StringFixed())
+
+	for _, input := range []string{"", "abc", "1.2.3", "1e3", "--1", ".", "NaN"} {
+		_, err := decimal.Parse(input)
+		assert.ErrorIs(t, err, decimal.ErrInvalid, input)
+	}
+	_, err := decimal.Parse("0.0000001")
+	assert.ErrorIs(t, err, decimal.ErrTooPrecise)
+	_, err = decimal.Parse("99999999999999999999")
+	assert.ErrorIs(t, err, decimal.ErrOutOfRange)
+	_, err = decimal.FromUnits(math.MaxInt64).TryAdd(decimal.FromUnits(1))
+	assert.ErrorIs(t, err, decimal.ErrOutOfRange)
+
+	d, err := decimal.ParseRound("0.0000005", decimal.RoundHalfEven)
+	assert.NoError(t, err)
+	assert.True(t, d.IsZero())
+	d, err = decimal.ParseRound("0.0000015", decimal.RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.000002", d.String())
+}
+
+// TestDecimalRoundingModes 测试各舍入模式
+func TestDecimalRoundingModes(t *testing.T) {
+	cases := []struct {
+		value    string
+		mode     decimal.RoundingMode
+		expected string
+	}{
+		{"2.5", decimal.RoundDown, "2"},
+		{"-2.5", decimal.RoundDown, "-2"},
+		{"2.1", decimal.RoundUp, "3"},
+		{"-2.1", decimal.RoundUp, "-3"},
+		{"2.5", decimal.RoundHalfUp, "3"},
+		{"-2.5", decimal.RoundHalfUp, "-3"},
+		{"2.5", decimal.RoundHalfEven, "2"},
+		{"3.5", decimal.RoundHalfEven, "4"},
+		{"-2.5", decimal.RoundHalfEven, "-2"},
+		{"2.9", decimal.RoundFloor, "2"},
+		{"-2.1", decimal.RoundFloor, "-3"},
+		{"2.1", decimal.RoundCeiling, "3"},
+		{"-2.9", decimal.RoundCeiling, "-2"},
+	}
+	for _, c := range cases {
+		got := decimal.RequireFromString(c.value).Round(0, c.mode)
+		assert.Equal(t, c.expected, got.String(), "%s mode %d", c.value, c.mode)
+	}
+	assert.Equal(t, "1.24", decimal.RequireFromString("1.235").Round(2, decimal.RoundHalfEven).String())
+}
+
+// TestDecimalArithmetic 测试乘除只舍入一次
+func TestDecimalArithmetic(t *testing.T) {
+	a := decimal.RequireFromString("0.1")
+	b := decimal.RequireFromString("0.2")
+	assert.Equal(t, decimal.RequireFromString("0.3"), a.Add(b))
+	assert.Equal(t, decimal.RequireFromString("-0.1"), a.Sub(b))
+
+	one := decimal.FromInt(1)
+	three := decimal.FromInt(3)
+	assert.Equal(t, "0.333333", one.Div(three, decimal.RoundDown).String())
+	assert.Equal(t, "0.333334", one.Div(three, decimal.RoundUp).String())
+	assert.Equal(t, "-0.333334", one.Neg().Div(three, decimal.RoundFloor).String())
+	milli, tiny := decimal.RequireFromString("0.001"), decimal.RequireFromString("0.0015")
+	assert.Equal(t, "0.000002", milli.Mul(tiny, decimal.RoundHalfUp).String())
+	assert.Equal(t, "0.000001", milli.Mul(tiny, decimal.RoundDown).String())
+
+	// 先乘后除只在最后舍入：650000 × 6500 / 656500
+	reserve := decimal.FromInt(650000)
+	out := reserve.MulDiv(decimal.FromInt(6500), decimal.FromInt(656500), decimal.RoundDown)
+	assert.Equal(t, "6435.643564", out.String())
+	// 中间结果超过 int64 也不会溢出
+	big := decimal.FromInt(5000000000)
+	assert.Equal(t, big, big.MulDiv(big, big, decimal.RoundDown))
+	_, err := big.TryMulDiv(big, decimal.FromInt(1), decimal.RoundDown)
+	assert.ErrorIs(t, err, decimal.ErrOutOfRange)
+
+	parts := []decimal.Decimal{}
+	for i := 0; i < 3; i++ {
+		parts = append(parts, one.Div(three, decimal.RoundDown))
+	}
+	assert.Equal(t, "0.999999", decimal.Sum(parts...).String())
+	assert.Equal(t, a, decimal.Min(a, b))
+	assert.Equal(t, b, decimal.Max(a, b))
+
+	assert.Panics(t, func() { one.Div(decimal.Zero, decimal.RoundDown) })
+	assert.Panics(t, func() { big.Mul(big, decimal.RoundDown) })
+}
+
+// TestDecimalSqrt 测试整数平方根及舍入
+func TestDecimalSqrt(t *testing.T) {
+	assert.Equal(t, decimal.FromInt(3), decimal.FromInt(9).Sqrt(decimal.RoundDown))
+	assert.Equal(t, decimal.Zero, decimal.Zero.Sqrt(decimal.RoundUp))
+	two := decimal.FromInt(2)
+	assert.Equal(t, "1.414213", two.Sqrt(decimal.RoundDown).String())
+	assert.Equal(t, "1.414214", two.Sqrt(decimal.RoundHalfEven).String())
+	assert.Equal(t, "1.414214", two.Sqrt(decimal.RoundCeiling).String())
+	assert.Equal(t, "0.001", decimal.RequireFromString("0.000001").Sqrt(decimal.RoundDown).String())
+	// 最大值的平方根仍然精确到最后一位
+	max := decimal.FromUnits(math.MaxInt64)
+	root := max.Sqrt(decimal.RoundDown)
+	assert.False(t, root.Mul(root, decimal.RoundDown).GreaterThan(max))
+	next := root.Add(decimal.FromUnits(1))
+	assert.Panics(t, func() { next.Mul(next, decimal.RoundUp) })
+	assert.Panics(t, func() { two.Neg().Sqrt(decimal.RoundDown) })
+}
+
+// TestDecimalJSON 测试 JSON 编解码
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]decimal.Decimal{"amount": decimal.RequireFromString("1234.5")})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1234.5}`, string(data))
+
+	var body struct {
+		Amount decimal.Decimal `json:"amount"`
+	}
+	for input, expected := range map[string]string{
+		`{"amount":250.5}`:              "250.5",
+		`{"amount":"0.1"}`:              "0.1",
+		`{"amount":1e3}`:                "1000",
+		`{"amount":1.5e-6}`:             "",
+		`{"amount":"1e3"}`:              "",
+		`{"amount":0.00001}`:            "0.00001",
+		`{"amount":"999999999.999999"}`: "999999999.999999",
+		`{"amount":-999999999.999999}`:  "-999999999.999999",
+		`{"amount":1e9}`:                "",
+		`{"amount":"-1000000000"}`:      "",
+	} {
+		body.Amount = decimal.Zero
+		err := json.Unmarshal([]byte(input), &body)
+		if expected == "" {
+			assert.Error(t, err, input)
+			continue
+		}
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expected, body.Amount.String(), input)
+		}
+	}
+}
+
+// TestDecimalScan 测试从数据库读取
+func TestDecimalScan(t *testing.T) {
+	var d decimal.Decimal
+	assert.NoError(t, d.Scan(0.30000000000000004))
+	assert.Equal(t, "0.3", d.String())
+	assert.NoError(t, d.Scan([]byte("650000.000001")))
+	assert.Equal(t, "650000.000001", d.String())
+	assert.NoError(t, d.Scan(int64(-16000)))
+	assert.Equal(t, decimal.FromInt(-16000), d)
+	assert.NoError(t, d.Scan(nil))
+	assert.True(t, d.IsZero())
+	assert.Error(t, d.Scan(true))
+
+	value, err := decimal.RequireFromString("7.25").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "7.250000", value)
+}
@@ -0,0 +1,526 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"yolo/activitypub"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// remoteInstance 模拟的远程实例，有一个参与者和校验签名的收件箱
+type remoteInstance struct {
+	server    *httptest.Server
+	client    *activitypub.Client
+	key       *rsa.PrivateKey
+	publicPEM string
+
+	mu       sync.Mutex
+	received []activitypub.Activity
+	failures int // 接下来多少次投递返回 500
+}
+
+// newRemoteInstance 启动远程实例
+func newRemoteInstance(t *testing.T) *remoteInstance {
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := activitypub.ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &remoteInstance{
+		client:    activitypub.NewClient(activitypub.ClientConfig{Timeout: 5 * time.Second, Insecure: true}),
+		key:       key,
+		publicPEM: publicPEM,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/mallory", func(w http.ResponseWriter, r *http.Request) {
+		actorID := remote.actorID()
+		w.Header().Set("Content-Type", activitypub.ContentType)
+		json.NewEncoder(w).Encode(activitypub.Actor{
+			Context:           activitypub.DefaultContext,
+			ID:                actorID,
+			Type:              activitypub.TypePerson,
+			PreferredUsername: "mallory",
+			Inbox:             actorID + "/inbox",
+			Endpoints:         &activitypub.Endpoints{SharedInbox: remote.server.URL + "/inbox"},
+			PublicKey:         activitypub.PublicKey{ID: actorID + "#main-key", Owner: actorID, PublicKeyPEM: publicPEM},
+		})
+	})
+	inbox := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, err := activitypub.VerifyRequest(r, body, time.Now(), func(keyID string) (*rsa.PublicKey, string, error) {
+			actorURL, _, _ := strings.Cut(keyID, "#")
+			actor, err := remote.client.FetchActor(context.Background(), actorURL)
+			if err != nil {
+				return nil, "", err
+			}
+			publicKey, err := activitypub.ParsePublicKey(actor.PublicKey.PublicKeyPEM)
+			return publicKey, actor.ID, err
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		remote.mu.Lock()
+		defer remote.mu.Unlock()
+		if remote.failures > 0 {
+			remote.failures--
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		var activity activitypub.Activity
+		json.Unmarshal(body, &activity)
+		remote.received = append(remote.received, activity)
+		w.WriteHeader(http.StatusAccepted)
+	}
+	mux.HandleFunc("/inbox", inbox)
+	mux.HandleFunc("/users/mallory/inbox", inbox)
+	remote.server = httptest.NewServer(mux)
+	return remote
+}
+
+// actorID 远程参与者ID
+func (r *remoteInstance) actorID() string {
+	return r.server.URL + "/users/mallory"
+}
+
+// activities 取出收到的活动并清空
+func (r *remoteInstance) activities() []activitypub.Activity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	received := r.received
+	r.received = nil
+	return received
+}
+
+// FederationTestSuite ActivityPub 联邦测试套件
+type FederationTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	server *httptest.Server
+	remote *remoteInstance
+	alice  *models.User
+}
+
+// SetupSuite 测试套件初始化，本站和远程实例都在进程内通过 HTTP 互相访问
+func (suite *FederationTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+	suite.server = httptest.NewServer(suite.router)
+	suite.remote = newRemoteInstance(suite.T())
+
+	cfg := services.DefaultFederationConfig()
+	cfg.Enabled = true
+	cfg.BaseURL = suite.server.URL
+	cfg.MaxAttempts = 3
+	cfg.Client.Insecure = true
+	suite.Require().NoError(services.FederationService.SetConfig(cfg))
+}
+
+// TearDownSuite 测试套件清理
+func (suite *FederationTestSuite) TearDownSuite() {
+	services.FederationService.SetConfig(services.DefaultFederationConfig())
+	suite.remote.server.Close()
+	suite.server.Close()
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *FederationTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+	suite.remote.activities()
+	suite.remote.mu.Lock()
+	suite.remote.failures = 0
+	suite.remote.mu.Unlock()
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// actorURI 本站 alice 的参与者ID
+func (suite *FederationTestSuite) actorURI() string {
+	return suite.server.URL + "/ap/users/alice"
+}
+
+// get 请求本站的联邦接口
+func (suite *FederationTestSuite) get(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Accept", activitypub.ContentType)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// postInbox 以远程参与者的身份签名并投递活动到 alice 的收件箱
+func (suite *FederationTestSuite) postInbox(activity any, sign bool) *http.Response {
+	body, err := json.Marshal(activity)
+	suite.Require().NoError(err)
+	req, err := http.NewRequest("POST", suite.actorURI()+"/inbox", bytes.NewReader(body))
+	suite.Require().NoError(err)
+	req.Header.Set("Content-Type", activitypub.LDContentType)
+	if sign {
+		suite.Require().NoError(activitypub.SignRequest(req, suite.remote.actorID()+"#main-key", suite.remote.key, body))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	return resp
+}
+
+// follow 远程参与者关注 alice
+func (suite *FederationTestSuite) follow() *activitypub.Activity {
+	follow := &activitypub.Activity{
+		Context: activitypub.ActivityStreamsContext,
+		ID:      suite.remote.actorID() + "#follows/1",
+		Type:    activitypub.TypeFollow,
+		Actor:   suite.remote.actorID(),
+		Object:  json.RawMessage(`"` + suite.actorURI() + `"`),
+	}
+	suite.Require().Equal(http.StatusAccepted, suite.postInbox(follow, true).StatusCode)
+	return follow
+}
+
+// deliver 投递所有到期的活动并返回远程实例收到的活动
+func (suite *FederationTestSuite) deliver() []activitypub.Activity {
+	_, err := services.FederationService.DeliverDue(time.Now())
+	suite.Require().NoError(err)
+	return suite.remote.activities()
+}
+
+// TestWebFingerAndActor 测试 WebFinger 和参与者文档
+func (suite *FederationTestSuite) TestWebFingerAndActor() {
+	host := strings.TrimPrefix(suite.server.URL, "http://")
+	w := suite.get("/.well-known/webfinger?resource=" + url.QueryEscape("acct:alice@"+host))
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "application/jrd+json; charset=utf-8", w.Header().Get("Content-Type"))
+	var finger activitypub.WebFinger
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &finger))
+	assert.Equal(suite.T(), "acct:alice@"+host, finger.Subject)
+	suite.Require().Len(finger.Links, 1)
+	assert.Equal(suite.T(), suite.actorURI(), finger.Links[0].Href)
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/.well-known/webfinger?resource=acct:alice@other.example").Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/.well-known/webfinger?resource=acct:nobody@"+host).Code)
+
+	w = suite.get("/ap/users/alice")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.True(suite.T(), activitypub.IsActivityPubType(w.Header().Get("Content-Type")))
+	var actor activitypub.Actor
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &actor))
+	assert.Equal(suite.T(), suite.actorURI(), actor.ID)
+	assert.Equal(suite.T(), activitypub.TypePerson, actor.Type)
+	assert.Equal(suite.T(), suite.actorURI()+"/inbox", actor.Inbox)
+	assert.Equal(suite.T(), suite.actorURI()+"#main-key", actor.PublicKey.ID)
+	_, err := activitypub.ParsePublicKey(actor.PublicKey.PublicKeyPEM)
+	assert.NoError(suite.T(), err)
+
+	// 密钥只生成一次
+	w = suite.get("/ap/users/alice")
+	var again activitypub.Actor
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &again))
+	assert.Equal(suite.T(), actor.PublicKey.PublicKeyPEM, again.PublicKey.PublicKeyPEM)
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/ap/users/nobody").Code)
+}
+
+// TestFollowAndDeliverPosts 测试远程关注、Accept 回复以及帖子的发布和删除投递
+func (suite *FederationTestSuite) TestFollowAndDeliverPosts() {
+	follow := suite.follow()
+
+	var follower models.RemoteFollower
+	suite.Require().NoError(suite.db.Preload("RemoteActor").Where("user_id = ?", suite.alice.ID).First(&follower).Error)
+	assert.Equal(suite.T(), follow.ID, follower.ActivityID)
+	assert.Equal(suite.T(), suite.remote.actorID(), follower.RemoteActor.URI)
+	assert.Equal(suite.T(), "mallory", follower.RemoteActor.Username)
+
+	received := suite.deliver()
+	suite.Require().Len(received, 1)
+	assert.Equal(suite.T(), activitypub.TypeAccept, received[0].Type)
+	assert.Equal(suite.T(), suite.actorURI(), received[0].Actor)
+	assert.Equal(suite.T(), follow.ID, received[0].ObjectID())
+
+	// 公开帖子投递 Create(Note) 到共享收件箱
+	post, err := services.PostService.CreatePost(suite.alice.ID, "hello <b>fediverse</b>\nsecond line")
+	suite.Require().NoError(err)
+	var delivery models.FederationDelivery
+	suite.Require().NoError(suite.db.Where("status = ?", models.DeliveryStatusPending).First(&delivery).Error)
+	assert.Equal(suite.T(), suite.remote.server.URL+"/inbox", delivery.Inbox)
+
+	received = suite.deliver()
+	suite.Require().Len(received, 1)
+	assert.Equal(suite.T(), activitypub.TypeCreate, received[0].Type)
+	var note activitypub.Note
+	suite.Require().NoError(json.Unmarshal(received[0].Object, &note))
+	assert.Equal(suite.T(), suite.server.URL+"/ap/posts/"+post.ID.String(), note.ID)
+	assert.Equal(suite.T(), suite.actorURI(), note.AttributedTo)
+	assert.Equal(suite.T(), "<p>hello &lt;b&gt;fediverse&lt;/b&gt;<br>second line</p>", note.Content)
+	assert.Equal(suite.T(), []string{activitypub.PublicCollection}, note.To)
+
+	// 非公开帖子不联邦
+	_, err = services.PostService.CreatePostWithOptions(suite.alice.ID, services.CreatePostOptions{
+		Content:    "followers only",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), suite.deliver())
+
+	// 删除帖子投递 Delete(Tombstone)
+	suite.Require().NoError(services.PostService.DeletePost(suite.alice.ID, post.ID))
+	received = suite.deliver()
+	suite.Require().Len(received, 1)
+	assert.Equal(suite.T(), activitypub.TypeDelete, received[0].Type)
+	assert.Equal(suite.T(), note.ID, received[0].ObjectID())
+
+	// 取消关注后不再投递
+	undo, err := activitypub.NewActivity(suite.remote.actorID()+"#follows/1/undo", activitypub.TypeUndo, suite.remote.actorID(), follow)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusAccepted, suite.postInbox(undo, true).StatusCode)
+	var count int64
+	suite.db.Model(&models.RemoteFollower{}).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+
+	_, err = services.PostService.CreatePost(suite.alice.ID, "nobody is listening")
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), suite.deliver())
+}
+
+// TestDeliveryRetry 测试投递失败后按退避时间重试，用尽次数后放弃
+func (suite *FederationTestSuite) TestDeliveryRetry() {
+	suite.follow()
+	suite.remote.mu.Lock()
+	suite.remote.failures = 1
+	suite.remote.mu.Unlock()
+
+	now := time.Now()
+	delivered, err := services.FederationService.DeliverDue(now)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 0, delivered)
+
+	var delivery models.FederationDelivery
+	suite.Require().NoError(suite.db.First(&delivery).Error)
+	assert.Equal(suite.T(), models.DeliveryStatusPending, delivery.Status)
+	assert.Equal(suite.T(), 1, delivery.Attempts)
+	suite.Require().NotNil(delivery.LastError)
+	assert.Contains(suite.T(), *delivery.LastError, "500")
+	assert.WithinDuration(suite.T(), now.Add(time.Minute), delivery.NextAttemptAt, time.Second)
+
+	// 未到重试时间不投递
+	delivered, _ = services.FederationService.DeliverDue(now.Add(30 * time.Second))
+	assert.Equal(suite.T(), 0, delivered)
+
+	delivered, _ = services.FederationService.DeliverDue(now.Add(2 * time.Minute))
+	assert.Equal(suite.T(), 1, delivered)
+	suite.Require().NoError(suite.db.First(&delivery).Error)
+	assert.Equal(suite.T(), models.DeliveryStatusDelivered, delivery.Status)
+	assert.Nil(suite.T(), delivery.LastError)
+	suite.Require().Len(suite.remote.activities(), 1)
+
+	// 重试次数用尽后标记为失败
+	_, err = services.PostService.CreatePost(suite.alice.ID, "never arrives")
+	suite.Require().NoError(err)
+	suite.remote.mu.Lock()
+	suite.remote.failures = 10
+	suite.remote.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		services.FederationService.DeliverDue(now.Add(time.Duration(i+1) * 24 * time.Hour))
+	}
+	var failed models.FederationDelivery
+	suite.Require().NoError(suite.db.Where("status <> ?", models.DeliveryStatusDelivered).First(&failed).Error)
+	assert.Equal(suite.T(), models.DeliveryStatusFailed, failed.Status)
+	assert.Equal(suite.T(), 3, failed.Attempts)
+	assert.Empty(suite.T(), suite.remote.activities())
+}
+
+// TestInboxRejectsInvalidRequests 测试收件箱拒绝未签名、被篡改或冒名的活动
+func (suite *FederationTestSuite) TestInboxRejectsInvalidRequests() {
+	follow := map[string]string{
+		"id":     suite.remote.actorID() + "#follows/2",
+		"type":   activitypub.TypeFollow,
+		"actor":  suite.remote.actorID(),
+		"object": suite.actorURI(),
+	}
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.postInbox(follow, false).StatusCode)
+
+	// 签名后篡改请求体
+	body, _ := json.Marshal(follow)
+	req, _ := http.NewRequest("POST", suite.actorURI()+"/inbox", bytes.NewReader(body))
+	suite.Require().NoError(activitypub.SignRequest(req, suite.remote.actorID()+"#main-key", suite.remote.key, body))
+	tampered := bytes.Replace(body, []byte("follows/2"), []byte("follows/3"), 1)
+	req.Body = io.NopCloser(bytes.NewReader(tampered))
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+
+	// 以自己的密钥冒充其他参与者
+	follow["actor"] = suite.server.URL + "/ap/users/alice"
+	assert.Equal(suite.T(), http.StatusForbidden, suite.postInbox(follow, true).StatusCode)
+
+	var count int64
+	suite.db.Model(&models.RemoteFollower{}).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+// TestOutboxAndNote 测试发件箱和 Note 对象只包含公开帖子
+func (suite *FederationTestSuite) TestOutboxAndNote() {
+	post, err := services.PostService.CreatePost(suite.alice.ID, "public post")
+	suite.Require().NoError(err)
+	private, err := services.PostService.CreatePostWithOptions(suite.alice.ID, services.CreatePostOptions{
+		Content:    "followers only",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+
+	w := suite.get("/ap/users/alice/outbox")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var outbox struct {
+		TotalItems   int64                  `json:"totalItems"`
+		OrderedItems []activitypub.Activity `json:"orderedItems"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &outbox))
+	assert.Equal(suite.T(), int64(1), outbox.TotalItems)
+	suite.Require().Len(outbox.OrderedItems, 1)
+	assert.Equal(suite.T(), activitypub.TypeCreate, outbox.OrderedItems[0].Type)
+
+	w = suite.get("/ap/posts/" + post.ID.String())
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var note activitypub.Note
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &note))
+	assert.Equal(suite.T(), "<p>public post</p>", note.Content)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/ap/posts/"+private.ID.String()).Code)
+}
+
+// TestFederationDisabled 测试未开启联邦时所有接口返回 404
+func (suite *FederationTestSuite) TestFederationDisabled() {
+	cfg := services.DefaultFederationConfig()
+	suite.Require().NoError(services.FederationService.SetConfig(cfg))
+	defer func() {
+		cfg.Enabled = true
+		cfg.BaseURL = suite.server.URL
+		cfg.MaxAttempts = 3
+		cfg.Client.Insecure = true
+		services.FederationService.SetConfig(cfg)
+	}()
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/ap/users/alice").Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/.well-known/webfinger?resource=acct:alice@example.com").Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.postInbox(map[string]string{"type": "Follow"}, true).StatusCode)
+}
+
+// TestFederationSuite 运行联邦测试套件
+func TestFederationSuite(t *testing.T) {
+	suite.Run(t, new(FederationTestSuite))
+}
+
+// TestHTTPSignatures 测试 HTTP Signatures 的签名和校验
+func TestHTTPSignatures(t *testing.T) {
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	assert.NoError(t, err)
+	key, err := activitypub.ParsePrivateKey(privatePEM)
+	assert.NoError(t, err)
+	publicKey, err := activitypub.ParsePublicKey(publicPEM)
+	assert.NoError(t, err)
+	resolve := func(keyID string) (*rsa.PublicKey, string, error) {
+		return publicKey, "https://remote.example/users/bob", nil
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("POST", "https://yolo.example/ap/users/alice/inbox", bytes.NewReader(body))
+		assert.NoError(t, activitypub.SignRequest(req, "https://remote.example/users/bob#main-key", key, body))
+		return req
+	}
+
+	owner, err := activitypub.VerifyRequest(newRequest(), body, time.Now(), resolve)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://remote.example/users/bob", owner)
+
+	// 签名的头被修改
+	req := newRequest()
+	req.URL.Path = "/ap/users/carol/inbox"
+	_, err = activitypub.VerifyRequest(req, body, time.Now(), resolve)
+	assert.ErrorIs(t, err, activitypub.ErrInvalidSignature)
+
+	// 请求体与摘要不一致
+	_, err = activitypub.VerifyRequest(newRequest(), []byte(`{"type":"Undo"}`), time.Now(), resolve)
+	assert.ErrorIs(t, err, activitypub.ErrDigestMismatch)
+
+	// 超出允许的时间偏差
+	_, err = activitypub.VerifyRequest(newRequest(), body, time.Now().Add(2*time.Hour), resolve)
+	assert.ErrorIs(t, err, activitypub.ErrSignatureExpired)
+
+	// 缺少签名
+	req, _ = http.NewRequest("POST", "https://yolo.example/ap/users/alice/inbox", bytes.NewReader(body))
+	_, err = activitypub.VerifyRequest(req, body, time.Now(), resolve)
+	assert.ErrorIs(t, err, activitypub.ErrMissingSignature)
+
+	// 其他密钥的签名
+	otherPEM, _, _ := activitypub.GenerateKey()
+	other, _ := activitypub.ParsePrivateKey(otherPEM)
+	req, _ = http.NewRequest("POST", "https://yolo.example/ap/users/alice/inbox", bytes.NewReader(body))
+	assert.NoError(t, activitypub.SignRequest(req, "https://remote.example/users/bob#main-key", other, body))
+	_, err = activitypub.VerifyRequest(req, body, time.Now(), resolve)
+	assert.ErrorIs(t, err, activitypub.ErrInvalidSignature)
+}
+
+// TestClientRejectsSchemeChangeOnRedirect 测试重定向不能改变协议
+func TestClientRejectsSchemeChangeOnRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://"+r.Host+"/users/bob", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := activitypub.NewClient(activitypub.ClientConfig{Timeout: 5 * time.Second, Insecure: true})
+	_, err := client.FetchActor(context.Background(), server.URL+"/users/bob")
+	assert.ErrorIs(t, err, activitypub.ErrInvalidURL)
+}
+
+// TestClientRejectsActorFromRedirectedHost 测试重定向到其他主机后返回的文档不能冒充原主机的参与者
+func TestClientRejectsActorFromRedirectedHost(t *testing.T) {
+	origin := httptest.NewServer(nil)
+	defer origin.Close()
+	pemKey, _, _ := activitypub.GenerateKey()
+	evil := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", activitypub.ContentType)
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":        origin.URL + "/users/bob",
+			"type":      "Person",
+			"inbox":     origin.URL + "/users/bob/inbox",
+			"publicKey": map[string]any{"id": origin.URL + "/users/bob#main-key", "owner": origin.URL + "/users/bob", "publicKeyPem": pemKey},
+		})
+	}))
+	defer evil.Close()
+	origin.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, evil.URL+r.URL.Path, http.StatusFound)
+	})
+
+	client := activitypub.NewClient(activitypub.ClientConfig{Timeout: 5 * time.Second, Insecure: true})
+	_, err := client.FetchActor(context.Background(), origin.URL+"/users/bob")
+	assert.ErrorIs(t, err, activitypub.ErrUnexpectedType)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"yolo/feed"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// FeedTestSuite 用户订阅源测试套件
+type FeedTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *FeedTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *FeedTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *FeedTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+	// 资料修改时间也计入订阅源的更新时间，提前到帖子之前
+	suite.db.Model(&models.User{}).Where("1 = 1").Update("updated_at", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// fetch 请求订阅源，headers 为附加的请求头
+func (suite *FeedTestSuite) fetch(path string, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/users/"+path, nil)
+	req.Host = "yolo.example"
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestAtomFeed 测试 Atom 订阅源的条目、标识和时间
+func (suite *FeedTestSuite) TestAtomFeed() {
+	published := time.Date(2025, 4, 1, 9, 30, 0, 0, time.UTC)
+	first := createPostAt(suite.T(), suite.db, suite.alice, "Shipping the new dashboard today\nmore details soon", published)
+	second := createPostAt(suite.T(), suite.db, suite.alice, "<b>escaped</b> & safe", published.Add(time.Hour))
+	_, err := services.PostService.CreatePostWithOptions(suite.alice.ID, services.CreatePostOptions{
+		Content:    "followers only",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+	createPostAt(suite.T(), suite.db, suite.bob, "someone else", published)
+
+	w := suite.fetch("alice/feed.atom", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "application/atom+xml; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var doc struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Content   string `xml:"content"`
+		} `xml:"entry"`
+	}
+	suite.Require().NoError(xml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(suite.T(), "urn:uuid:"+suite.alice.ID.String(), doc.ID)
+	assert.Equal(suite.T(), "Alice (@alice)", doc.Title)
+	assert.Contains(suite.T(), doc.Links[0].Href, "http://yolo.example/api/v1/users/alice/feed.atom")
+
+	// 只包含公开帖子，按时间倒序
+	suite.Require().Len(doc.Entries, 2)
+	assert.Equal(suite.T(), "urn:uuid:"+second.ID.String(), doc.Entries[0].ID)
+	assert.Equal(suite.T(), "<b>escaped</b> & safe", doc.Entries[0].Content)
+	assert.Equal(suite.T(), "urn:uuid:"+first.ID.String(), doc.Entries[1].ID)
+	assert.Equal(suite.T(), "Shipping the new dashboard today", doc.Entries[1].Title)
+	assert.Equal(suite.T(), "2025-04-01T09:30:00Z", doc.Entries[1].Published)
+	assert.Equal(suite.T(), "2025-04-01T10:30:00Z", doc.Updated)
+}
+
+// TestRSSAndJSONFeeds 测试 RSS 和 JSON Feed 使用相同的条目标识
+func (suite *FeedTestSuite) TestRSSAndJSONFeeds() {
+	original := createPostAt(suite.T(), suite.db, suite.bob, "original thought", time.Now().Add(-time.Hour))
+	post := createPostAt(suite.T(), suite.db, suite.alice, "line one\nline two", time.Now().Add(-30*time.Minute))
+	repost, err := services.PostService.Repost(suite.alice.ID, original.ID)
+	suite.Require().NoError(err)
+
+	w := suite.fetch("alice/feed.rss", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "application/rss+xml; charset=utf-8", w.Header().Get("Content-Type"))
+	var rss struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				GUID        string `xml:"guid"`
+				PubDate     string `xml:"pubDate"`
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	suite.Require().NoError(xml.Unmarshal(w.Body.Bytes(), &rss))
+	assert.Equal(suite.T(), "2.0", rss.Version)
+	suite.Require().Len(rss.Channel.Items, 2)
+	assert.Equal(suite.T(), "urn:uuid:"+repost.ID.String(), rss.Channel.Items[0].GUID)
+	assert.Equal(suite.T(), "Reposted @bob: original thought", rss.Channel.Items[0].Description)
+	assert.Equal(suite.T(), "line one<br>line two", rss.Channel.Items[1].Description)
+	_, err = time.Parse(time.RFC1123Z, rss.Channel.Items[1].PubDate)
+	assert.NoError(suite.T(), err)
+
+	w = suite.fetch("alice/feed.json", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "application/feed+json; charset=utf-8", w.Header().Get("Content-Type"))
+	var jsonFeed struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ID            string `json:"id"`
+			ContentText   string `json:"content_text"`
+			DatePublished string `json:"date_published"`
+		} `json:"items"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &jsonFeed))
+	assert.Equal(suite.T(), "https://jsonfeed.org/version/1.1", jsonFeed.Version)
+	suite.Require().Len(jsonFeed.Items, 2)
+	assert.Equal(suite.T(), "urn:uuid:"+post.ID.String(), jsonFeed.Items[1].ID)
+	assert.Equal(suite.T(), "line one\nline two", jsonFeed.Items[1].ContentText)
+
+	// 原帖删除后转发从订阅源中移除
+	suite.Require().NoError(services.PostService.DeletePost(suite.bob.ID, original.ID))
+	w = suite.fetch("alice/feed.json", nil)
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &jsonFeed))
+	assert.Len(suite.T(), jsonFeed.Items, 1)
+}
+
+// TestConditionalRequests 测试 ETag 和 Last-Modified 条件请求
+func (suite *FeedTestSuite) TestConditionalRequests() {
+	createPostAt(suite.T(), suite.db, suite.alice, "first", time.Now().Add(-time.Hour))
+
+	w := suite.fetch("alice/feed.atom", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	suite.Require().True(strings.HasPrefix(etag, `W/"`))
+	suite.Require().NotEmpty(lastModified)
+
+	w = suite.fetch("alice/feed.atom", map[string]string{"If-None-Match": etag})
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+	assert.Empty(suite.T(), w.Body.String())
+	w = suite.fetch("alice/feed.atom", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+
+	// 不同格式的 ETag 不同
+	w = suite.fetch("alice/feed.rss", map[string]string{"If-None-Match": etag})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// 发布新帖子后缓存失效
+	createPostAt(suite.T(), suite.db, suite.alice, "second", time.Now())
+	w = suite.fetch("alice/feed.atom", map[string]string{"If-None-Match": etag})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotEqual(suite.T(), etag, w.Header().Get("ETag"))
+	w = suite.fetch("alice/feed.atom", map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+// TestUnknownUser 测试不存在的用户
+func (suite *FeedTestSuite) TestUnknownUser() {
+	assert.Equal(suite.T(), http.StatusNotFound, suite.fetch("nobody/feed.atom", nil).Code)
+}
+
+// TestFeedSuite 运行订阅源测试套件
+func TestFeedSuite(t *testing.T) {
+	suite.Run(t, new(FeedTestSuite))
+}
+
+// TestFeedItemTitle 测试条目标题取正文第一行并截断
+func TestFeedItemTitle(t *testing.T) {
+	f := feed.Feed{
+		ID:    "urn:uuid:feed",
+		Title: "Feed",
+		Items: []feed.Item{{
+			ID:        "urn:uuid:item",
+			Content:   strings.Repeat("长", 100) + "\nsecond line",
+			Published: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	body, err := f.Atom()
+	assert.NoError(t, err)
+
+	var doc struct {
+		Entries []struct {
+			Title string `xml:"title"`
+		} `xml:"entry"`
+	}
+	assert.NoError(t, xml.Unmarshal(body, &doc))
+	assert.Equal(t, strings.Repeat("长", 79)+"…", doc.Entries[0].Title)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), f.LastModified())
+
+	_, err = f.Encode("opml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"testing"
+	"yolo/hub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHub_PublishToSubscribedChannels 测试事件只分发给订阅了该频道的订阅者
+func TestHub_PublishToSubscribedChannels(t *testing.T) {
+	h := hub.New(hub.DefaultConfig())
+
+	timeline, _, _ := h.Subscribe([]string{hub.TimelineChannel}, "")
+	defer timeline.Close()
+	stock, _, _ := h.Subscribe([]string{hub.StockChannel("sam")}, "")
+	defer stock.Close()
+
+	_, err := h.Publish(hub.StockChannel("SAM"), "price", map[string]float64{"price": 1.5})
+	require.NoError(t, err)
+
+	event := <-stock.Events()
+	assert.Equal(t, "stock:SAM", event.Channel)
+	assert.JSONEq(t, `{"price":1.5}`, string(event.Data))
+	assert.Empty(t, timeline.Events())
+}
+
+// TestHub_ResumeFromLastEventID 测试根据 Last-Event-ID 补发错过的事件
+func TestHub_ResumeFromLastEventID(t *testing.T) {
+	h := hub.New(hub.Config{HistorySize: 3})
+
+	first, err := h.Publish(hub.TimelineChannel, "post.created", 1)
+	require.NoError(t, err)
+	_, err = h.Publish("other", "post.created", 2)
+	require.NoError(t, err)
+	third, err := h.Publish(hub.TimelineChannel, "post.created", 3)
+	require.NoError(t, err)
+
+	sub, replay, resumed := h.Subscribe([]string{hub.TimelineChannel}, first.ID)
+	defer sub.Close()
+	assert.True(t, resumed)
+	require.Len(t, replay, 1)
+	assert.Equal(t, third.ID, replay[0].ID)
+
+	// 历史被淘汰后无法续传
+	for i := 0; i < 3; i++ {
+		_, err = h.Publish(hub.TimelineChannel, "post.created", i)
+		require.NoError(t, err)
+	}
+	_, replay, resumed = h.Subscribe([]string{hub.TimelineChannel}, first.ID)
+	assert.False(t, resumed)
+	assert.Empty(t, replay)
+
+	// 来自其他实例或重启前的ID无法续传
+	_, _, resumed = h.Subscribe([]string{hub.TimelineChannel}, "stale-1")
+	assert.False(t, resumed)
+}
+
+// TestHub_SlowSubscriberIsDisconnected 测试慢消费者被断开而不会阻塞发布
+func TestHub_SlowSubscriberIsDisconnected(t *testing.T) {
+	h := hub.New(hub.Config{SubscriberBuffer: 2})
+
+	slow, _, _ := h.Subscribe([]string{hub.TimelineChannel}, "")
+	for i := 0; i < 3; i++ {
+		_, err := h.Publish(hub.TimelineChannel, "post.created", i)
+		require.NoError(t, err)
+	}
+
+	assert.True(t, slow.Lagged())
+	assert.Equal(t, 0, h.SubscriberCount())
+
+	// 已缓冲的事件仍可读完，随后通道关闭
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	assert.Equal(t, 2, received)
+	slow.Close()
+}
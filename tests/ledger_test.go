@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// LedgerTestSuite 复式记账测试套件
+type LedgerTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *LedgerTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *LedgerTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *LedgerTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// TestInterleavedGiftsGrantOnce 测试交替赠送时开户只发放一次初始余额，余额与分录一致
+func (suite *LedgerTestSuite) TestInterleavedGiftsGrantOnce() {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := services.LedgerService.Gift(suite.alice.ID, "bob", decimal.FromInt(100), "")
+			assert.NoError(suite.T(), err)
+		}()
+	}
+	wg.Wait()
+
+	alice, err := services.LedgerService.GetBalance(suite.alice.ID)
+	suite.Require().NoError(err)
+	bob, err := services.LedgerService.GetBalance(suite.bob.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), decimal.FromInt(7000), alice)
+	assert.Equal(suite.T(), decimal.FromInt(9000), bob)
+
+	var grants int64
+	suite.db.Model(&models.JournalEntry{}).Where("type = ?", models.JournalTypeGrant).Count(&grants)
+	assert.Equal(suite.T(), int64(2), grants)
+
+	var treasury models.LedgerAccount
+	suite.Require().NoError(suite.db.Where("code = ?", models.LedgerTreasuryCode).First(&treasury).Error)
+	assert.Equal(suite.T(), decimal.FromInt(-16000), treasury.Balance)
+
+	report := assertLedgerBalanced(suite.T())
+	assert.Equal(suite.T(), int64(12), report.Entries)
+	assert.Equal(suite.T(), int64(3), report.Accounts)
+}
+
+// TestGift 测试赠送接口的校验和通知
+func (suite *LedgerTestSuite) TestGift() {
+	w := performRequest(suite.router, "POST", "/api/v1/users/bob/gifts", map[string]interface{}{"amount": 250.5, "memo": "thanks"}, suite.alice)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"balance":7749.5`)
+
+	var notification models.Notification
+	suite.Require().NoError(suite.db.Where("user_id = ? AND type = ?", suite.bob.ID, models.NotificationTypeGift).First(&notification).Error)
+	suite.Require().NotNil(notification.ActorID)
+	assert.Equal(suite.T(), suite.alice.ID, *notification.ActorID)
+
+	for _, body := range []map[string]interface{}{
+		{"amount": 0},
+		{"amount": -5},
+		{"amount": 0.0000001},
+		{"amount": 7749.51},
+		{"amount": "1000000000"},
+		{"amount": 1, "memo": strings.Repeat("a", services.MaxGiftMemoLen+1)},
+	} {
+		w = performRequest(suite.router, "POST", "/api/v1/users/bob/gifts", body, suite.alice)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+	w = performRequest(suite.router, "POST", "/api/v1/users/alice/gifts", map[string]interface{}{"amount": 1}, suite.alice)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = performRequest(suite.router, "POST", "/api/v1/users/nobody/gifts", map[string]interface{}{"amount": 1}, suite.alice)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestStatement 测试账户明细按时间倒序列出开户发放和赠送
+func (suite *LedgerTestSuite) TestStatement() {
+	w := performRequest(suite.router, "GET", "/api/v1/user/ledger", nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var statement controllers.LedgerStatementResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &statement))
+	assert.Empty(suite.T(), statement.Postings)
+	assert.Equal(suite.T(), models.DefaultUserBalance, statement.Balance)
+
+	_, err := services.LedgerService.Gift(suite.alice.ID, "bob", decimal.FromInt(40), "coffee")
+	suite.Require().NoError(err)
+
+	w = performRequest(suite.router, "GET", "/api/v1/user/ledger", nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &statement))
+	suite.Require().Len(statement.Postings, 2)
+	types := map[string]decimal.Decimal{}
+	for _, posting := range statement.Postings {
+		types[posting.Type] = posting.Amount
+	}
+	assert.Equal(suite.T(), map[string]decimal.Decimal{
+		models.JournalTypeGrant: decimal.FromInt(8000),
+		models.JournalTypeGift:  decimal.FromInt(-40),
+	}, types)
+	assert.Equal(suite.T(), decimal.FromInt(7960), statement.Balance)
+	assert.Equal(suite.T(), int64(2), statement.PageInfo.TotalPosts)
+}
+
+// TestEntriesAreImmutable 测试凭证和分录不能修改或删除
+func (suite *LedgerTestSuite) TestEntriesAreImmutable() {
+	entry, err := services.LedgerService.Gift(suite.alice.ID, "bob", decimal.FromInt(10), "")
+	suite.Require().NoError(err)
+
+	assert.ErrorIs(suite.T(), suite.db.Model(entry).Update("memo", "changed").Error, models.ErrLedgerImmutable)
+	assert.ErrorIs(suite.T(), suite.db.Delete(entry).Error, models.ErrLedgerImmutable)
+	posting := entry.Postings[0]
+	assert.ErrorIs(suite.T(), suite.db.Model(&posting).Update("amount", 0).Error, models.ErrLedgerImmutable)
+	assert.ErrorIs(suite.T(), suite.db.Delete(&posting).Error, models.ErrLedgerImmutable)
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestCheckInvariantsDetectsDrift 测试检查器能发现被篡改的余额和分录
+func (suite *LedgerTestSuite) TestCheckInvariantsDetectsDrift() {
+	entry, err := services.LedgerService.Gift(suite.alice.ID, "bob", decimal.FromInt(10), "")
+	suite.Require().NoError(err)
+	assertLedgerBalanced(suite.T())
+
+	suite.db.Exec("UPDATE ledger_accounts SET balance = balance + 1 WHERE owner_id = ?", suite.bob.ID)
+	report, err := services.LedgerService.CheckInvariants()
+	suite.Require().NoError(err)
+	assert.False(suite.T(), report.Balanced())
+	assert.Equal(suite.T(), decimal.FromInt(1), report.Total)
+	assert.Contains(suite.T(), report.MismatchedAccounts, "user:"+suite.bob.ID.String())
+	assert.Empty(suite.T(), report.UnbalancedEntries)
+
+	suite.db.Exec("UPDATE ledger_postings SET amount = amount + 1 WHERE id = ?", entry.Postings[1].ID)
+	report, err = services.LedgerService.CheckInvariants()
+	suite.Require().NoError(err)
+	// 分录也被改了之后余额快照与分录之和重新一致，但凭证不再平衡
+	assert.Empty(suite.T(), report.MismatchedAccounts)
+	suite.Require().Len(report.UnbalancedEntries, 1)
+	assert.Equal(suite.T(), entry.ID, report.UnbalancedEntries[0])
+
+	// 一个最小单位的偏差同样能被发现
+	suite.db.Exec("UPDATE ledger_postings SET amount = amount - 1 WHERE id = ?", entry.Postings[1].ID)
+	suite.db.Exec("UPDATE ledger_accounts SET balance = balance - 1.000001 WHERE owner_id = ?", suite.bob.ID)
+	report, err = services.LedgerService.CheckInvariants()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), decimal.FromUnits(-1), report.Total)
+	assert.Equal(suite.T(), []string{"user:" + suite.bob.ID.String()}, report.MismatchedAccounts)
+	assert.Empty(suite.T(), report.UnbalancedEntries)
+}
+
+// TestLargeBalancesStayExact 测试接近 MaxAmount 的余额多次增减后仍与分录之和精确一致
+func (suite *LedgerTestSuite) TestLargeBalancesStayExact() {
+	// 先赠送一次完成开户，再按一张平衡的凭证给 alice 补足到接近上限的余额
+	_, err := services.LedgerService.Gift(suite.alice.ID, "bob", decimal.FromInt(1), "")
+	suite.Require().NoError(err)
+	seed := decimal.RequireFromString("999990000.123457")
+	var accounts []models.LedgerAccount
+	suite.Require().NoError(suite.db.Where("owner_id = ?", suite.alice.ID).Or("code = ?", models.LedgerTreasuryCode).Find(&accounts).Error)
+	suite.Require().Len(accounts, 2)
+	entry := models.JournalEntry{Type: models.JournalTypeGrant, Memo: "seed"}
+	suite.Require().NoError(suite.db.Create(&entry).Error)
+	for _, account := range accounts {
+		amount := seed
+		if account.Code == models.LedgerTreasuryCode {
+			amount = seed.Neg()
+		}
+		suite.Require().NoError(suite.db.Create(&models.LedgerPosting{EntryID: entry.ID, AccountID: account.ID, Amount: amount}).Error)
+		suite.Require().NoError(suite.db.Model(&account).Update("balance", account.Balance.Add(amount)).Error)
+	}
+
+	expected := models.DefaultUserBalance.Sub(decimal.FromInt(1)).Add(seed)
+	// 按浮点数相加时十几次就会偏离一个最小单位
+	for _, amount := range []string{"0.000001", "0.3", "123.456789"} {
+		for i := 0; i < 20; i++ {
+			_, err := services.LedgerService.Gift(suite.alice.ID, "bob", decimal.RequireFromString(amount), "")
+			suite.Require().NoError(err)
+			expected = expected.Sub(decimal.RequireFromString(amount))
+		}
+	}
+	balance, err := services.LedgerService.GetBalance(suite.alice.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), expected.String(), balance.String())
+	assertLedgerBalanced(suite.T())
+}
+
+// TestLedgerSuite 运行复式记账测试套件
+func TestLedgerSuite(t *testing.T) {
+	suite.Run(t, new(LedgerTestSuite))
+}
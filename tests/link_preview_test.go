@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// LinkPreviewTestSuite 链接预览测试套件，抓取本地 httptest 服务器
+type LinkPreviewTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	server *httptest.Server
+	hits   atomic.Int32
+	user   *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *LinkPreviewTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		suite.hits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head>
+			<title>Article</title>
+			<meta property="og:title" content="Markets rally">
+			<meta property="og:description" content="Stocks closed higher on Friday.">
+			<meta property="og:image" content="/cover.png">
+			<meta property="og:site_name" content="Daily Ticker">
+		</head><body>...</body></html>`)
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<head><title>Other page</title></head>`)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	suite.server = httptest.NewServer(mux)
+}
+
+// TearDownSuite 测试套件清理
+func (suite *LinkPreviewTestSuite) TearDownSuite() {
+	suite.server.Close()
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前清理数据，允许抓取本机地址
+func (suite *LinkPreviewTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+	suite.hits.Store(0)
+
+	cfg := services.DefaultLinkPreviewConfig()
+	cfg.Fetch.AllowPrivate = true
+	services.LinkPreviewService.SetConfig(cfg)
+
+	var err error
+	suite.user, err = services.UserService.CreateUser("Lin", "lin", "lin@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// createPost 发帖并返回响应
+func (suite *LinkPreviewTestSuite) createPost(content string) controllers.PostResponse {
+	body, _ := json.Marshal(map[string]string{"content": content})
+	req := createAuthenticatedRequest("POST", "/api/v1/posts", body, suite.user.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	return post
+}
+
+// getPost 获取帖子
+func (suite *LinkPreviewTestSuite) getPost(id string) controllers.PostResponse {
+	req := createAuthenticatedRequest("GET", "/api/v1/posts/"+id, nil, suite.user.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	return post
+}
+
+// waitForFetch 等待帖子中的链接全部抓取结束
+func (suite *LinkPreviewTestSuite) waitForFetch() {
+	suite.Require().Eventually(func() bool {
+		var pending int64
+		suite.db.Model(&models.LinkPreview{}).Where("status = ?", models.LinkPreviewStatusPending).Count(&pending)
+		return pending == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestPreviewAttachedInBackground 测试发帖后后台抓取预览并随帖子返回
+func (suite *LinkPreviewTestSuite) TestPreviewAttachedInBackground() {
+	post := suite.createPost("Read this " + suite.server.URL + "/article#comments and " + suite.server.URL + "/other.")
+	assert.Empty(suite.T(), post.Links)
+
+	suite.waitForFetch()
+	post = suite.getPost(post.ID)
+	suite.Require().Len(post.Links, 2)
+	assert.Equal(suite.T(), controllers.LinkPreview{
+		URL:         suite.server.URL + "/article",
+		Title:       "Markets rally",
+		Description: "Stocks closed higher on Friday.",
+		ImageURL:    suite.server.URL + "/cover.png",
+		SiteName:    "Daily Ticker",
+	}, post.Links[0])
+	assert.Equal(suite.T(), "Other page", post.Links[1].Title)
+	assert.Equal(suite.T(), "127.0.0.1", post.Links[1].SiteName)
+}
+
+// TestPreviewCachedPerURL 测试同一链接只抓取一次，多个帖子共用预览
+func (suite *LinkPreviewTestSuite) TestPreviewCachedPerURL() {
+	first := suite.createPost(suite.server.URL + "/article")
+	suite.waitForFetch()
+	second := suite.createPost("again " + suite.server.URL + "/article and again " + suite.server.URL + "/article")
+	suite.waitForFetch()
+
+	assert.Equal(suite.T(), int32(1), suite.hits.Load())
+	assert.Len(suite.T(), suite.getPost(first.ID).Links, 1)
+	assert.Len(suite.T(), suite.getPost(second.ID).Links, 1)
+
+	// 缓存过期后再次被引用时刷新
+	suite.db.Model(&models.LinkPreview{}).Where("1 = 1").Update("fetched_at", time.Now().Add(-48*time.Hour))
+	suite.createPost("still relevant " + suite.server.URL + "/article")
+	suite.Require().Eventually(func() bool {
+		return suite.hits.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestFailedPreviewHidden 测试抓取失败和内网地址的链接不展示预览
+func (suite *LinkPreviewTestSuite) TestFailedPreviewHidden() {
+	post := suite.createPost(suite.server.URL + "/missing")
+	suite.waitForFetch()
+	assert.Empty(suite.T(), suite.getPost(post.ID).Links)
+
+	var preview models.LinkPreview
+	suite.Require().NoError(suite.db.First(&preview).Error)
+	assert.Equal(suite.T(), models.LinkPreviewStatusFailed, preview.Status)
+	suite.Require().NotNil(preview.Error)
+
+	// 默认配置不允许访问本机地址
+	services.LinkPreviewService.SetConfig(services.DefaultLinkPreviewConfig())
+	post = suite.createPost(suite.server.URL + "/article")
+	suite.waitForFetch()
+	assert.Empty(suite.T(), suite.getPost(post.ID).Links)
+	assert.Zero(suite.T(), suite.hits.Load())
+}
+
+// TestPreviewLimitAndDraftEdit 测试每帖链接数量上限，以及修改草稿后重新提取链接
+func (suite *LinkPreviewTestSuite) TestPreviewLimitAndDraftEdit() {
+	content := ""
+	for i := 0; i < services.MaxPostLinkPreviews+2; i++ {
+		content += fmt.Sprintf("%s/other?page=%d ", suite.server.URL, i)
+	}
+	suite.createPost(content)
+	var count int64
+	suite.db.Model(&models.PostLink{}).Count(&count)
+	assert.Equal(suite.T(), int64(services.MaxPostLinkPreviews), count)
+	suite.waitForFetch()
+
+	draft, err := services.PostService.CreatePostWithOptions(suite.user.ID, services.CreatePostOptions{
+		Content: "draft with " + suite.server.URL + "/other?page=0",
+		Draft:   true,
+	})
+	suite.Require().NoError(err)
+
+	content = "rewritten with " + suite.server.URL + "/article"
+	_, err = services.PostService.UpdateDraft(suite.user.ID, draft.ID, services.UpdateDraftOptions{Content: &content})
+	suite.Require().NoError(err)
+	suite.waitForFetch()
+
+	var links []models.PostLink
+	suite.Require().NoError(suite.db.Preload("Preview").Where("post_id = ?", draft.ID).Find(&links).Error)
+	suite.Require().Len(links, 1)
+	assert.Equal(suite.T(), "Markets rally", links[0].Preview.Title)
+}
+
+// TestLinkPreviewTestSuite 运行链接预览测试套件
+func TestLinkPreviewTestSuite(t *testing.T) {
+	suite.Run(t, new(LinkPreviewTestSuite))
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// MediaTestSuite 媒体附件测试套件
+type MediaTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	user   *models.User
+	other  *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *MediaTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *MediaTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *MediaTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.user, err = services.UserService.CreateUser("Mia", "mia", "mia@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.other, err = services.UserService.CreateUser("Oli", "oli", "oli@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// pngBytes 生成PNG图片
+func (suite *MediaTestSuite) pngBytes(width, height int) []byte {
+	var buf bytes.Buffer
+	suite.Require().NoError(png.Encode(&buf, solidImage(width, height, color.RGBA{R: 20, G: 120, B: 220, A: 255})))
+	return buf.Bytes()
+}
+
+// upload 以multipart方式上传文件
+func (suite *MediaTestSuite) upload(user *models.User, data []byte, altText string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "upload.bin")
+	part.Write(data)
+	writer.WriteField("alt_text", altText)
+	writer.Close()
+
+	req := createAuthenticatedRequest("POST", "/api/v1/media", body.Bytes(), user.ID.String())
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// uploadAndWait 上传并等待后台处理结束
+func (suite *MediaTestSuite) uploadAndWait(data []byte, altText string) controllers.MediaResponse {
+	w := suite.upload(suite.user, data, altText)
+	suite.Require().Equal(http.StatusAccepted, w.Code, w.Body.String())
+
+	var media controllers.MediaResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &media))
+	suite.Equal(models.MediaStatusProcessing, media.Status)
+
+	suite.Require().Eventually(func() bool {
+		req := createAuthenticatedRequest("GET", "/api/v1/media/"+media.ID, nil, suite.user.ID.String())
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &media))
+		return media.Status != models.MediaStatusProcessing
+	}, 5*time.Second, 10*time.Millisecond)
+	return media
+}
+
+// createPost 发布帖子
+func (suite *MediaTestSuite) createPost(body string) *httptest.ResponseRecorder {
+	req := createAuthenticatedRequest("POST", "/api/v1/posts", []byte(body), suite.user.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestImage_ProcessedAndAttached 测试图片处理并附加到帖子
+func (suite *MediaTestSuite) TestImage_ProcessedAndAttached() {
+	media := suite.uploadAndWait(suite.pngBytes(800, 600), "a blue square")
+
+	suite.Require().Equal(models.MediaStatusReady, media.Status)
+	assert.Equal(suite.T(), models.MediaTypeImage, media.Type)
+	assert.Equal(suite.T(), "image/png", media.ContentType)
+	assert.Equal(suite.T(), 800, media.Width)
+	assert.Equal(suite.T(), 600, media.Height)
+	assert.Equal(suite.T(), "a blue square", media.AltText)
+	suite.Require().NotNil(media.Blurhash)
+	assert.Len(suite.T(), *media.Blurhash, 28)
+	suite.Require().NotNil(media.ThumbnailURL)
+
+	// 缩略图通过存储接口对外提供
+	req, _ := http.NewRequest("GET", *media.ThumbnailURL, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "image/jpeg", w.Header().Get("Content-Type"))
+
+	// 仅附件、无文字的帖子
+	w = suite.createPost(fmt.Sprintf(`{"media_ids":["%s"]}`, media.ID))
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	suite.Require().Len(post.Media, 1)
+	assert.Equal(suite.T(), media, post.Media[0])
+
+	// 同一媒体不能附加两次
+	w = suite.createPost(fmt.Sprintf(`{"content":"again","media_ids":["%s"]}`, media.ID))
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// 替代文本在发布后仍可修改
+	req = createAuthenticatedRequest("PATCH", "/api/v1/media/"+media.ID, []byte(`{"alt_text":"updated"}`), suite.user.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	loaded, err := services.PostService.GetPostByID(suite.user.ID, uuid.MustParse(post.ID))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "updated", loaded.Media[0].AltText)
+}
+
+// TestCreatePost_MediaValidation 测试附件数量、归属和空帖子校验
+func (suite *MediaTestSuite) TestCreatePost_MediaValidation() {
+	var ids []string
+	for i := 0; i < 5; i++ {
+		ids = append(ids, `"`+suite.uploadAndWait(suite.pngBytes(10, 10), "").ID+`"`)
+	}
+
+	w := suite.createPost(`{"content":"too many","media_ids":[` + strings.Join(ids, ",") + `]}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.createPost(`{"content":""}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// 四个附件按传入顺序展示
+	w = suite.createPost(`{"content":"four","media_ids":[` + strings.Join(ids[:4], ",") + `]}`)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	suite.Require().Len(post.Media, 4)
+	for i, media := range post.Media {
+		assert.Equal(suite.T(), strings.Trim(ids[i], `"`), media.ID)
+	}
+
+	// 不能使用别人的媒体
+	req := createAuthenticatedRequest("POST", "/api/v1/posts",
+		[]byte(`{"content":"steal","media_ids":[`+ids[4]+`]}`), suite.other.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// 失败的事务不会留下帖子
+	var count int64
+	suite.db.Model(&models.Post{}).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+// TestUpload_Validation 测试上传类型和视频处理
+func (suite *MediaTestSuite) TestUpload_Validation() {
+	w := suite.upload(suite.user, []byte("just some text"), "")
+	assert.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code)
+
+	video := suite.uploadAndWait(buildTestMP4(1280, 720, 12*time.Second), "clip")
+	assert.Equal(suite.T(), models.MediaStatusReady, video.Status)
+	assert.Equal(suite.T(), models.MediaTypeVideo, video.Type)
+	assert.Equal(suite.T(), 1280, video.Width)
+	assert.Equal(suite.T(), int64(12000), video.DurationMs)
+
+	long := suite.uploadAndWait(buildTestMP4(1280, 720, 5*time.Minute), "")
+	assert.Equal(suite.T(), models.MediaStatusFailed, long.Status)
+	assert.NotNil(suite.T(), long.Error)
+
+	w = suite.createPost(`{"content":"failed","media_ids":["` + long.ID + `"]}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// 只能查看自己上传的媒体
+	req := createAuthenticatedRequest("GET", "/api/v1/media/"+video.ID, nil, suite.other.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/media/files/../secret", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.NotEqual(suite.T(), http.StatusOK, w.Code)
+}
+
+// TestMediaTestSuite 运行媒体附件测试套件
+func TestMediaTestSuite(t *testing.T) {
+	suite.Run(t, new(MediaTestSuite))
+}
@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ModerationTestSuite 举报与审核测试套件
+type ModerationTestSuite struct {
+	suite.Suite
+	router    *gin.Engine
+	db        *gorm.DB
+	author    *models.User
+	reporter  *models.User
+	moderator *models.User
+	other     *models.User
+	post      *models.Post
+}
+
+// SetupSuite 测试套件初始化
+func (suite *ModerationTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *ModerationTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *ModerationTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.author, err = services.UserService.CreateUser("Troll", "troll", "troll@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.reporter, err = services.UserService.CreateUser("Ria", "ria", "ria@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.other, err = services.UserService.CreateUser("Oz", "oz", "oz@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.moderator, err = services.UserService.CreateUser("Mod", "mod", "mod@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(suite.moderator).Update("role", models.UserRoleModerator).Error)
+
+	suite.post, err = services.PostService.CreatePost(suite.author.ID, "buy $SCAM now")
+	suite.Require().NoError(err)
+}
+
+// report 举报帖子并返回举报ID
+func (suite *ModerationTestSuite) report(user *models.User, reason string) string {
+	w := performRequest(suite.router, "POST", "/api/v1/reports",
+		fmt.Sprintf(`{"target_type":"post","target_id":"%s","reason":"%s"}`, suite.post.ID, reason), user)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var report controllers.ReportResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(suite.T(), models.ReportStatusOpen, report.Status)
+	return report.ID
+}
+
+// TestCreateReport_Validation 测试举报参数校验和重复举报
+func (suite *ModerationTestSuite) TestCreateReport_Validation() {
+	suite.report(suite.reporter, models.ReportReasonScam)
+
+	cases := []struct {
+		body string
+		code int
+	}{
+		{fmt.Sprintf(`{"target_type":"post","target_id":"%s","reason":"scam"}`, suite.post.ID), http.StatusConflict},
+		{fmt.Sprintf(`{"target_type":"post","target_id":"%s","reason":"boring"}`, suite.post.ID), http.StatusBadRequest},
+		{fmt.Sprintf(`{"target_type":"user","target_id":"%s","reason":"other"}`, suite.author.ID), http.StatusBadRequest},
+		{fmt.Sprintf(`{"target_type":"user","target_id":"%s","reason":"spam"}`, suite.reporter.ID), http.StatusBadRequest},
+		{fmt.Sprintf(`{"target_type":"stock","target_id":"%s","reason":"scam"}`, suite.post.ID), http.StatusNotFound},
+		{fmt.Sprintf(`{"target_type":"comment","target_id":"%s","reason":"spam"}`, suite.post.ID), http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := performRequest(suite.router, "POST", "/api/v1/reports", tc.body, suite.reporter)
+		assert.Equal(suite.T(), tc.code, w.Code, tc.body)
+	}
+
+	// 看不到的帖子不能举报
+	hidden, err := services.PostService.CreatePostWithOptions(suite.author.ID, services.CreatePostOptions{
+		Content:    "secret",
+		Visibility: models.VisibilityOnlyMe,
+	})
+	suite.Require().NoError(err)
+	w := performRequest(suite.router, "POST", "/api/v1/reports",
+		fmt.Sprintf(`{"target_type":"post","target_id":"%s","reason":"spam"}`, hidden.ID), suite.reporter)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// 股票举报归属于创作者
+	stock := &models.Stock{UserID: suite.author.ID, Name: "Troll", Symbol: "TROLL", Status: "active"}
+	suite.Require().NoError(suite.db.Create(stock).Error)
+	w = performRequest(suite.router, "POST", "/api/v1/reports",
+		fmt.Sprintf(`{"target_type":"stock","target_id":"%s","reason":"other","details":"pump and dump"}`, stock.ID), suite.reporter)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var report models.Report
+	suite.Require().NoError(suite.db.Where("target_type = ?", models.ReportTargetStock).First(&report).Error)
+	assert.Equal(suite.T(), suite.author.ID, report.TargetUserID)
+}
+
+// TestQueue_ClaimAndHidePost 测试审核队列、认领和隐藏帖子
+func (suite *ModerationTestSuite) TestQueue_ClaimAndHidePost() {
+	reportID := suite.report(suite.reporter, models.ReportReasonScam)
+	suite.report(suite.other, models.ReportReasonSpam)
+
+	// 普通用户不能访问审核队列
+	w := performRequest(suite.router, "GET", "/api/v1/moderation/reports", "", suite.reporter)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = performRequest(suite.router, "GET", "/api/v1/moderation/reports", "", suite.moderator)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var queue controllers.ReportsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &queue))
+	suite.Require().Len(queue.Reports, 2)
+	assert.Equal(suite.T(), reportID, queue.Reports[0].ID)
+	assert.Equal(suite.T(), suite.author.ID.String(), queue.Reports[0].TargetUser.ID)
+
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/claim", "", suite.moderator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/claim", "", suite.moderator)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// 其他审核员不能处理已认领的举报
+	second, err := services.UserService.CreateUser("Mod2", "mod2", "mod2@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(second).Update("role", models.UserRoleModerator).Error)
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/claim", "", second)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/dismiss", "", second)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/resolve",
+		`{"action":"hide_post","note":"Misleading financial promotion"}`, suite.moderator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	// 帖子对所有人（包括作者）隐藏
+	for _, user := range []*models.User{suite.author, suite.reporter} {
+		_, err := services.PostService.GetPostByID(user.ID, suite.post.ID)
+		assert.ErrorIs(suite.T(), err, services.ErrPostNotFound)
+	}
+
+	// 同一帖子的其他举报一并关闭，处理过的举报不能再次处理
+	var open int64
+	suite.db.Model(&models.Report{}).Where("status IN ?", []string{models.ReportStatusOpen, models.ReportStatusClaimed}).Count(&open)
+	assert.Equal(suite.T(), int64(0), open)
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/dismiss", "", suite.moderator)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// 被处理的用户可以看到处理记录并收到通知
+	w = performRequest(suite.router, "GET", "/api/v1/user/moderation-actions", "", suite.author)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var actions controllers.ModerationActionsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &actions))
+	suite.Require().Len(actions.Actions, 1)
+	assert.Equal(suite.T(), models.ModerationActionHidePost, actions.Actions[0].Action)
+	assert.Equal(suite.T(), models.ReportReasonScam, actions.Actions[0].Reason)
+	assert.Equal(suite.T(), "Misleading financial promotion", actions.Actions[0].Note)
+	assert.NotContains(suite.T(), w.Body.String(), suite.reporter.ID.String())
+	assert.NotContains(suite.T(), w.Body.String(), suite.moderator.ID.String())
+
+	var notification models.Notification
+	suite.Require().NoError(suite.db.Where("user_id = ? AND type = ?", suite.author.ID, models.NotificationTypeModeration).First(&notification).Error)
+	assert.Nil(suite.T(), notification.ActorID)
+}
+
+// TestSuspendAndWarn 测试封禁、警告和驳回
+func (suite *ModerationTestSuite) TestSuspendAndWarn() {
+	reportID := suite.report(suite.reporter, models.ReportReasonHarassment)
+
+	// 隐藏帖子只适用于帖子举报
+	w := performRequest(suite.router, "POST", "/api/v1/reports",
+		fmt.Sprintf(`{"target_type":"user","target_id":"%s","reason":"harassment"}`, suite.author.ID), suite.reporter)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var userReport controllers.ReportResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &userReport))
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+userReport.ID+"/resolve", `{"action":"hide_post"}`, suite.moderator)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+userReport.ID+"/resolve",
+		`{"action":"suspend_user","duration_hours":48}`, suite.moderator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var action controllers.ModerationActionResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &action))
+	suite.Require().NotNil(action.ExpiresAt)
+
+	// 封禁期间不能发帖
+	w = performRequest(suite.router, "POST", "/api/v1/posts", `{"content":"still here"}`, suite.author)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	_, err := services.PostService.CreatePost(suite.author.ID, "still here")
+	assert.ErrorIs(suite.T(), err, services.ErrUserSuspended)
+
+	// 用户举报的处理不会关闭同一用户帖子的举报
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+reportID+"/resolve", `{"action":"warn","note":"Be civil"}`, suite.moderator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	dismissedID := suite.report(suite.other, models.ReportReasonSpam)
+	w = performRequest(suite.router, "POST", "/api/v1/moderation/reports/"+dismissedID+"/dismiss", `{"note":"Not spam"}`, suite.moderator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	w = performRequest(suite.router, "GET", "/api/v1/moderation/reports?status=dismissed", "", suite.moderator)
+	var queue controllers.ReportsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &queue))
+	suite.Require().Len(queue.Reports, 1)
+	assert.Equal(suite.T(), dismissedID, queue.Reports[0].ID)
+	suite.Require().NotNil(queue.Reports[0].ActionID)
+
+	// 每个决定都有记录，驳回不发通知
+	actions, total, err := services.ModerationService.GetActionsForUser(suite.author.ID, 1, 10)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(3), total)
+	assert.Equal(suite.T(), models.ModerationActionDismiss, actions[0].Action)
+
+	var count int64
+	suite.db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", suite.author.ID, models.NotificationTypeModeration).Count(&count)
+	assert.Equal(suite.T(), int64(2), count)
+}
+
+// TestModerationTestSuite 运行举报与审核测试套件
+func TestModerationTestSuite(t *testing.T) {
+	suite.Run(t, new(ModerationTestSuite))
+}
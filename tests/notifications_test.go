@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// NotificationsTestSuite 通知测试套件
+type NotificationsTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *NotificationsTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *NotificationsTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *NotificationsTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// getNotifications 请求通知列表
+func (suite *NotificationsTestSuite) getNotifications(user *models.User, query string) controllers.NotificationsResponse {
+	req := createAuthenticatedRequest("GET", "/api/v1/notifications"+query, nil, user.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response controllers.NotificationsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// TestMentionAndRepost_CreateNotifications 测试提及和转发产生通知，自己提及自己不通知
+func (suite *NotificationsTestSuite) TestMentionAndRepost_CreateNotifications() {
+	post, err := services.PostService.CreatePost(suite.alice.ID, "hello @bob and @alice")
+	suite.Require().NoError(err)
+	_, err = services.PostService.Repost(suite.bob.ID, post.ID)
+	suite.Require().NoError(err)
+
+	bobs := suite.getNotifications(suite.bob, "")
+	suite.Require().Len(bobs.Notifications, 1)
+	assert.Equal(suite.T(), models.NotificationTypeMention, bobs.Notifications[0].Type)
+	assert.Equal(suite.T(), "Alice", bobs.Notifications[0].Actor.Name)
+	assert.Equal(suite.T(), post.ID.String(), *bobs.Notifications[0].PostID)
+	assert.Equal(suite.T(), int64(1), bobs.UnreadCount)
+
+	alices := suite.getNotifications(suite.alice, "")
+	suite.Require().Len(alices.Notifications, 1)
+	assert.Equal(suite.T(), models.NotificationTypeRepost, alices.Notifications[0].Type)
+}
+
+// TestMarkRead 测试标记已读和未读计数
+func (suite *NotificationsTestSuite) TestMarkRead() {
+	for i := 0; i < 3; i++ {
+		_, err := services.NotificationService.Emit(services.NotificationEvent{
+			UserID:  suite.bob.ID,
+			ActorID: &suite.alice.ID,
+			Type:    models.NotificationTypeFollow,
+		})
+		suite.Require().NoError(err)
+	}
+
+	list := suite.getNotifications(suite.bob, "")
+	suite.Require().Len(list.Notifications, 3)
+
+	body, _ := json.Marshal(controllers.MarkNotificationsReadRequest{IDs: []string{list.Notifications[0].ID}})
+	req := createAuthenticatedRequest("POST", "/api/v1/notifications/read", body, suite.bob.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	// 其他用户不能标记别人的通知
+	req = createAuthenticatedRequest("POST", "/api/v1/notifications/read", body, suite.alice.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{"updated":0}`, w.Body.String())
+
+	req = createAuthenticatedRequest("GET", "/api/v1/notifications/unread-count", nil, suite.bob.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.JSONEq(suite.T(), `{"unreadCount":2}`, w.Body.String())
+
+	unread := suite.getNotifications(suite.bob, "?unread=true")
+	assert.Len(suite.T(), unread.Notifications, 2)
+
+	req = createAuthenticatedRequest("POST", "/api/v1/notifications/read", []byte(`{}`), suite.bob.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.JSONEq(suite.T(), `{"updated":2}`, w.Body.String())
+
+	count, err := services.NotificationService.CountUnread(suite.bob.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+// TestPreferences 测试通知偏好决定保存和推送
+func (suite *NotificationsTestSuite) TestPreferences() {
+	var delivered []*models.Notification
+	services.NotificationService.RegisterDeliverer(func(n *models.Notification) {
+		delivered = append(delivered, n)
+	})
+
+	body, _ := json.Marshal(controllers.UpdateNotificationPreferencesRequest{
+		Preferences: []services.NotificationPreferenceSetting{
+			{Type: models.NotificationTypeMention, Store: false, Deliver: true},
+			{Type: models.NotificationTypeRepost, Store: true, Deliver: false},
+		},
+	})
+	req := createAuthenticatedRequest("PUT", "/api/v1/notifications/preferences", body, suite.bob.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	prefs, err := services.NotificationService.GetPreferences(suite.bob.ID)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), prefs, len(models.NotificationTypes))
+
+	// 提及：只推送不保存
+	_, err = services.PostService.CreatePost(suite.alice.ID, "hi @bob")
+	suite.Require().NoError(err)
+	// 转发：只保存不推送
+	post, err := services.PostService.CreatePost(suite.bob.ID, "my post")
+	suite.Require().NoError(err)
+	_, err = services.PostService.Repost(suite.alice.ID, post.ID)
+	suite.Require().NoError(err)
+
+	list := suite.getNotifications(suite.bob, "")
+	suite.Require().Len(list.Notifications, 1)
+	assert.Equal(suite.T(), models.NotificationTypeRepost, list.Notifications[0].Type)
+
+	suite.Require().Len(delivered, 1)
+	assert.Equal(suite.T(), models.NotificationTypeMention, delivered[0].Type)
+
+	// 非法类型
+	req = createAuthenticatedRequest("PUT", "/api/v1/notifications/preferences",
+		[]byte(`{"preferences":[{"type":"unknown","store":true,"deliver":true}]}`), suite.bob.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestNotificationsTestSuite 运行通知测试套件
+func TestNotificationsTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationsTestSuite))
+}
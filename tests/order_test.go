@@ -0,0 +1,439 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// OrderTestSuite 订单簿撮合测试套件
+type OrderTestSuite struct {
+	suite.Suite
+	router  *gin.Engine
+	db      *gorm.DB
+	creator *models.User
+	alice   *models.User
+	bob     *models.User
+	stock   *models.Stock
+}
+
+// SetupSuite 测试套件初始化
+func (suite *OrderTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *OrderTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *OrderTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	services.StockService.SetConfig(cfg)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stock, err = services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "SAM", Name: "Sam"})
+	suite.Require().NoError(err)
+}
+
+// place 下单，必须成功
+func (suite *OrderTestSuite) place(user *models.User, opts services.PlaceOrderOptions) *services.OrderResult {
+	result, err := services.OrderService.PlaceOrder(user.ID, "SAM", opts)
+	suite.Require().NoError(err)
+	return result
+}
+
+// limit 构造限价单参数
+func limit(side, price, quantity, timeInForce string) services.PlaceOrderOptions {
+	return services.PlaceOrderOptions{
+		Side:        side,
+		TimeInForce: timeInForce,
+		Price:       decimal.RequireFromString(price),
+		Quantity:    decimal.RequireFromString(quantity),
+	}
+}
+
+// giveShares 直接给用户发放持仓
+func (suite *OrderTestSuite) giveShares(user *models.User, quantity int64) {
+	suite.Require().NoError(suite.db.Create(&models.UserHolding{UserID: user.ID, StockID: suite.stock.ID, Quantity: decimal.FromInt(quantity)}).Error)
+}
+
+// holding 用户持仓
+func (suite *OrderTestSuite) holding(user *models.User) models.UserHolding {
+	var holding models.UserHolding
+	suite.db.Where("user_id = ? AND stock_id = ?", user.ID, suite.stock.ID).First(&holding)
+	return holding
+}
+
+// balance 用户 YOLO 余额
+func (suite *OrderTestSuite) balance(user *models.User) decimal.Decimal {
+	balance, err := services.LedgerService.GetBalance(user.ID)
+	suite.Require().NoError(err)
+	return balance
+}
+
+// TestRestingOrderMatches 测试挂单冻结资产并与后来的对手单按挂单价成交
+func (suite *OrderTestSuite) TestRestingOrderMatches() {
+	// 高于池中价格的卖单不会卖给池子，全部挂单
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/orders", map[string]interface{}{
+		"side": "sell", "price": "2", "quantity": 100,
+	}, suite.creator)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var placed controllers.PlaceOrderResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &placed))
+	assert.Equal(suite.T(), models.OrderStatusOpen, placed.Order.Status)
+	assert.Equal(suite.T(), models.TimeInForceGTC, placed.Order.TimeInForce)
+	assert.Empty(suite.T(), placed.Fills)
+	assert.Equal(suite.T(), decimal.FromInt(100), suite.holding(suite.creator).Locked)
+
+	result := suite.place(suite.alice, limit("buy", "2.5", "40", "gtc"))
+	suite.Require().Len(result.Trades, 1)
+	trade := result.Trades[0]
+	assert.Equal(suite.T(), decimal.FromInt(2), trade.Price)
+	assert.Equal(suite.T(), decimal.FromInt(80), trade.TotalValue)
+	suite.Require().NotNil(trade.BuyerID)
+	suite.Require().NotNil(trade.SellerID)
+	assert.Equal(suite.T(), suite.alice.ID, *trade.BuyerID)
+	assert.Equal(suite.T(), suite.creator.ID, *trade.SellerID)
+	assert.Equal(suite.T(), result.Order.ID, *trade.BuyOrderID)
+	assert.Equal(suite.T(), models.OrderStatusFilled, result.Order.Status)
+
+	assert.Equal(suite.T(), decimal.FromInt(7920), suite.balance(suite.alice))
+	assert.Equal(suite.T(), decimal.FromInt(8080), suite.balance(suite.creator))
+	assert.Equal(suite.T(), decimal.FromInt(40), suite.holding(suite.alice).Quantity)
+	creator := suite.holding(suite.creator)
+	assert.Equal(suite.T(), decimal.FromInt(349960), creator.Quantity)
+	assert.Equal(suite.T(), decimal.FromInt(60), creator.Locked)
+
+	var maker models.Order
+	suite.Require().NoError(suite.db.Where("id = ?", *trade.SellOrderID).First(&maker).Error)
+	assert.Equal(suite.T(), decimal.FromInt(40), maker.Filled)
+	assert.Equal(suite.T(), models.OrderStatusOpen, maker.Status)
+
+	// 冻结的股份不能再卖给流动性池
+	suite.place(suite.creator, limit("sell", "3", "349900", "gtc"))
+	_, err := services.AMMService.Trade(suite.creator.ID, "SAM", services.TradeOptions{Type: "sell", AmountIn: decimal.FromInt(1)})
+	assert.ErrorIs(suite.T(), err, services.ErrInsufficientShares)
+
+	w = performRequest(suite.router, "GET", "/api/v1/stocks/SAM/orderbook", nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var book controllers.OrderBookResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &book))
+	assert.Empty(suite.T(), book.Bids)
+	suite.Require().Len(book.Asks, 2)
+	assert.Equal(suite.T(), controllers.OrderBookLevelResponse{Price: decimal.FromInt(2), Quantity: decimal.FromInt(60), Orders: 1}, book.Asks[0])
+	assert.Equal(suite.T(), decimal.FromInt(3), book.Asks[1].Price)
+	assert.Equal(suite.T(), decimal.FromInt(1), book.MidPrice)
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestPriceTimePriority 测试先按价格、同价按下单先后成交，且重放相同的下单顺序得到相同的成交
+func (suite *OrderTestSuite) TestPriceTimePriority() {
+	run := func() []string {
+		suite.SetupTest()
+		suite.giveShares(suite.alice, 100)
+		suite.giveShares(suite.bob, 100)
+		first := suite.place(suite.alice, limit("sell", "1.5", "20", "gtc")).Order.ID
+		second := suite.place(suite.bob, limit("sell", "1.5", "20", "gtc")).Order.ID
+		cheaper := suite.place(suite.bob, limit("sell", "1.4", "10", "gtc")).Order.ID
+
+		result := suite.place(suite.creator, limit("buy", "1.5", "25", "ioc"))
+		suite.Require().Len(result.Trades, 2)
+		assert.Equal(suite.T(), cheaper, *result.Trades[0].SellOrderID)
+		assert.Equal(suite.T(), decimal.FromInt(10), result.Trades[0].Amount)
+		assert.Equal(suite.T(), first, *result.Trades[1].SellOrderID)
+		assert.Equal(suite.T(), decimal.FromInt(15), result.Trades[1].Amount)
+		assert.Equal(suite.T(), models.OrderStatusFilled, result.Order.Status)
+
+		var untouched models.Order
+		suite.Require().NoError(suite.db.Where("id = ?", second).First(&untouched).Error)
+		assert.True(suite.T(), untouched.Filled.IsZero())
+
+		var fills []string
+		for _, trade := range result.Trades {
+			fills = append(fills, fmt.Sprintf("%s@%s", trade.Amount, trade.Price))
+		}
+		assertLedgerBalanced(suite.T())
+		return fills
+	}
+	assert.Equal(suite.T(), run(), run())
+}
+
+// TestRemainderRoutesToPool 测试订单簿吃完后剩余部分在限价内与流动性池成交，再剩余的挂单
+func (suite *OrderTestSuite) TestRemainderRoutesToPool() {
+	suite.giveShares(suite.bob, 100)
+	suite.place(suite.bob, limit("sell", "1.005", "100", "gtc"))
+
+	result := suite.place(suite.alice, limit("buy", "1.01", "5000", "gtc"))
+	suite.Require().Len(result.Trades, 2)
+	assert.Equal(suite.T(), decimal.FromInt(100), result.Trades[0].Amount)
+	assert.NotNil(suite.T(), result.Trades[0].SellerID)
+	ammTrade := result.Trades[1]
+	assert.Nil(suite.T(), ammTrade.SellerID)
+	assert.Equal(suite.T(), result.Order.ID, *ammTrade.BuyOrderID)
+	assert.False(suite.T(), ammTrade.Price.GreaterThan(decimal.RequireFromString("1.01")))
+
+	// 池中价格被推到限价附近但不超过限价，剩余部分按限价冻结后挂单
+	pool := models.LiquidityPool{}
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	midPrice := pool.YoloReserve.Div(pool.StockReserve, decimal.RoundHalfEven)
+	assert.False(suite.T(), midPrice.GreaterThan(decimal.RequireFromString("1.01")))
+	assert.True(suite.T(), midPrice.GreaterThan(decimal.RequireFromString("1.0099")))
+	assert.Equal(suite.T(), decimal.FromInt(650000).Sub(ammTrade.Amount), pool.StockReserve)
+
+	order := result.Order
+	assert.Equal(suite.T(), models.OrderStatusOpen, order.Status)
+	assert.Equal(suite.T(), decimal.FromInt(100).Add(ammTrade.Amount), order.Filled)
+	assert.Equal(suite.T(), decimal.RequireFromString("1.01").Mul(order.Remaining(), decimal.RoundUp), order.Reserved)
+	spent := decimal.RequireFromString("100.5").Add(ammTrade.TotalValue).Add(order.Reserved)
+	assert.Equal(suite.T(), models.DefaultUserBalance.Sub(spent), suite.balance(suite.alice))
+	assert.Equal(suite.T(), order.Filled, suite.holding(suite.alice).Quantity)
+
+	// 市价卖单先吃掉买单，再卖给池子
+	sell := suite.place(suite.creator, services.PlaceOrderOptions{Side: "sell", Type: "market", Quantity: order.Remaining().Add(decimal.FromInt(10))})
+	suite.Require().Len(sell.Trades, 2)
+	assert.Equal(suite.T(), order.ID, *sell.Trades[0].BuyOrderID)
+	assert.Equal(suite.T(), decimal.RequireFromString("1.01"), sell.Trades[0].Price)
+	assert.Nil(suite.T(), sell.Trades[1].BuyerID)
+	assert.Equal(suite.T(), models.OrderStatusFilled, sell.Order.Status)
+
+	var filled models.Order
+	suite.Require().NoError(suite.db.Where("id = ?", order.ID).First(&filled).Error)
+	assert.Equal(suite.T(), models.OrderStatusFilled, filled.Status)
+	assert.True(suite.T(), filled.Reserved.IsZero())
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestMarketBuyLargerThanPool 测试超过池中储备的市价买单按余额能支付的数量成交，而不是溢出
+func (suite *OrderTestSuite) TestMarketBuyLargerThanPool() {
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/SAM/orders", map[string]interface{}{
+		"side": "buy", "type": "market", "quantity": 1000000,
+	}, suite.alice)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var placed controllers.PlaceOrderResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &placed))
+	assert.Equal(suite.T(), models.OrderStatusCancelled, placed.Order.Status)
+	suite.Require().Len(placed.Fills, 1)
+	assert.False(suite.T(), placed.Fills[0].TotalValue.GreaterThan(models.DefaultUserBalance))
+	balance := suite.balance(suite.alice)
+	assert.False(suite.T(), balance.IsNegative())
+	assert.True(suite.T(), balance.LessThan(decimal.FromUnits(10)), balance.String())
+	assert.Equal(suite.T(), placed.Fills[0].Shares, suite.holding(suite.alice).Quantity)
+
+	// 没有余额时拒绝
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/SAM/orders", map[string]interface{}{
+		"side": "buy", "type": "market", "quantity": 1000000,
+	}, suite.alice)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+	assertLedgerBalanced(suite.T())
+}
+
+// TestPoolFillIncludesFee 测试有手续费时略优于池中价格的限价单按含手续费的边际价格部分成交
+func (suite *OrderTestSuite) TestPoolFillIncludesFee() {
+	feeRate := decimal.RequireFromString("0.003")
+	cfg := services.StockService.Config()
+	cfg.TradeFeeRate = feeRate
+	services.StockService.SetConfig(cfg)
+	one := decimal.FromInt(1)
+
+	buyLimit := decimal.RequireFromString("1.005")
+	result := suite.place(suite.alice, limit("buy", "1.005", "5000", "ioc"))
+	suite.Require().Len(result.Trades, 1)
+	bought := result.Trades[0]
+	assert.True(suite.T(), bought.Amount.GreaterThan(decimal.FromInt(600)), bought.Amount.String())
+	assert.True(suite.T(), bought.Amount.LessThan(decimal.FromInt(700)), bought.Amount.String())
+	assert.False(suite.T(), bought.Price.GreaterThan(buyLimit))
+	assert.True(suite.T(), bought.Fee.IsPositive())
+	pool := models.LiquidityPool{}
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	marginal := pool.YoloReserve.Mul(one.Add(feeRate), decimal.RoundDown).Div(pool.StockReserve, decimal.RoundDown)
+	assert.False(suite.T(), marginal.GreaterThan(buyLimit), marginal.String())
+
+	sellLimit := decimal.RequireFromString("0.995")
+	result = suite.place(suite.creator, limit("sell", "0.995", "5000", "ioc"))
+	suite.Require().Len(result.Trades, 1)
+	sold := result.Trades[0]
+	assert.True(suite.T(), sold.Amount.IsPositive())
+	assert.True(suite.T(), sold.Amount.LessThan(decimal.FromInt(5000)), sold.Amount.String())
+	assert.False(suite.T(), sold.Price.LessThan(sellLimit))
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	marginal = pool.YoloReserve.Mul(one.Sub(feeRate), decimal.RoundUp).Div(pool.StockReserve, decimal.RoundUp)
+	assert.False(suite.T(), marginal.LessThan(sellLimit), marginal.String())
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestTimeInForce 测试 IOC 撤销剩余部分、FOK 不能全部成交时整单拒绝
+func (suite *OrderTestSuite) TestTimeInForce() {
+	// 低于池中价格的买单既无挂单也无法与池子成交
+	result := suite.place(suite.alice, limit("buy", "0.9", "100", "ioc"))
+	assert.Empty(suite.T(), result.Trades)
+	assert.Equal(suite.T(), models.OrderStatusCancelled, result.Order.Status)
+	assert.Equal(suite.T(), models.DefaultUserBalance, suite.balance(suite.alice))
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/SAM/orders", map[string]interface{}{
+		"side": "buy", "price": 0.9, "quantity": 100, "time_in_force": "fok",
+	}, suite.alice)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, w.Body.String())
+	var orders int64
+	suite.db.Model(&models.Order{}).Count(&orders)
+	assert.Equal(suite.T(), int64(1), orders)
+
+	result = suite.place(suite.alice, limit("buy", "1.5", "100", "fok"))
+	assert.Equal(suite.T(), models.OrderStatusFilled, result.Order.Status)
+	assert.Equal(suite.T(), decimal.FromInt(100), suite.holding(suite.alice).Quantity)
+
+	for _, body := range []map[string]interface{}{
+		{"side": "hold", "price": 1, "quantity": 1},
+		{"side": "buy", "quantity": 1},
+		{"side": "buy", "price": 1, "quantity": 0},
+		{"side": "buy", "price": 1, "quantity": 1, "time_in_force": "day"},
+		{"side": "buy", "type": "market", "quantity": 1, "time_in_force": "gtc"},
+		{"side": "buy", "type": "stop", "price": 1, "quantity": 1},
+		{"side": "buy", "price": 0.0000001, "quantity": 1},
+		{"side": "sell", "price": 1, "quantity": 101},
+	} {
+		w = performRequest(suite.router, "POST", "/api/v1/stocks/SAM/orders", body, suite.alice)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+	assertLedgerBalanced(suite.T())
+}
+
+// TestCancelOrder 测试撤单退回冻结的 YOLO 和股份
+func (suite *OrderTestSuite) TestCancelOrder() {
+	buy := suite.place(suite.alice, limit("buy", "0.333333", "0.5", "gtc")).Order
+	assert.Equal(suite.T(), decimal.RequireFromString("0.166667"), buy.Reserved)
+	assert.Equal(suite.T(), models.DefaultUserBalance.Sub(buy.Reserved), suite.balance(suite.alice))
+
+	// 部分成交后剩余的冻结额按剩余数量重新计算
+	suite.giveShares(suite.bob, 1)
+	suite.place(suite.bob, limit("sell", "0.3", "0.25", "ioc"))
+	suite.Require().NoError(suite.db.Where("id = ?", buy.ID).First(&buy).Error)
+	assert.Equal(suite.T(), decimal.RequireFromString("0.083334"), buy.Reserved)
+	assertLedgerBalanced(suite.T())
+
+	w := performRequest(suite.router, "DELETE", "/api/v1/orders/"+buy.ID.String(), nil, suite.bob)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = performRequest(suite.router, "DELETE", "/api/v1/orders/"+buy.ID.String(), nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var cancelled controllers.OrderResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &cancelled))
+	assert.Equal(suite.T(), models.OrderStatusCancelled, cancelled.Status)
+	assert.Equal(suite.T(), "SAM", cancelled.Symbol)
+	assert.Equal(suite.T(), decimal.RequireFromString("0.25"), cancelled.Filled)
+	assert.Equal(suite.T(), models.DefaultUserBalance.Sub(decimal.RequireFromString("0.083333")), suite.balance(suite.alice))
+	w = performRequest(suite.router, "DELETE", "/api/v1/orders/"+buy.ID.String(), nil, suite.alice)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	sell := suite.place(suite.creator, limit("sell", "5", "1000", "gtc")).Order
+	assert.Equal(suite.T(), decimal.FromInt(1000), suite.holding(suite.creator).Locked)
+	_, err := services.OrderService.CancelOrder(suite.creator.ID, sell.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), suite.holding(suite.creator).Locked.IsZero())
+
+	w = performRequest(suite.router, "GET", "/api/v1/user/orders?status=cancelled", nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var list controllers.OrderListResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &list))
+	suite.Require().Len(list.Orders, 1)
+	assert.Equal(suite.T(), buy.ID.String(), list.Orders[0].ID)
+	w = performRequest(suite.router, "GET", "/api/v1/user/orders?status=done", nil, suite.alice)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	assertLedgerBalanced(suite.T())
+}
+
+// TestSelfTradePrevention 测试撮合到自己的挂单时撤销该挂单
+func (suite *OrderTestSuite) TestSelfTradePrevention() {
+	own := suite.place(suite.creator, limit("sell", "1.2", "10", "gtc")).Order
+	result := suite.place(suite.creator, limit("buy", "1.3", "5", "ioc"))
+	suite.Require().Len(result.Trades, 1)
+	assert.Nil(suite.T(), result.Trades[0].SellerID)
+
+	suite.Require().NoError(suite.db.Where("id = ?", own.ID).First(&own).Error)
+	assert.Equal(suite.T(), models.OrderStatusCancelled, own.Status)
+	assert.True(suite.T(), suite.holding(suite.creator).Locked.IsZero())
+	assertLedgerBalanced(suite.T())
+}
+
+// TestInterleavedOrdersBalance 测试多个 goroutine 交替下单后账本平衡、股份守恒（单连接测试库下事务依次执行）
+func (suite *OrderTestSuite) TestInterleavedOrdersBalance() {
+	users := []*models.User{suite.alice, suite.bob}
+	for i := 0; i < 4; i++ {
+		user, err := services.UserService.CreateUser(fmt.Sprintf("User %d", i), fmt.Sprintf("user%d", i), fmt.Sprintf("user%d@example.com", i), "password123")
+		suite.Require().NoError(err)
+		users = append(users, user)
+	}
+	for _, user := range users {
+		suite.place(user, limit("buy", "1.2", "500", "ioc"))
+	}
+
+	var wg sync.WaitGroup
+	for i, user := range users {
+		for j := 0; j < 6; j++ {
+			wg.Add(1)
+			go func(userID uuid.UUID, n int) {
+				defer wg.Done()
+				price := decimal.RequireFromString(fmt.Sprintf("1.%02d", 5+n%10))
+				opts := services.PlaceOrderOptions{Side: "buy", Price: price, Quantity: decimal.FromInt(40)}
+				if n%2 == 1 {
+					opts.Side = "sell"
+				}
+				if n%3 == 0 {
+					opts.TimeInForce = models.TimeInForceIOC
+				}
+				_, _ = services.OrderService.PlaceOrder(userID, "SAM", opts)
+			}(user.ID, i+j)
+		}
+	}
+	wg.Wait()
+
+	var pool models.LiquidityPool
+	suite.Require().NoError(suite.db.Where("stock_id = ?", suite.stock.ID).First(&pool).Error)
+	var totalShares decimal.Decimal
+	suite.Require().NoError(suite.db.Model(&models.UserHolding{}).Where("stock_id = ?", suite.stock.ID).
+		Select("COALESCE(SUM(quantity), 0)").Row().Scan(&totalShares))
+	assert.Equal(suite.T(), suite.stock.Supply, totalShares.Add(pool.StockReserve))
+
+	var lockedMismatch int64
+	suite.db.Model(&models.UserHolding{}).
+		Where("ABS(locked - COALESCE((SELECT SUM(quantity - filled) FROM orders WHERE orders.user_id = user_holdings.user_id AND orders.side = 'sell' AND orders.status = 'open'), 0)) > 0.0000005").
+		Count(&lockedMismatch)
+	assert.Zero(suite.T(), lockedMismatch)
+	assertLedgerBalanced(suite.T())
+}
+
+// TestOrderSuite 运行订单簿撮合测试套件
+func TestOrderSuite(t *testing.T) {
+	suite.Run(t, new(OrderTestSuite))
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// PaginationTestSuite 游标分页测试套件
+type PaginationTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	user   *models.User
+	base   time.Time
+}
+
+// SetupSuite 测试套件初始化
+func (suite *PaginationTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *PaginationTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *PaginationTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.user, err = services.UserService.CreateUser("Writer", "writer", "writer@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.base = time.Now().Add(-time.Hour).Truncate(time.Second)
+}
+
+// getPage 请求一页时间线
+func (suite *PaginationTestSuite) getPage(path string, params url.Values) controllers.CursorPostsResponse {
+	req, _ := http.NewRequest("GET", path+"?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var response controllers.CursorPostsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// contents 提取帖子内容
+func contents(posts []controllers.PostResponse) []string {
+	result := make([]string, 0, len(posts))
+	for _, post := range posts {
+		result = append(result, post.Content)
+	}
+	return result
+}
+
+// TestCursor_WalksTimelineWithoutDuplicates 测试游标翻页在新帖插入时不重复也不遗漏
+func (suite *PaginationTestSuite) TestCursor_WalksTimelineWithoutDuplicates() {
+	// p2 和 p3 时间相同，依靠id打破平局
+	createPostAt(suite.T(), suite.db, suite.user, "p1", suite.base.Add(1*time.Minute))
+	createPostAt(suite.T(), suite.db, suite.user, "p2", suite.base.Add(2*time.Minute))
+	createPostAt(suite.T(), suite.db, suite.user, "p3", suite.base.Add(2*time.Minute))
+	createPostAt(suite.T(), suite.db, suite.user, "p4", suite.base.Add(3*time.Minute))
+	createPostAt(suite.T(), suite.db, suite.user, "p5", suite.base.Add(4*time.Minute))
+
+	first := suite.getPage("/api/v1/posts/timeline", url.Values{"cursor": {""}, "limit": {"2"}})
+	suite.Require().Len(first.Posts, 2)
+	assert.Equal(suite.T(), []string{"p5", "p4"}, contents(first.Posts))
+	assert.Nil(suite.T(), first.PageInfo.TotalPosts)
+	suite.Require().NotNil(first.PageInfo.NextCursor)
+
+	// 翻页过程中插入新帖，不影响后续页
+	createPostAt(suite.T(), suite.db, suite.user, "p6", suite.base.Add(5*time.Minute))
+
+	seen := contents(first.Posts)
+	next := first.PageInfo.NextCursor
+	for next != nil {
+		page := suite.getPage("/api/v1/posts/timeline", url.Values{"cursor": {*next}, "limit": {"2"}})
+		seen = append(seen, contents(page.Posts)...)
+		next = page.PageInfo.NextCursor
+	}
+	assert.Len(suite.T(), seen, 5)
+	assert.ElementsMatch(suite.T(), []string{"p1", "p2", "p3", "p4", "p5"}, seen)
+
+	// 通过prev游标获取新帖
+	newer := suite.getPage("/api/v1/posts/timeline", url.Values{"cursor": {*first.PageInfo.PrevCursor}, "limit": {"2"}})
+	assert.Equal(suite.T(), []string{"p6"}, contents(newer.Posts))
+	suite.Require().NotNil(newer.PageInfo.NextCursor)
+	suite.Require().NotNil(newer.PageInfo.PrevCursor)
+
+	// 没有更新的内容时返回空列表，prev游标保持可用
+	latest := suite.getPage("/api/v1/posts/timeline", url.Values{"cursor": {*newer.PageInfo.PrevCursor}})
+	assert.Empty(suite.T(), latest.Posts)
+	assert.Equal(suite.T(), *newer.PageInfo.PrevCursor, *latest.PageInfo.PrevCursor)
+}
+
+// TestCursor_UserPostsWithTotal 测试用户帖子游标分页和可选总数
+func (suite *PaginationTestSuite) TestCursor_UserPostsWithTotal() {
+	other, err := services.UserService.CreateUser("Other", "other", "other@example.com", "password123")
+	suite.Require().NoError(err)
+	_, err = services.PostService.CreatePost(other.ID, "not mine")
+	suite.Require().NoError(err)
+	createPostAt(suite.T(), suite.db, suite.user, "a", suite.base.Add(1*time.Minute))
+	createPostAt(suite.T(), suite.db, suite.user, "b", suite.base.Add(2*time.Minute))
+
+	page := suite.getPage("/api/v1/users/writer/posts", url.Values{"cursor": {""}, "limit": {"1"}, "includeTotal": {"true"}})
+	assert.Equal(suite.T(), []string{"b"}, contents(page.Posts))
+	suite.Require().NotNil(page.PageInfo.TotalPosts)
+	assert.Equal(suite.T(), int64(2), *page.PageInfo.TotalPosts)
+
+	page = suite.getPage("/api/v1/users/writer/posts", url.Values{"cursor": {*page.PageInfo.NextCursor}, "limit": {"1"}})
+	assert.Equal(suite.T(), []string{"a"}, contents(page.Posts))
+	assert.Nil(suite.T(), page.PageInfo.NextCursor)
+}
+
+// TestCursor_InvalidAndLegacy 测试非法游标以及旧的页码分页仍然可用
+func (suite *PaginationTestSuite) TestCursor_InvalidAndLegacy() {
+	req, _ := http.NewRequest("GET", "/api/v1/posts/timeline?cursor=garbage", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	createPostAt(suite.T(), suite.db, suite.user, "legacy", suite.base)
+	req, _ = http.NewRequest("GET", "/api/v1/posts/timeline?page=1&limit=10", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var legacy controllers.TimelineResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &legacy))
+	assert.Equal(suite.T(), 1, legacy.PageInfo.CurrentPage)
+	assert.Equal(suite.T(), int64(1), legacy.PageInfo.TotalPosts)
+}
+
+// TestPaginationTestSuite 运行游标分页测试套件
+func TestPaginationTestSuite(t *testing.T) {
+	suite.Run(t, new(PaginationTestSuite))
+}
@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// PollTestSuite 投票测试套件
+type PollTestSuite struct {
+	suite.Suite
+	router  *gin.Engine
+	db      *gorm.DB
+	creator *models.User
+	holder  *models.User
+	whale   *models.User
+	visitor *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *PollTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *PollTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备：创作者发行股票，两个用户分别持有10股和40股
+func (suite *PollTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Cleo", "cleo", "cleo@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.holder, err = services.UserService.CreateUser("Hugo", "hugo", "hugo@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.whale, err = services.UserService.CreateUser("Wes", "wes", "wes@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.visitor, err = services.UserService.CreateUser("Vi", "vi", "vi@example.com", "password123")
+	suite.Require().NoError(err)
+
+	stock := &models.Stock{UserID: suite.creator.ID, Name: "Cleo", Symbol: "CLEO", Status: "active"}
+	suite.Require().NoError(suite.db.Create(stock).Error)
+	suite.Require().NoError(suite.db.Create(&models.UserHolding{UserID: suite.holder.ID, StockID: stock.ID, Quantity: decimal.FromInt(10)}).Error)
+	suite.Require().NoError(suite.db.Create(&models.UserHolding{UserID: suite.whale.ID, StockID: stock.ID, Quantity: decimal.FromInt(40)}).Error)
+}
+
+// createPoll 创作者发布带投票的帖子
+func (suite *PollTestSuite) createPoll(poll map[string]interface{}, visibility string) controllers.PostResponse {
+	if _, ok := poll["closes_at"]; !ok {
+		poll["closes_at"] = time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	}
+	w := performRequest(suite.router, "POST", "/api/v1/posts", map[string]interface{}{
+		"content":    "What should I build next?",
+		"visibility": visibility,
+		"poll":       poll,
+	}, suite.creator)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	suite.Require().NotNil(post.Poll)
+	return post
+}
+
+// vote 投票并返回响应
+func (suite *PollTestSuite) vote(post controllers.PostResponse, user *models.User, optionIDs ...string) *httptest.ResponseRecorder {
+	return performRequest(suite.router, "POST", "/api/v1/posts/"+post.ID+"/poll/votes", map[string]interface{}{"option_ids": optionIDs}, user)
+}
+
+// getPoll 以指定用户身份获取投票
+func (suite *PollTestSuite) getPoll(post controllers.PostResponse, user *models.User) controllers.PollResponse {
+	w := performRequest(suite.router, "GET", "/api/v1/posts/"+post.ID+"/poll", nil, user)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var poll controllers.PollResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &poll))
+	return poll
+}
+
+// TestCreatePollValidation 测试选项数量、重复选项、截止时间和加权权限
+func (suite *PollTestSuite) TestCreatePollValidation() {
+	post := suite.createPoll(map[string]interface{}{"options": []string{" Apps ", "Games", "Tools"}}, "")
+	assert.Equal(suite.T(), []string{"Apps", "Games", "Tools"}, []string{post.Poll.Options[0].Text, post.Poll.Options[1].Text, post.Poll.Options[2].Text})
+	assert.False(suite.T(), post.Poll.Voted)
+	assert.Nil(suite.T(), post.Poll.Voters)
+	assert.Nil(suite.T(), post.Poll.Options[0].Votes)
+
+	soon := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	late := time.Now().Add(8 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	for _, poll := range []map[string]interface{}{
+		{"options": []string{"only"}},
+		{"options": []string{"a", "b", "c", "d", "e"}},
+		{"options": []string{"same", "SAME"}},
+		{"options": []string{"a", " "}},
+		{"options": []string{"a", "b"}, "closes_at": soon},
+		{"options": []string{"a", "b"}, "closes_at": late},
+	} {
+		if _, ok := poll["closes_at"]; !ok {
+			poll["closes_at"] = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		}
+		w := performRequest(suite.router, "POST", "/api/v1/posts", map[string]interface{}{"content": "poll", "poll": poll}, suite.creator)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	// 定时帖子的截止时间从发布时间起算
+	publishAt := time.Now().Add(6 * 24 * time.Hour)
+	w := performRequest(suite.router, "POST", "/api/v1/posts", map[string]interface{}{
+		"content":    "later",
+		"publish_at": publishAt.UTC().Format(time.RFC3339),
+		"poll":       map[string]interface{}{"options": []string{"a", "b"}, "closes_at": publishAt.Add(3 * 24 * time.Hour).UTC().Format(time.RFC3339)},
+	}, suite.creator)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	// 只有创作者可以发起按股份加权的投票
+	w = performRequest(suite.router, "POST", "/api/v1/posts", map[string]interface{}{
+		"content": "weighted",
+		"poll": map[string]interface{}{
+			"options": []string{"a", "b"}, "weight_by_shares": true,
+			"closes_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		},
+	}, suite.visitor)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+// TestSingleChoiceVoting 测试单选投票、每人一票以及结果在投票后可见
+func (suite *PollTestSuite) TestSingleChoiceVoting() {
+	post := suite.createPoll(map[string]interface{}{"options": []string{"Yes", "No"}}, "")
+	yes, no := post.Poll.Options[0].ID, post.Poll.Options[1].ID
+
+	w := suite.vote(post, suite.visitor, yes, no)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = suite.vote(post, suite.visitor, "00000000-0000-0000-0000-000000000000")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.vote(post, suite.visitor, yes)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var poll controllers.PollResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &poll))
+	assert.True(suite.T(), poll.Voted)
+	assert.Equal(suite.T(), []string{yes}, poll.OwnChoices)
+	suite.Require().NotNil(poll.Voters)
+	assert.Equal(suite.T(), int64(1), *poll.Voters)
+	assert.Equal(suite.T(), int64(1), *poll.Options[0].Votes)
+	assert.Equal(suite.T(), int64(0), *poll.Options[1].Votes)
+	assert.Nil(suite.T(), poll.TotalWeight)
+
+	w = suite.vote(post, suite.visitor, no)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// 未投票的用户看不到结果，投票后通过帖子接口也能看到自己的选择
+	assert.Nil(suite.T(), suite.getPoll(post, suite.holder).Voters)
+	suite.Require().Equal(http.StatusOK, suite.vote(post, suite.holder, no).Code)
+	w = performRequest(suite.router, "GET", "/api/v1/posts/"+post.ID, nil, suite.holder)
+	var fetched controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(suite.T(), []string{no}, fetched.Poll.OwnChoices)
+	assert.Equal(suite.T(), int64(2), *fetched.Poll.Voters)
+}
+
+// TestMultipleChoiceAndClose 测试多选投票，以及截止后不能投票且结果对所有人公开
+func (suite *PollTestSuite) TestMultipleChoiceAndClose() {
+	post := suite.createPoll(map[string]interface{}{"options": []string{"Red", "Green", "Blue"}, "multiple_choice": true}, "")
+	red, green, blue := post.Poll.Options[0].ID, post.Poll.Options[1].ID, post.Poll.Options[2].ID
+
+	w := suite.vote(post, suite.visitor, red, red)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.Require().Equal(http.StatusOK, suite.vote(post, suite.visitor, red, blue).Code)
+	suite.Require().Equal(http.StatusOK, suite.vote(post, suite.holder, blue).Code)
+
+	suite.db.Model(&models.Poll{}).Where("post_id = ?", post.ID).Update("closes_at", time.Now().Add(-time.Minute))
+	w = suite.vote(post, suite.whale, green)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	poll := suite.getPoll(post, suite.whale)
+	assert.True(suite.T(), poll.Closed)
+	assert.False(suite.T(), poll.Voted)
+	assert.Equal(suite.T(), int64(2), *poll.Voters)
+	assert.Equal(suite.T(), []int64{1, 0, 2}, []int64{*poll.Options[0].Votes, *poll.Options[1].Votes, *poll.Options[2].Votes})
+}
+
+// TestWeightedHolderPoll 测试持有者可见帖子中按股份加权的投票
+func (suite *PollTestSuite) TestWeightedHolderPoll() {
+	post := suite.createPoll(map[string]interface{}{"options": []string{"Buyback", "Dividend"}, "weight_by_shares": true}, models.VisibilityHolders)
+	buyback, dividend := post.Poll.Options[0].ID, post.Poll.Options[1].ID
+
+	// 非持有者看不到帖子
+	assert.Equal(suite.T(), http.StatusNotFound, suite.vote(post, suite.visitor, buyback).Code)
+
+	suite.Require().Equal(http.StatusOK, suite.vote(post, suite.holder, buyback).Code)
+	w := suite.vote(post, suite.whale, dividend)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var poll controllers.PollResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &poll))
+	assert.True(suite.T(), poll.WeightByShares)
+	assert.Equal(suite.T(), int64(2), *poll.Voters)
+	assert.Equal(suite.T(), 50.0, *poll.TotalWeight)
+	assert.Equal(suite.T(), 10.0, *poll.Options[0].Weight)
+	assert.Equal(suite.T(), 40.0, *poll.Options[1].Weight)
+
+	// 公开的加权投票同样只允许持有者投票
+	public := suite.createPoll(map[string]interface{}{"options": []string{"a", "b"}, "weight_by_shares": true}, "")
+	w = suite.vote(public, suite.visitor, public.Poll.Options[0].ID)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+// TestPollOnRepost 测试转发中嵌入的原帖投票带有查看者自己的投票状态
+func (suite *PollTestSuite) TestPollOnRepost() {
+	post := suite.createPoll(map[string]interface{}{"options": []string{"Up", "Down"}}, "")
+	suite.Require().Equal(http.StatusOK, suite.vote(post, suite.holder, post.Poll.Options[0].ID).Code)
+
+	w := performRequest(suite.router, "POST", "/api/v1/posts/"+post.ID+"/repost", nil, suite.visitor)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var repost controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &repost))
+
+	w = performRequest(suite.router, "GET", "/api/v1/posts/"+repost.ID, nil, suite.holder)
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &repost))
+	suite.Require().NotNil(repost.RepostOf.Post.Poll)
+	assert.True(suite.T(), repost.RepostOf.Post.Poll.Voted)
+	assert.Equal(suite.T(), int64(1), *repost.RepostOf.Post.Poll.Voters)
+}
+
+// TestPollTestSuite 运行投票测试套件
+func TestPollTestSuite(t *testing.T) {
+	suite.Run(t, new(PollTestSuite))
+}
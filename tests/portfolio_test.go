@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// PortfolioTestSuite 投资组合估值和每日快照测试套件
+type PortfolioTestSuite struct {
+	suite.Suite
+	router  *gin.Engine
+	db      *gorm.DB
+	creator *models.User
+	trader  *models.User
+	stock   *models.Stock
+}
+
+// SetupSuite 测试套件初始化
+func (suite *PortfolioTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *PortfolioTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *PortfolioTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	services.StockService.SetConfig(cfg)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Pat", "pat", "pat@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.trader, err = services.UserService.CreateUser("Quinn", "quinn", "quinn@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stock, err = services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "PAT", Name: "Pat"})
+	suite.Require().NoError(err)
+}
+
+// trade 与池子交易，必须成功
+func (suite *PortfolioTestSuite) trade(tradeType string, amount decimal.Decimal) models.Trade {
+	result, err := services.AMMService.Trade(suite.trader.ID, "PAT", services.TradeOptions{Type: tradeType, AmountIn: amount})
+	suite.Require().NoError(err)
+	return result.Trade
+}
+
+// midPrice 当前池中价格
+func (suite *PortfolioTestSuite) midPrice() decimal.Decimal {
+	_, pool, err := services.AMMService.GetPool("PAT")
+	suite.Require().NoError(err)
+	return pool.YoloReserve.Div(pool.StockReserve, decimal.RoundHalfEven)
+}
+
+// TestFIFOLotsAndPnL 测试先进先出批次、平均成本、已实现和未实现盈亏
+func (suite *PortfolioTestSuite) TestFIFOLotsAndPnL() {
+	first := suite.trade("buy", decimal.FromInt(650))
+	time.Sleep(5 * time.Millisecond)
+	second := suite.trade("buy", decimal.FromInt(1300))
+	time.Sleep(5 * time.Millisecond)
+	// 卖出第一批全部和第二批的一半
+	half := second.Amount.Div(decimal.FromInt(2), decimal.RoundDown)
+	sold := suite.trade("sell", first.Amount.Add(half))
+
+	portfolio, err := services.PortfolioService.GetPortfolio(suite.trader.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(portfolio.Positions, 1)
+	position := portfolio.Positions[0]
+
+	remaining := second.Amount.Sub(half)
+	soldCost := second.TotalValue.MulDiv(half, second.Amount, decimal.RoundHalfEven)
+	costBasis := second.TotalValue.Sub(soldCost)
+	assert.Equal(suite.T(), "PAT", position.Stock.Symbol)
+	assert.Equal(suite.T(), remaining, position.Quantity)
+	suite.Require().Len(position.Lots, 1)
+	assert.Equal(suite.T(), remaining, position.Lots[0].Quantity)
+	assert.Equal(suite.T(), costBasis, position.Lots[0].Cost)
+	assert.Equal(suite.T(), second.TotalValue.Div(second.Amount, decimal.RoundHalfEven), position.Lots[0].Price)
+	assert.WithinDuration(suite.T(), second.CreatedAt, position.Lots[0].AcquiredAt, time.Second)
+	assert.Equal(suite.T(), costBasis, position.CostBasis)
+	assert.Equal(suite.T(), costBasis.Div(remaining, decimal.RoundHalfEven), position.AverageCost)
+
+	realized := sold.TotalValue.Sub(first.TotalValue).Sub(soldCost)
+	assert.Equal(suite.T(), realized, position.RealizedPnL)
+	assert.Equal(suite.T(), realized, portfolio.RealizedPnL)
+
+	mid := suite.midPrice()
+	value := remaining.Mul(mid, decimal.RoundHalfEven)
+	assert.Equal(suite.T(), mid, position.MidPrice)
+	assert.Equal(suite.T(), value, position.Value)
+	assert.Equal(suite.T(), value.Sub(costBasis), position.UnrealizedPnL)
+
+	cash, err := services.LedgerService.GetBalance(suite.trader.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), cash, portfolio.Cash)
+	assert.Equal(suite.T(), cash.Add(value), portfolio.TotalValue)
+	assert.InDelta(suite.T(), 100, position.Allocation+portfolio.CashAllocation, 1e-9)
+	assert.InDelta(suite.T(), value.Float64()/portfolio.TotalValue.Float64()*100, position.Allocation, 1e-9)
+
+	// 全部卖出后不再列出持仓，已实现盈亏仍计入合计
+	suite.trade("sell", remaining)
+	portfolio, err = services.PortfolioService.GetPortfolio(suite.trader.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), portfolio.Positions)
+	assert.True(suite.T(), portfolio.CostBasis.IsZero())
+	assert.Equal(suite.T(), portfolio.Cash.Sub(models.DefaultUserBalance), portfolio.RealizedPnL)
+}
+
+// TestCreatorAllocationAndOpenOrders 测试创作者的发行份额按零成本计入，挂单冻结的 YOLO 计入总价值
+func (suite *PortfolioTestSuite) TestCreatorAllocationAndOpenOrders() {
+	suite.trade("buy", decimal.FromInt(1000))
+	_, err := services.OrderService.PlaceOrder(suite.creator.ID, "PAT", services.PlaceOrderOptions{
+		Side: "buy", Type: "limit", Price: decimal.RequireFromString("0.5"), Quantity: decimal.FromInt(100),
+	})
+	suite.Require().NoError(err)
+
+	w := performRequest(suite.router, "GET", "/api/v1/user/portfolio", nil, suite.creator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var response controllers.PortfolioResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+	suite.Require().Len(response.Positions, 1)
+	position := response.Positions[0]
+	allocation := suite.stock.Supply.Mul(services.CreatorAllocation, decimal.RoundDown)
+	assert.Equal(suite.T(), allocation, position.Quantity)
+	suite.Require().Len(position.Lots, 1)
+	assert.True(suite.T(), position.Lots[0].Cost.IsZero())
+	assert.Equal(suite.T(), suite.stock.CreatedAt.Format("2006-01-02T15:04:05Z"), position.Lots[0].AcquiredAt)
+	assert.True(suite.T(), position.AverageCost.IsZero())
+	assert.Equal(suite.T(), position.Value, position.UnrealizedPnL)
+	assert.True(suite.T(), position.RealizedPnL.IsZero())
+
+	assert.Equal(suite.T(), decimal.FromInt(50), response.OpenOrders)
+	assert.Equal(suite.T(), models.DefaultUserBalance.Sub(decimal.FromInt(50)), response.Cash)
+	assert.Equal(suite.T(), decimal.Sum(response.Cash, response.OpenOrders, position.Value), response.TotalValue)
+
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	w = performRequest(suite.router, "GET", "/api/v1/user/portfolio", nil, suite.creator)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestDailySnapshots 测试快照每天一行、当天内覆盖，以及资料页的历史接口
+func (suite *PortfolioTestSuite) TestDailySnapshots() {
+	now := time.Now()
+	_, err := services.PortfolioService.Snapshot(suite.trader.ID, now.AddDate(0, 0, -2))
+	suite.Require().NoError(err)
+
+	suite.trade("buy", decimal.FromInt(1000))
+	count, err := services.PortfolioService.SnapshotAll(now)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, count, "trader has an account and creator holds shares")
+
+	suite.trade("buy", decimal.FromInt(1000))
+	_, err = services.PortfolioService.SnapshotAll(now)
+	suite.Require().NoError(err)
+	var rows int64
+	suite.db.Model(&models.PortfolioSnapshot{}).Where("user_id = ?", suite.trader.ID).Count(&rows)
+	assert.Equal(suite.T(), int64(2), rows)
+
+	portfolio, err := services.PortfolioService.GetPortfolio(suite.trader.ID)
+	suite.Require().NoError(err)
+
+	w := performRequest(suite.router, "GET", "/api/v1/users/quinn/portfolio/history", nil, suite.creator)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var history controllers.PortfolioHistoryResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &history))
+	suite.Require().Len(history.Snapshots, 2)
+	assert.Equal(suite.T(), now.AddDate(0, 0, -2).UTC().Format("2006-01-02"), history.Snapshots[0].Date)
+	assert.Equal(suite.T(), models.DefaultUserBalance, history.Snapshots[0].TotalValue)
+	assert.Equal(suite.T(), now.UTC().Format("2006-01-02"), history.Snapshots[1].Date)
+	assert.Equal(suite.T(), portfolio.TotalValue, history.Snapshots[1].TotalValue)
+	assert.Equal(suite.T(), portfolio.HoldingsValue, history.Snapshots[1].HoldingsValue)
+	assert.Equal(suite.T(), portfolio.CostBasis, history.Snapshots[1].CostBasis)
+
+	w = performRequest(suite.router, "GET", "/api/v1/users/quinn/portfolio/history?days=1", nil, suite.creator)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(suite.T(), history.Snapshots, 1)
+
+	for _, days := range []string{"0", "abc", "400"} {
+		w = performRequest(suite.router, "GET", "/api/v1/users/quinn/portfolio/history?days="+days, nil, suite.creator)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, days)
+	}
+	w = performRequest(suite.router, "GET", "/api/v1/users/nobody/portfolio/history", nil, suite.creator)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestPortfolioTestSuite(t *testing.T) {
+	suite.Run(t, new(PortfolioTestSuite))
+}
@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// PostsTestSuite 帖子功能测试套件
+type PostsTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	author *models.User
+	reader *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *PostsTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *PostsTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *PostsTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.author, err = services.UserService.CreateUser("Author", "author", "author@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.reader, err = services.UserService.CreateUser("Reader", "reader", "reader@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// getTimeline 请求时间线
+func (suite *PostsTestSuite) getTimeline() controllers.TimelineResponse {
+	req, _ := http.NewRequest("GET", "/api/v1/posts/timeline", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response controllers.TimelineResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// TestRepost_AppearsInTimelineAndProfile 测试转发出现在时间线和转发者主页
+func (suite *PostsTestSuite) TestRepost_AppearsInTimelineAndProfile() {
+	original, err := services.PostService.CreatePost(suite.author.ID, "original content")
+	suite.Require().NoError(err)
+
+	req := createAuthenticatedRequest("POST", "/api/v1/posts/"+original.ID.String()+"/repost", nil, suite.reader.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	var repost controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &repost))
+	assert.Equal(suite.T(), models.PostTypeRepost, repost.Type)
+	assert.Equal(suite.T(), "Reader", repost.User.Name)
+	suite.Require().NotNil(repost.RepostOf)
+	suite.Require().NotNil(repost.RepostOf.Post)
+	assert.Equal(suite.T(), "original content", repost.RepostOf.Post.Content)
+
+	timeline := suite.getTimeline()
+	assert.Len(suite.T(), timeline.Posts, 2)
+	assert.Equal(suite.T(), int64(2), timeline.PageInfo.TotalPosts)
+
+	req, _ = http.NewRequest("GET", "/api/v1/users/reader/posts", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var userPosts controllers.PostsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &userPosts))
+	suite.Require().Len(userPosts.Posts, 1)
+	assert.Equal(suite.T(), models.PostTypeRepost, userPosts.Posts[0].Type)
+	assert.Equal(suite.T(), original.ID.String(), userPosts.Posts[0].RepostOf.ID)
+}
+
+// TestRepost_Duplicate 测试重复转发被拒绝，取消后可重新转发
+func (suite *PostsTestSuite) TestRepost_Duplicate() {
+	original, err := services.PostService.CreatePost(suite.author.ID, "original content")
+	suite.Require().NoError(err)
+
+	_, err = services.PostService.Repost(suite.reader.ID, original.ID)
+	suite.Require().NoError(err)
+
+	_, err = services.PostService.Repost(suite.reader.ID, original.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrAlreadyReposted)
+
+	suite.Require().NoError(services.PostService.Unrepost(suite.reader.ID, original.ID))
+	assert.ErrorIs(suite.T(), services.PostService.Unrepost(suite.reader.ID, original.ID), services.ErrRepostNotFound)
+
+	_, err = services.PostService.Repost(suite.reader.ID, original.ID)
+	assert.NoError(suite.T(), err)
+}
+
+// TestRepost_OfRepostResolvesToOriginal 测试转发一条转发时指向原帖
+func (suite *PostsTestSuite) TestRepost_OfRepostResolvesToOriginal() {
+	original, err := services.PostService.CreatePost(suite.author.ID, "original content")
+	suite.Require().NoError(err)
+	repost, err := services.PostService.Repost(suite.reader.ID, original.ID)
+	suite.Require().NoError(err)
+
+	again, err := services.PostService.Repost(suite.author.ID, repost.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(again.RepostOfID)
+	assert.Equal(suite.T(), original.ID, *again.RepostOfID)
+}
+
+// TestQuotePost_Create 测试通过接口创建引用帖子
+func (suite *PostsTestSuite) TestQuotePost_Create() {
+	original, err := services.PostService.CreatePost(suite.author.ID, "original content")
+	suite.Require().NoError(err)
+
+	body, _ := json.Marshal(controllers.CreatePostRequest{
+		Content:     "my take",
+		QuotePostID: original.ID.String(),
+	})
+	req := createAuthenticatedRequest("POST", "/api/v1/posts", body, suite.reader.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	var quote controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &quote))
+	assert.Equal(suite.T(), models.PostTypeQuote, quote.Type)
+	assert.Equal(suite.T(), "my take", quote.Content)
+	suite.Require().NotNil(quote.QuotedPost)
+	assert.Equal(suite.T(), "original content", quote.QuotedPost.Post.Content)
+	assert.Equal(suite.T(), "Author", quote.QuotedPost.Post.User.Name)
+}
+
+// TestQuotePost_MissingOriginal 测试引用不存在的帖子
+func (suite *PostsTestSuite) TestQuotePost_MissingOriginal() {
+	body, _ := json.Marshal(controllers.CreatePostRequest{
+		Content:     "my take",
+		QuotePostID: "00000000-0000-0000-0000-000000000001",
+	})
+	req := createAuthenticatedRequest("POST", "/api/v1/posts", body, suite.reader.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestDeleteOriginal_KeepsRepostsAndQuotes 测试原帖删除后转发和引用仍可正常返回
+func (suite *PostsTestSuite) TestDeleteOriginal_KeepsRepostsAndQuotes() {
+	original, err := services.PostService.CreatePost(suite.author.ID, "original content")
+	suite.Require().NoError(err)
+	_, err = services.PostService.Repost(suite.reader.ID, original.ID)
+	suite.Require().NoError(err)
+	_, err = services.PostService.CreateQuotePost(suite.reader.ID, "my take", original.ID)
+	suite.Require().NoError(err)
+
+	// 非作者不能删除
+	req := createAuthenticatedRequest("DELETE", "/api/v1/posts/"+original.ID.String(), nil, suite.reader.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusForbidden, w.Code)
+
+	req = createAuthenticatedRequest("DELETE", "/api/v1/posts/"+original.ID.String(), nil, suite.author.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	timeline := suite.getTimeline()
+	suite.Require().Len(timeline.Posts, 2)
+	for _, post := range timeline.Posts {
+		var embedded *controllers.EmbeddedPost
+		switch post.Type {
+		case models.PostTypeRepost:
+			embedded = post.RepostOf
+		case models.PostTypeQuote:
+			embedded = post.QuotedPost
+			assert.Equal(suite.T(), "my take", post.Content)
+		default:
+			suite.Failf("unexpected post type", "type %s", post.Type)
+			continue
+		}
+		suite.Require().NotNil(embedded)
+		assert.Equal(suite.T(), original.ID.String(), embedded.ID)
+		assert.True(suite.T(), embedded.Unavailable)
+		assert.Nil(suite.T(), embedded.Post)
+	}
+
+	// 已删除的原帖不能再被转发
+	_, err = services.PostService.Repost(suite.author.ID, original.ID)
+	assert.ErrorIs(suite.T(), err, services.ErrPostNotFound)
+}
+
+// TestPostsTestSuite 运行帖子测试套件
+func TestPostsTestSuite(t *testing.T) {
+	suite.Run(t, new(PostsTestSuite))
+}
@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// QuoteTestSuite 签名报价和滑点保护测试套件
+type QuoteTestSuite struct {
+	suite.Suite
+	router  *gin.Engine
+	db      *gorm.DB
+	creator *models.User
+	trader  *models.User
+	whale   *models.User
+	stock   *models.Stock
+}
+
+// SetupSuite 测试套件初始化
+func (suite *QuoteTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *QuoteTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *QuoteTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	suite.configure(decimal.Zero, 15*time.Second)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.trader, err = services.UserService.CreateUser("Tina", "tina", "tina@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.whale, err = services.UserService.CreateUser("Wally", "wally", "wally@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stock, err = services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "SAM", Name: "Sam"})
+	suite.Require().NoError(err)
+}
+
+// configure 设置手续费比例和报价有效期
+func (suite *QuoteTestSuite) configure(feeRate decimal.Decimal, ttl time.Duration) {
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	cfg.TradeFeeRate = feeRate
+	cfg.QuoteTTL = ttl
+	cfg.QuoteSecret = []byte("test-quote-secret")
+	services.StockService.SetConfig(cfg)
+}
+
+// quote 获取签名报价，必须成功
+func (suite *QuoteTestSuite) quote(user *models.User, body map[string]interface{}) controllers.SignedQuoteResponse {
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/quote", body, user)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var response controllers.SignedQuoteResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// trade 交易，必须成功
+func (suite *QuoteTestSuite) trade(user *models.User, body map[string]interface{}) controllers.TradeResponse {
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", body, user)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var response controllers.TradeResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// balance 用户 YOLO 余额
+func (suite *QuoteTestSuite) balance(user *models.User) decimal.Decimal {
+	balance, err := services.LedgerService.GetBalance(user.ID)
+	suite.Require().NoError(err)
+	return balance
+}
+
+// TestSignedQuoteExecutesOnce 测试按报价ID成交，同一报价只能成交一次
+func (suite *QuoteTestSuite) TestSignedQuoteExecutesOnce() {
+	quote := suite.quote(suite.trader, map[string]interface{}{"type": "buy", "amount": 6500})
+	assert.NotEmpty(suite.T(), quote.QuoteID)
+	assert.Equal(suite.T(), decimal.RequireFromString("6435.643564"), quote.AmountOut)
+	assert.True(suite.T(), quote.Fee.IsZero())
+	assert.Equal(suite.T(), decimal.RequireFromString("0.005"), quote.Slippage)
+	// 6435.643564 × 0.995 向下舍入
+	suite.Require().NotNil(quote.MinOut)
+	assert.Equal(suite.T(), decimal.RequireFromString("6403.465346"), *quote.MinOut)
+	assert.Nil(suite.T(), quote.MaxIn)
+
+	traded := suite.trade(suite.trader, map[string]interface{}{"quote_id": quote.QuoteID})
+	assert.Equal(suite.T(), quote.AmountOut, traded.Shares)
+	assert.Equal(suite.T(), decimal.FromInt(1500), traded.Balance)
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"quote_id": quote.QuoteID}, suite.trader)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), services.ErrQuoteUsed.Error())
+	assert.Equal(suite.T(), decimal.FromInt(1500), suite.balance(suite.trader))
+
+	var trades int64
+	suite.db.Model(&models.Trade{}).Where("quote_id IS NOT NULL").Count(&trades)
+	assert.Equal(suite.T(), int64(1), trades)
+}
+
+// TestRejectsWhenPoolMoved 测试池子变动超出容差时拒绝成交且不修改余额
+func (suite *QuoteTestSuite) TestRejectsWhenPoolMoved() {
+	quote := suite.quote(suite.trader, map[string]interface{}{"type": "buy", "amount": 1000, "slippage": "0.01"})
+	// 报价后有人大额买入，价格上涨约 2%
+	suite.trade(suite.whale, map[string]interface{}{"type": "buy", "amount": 7000})
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"quote_id": quote.QuoteID}, suite.trader)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), services.ErrSlippageExceeded.Error())
+	assert.Equal(suite.T(), models.DefaultUserBalance, suite.balance(suite.trader))
+
+	// 直接指定 min_out / max_in 同样生效
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{
+		"type": "buy", "amount": 1000, "min_out": quote.AmountOut,
+	}, suite.trader)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, w.Body.String())
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{
+		"type": "buy", "amount_out": quote.AmountOut, "max_in": 1000,
+	}, suite.trader)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, w.Body.String())
+
+	// 容差内的变动可以成交
+	loose := suite.quote(suite.trader, map[string]interface{}{"type": "buy", "amount": 1000, "slippage": "0.05"})
+	suite.trade(suite.whale, map[string]interface{}{"type": "buy", "amount": 100})
+	traded := suite.trade(suite.trader, map[string]interface{}{"quote_id": loose.QuoteID})
+	assert.True(suite.T(), traded.Shares.LessThan(loose.AmountOut))
+	assert.False(suite.T(), traded.Shares.LessThan(*loose.MinOut))
+	assertLedgerBalanced(suite.T())
+}
+
+// TestQuoteValidation 测试报价绑定用户和股票、签名防篡改和有效期
+func (suite *QuoteTestSuite) TestQuoteValidation() {
+	quote := suite.quote(suite.trader, map[string]interface{}{"type": "sell", "amount_out": 10})
+
+	w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"quote_id": quote.QuoteID}, suite.whale)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "quote belongs to another user")
+	payload, signature, _ := strings.Cut(quote.QuoteID, ".")
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"quote_id": payload + "x." + signature}, suite.trader)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "tampered quote")
+
+	other, err := services.StockService.CreateStock(suite.creator.ID, services.CreateStockOptions{Symbol: "OTHER", Name: "Other"})
+	suite.Require().NoError(err)
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/"+other.Symbol+"/trade", map[string]interface{}{"quote_id": quote.QuoteID}, suite.trader)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "quote for another stock")
+
+	suite.configure(decimal.Zero, time.Millisecond)
+	expiring := suite.quote(suite.creator, map[string]interface{}{"type": "sell", "amount": 10})
+	time.Sleep(5 * time.Millisecond)
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"quote_id": expiring.QuoteID}, suite.creator)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), services.ErrQuoteExpired.Error())
+
+	for _, body := range []map[string]interface{}{
+		{"type": "buy", "amount": 10, "amount_out": 10},
+		{"type": "buy", "amount": 10, "slippage": "0.9"},
+		{"type": "buy"},
+		{"type": "hold", "amount": 10},
+	} {
+		w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/quote", body, suite.trader)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "%v", body)
+	}
+	w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", map[string]interface{}{"type": "buy", "amount": 10, "amount_out": 10}, suite.trader)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestExactOutputWithFee 测试精确输出成交和手续费记账
+func (suite *QuoteTestSuite) TestExactOutputWithFee() {
+	suite.configure(decimal.RequireFromString("0.01"), 15*time.Second)
+
+	quote := suite.quote(suite.trader, map[string]interface{}{"type": "buy", "amount_out": 1000})
+	suite.Require().NotNil(quote.MaxIn)
+	assert.True(suite.T(), quote.Fee.IsPositive())
+	assert.False(suite.T(), quote.AmountOut.LessThan(decimal.FromInt(1000)))
+	bought := suite.trade(suite.trader, map[string]interface{}{"quote_id": quote.QuoteID})
+	assert.Equal(suite.T(), quote.AmountOut, bought.Shares)
+	assert.Equal(suite.T(), quote.Fee, bought.Fee)
+	assert.Equal(suite.T(), models.DefaultUserBalance.Sub(quote.AmountIn), bought.Balance)
+
+	sold := suite.trade(suite.trader, map[string]interface{}{"type": "sell", "amount_out": 500, "max_in": 600})
+	assert.False(suite.T(), sold.TotalValue.LessThan(decimal.FromInt(500)))
+	assert.True(suite.T(), sold.Fee.IsPositive())
+
+	// 通过订单簿与池子成交同样收取手续费
+	result, err := services.OrderService.PlaceOrder(suite.whale.ID, "SAM", services.PlaceOrderOptions{
+		Side: "buy", Type: "market", Quantity: decimal.FromInt(100),
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(result.Trades, 1)
+	orderFee := result.Trades[0].Fee
+	assert.True(suite.T(), orderFee.IsPositive())
+
+	var fees models.LedgerAccount
+	suite.Require().NoError(suite.db.Where("code = ?", models.LedgerFeesCode).First(&fees).Error)
+	assert.Equal(suite.T(), decimal.Sum(bought.Fee, sold.Fee, orderFee), fees.Balance)
+	assertLedgerBalanced(suite.T())
+}
+
+// TestExactOutputNearReserve 测试精确输出接近池中储备时所需输入超出范围，报价和交易都返回 400
+func (suite *QuoteTestSuite) TestExactOutputNearReserve() {
+	for _, body := range []map[string]interface{}{
+		{"type": "buy", "amount_out": "649999.999999"},
+		{"type": "sell", "amount_out": "649999.999999"},
+	} {
+		w := performRequest(suite.router, "POST", "/api/v1/stocks/sam/quote", body, suite.trader)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "%v: %s", body, w.Body.String())
+		assert.Contains(suite.T(), w.Body.String(), services.ErrInsufficientLiquidity.Error())
+		w = performRequest(suite.router, "POST", "/api/v1/stocks/sam/trade", body, suite.trader)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "%v: %s", body, w.Body.String())
+	}
+	assertLedgerBalanced(suite.T())
+}
+
+func TestQuoteTestSuite(t *testing.T) {
+	suite.Run(t, new(QuoteTestSuite))
+}
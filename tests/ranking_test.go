@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// RankingTestSuite 热门话题和热门时间线测试套件
+type RankingTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+	carol  *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *RankingTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *RankingTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *RankingTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.carol, err = services.UserService.CreateUser("Carol", "carol", "carol@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// get 发起未登录的GET请求
+func (suite *RankingTestSuite) get(target string, out interface{}) int {
+	req, _ := http.NewRequest("GET", target, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	if out != nil && w.Code == http.StatusOK {
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), out))
+	}
+	return w.Code
+}
+
+// topics 获取热门话题并返回按顺序排列的话题值
+func (suite *RankingTestSuite) topics(query string) []string {
+	var response controllers.TrendingResponse
+	suite.Require().Equal(http.StatusOK, suite.get("/api/v1/trending?"+query, &response))
+
+	values := make([]string, 0, len(response.Topics))
+	for _, topic := range response.Topics {
+		values = append(values, topic.Topic)
+	}
+	return values
+}
+
+// TestTrendingTopics 测试窗口、衰减、作者去重和可见性过滤
+func (suite *RankingTestSuite) TestTrendingTopics() {
+	// #earnings 最近被两位作者讨论
+	createPostAt(suite.T(), suite.db, suite.alice, "results out #earnings", time.Now().Add(-10*time.Minute))
+	createPostAt(suite.T(), suite.db, suite.bob, "big beat #earnings $ACME", time.Now().Add(-20*time.Minute))
+	// $ACME 和 #macro 在较早的时候被讨论，只出现在更长的窗口中
+	createPostAt(suite.T(), suite.db, suite.carol, "still holding $ACME #macro", time.Now().Add(-5*time.Hour))
+	createPostAt(suite.T(), suite.db, suite.bob, "rates #macro", time.Now().Add(-6*time.Hour))
+	// 单个作者刷屏不能上榜
+	for i := 0; i < 5; i++ {
+		createPostAt(suite.T(), suite.db, suite.alice, "buy buy buy #pump", time.Now().Add(-time.Duration(i)*time.Minute))
+	}
+	// 非公开帖子不计入
+	_, err := services.PostService.CreatePostWithOptions(suite.carol.ID, services.CreatePostOptions{
+		Content:    "secret #pump",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+	// 超出最长窗口的帖子不计入
+	createPostAt(suite.T(), suite.db, suite.alice, "ancient #history", time.Now().Add(-8*24*time.Hour))
+	createPostAt(suite.T(), suite.db, suite.bob, "ancient #history", time.Now().Add(-8*24*time.Hour))
+
+	_, err = services.RankingService.RefreshTrending(time.Now())
+	suite.Require().NoError(err)
+
+	assert.Equal(suite.T(), []string{"earnings"}, suite.topics("window=1h"))
+	assert.Equal(suite.T(), []string{"earnings", "ACME", "macro"}, suite.topics(""))
+	assert.Equal(suite.T(), []string{"ACME"}, suite.topics("window=7d&type=cashtag"))
+	assert.Equal(suite.T(), []string{"earnings"}, suite.topics("window=24h&type=hashtag&limit=1"))
+
+	var response controllers.TrendingResponse
+	suite.Require().Equal(http.StatusOK, suite.get("/api/v1/trending?type=cashtag", &response))
+	suite.Require().Len(response.Topics, 1)
+	assert.Equal(suite.T(), 2, response.Topics[0].Authors)
+	assert.Equal(suite.T(), 2, response.Topics[0].Posts)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.get("/api/v1/trending?window=2h", nil))
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.get("/api/v1/trending?type=mention", nil))
+}
+
+// TestHotScore 测试热度分随互动增加、随发布时间推移
+func (suite *RankingTestSuite) TestHotScore() {
+	t := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.Greater(suite.T(), services.HotScore(10, t), services.HotScore(0, t))
+	assert.Greater(suite.T(), services.HotScore(0, t.Add(time.Hour)), services.HotScore(0, t))
+	// 晚12.5小时发布相当于互动数多10倍
+	assert.InDelta(suite.T(), services.HotScore(9, t), services.HotScore(0, t.Add(12*time.Hour+30*time.Minute)), 1e-9)
+}
+
+// TestHotTimeline 测试热门时间线的排序和重算
+func (suite *RankingTestSuite) TestHotTimeline() {
+	popular := createPostAt(suite.T(), suite.db, suite.alice, "popular take", time.Now().Add(-5*time.Hour))
+	fresh := createPostAt(suite.T(), suite.db, suite.bob, "fresh take", time.Now())
+	old := createPostAt(suite.T(), suite.db, suite.carol, "old news", time.Now().Add(-4*24*time.Hour))
+
+	for _, user := range []*models.User{suite.bob, suite.carol} {
+		_, err := services.PostService.Repost(user.ID, popular.ID)
+		suite.Require().NoError(err)
+		_, _, err = services.BookmarkService.AddBookmark(user.ID, popular.ID, nil)
+		suite.Require().NoError(err)
+	}
+	_, err := services.PostService.CreateQuotePost(suite.carol.ID, "agreed", popular.ID)
+	suite.Require().NoError(err)
+
+	updated, err := services.RankingService.RefreshHotScores(time.Now())
+	suite.Require().NoError(err)
+	// 转发不参与排序，超出重算窗口的旧帖子不重算
+	assert.Equal(suite.T(), 3, updated)
+
+	var score models.PostScore
+	suite.Require().NoError(suite.db.First(&score, "post_id = ?", popular.ID).Error)
+	assert.Equal(suite.T(), 2*2.0+3.0+2*1.0, score.Engagement)
+	assert.Error(suite.T(), suite.db.First(&models.PostScore{}, "post_id = ?", old.ID).Error)
+
+	var response controllers.TimelineResponse
+	suite.Require().Equal(http.StatusOK, suite.get("/api/v1/posts/timeline?sort=hot", &response))
+	contents := make([]string, 0, len(response.Posts))
+	for _, post := range response.Posts {
+		contents = append(contents, post.Content)
+	}
+	assert.Equal(suite.T(), []string{"popular take", "agreed", "fresh take"}, contents)
+	assert.Equal(suite.T(), int64(3), response.PageInfo.TotalPosts)
+
+	// 互动减少后重算，新帖子排到前面
+	suite.Require().NoError(services.PostService.Unrepost(suite.bob.ID, popular.ID))
+	suite.Require().NoError(services.PostService.Unrepost(suite.carol.ID, popular.ID))
+	suite.db.Exec("DELETE FROM bookmarks")
+	suite.db.Exec("DELETE FROM posts WHERE quote_of_id IS NOT NULL")
+	_, err = services.RankingService.RefreshHotScores(time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, suite.get("/api/v1/posts/timeline?sort=hot", &response))
+	suite.Require().Len(response.Posts, 2)
+	assert.Equal(suite.T(), fresh.ID.String(), response.Posts[0].ID)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.get("/api/v1/posts/timeline?sort=best", nil))
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.get("/api/v1/posts/timeline?sort=hot&cursor=", nil))
+}
+
+// TestRankingTestSuite 运行热门排序测试套件
+func TestRankingTestSuite(t *testing.T) {
+	suite.Run(t, new(RankingTestSuite))
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ScheduleTestSuite 草稿与定时发布测试套件
+type ScheduleTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	author *models.User
+	reader *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *ScheduleTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *ScheduleTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *ScheduleTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.author, err = services.UserService.CreateUser("Ada", "ada", "ada@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.reader, err = services.UserService.CreateUser("Rex", "rex", "rex@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// schedule 创建一个即将到期的定时帖子
+func (suite *ScheduleTestSuite) schedule(content string) *models.Post {
+	publishAt := time.Now().Add(time.Minute)
+	post, err := services.PostService.CreatePostWithOptions(suite.author.ID, services.CreatePostOptions{
+		Content:   content,
+		PublishAt: &publishAt,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(models.PostStatusScheduled, post.Status)
+	return post
+}
+
+// mentionCount 返回提及通知数量
+func (suite *ScheduleTestSuite) mentionCount() int64 {
+	var count int64
+	suite.db.Model(&models.Notification{}).Where("type = ?", models.NotificationTypeMention).Count(&count)
+	return count
+}
+
+// TestDraft_PrivateUntilPublished 测试草稿对所有人不可见，发布后才推送提及通知
+func (suite *ScheduleTestSuite) TestDraft_PrivateUntilPublished() {
+	w := performRequest(suite.router, "POST", "/api/v1/posts", `{"content":"draft for @rex","draft":true}`, suite.author)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var draft controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &draft))
+	assert.Equal(suite.T(), models.PostStatusDraft, draft.Status)
+	assert.Equal(suite.T(), int64(0), suite.mentionCount())
+
+	// 草稿不出现在时间线和详情中，作者本人也只能在草稿箱中看到
+	for _, user := range []*models.User{suite.author, suite.reader} {
+		req := createAuthenticatedRequest("GET", "/api/v1/posts/"+draft.ID, nil, user.ID.String())
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+		posts, _, err := services.PostService.GetTimeline(user.ID, 1, 10)
+		suite.Require().NoError(err)
+		assert.Empty(suite.T(), posts)
+	}
+
+	w = performRequest(suite.router, "GET", "/api/v1/posts/drafts", "", suite.author)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var drafts controllers.TimelineResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &drafts))
+	suite.Require().Len(drafts.Posts, 1)
+
+	w = performRequest(suite.router, "PUT", "/api/v1/posts/"+draft.ID, `{"content":"final for @rex #launch"}`, suite.author)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var updated controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(suite.T(), "final for @rex #launch", updated.Content)
+	assert.Len(suite.T(), updated.Entities, 2)
+
+	w = performRequest(suite.router, "POST", "/api/v1/posts/"+draft.ID+"/publish", "", suite.author)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), int64(1), suite.mentionCount())
+
+	// 已发布的帖子不能再作为草稿修改或重复发布
+	w = performRequest(suite.router, "PUT", "/api/v1/posts/"+draft.ID, `{"content":"edited"}`, suite.author)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = performRequest(suite.router, "POST", "/api/v1/posts/"+draft.ID+"/publish", "", suite.author)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	posts, _, err := services.PostService.GetTimeline(suite.reader.ID, 1, 10)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), posts, 1)
+}
+
+// TestScheduled_PublishedWhenDue 测试定时帖子到期发布，时间戳变为实际发布时间
+func (suite *ScheduleTestSuite) TestScheduled_PublishedWhenDue() {
+	post := suite.schedule("launch day @rex")
+	assert.Equal(suite.T(), int64(0), suite.mentionCount())
+
+	count, err := services.PostService.PublishDuePosts(time.Now())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 0, count)
+
+	before := time.Now()
+	count, err = services.PostService.PublishDuePosts(time.Now().Add(2 * time.Minute))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, count)
+
+	loaded, err := services.PostService.GetPostByID(suite.reader.ID, post.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), models.PostStatusPublished, loaded.Status)
+	assert.False(suite.T(), loaded.Timestamp.Before(before.Add(-time.Second)))
+	assert.Equal(suite.T(), int64(1), suite.mentionCount())
+
+	// 已发布的帖子不会被再次发布
+	count, err = services.PostService.PublishDuePosts(time.Now().Add(2 * time.Minute))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 0, count)
+	assert.Equal(suite.T(), int64(1), suite.mentionCount())
+}
+
+// TestScheduled_NoDoublePublish 测试多个实例同时发布时每个帖子只发布一次
+func (suite *ScheduleTestSuite) TestScheduled_NoDoublePublish() {
+	for i := 0; i < 5; i++ {
+		suite.schedule("hello @rex")
+	}
+
+	var wg sync.WaitGroup
+	counts := make([]int, 4)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			count, err := services.PostService.PublishDuePosts(time.Now().Add(2 * time.Minute))
+			assert.NoError(suite.T(), err)
+			counts[i] = count
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	assert.Equal(suite.T(), 5, total)
+	assert.Equal(suite.T(), int64(5), suite.mentionCount())
+}
+
+// TestSchedule_Endpoints 测试设置、取消定时发布和时间校验
+func (suite *ScheduleTestSuite) TestSchedule_Endpoints() {
+	w := performRequest(suite.router, "POST", "/api/v1/posts", `{"content":"soon","publish_at":"2000-01-01T00:00:00Z"}`, suite.author)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w = performRequest(suite.router, "POST", "/api/v1/posts", `{"content":"soon","draft":true,"publish_at":"`+publishAt+`"}`, suite.author)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = performRequest(suite.router, "POST", "/api/v1/posts", `{"content":"soon","draft":true}`, suite.author)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+
+	w = performRequest(suite.router, "PUT", "/api/v1/posts/"+post.ID+"/schedule", `{"publish_at":"`+publishAt+`"}`, suite.author)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(suite.T(), models.PostStatusScheduled, post.Status)
+	suite.Require().NotNil(post.PublishAt)
+
+	w = performRequest(suite.router, "GET", "/api/v1/posts/scheduled", "", suite.author)
+	var scheduled controllers.TimelineResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &scheduled))
+	assert.Len(suite.T(), scheduled.Posts, 1)
+
+	w = performRequest(suite.router, "DELETE", "/api/v1/posts/"+post.ID+"/schedule", "", suite.author)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var unscheduled controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &unscheduled))
+	assert.Equal(suite.T(), models.PostStatusDraft, unscheduled.Status)
+	assert.Nil(suite.T(), unscheduled.PublishAt)
+
+	// 其他用户不能修改别人的草稿
+	req := createAuthenticatedRequest("POST", "/api/v1/posts/"+post.ID+"/publish", nil, suite.reader.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// TestScheduleTestSuite 运行草稿与定时发布测试套件
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
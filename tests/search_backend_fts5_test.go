@@ -0,0 +1,8 @@
+//go:build sqlite_fts5
+
+package tests
+
+import "yolo/database"
+
+// sqliteSearchBackend 以 -tags sqlite_fts5 编译时 SQLite 必须使用 FTS5，不能退化为逐行匹配
+const sqliteSearchBackend = database.SearchBackendFTS5
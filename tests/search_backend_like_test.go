@@ -0,0 +1,8 @@
+//go:build !sqlite_fts5
+
+package tests
+
+import "yolo/database"
+
+// sqliteSearchBackend 未加 sqlite_fts5 标签编译时 SQLite 退化为逐行匹配，FTS5 相关测试会跳过
+const sqliteSearchBackend = database.SearchBackendLike
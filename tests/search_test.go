@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+	"yolo/controllers"
+	"yolo/database"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// SearchTestSuite 帖子搜索测试套件
+type SearchTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *SearchTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *SearchTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *SearchTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// search 发起搜索请求，user为nil时不带token
+func (suite *SearchTestSuite) search(params url.Values, user *models.User) *httptest.ResponseRecorder {
+	target := "/api/v1/posts/search?" + params.Encode()
+	req, _ := http.NewRequest("GET", target, nil)
+	if user != nil {
+		req = createAuthenticatedRequest("GET", target, nil, user.ID.String())
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// contents 搜索并按顺序返回结果的内容
+func (suite *SearchTestSuite) contents(params url.Values, user *models.User) []string {
+	w := suite.search(params, user)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var response controllers.SearchPostsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(len(response.Posts)), response.PageInfo.TotalPosts)
+
+	contents := make([]string, 0, len(response.Posts))
+	for _, post := range response.Posts {
+		contents = append(contents, post.Content)
+	}
+	return contents
+}
+
+// TestTermsAndPhrases 测试词查询（全部命中、忽略大小写）和短语查询
+func (suite *SearchTestSuite) TestTermsAndPhrases() {
+	now := time.Now()
+	createPostAt(suite.T(), suite.db, suite.alice, "Interest rates rise again", now.Add(-3*time.Hour))
+	createPostAt(suite.T(), suite.db, suite.alice, "The rates of interest are falling", now.Add(-2*time.Hour))
+	createPostAt(suite.T(), suite.db, suite.bob, "Nothing to see here", now.Add(-time.Hour))
+
+	assert.Equal(suite.T(), []string{"The rates of interest are falling", "Interest rates rise again"},
+		suite.contents(url.Values{"q": {"INTEREST rates"}, "sort": {"recent"}}, nil))
+	assert.Equal(suite.T(), []string{"Interest rates rise again"},
+		suite.contents(url.Values{"q": {`"interest rates"`}}, nil))
+	assert.Equal(suite.T(), []string{"The rates of interest are falling"},
+		suite.contents(url.Values{"q": {`falling "rates of interest"`}}, nil))
+	assert.Empty(suite.T(), suite.contents(url.Values{"q": {"interest nothing"}}, nil))
+}
+
+// TestFilters 测试作者、话题和日期范围过滤
+func (suite *SearchTestSuite) TestFilters() {
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	createPostAt(suite.T(), suite.db, suite.alice, "earnings beat #tech", day)
+	createPostAt(suite.T(), suite.db, suite.alice, "earnings miss #retail", day.Add(24*time.Hour))
+	createPostAt(suite.T(), suite.db, suite.bob, "earnings season #tech", day.Add(48*time.Hour))
+
+	assert.Equal(suite.T(), []string{"earnings miss #retail", "earnings beat #tech"},
+		suite.contents(url.Values{"q": {"earnings"}, "author": {"alice"}, "sort": {"recent"}}, nil))
+	assert.Equal(suite.T(), []string{"earnings season #tech", "earnings beat #tech"},
+		suite.contents(url.Values{"q": {"earnings"}, "tag": {"#Tech"}, "sort": {"recent"}}, nil))
+	assert.Equal(suite.T(), []string{"earnings miss #retail"},
+		suite.contents(url.Values{"q": {"earnings"}, "since": {"2025-03-11"}, "until": {"2025-03-12"}}, nil))
+	assert.Equal(suite.T(), []string{"earnings season #tech"},
+		suite.contents(url.Values{"q": {"earnings"}, "since": {"2025-03-12T00:00:00Z"}}, nil))
+	assert.Empty(suite.T(), suite.contents(url.Values{"q": {"earnings"}, "author": {"nobody"}}, nil))
+}
+
+// TestBackend 测试实际使用的搜索后端与编译标签一致，避免 FTS5 未生效时静默退化为逐行匹配
+func (suite *SearchTestSuite) TestBackend() {
+	assert.Equal(suite.T(), sqliteSearchBackend, database.SearchBackend)
+	if database.SearchBackend == database.SearchBackendLike {
+		suite.T().Log("FTS5 not compiled in, search tests ran against the LIKE backend; run with -tags sqlite_fts5 (make test) to cover FTS5")
+	}
+}
+
+// TestRelevanceAndRecency 测试相关度和时间排序
+func (suite *SearchTestSuite) TestRelevanceAndRecency() {
+	now := time.Now()
+	createPostAt(suite.T(), suite.db, suite.alice, "dividend dividend dividend", now.Add(-2*time.Hour))
+	createPostAt(suite.T(), suite.db, suite.bob, "a long post that mentions a dividend once among many other unrelated words about the market", now.Add(-time.Hour))
+
+	recent := suite.contents(url.Values{"q": {"dividend"}, "sort": {"recent"}}, nil)
+	suite.Require().Len(recent, 2)
+	assert.Equal(suite.T(), "dividend dividend dividend", recent[1])
+
+	if database.SearchBackend == database.SearchBackendLike {
+		suite.T().Skip("relevance ranking needs FTS5, skipped: run with -tags sqlite_fts5 (make test) to cover the FTS5 backend")
+	}
+	relevant := suite.contents(url.Values{"q": {"dividend"}}, nil)
+	suite.Require().Len(relevant, 2)
+	assert.Equal(suite.T(), "dividend dividend dividend", relevant[0])
+}
+
+// TestVisibilityAndIndexUpdates 测试只返回可见帖子，删除和编辑后索引同步
+func (suite *SearchTestSuite) TestVisibilityAndIndexUpdates() {
+	now := time.Now()
+	_, err := services.PostService.CreatePostWithOptions(suite.alice.ID, services.CreatePostOptions{
+		Content:    "quarterly guidance for followers",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+	draft, err := services.PostService.CreatePostWithOptions(suite.alice.ID, services.CreatePostOptions{
+		Content: "quarterly guidance draft",
+		Draft:   true,
+	})
+	suite.Require().NoError(err)
+	deleted := createPostAt(suite.T(), suite.db, suite.alice, "quarterly guidance deleted", now)
+	suite.Require().NoError(services.PostService.DeletePost(suite.alice.ID, deleted.ID))
+
+	query := url.Values{"q": {"quarterly guidance"}}
+	assert.Empty(suite.T(), suite.contents(query, nil))
+	assert.Empty(suite.T(), suite.contents(query, suite.bob))
+	assert.Equal(suite.T(), []string{"quarterly guidance for followers"}, suite.contents(query, suite.alice))
+
+	suite.Require().NoError(services.UserService.Follow(suite.bob.ID, suite.alice.ID))
+	assert.Len(suite.T(), suite.contents(query, suite.bob), 1)
+
+	// 编辑后的草稿发布后按新内容检索
+	content := "revised buyback plan"
+	_, err = services.PostService.UpdateDraft(suite.alice.ID, draft.ID, services.UpdateDraftOptions{Content: &content})
+	suite.Require().NoError(err)
+	_, err = services.PostService.PublishNow(suite.alice.ID, draft.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []string{"revised buyback plan"}, suite.contents(url.Values{"q": {"buyback"}}, nil))
+	assert.Len(suite.T(), suite.contents(url.Values{"q": {"draft"}}, suite.alice), 0)
+}
+
+// TestInvalidSearch 测试非法的搜索参数
+func (suite *SearchTestSuite) TestInvalidSearch() {
+	for _, params := range []url.Values{
+		{},
+		{"q": {`"" !!`}},
+		{"q": {"stocks"}, "sort": {"popular"}},
+		{"q": {"stocks"}, "since": {"yesterday"}},
+		{"q": {"stocks"}, "since": {"2025-03-02"}, "until": {"2025-03-01"}},
+	} {
+		w := suite.search(params, nil)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, params.Encode())
+	}
+}
+
+// TestSearchTestSuite 运行帖子搜索测试套件
+func TestSearchTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchTestSuite))
+}
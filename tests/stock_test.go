@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// StockTestSuite 股票列表测试套件
+type StockTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *StockTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *StockTestSuite) TearDownSuite() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *StockTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	services.StockService.SetConfig(cfg)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// listStocks 获取股票列表
+func (suite *StockTestSuite) listStocks(query string) []controllers.StockResponse {
+	w := performRequest(suite.router, "GET", "/api/v1/stocks"+query, nil, suite.alice)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var stocks []controllers.StockResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &stocks))
+	return stocks
+}
+
+// symbols 股票符号列表
+func symbols(stocks []controllers.StockResponse) []string {
+	result := make([]string, 0, len(stocks))
+	for _, stock := range stocks {
+		result = append(result, stock.Symbol)
+	}
+	return result
+}
+
+// TestCreateStock 测试发行股票的符号归一化和校验
+func (suite *StockTestSuite) TestCreateStock() {
+	w := performRequest(suite.router, "POST", "/api/v1/stocks", map[string]interface{}{
+		"symbol":      " $alic3 ",
+		"name":        "Alice Studio",
+		"category":    "Domain Names",
+		"description": "my work",
+	}, suite.alice)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var created controllers.StockDetailResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(suite.T(), "ALIC3", created.Symbol)
+	assert.Equal(suite.T(), "domain_names", created.Category)
+	assert.Equal(suite.T(), services.DefaultStockSupply, created.Supply)
+	suite.Require().NotNil(created.Description)
+	assert.Equal(suite.T(), "my work", *created.Description)
+	assert.True(suite.T(), services.UserService.IsCreator(suite.alice.ID))
+
+	// 符号不区分大小写，不能重复
+	w = performRequest(suite.router, "POST", "/api/v1/stocks", map[string]interface{}{"symbol": "Alic3", "name": "Copy"}, suite.bob)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	for _, body := range []map[string]interface{}{
+		{"symbol": "yolo", "name": "Reserved"},
+		{"symbol": "1ABC", "name": "Leading digit"},
+		{"symbol": "TOOLONGSYMBOL", "name": "Long"},
+		{"symbol": "BO-B", "name": "Dash"},
+		{"symbol": "BOB", "name": "  "},
+		{"symbol": "BOB", "name": "Bob", "category": "cooking"},
+		{"symbol": "BOB", "name": "Bob", "supply": -1},
+	} {
+		w = performRequest(suite.router, "POST", "/api/v1/stocks", body, suite.bob)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+	assert.False(suite.T(), services.UserService.IsCreator(suite.bob.ID))
+
+	// 追加的保留符号
+	cfg := services.DefaultStockConfig()
+	cfg.Enabled = true
+	cfg.ReservedSymbols = append(cfg.ReservedSymbols, "bob")
+	services.StockService.SetConfig(cfg)
+	_, err := services.StockService.CreateStock(suite.bob.ID, services.CreateStockOptions{Symbol: "BOB", Name: "Bob"})
+	assert.ErrorIs(suite.T(), err, services.ErrSymbolReserved)
+}
+
+// TestListStocks 测试按分类筛选和排序
+func (suite *StockTestSuite) TestListStocks() {
+	for _, stock := range []models.Stock{
+		{UserID: suite.alice.ID, Name: "Art One", Symbol: "ART", Category: "art", Price: decimal.FromInt(3), Owners: 10},
+		{UserID: suite.bob.ID, Name: "Game", Symbol: "GAME", Category: "gaming", Price: decimal.FromInt(1), Owners: 30},
+		{UserID: suite.bob.ID, Name: "Art Two", Symbol: "PAINT", Category: "art", Price: decimal.FromInt(2), Owners: 20},
+	} {
+		suite.Require().NoError(suite.db.Create(&stock).Error)
+	}
+
+	assert.Len(suite.T(), suite.listStocks(""), 3)
+	assert.Len(suite.T(), suite.listStocks("?category=All"), 3)
+	assert.Equal(suite.T(), []string{"GAME", "PAINT", "ART"}, symbols(suite.listStocks("?sortBy=owners")))
+	assert.Equal(suite.T(), []string{"GAME", "PAINT", "ART"}, symbols(suite.listStocks("?sortBy=price&order=asc")))
+	assert.Equal(suite.T(), []string{"ART", "PAINT"}, symbols(suite.listStocks("?category=art&sortBy=price&order=desc")))
+
+	for _, query := range []string{"?sortBy=user_id", "?order=sideways", "?category=cooking"} {
+		w := performRequest(suite.router, "GET", "/api/v1/stocks"+query, nil, suite.alice)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// TestStockDetail 测试按符号获取详情
+func (suite *StockTestSuite) TestStockDetail() {
+	_, err := services.StockService.CreateStock(suite.alice.ID, services.CreateStockOptions{Symbol: "ALICE", Name: "Alice"})
+	suite.Require().NoError(err)
+
+	w := performRequest(suite.router, "GET", "/api/v1/stocks/alice", nil, suite.bob)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var detail controllers.StockDetailResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(suite.T(), "ALICE", detail.Symbol)
+	suite.Require().NotNil(detail.Creator)
+	assert.Equal(suite.T(), suite.alice.ID.String(), detail.Creator.ID)
+
+	assert.Equal(suite.T(), http.StatusNotFound, performRequest(suite.router, "GET", "/api/v1/stocks/NOPE", nil, suite.bob).Code)
+}
+
+// TestStocksDisabled 测试关闭时接口返回 404
+func (suite *StockTestSuite) TestStocksDisabled() {
+	services.StockService.SetConfig(services.DefaultStockConfig())
+
+	assert.Equal(suite.T(), http.StatusNotFound, performRequest(suite.router, "GET", "/api/v1/stocks", nil, suite.alice).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, performRequest(suite.router, "GET", "/api/v1/stocks/ALICE", nil, suite.alice).Code)
+	w := performRequest(suite.router, "POST", "/api/v1/stocks", map[string]interface{}{"symbol": "ALICE", "name": "Alice"}, suite.alice)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.False(suite.T(), services.UserService.IsCreator(suite.alice.ID))
+}
+
+// TestStockSuite 运行股票测试套件
+func TestStockSuite(t *testing.T) {
+	suite.Run(t, new(StockTestSuite))
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// StreamTestSuite 实时推送接口测试套件
+type StreamTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	db     *gorm.DB
+	alice  *models.User
+	bob    *models.User
+}
+
+// sseEvent 解析后的SSE事件
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// SetupSuite 测试套件初始化
+func (suite *StreamTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.server = httptest.NewServer(routes.SetupRoutes())
+}
+
+// TearDownSuite 测试套件清理
+func (suite *StreamTestSuite) TearDownSuite() {
+	suite.server.Close()
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *StreamTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.alice, err = services.UserService.CreateUser("Alice", "alice", "alice@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.bob, err = services.UserService.CreateUser("Bob", "bob", "bob@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// connect 建立SSE连接，返回事件通道
+func (suite *StreamTestSuite) connect(ctx context.Context, user *models.User, query, lastEventID string) (*http.Response, <-chan sseEvent) {
+	token, _ := utils.GenerateJWT(user.ID.String())
+	req, _ := http.NewRequestWithContext(ctx, "GET", suite.server.URL+"/api/v1/stream?access_token="+token+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		var current sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if current.Event != "" {
+					events <- current
+				}
+				current = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				current.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				current.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return resp, events
+}
+
+// next 读取下一个事件
+func (suite *StreamTestSuite) next(events <-chan sseEvent) sseEvent {
+	select {
+	case event, ok := <-events:
+		suite.Require().True(ok, "stream closed")
+		return event
+	case <-time.After(2 * time.Second):
+		suite.FailNow("timed out waiting for event")
+		return sseEvent{}
+	}
+}
+
+// TestStream_TimelineAndNotifications 测试推送时间线和通知事件
+func (suite *StreamTestSuite) TestStream_TimelineAndNotifications() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resp, events := suite.connect(ctx, suite.bob, "", "")
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(suite.T(), "ready", suite.next(events).Event)
+
+	post, err := services.PostService.CreatePost(suite.alice.ID, "hello @bob")
+	suite.Require().NoError(err)
+
+	created := suite.next(events)
+	assert.Equal(suite.T(), "post.created", created.Event)
+	assert.Contains(suite.T(), created.Data, post.ID.String())
+	assert.Contains(suite.T(), created.Data, `"channel":"timeline"`)
+
+	notification := suite.next(events)
+	assert.Equal(suite.T(), "notification", notification.Event)
+	assert.Contains(suite.T(), notification.Data, `"type":"mention"`)
+}
+
+// TestStream_ResumeWithLastEventID 测试断线后带 Last-Event-ID 续传
+func (suite *StreamTestSuite) TestStream_ResumeWithLastEventID() {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, events := suite.connect(ctx, suite.bob, "&channels=timeline", "")
+	suite.next(events)
+
+	_, err := services.PostService.CreatePost(suite.alice.ID, "first")
+	suite.Require().NoError(err)
+	first := suite.next(events)
+	cancel()
+
+	missed, err := services.PostService.CreatePost(suite.alice.ID, "second")
+	suite.Require().NoError(err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, events = suite.connect(ctx, suite.bob, "&channels=timeline", first.ID)
+	assert.Equal(suite.T(), "ready", suite.next(events).Event)
+	replayed := suite.next(events)
+	assert.Equal(suite.T(), "post.created", replayed.Event)
+	assert.Contains(suite.T(), replayed.Data, missed.ID.String())
+
+	// 未知的事件ID要求客户端重置
+	_, events = suite.connect(ctx, suite.bob, "&channels=timeline", "unknown-1")
+	suite.next(events)
+	assert.Equal(suite.T(), "reset", suite.next(events).Event)
+}
+
+// TestStream_Authorization 测试认证和频道权限
+func (suite *StreamTestSuite) TestStream_Authorization() {
+	resp, err := http.Get(suite.server.URL + "/api/v1/stream")
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp, _ = suite.connect(ctx, suite.bob, "&channels=notifications:"+suite.alice.ID.String(), "")
+	assert.Equal(suite.T(), http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = suite.connect(ctx, suite.bob, "&channels=unknown", "")
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+// TestStreamTestSuite 运行实时推送测试套件
+func TestStreamTestSuite(t *testing.T) {
+	suite.Run(t, new(StreamTestSuite))
+}
@@ -1,62 +1,181 @@
 package tests
 
-// import (
-// 	"os"
-// 	"yolo/database"
-// 	"yolo/models"
-// 	"yolo/services"
-
-// 	"github.com/gin-gonic/gin"
-// 	"gorm.io/driver/sqlite"
-// 	"gorm.io/gorm"
-// )
-
-// // SetupTestEnvironment 设置测试环境
-// func SetupTestEnvironment() (*gorm.DB, error) {
-// 	// 设置测试环境变量
-// 	os.Setenv("GIN_MODE", "test")
-// 	os.Setenv("DB_TYPE", "sqlite")
-// 	os.Setenv("DB_CONNECTION", ":memory:")
-// 	os.Setenv("JWT_SECRET", "test-secret-key-for-testing")
-// 	os.Setenv("SKIP_WEB3_INIT", "true")
-
-// 	gin.SetMode(gin.TestMode)
-
-// 	// 初始化内存数据库
-// 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// 设置全局数据库实例
-// 	database.DB = db
-
-// 	// 自动迁移
-// 	err = db.AutoMigrate(
-// 		&models.User{},
-// 		&models.Post{},
-// 		&models.Stock{},
-// 		&models.UserHolding{},
-// 		&models.Trade{},
-// 		&models.ChartData{},
-// 		&models.GiftRecord{},
-// 	)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// 初始化服务
-// 	services.InitServices()
-
-// 	return db, nil
-// }
-
-// // CleanupTestEnvironment 清理测试环境
-// func CleanupTestEnvironment(db *gorm.DB) {
-// 	if db != nil {
-// 		sqlDB, _ := db.DB()
-// 		if sqlDB != nil {
-// 			sqlDB.Close()
-// 		}
-// 	}
-// }
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yolo/database"
+	"yolo/models"
+	"yolo/services"
+	"yolo/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SetupTestEnvironment 设置测试环境
+func SetupTestEnvironment() (*gorm.DB, error) {
+	setTestEnv()
+
+	// 初始化内存数据库
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 内存数据库每个连接都是独立的库，限制为单连接。
+	// 因此多个 goroutine 的事务由连接池依次执行，只能验证交替执行后的不变量，
+	// 多个连接同时争用的情况见 SetupConcurrentTestEnvironment
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return initTestDatabase(db)
+}
+
+// SetupConcurrentTestEnvironment 设置多连接的测试环境。
+// 设置了 TEST_POSTGRES_DSN 时连接该 PostgreSQL 数据库，事务真正按行加锁，测试会清空其中的数据；
+// 否则在 dir 下创建 SQLite 文件数据库，各连接的事务以 BEGIN IMMEDIATE 争用写锁，在 busy_timeout 内等待
+func SetupConcurrentTestEnvironment(dir string) (*gorm.DB, error) {
+	setTestEnv()
+
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	var (
+		db  *gorm.DB
+		err error
+	)
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		db, err = gorm.Open(postgres.Open(dsn), config)
+	} else {
+		dsn := "file:" + filepath.Join(dir, "concurrency.db") + "?_journal_mode=WAL&_busy_timeout=30000&_txlock=immediate"
+		db, err = gorm.Open(sqlite.Open(dsn), config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(8)
+
+	return initTestDatabase(db)
+}
+
+// setTestEnv 设置测试环境变量
+func setTestEnv() {
+	os.Setenv("GIN_MODE", "test")
+	os.Setenv("DB_TYPE", "sqlite")
+	os.Setenv("DB_CONNECTION", ":memory:")
+	os.Setenv("JWT_SECRET", "test-secret-key-for-testing")
+	os.Setenv("SKIP_WEB3_INIT", "true")
+	os.Setenv("MEDIA_STORAGE_DIR", filepath.Join(os.TempDir(), "yolo-test-media"))
+	// 发帖过滤默认关闭，需要的测试单独开启
+	os.Setenv("CONTENT_FILTER_ENABLED", "false")
+	// 链接预览默认关闭，避免测试访问外网
+	os.Setenv("LINK_PREVIEW_ENABLED", "false")
+
+	gin.SetMode(gin.TestMode)
+}
+
+// initTestDatabase 设置全局数据库实例，迁移并初始化服务
+func initTestDatabase(db *gorm.DB) (*gorm.DB, error) {
+	database.DB = db
+
+	// 自动迁移
+	if err := database.AutoMigrate(); err != nil {
+		return nil, err
+	}
+
+	// 初始化服务
+	services.InitServices()
+
+	return db, nil
+}
+
+// CleanupTestEnvironment 清理测试环境
+func CleanupTestEnvironment(db *gorm.DB) {
+	if db != nil {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}
+}
+
+// testTables 每个测试前清空的业务表，引用其他表的排在前面
+var testTables = []string{
+	"portfolio_snapshots", "conditional_orders", "orders", "trades",
+	"ledger_postings", "journal_entries", "ledger_accounts", "liquidity_pools", "user_holdings", "stocks",
+	"moderation_actions", "reports",
+	"federation_deliveries", "remote_followers", "remote_actors", "actor_keys",
+	"bookmarks", "bookmark_collections",
+	"poll_votes", "poll_ballots", "poll_options", "polls",
+	"post_links", "link_previews", "media", "post_scores", "post_entities",
+	"notifications", "notification_preferences", "follows", "posts", "users",
+}
+
+// resetDatabase 清空业务表，每个测试从空库开始
+func resetDatabase(db *gorm.DB) {
+	for _, table := range testTables {
+		db.Exec("DELETE FROM " + table)
+	}
+}
+
+// performRequest 以指定用户身份请求路由。body 为字符串时原样发送，其他非空值编码为 JSON
+func performRequest(router *gin.Engine, method, target string, body interface{}, user *models.User) *httptest.ResponseRecorder {
+	var data []byte
+	switch v := body.(type) {
+	case nil:
+	case string:
+		data = []byte(v)
+	default:
+		data, _ = json.Marshal(v)
+	}
+	req := createAuthenticatedRequest(method, target, data, user.ID.String())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// createPostAt 发帖并把发布时间设为 timestamp
+func createPostAt(t *testing.T, db *gorm.DB, user *models.User, content string, timestamp time.Time) *models.Post {
+	post, err := services.PostService.CreatePost(user.ID, content)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(post).Updates(map[string]interface{}{"timestamp": timestamp, "updated_at": timestamp}).Error)
+	post.Timestamp = timestamp
+	return post
+}
+
+// assertLedgerBalanced 断言账本平衡并返回检查结果
+func assertLedgerBalanced(t *testing.T) *services.LedgerReport {
+	report, err := services.LedgerService.CheckInvariants()
+	require.NoError(t, err)
+	assert.True(t, report.Balanced(), "%+v", report)
+	return report
+}
+
+// createAuthenticatedRequest 创建带认证的请求
+func createAuthenticatedRequest(method, url string, body []byte, userID string) *http.Request {
+	req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	token, _ := utils.GenerateJWT(userID)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yolo/controllers"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// TopicsTestSuite 话题与股票讨论区测试套件
+type TopicsTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	db     *gorm.DB
+	user   *models.User
+}
+
+// SetupSuite 测试套件初始化
+func (suite *TopicsTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *TopicsTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备
+func (suite *TopicsTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.user, err = services.UserService.CreateUser("Sam", "sam", "sam@example.com", "password123")
+	suite.Require().NoError(err)
+}
+
+// getTopic 请求话题或股票讨论区
+func (suite *TopicsTestSuite) getTopic(url string) controllers.TopicPostsResponse {
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response controllers.TopicPostsResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// TestCreatePost_ReturnsEntities 测试发帖返回实体偏移，且只保留存在的提及
+func (suite *TopicsTestSuite) TestCreatePost_ReturnsEntities() {
+	body, _ := json.Marshal(controllers.CreatePostRequest{
+		Content: "hi @sam and @nobody, #Launch $SAM",
+	})
+	req := createAuthenticatedRequest("POST", "/api/v1/posts", body, suite.user.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	var post controllers.PostResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &post))
+	suite.Require().Len(post.Entities, 3)
+
+	assert.Equal(suite.T(), models.EntityTypeMention, post.Entities[0].Type)
+	assert.Equal(suite.T(), 3, post.Entities[0].Start)
+	assert.Equal(suite.T(), 7, post.Entities[0].End)
+	suite.Require().NotNil(post.Entities[0].UserID)
+	assert.Equal(suite.T(), suite.user.ID.String(), *post.Entities[0].UserID)
+
+	assert.Equal(suite.T(), models.EntityTypeHashtag, post.Entities[1].Type)
+	assert.Equal(suite.T(), "launch", post.Entities[1].Value)
+	assert.Equal(suite.T(), models.EntityTypeCashtag, post.Entities[2].Type)
+	assert.Equal(suite.T(), "SAM", post.Entities[2].Value)
+}
+
+// TestTopicFeeds 测试话题页和股票讨论页
+func (suite *TopicsTestSuite) TestTopicFeeds() {
+	_, err := services.PostService.CreatePost(suite.user.ID, "first #launch for $SAM")
+	suite.Require().NoError(err)
+	_, err = services.PostService.CreatePost(suite.user.ID, "#Launch #launch again")
+	suite.Require().NoError(err)
+	_, err = services.PostService.CreatePost(suite.user.ID, "nothing here")
+	suite.Require().NoError(err)
+
+	tag := suite.getTopic("/api/v1/tags/LAUNCH/posts")
+	assert.Equal(suite.T(), "launch", tag.Topic)
+	assert.Len(suite.T(), tag.Posts, 2)
+	assert.Equal(suite.T(), int64(2), tag.PageInfo.TotalPosts)
+
+	symbol := suite.getTopic("/api/v1/symbols/sam/posts")
+	assert.Equal(suite.T(), "SAM", symbol.Topic)
+	suite.Require().Len(symbol.Posts, 1)
+	assert.Equal(suite.T(), "first #launch for $SAM", symbol.Posts[0].Content)
+
+	empty := suite.getTopic("/api/v1/tags/unknown/posts")
+	assert.Empty(suite.T(), empty.Posts)
+}
+
+// TestTopicsTestSuite 运行话题测试套件
+func TestTopicsTestSuite(t *testing.T) {
+	suite.Run(t, new(TopicsTestSuite))
+}
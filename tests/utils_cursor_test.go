@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"yolo/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCursorRoundTrip 测试游标编码和解码
+func TestCursorRoundTrip(t *testing.T) {
+	timestamp := time.Date(2025, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	id := uuid.New()
+
+	cursor, err := utils.DecodeCursor(utils.EncodeCursor(timestamp, id, utils.CursorPrev))
+
+	assert.NoError(t, err)
+	assert.True(t, timestamp.Equal(cursor.Timestamp))
+	assert.Equal(t, id, cursor.ID)
+	assert.Equal(t, utils.CursorPrev, cursor.Direction)
+}
+
+// TestDecodeCursor_Invalid 测试非法游标
+func TestDecodeCursor_Invalid(t *testing.T) {
+	_, err := utils.DecodeCursor("not a cursor")
+	assert.Error(t, err)
+
+	_, err = utils.DecodeCursor("eyJ0IjoxLCJkIjoic2lkZXdheXMifQ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"testing"
+	"yolo/models"
+	"yolo/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExtractEntities 测试解析话题、提及和股票符号
+func TestExtractEntities(t *testing.T) {
+	entities := utils.ExtractEntities("Buying $elon today #YOLO @sam_a!")
+
+	assert.Equal(t, []utils.ParsedEntity{
+		{Type: models.EntityTypeCashtag, Value: "ELON", Start: 7, End: 12},
+		{Type: models.EntityTypeHashtag, Value: "yolo", Start: 19, End: 24},
+		{Type: models.EntityTypeMention, Value: "sam_a", Start: 25, End: 31},
+	}, entities)
+}
+
+// TestExtractEntities_UnicodeOffsets 测试偏移按Unicode字符计算
+func TestExtractEntities_UnicodeOffsets(t *testing.T) {
+	entities := utils.ExtractEntities("今天 #创业 很开心")
+
+	assert.Equal(t, []utils.ParsedEntity{
+		{Type: models.EntityTypeHashtag, Value: "创业", Start: 3, End: 6},
+	}, entities)
+}
+
+// TestExtractEntities_Ignored 测试不应被解析的内容
+func TestExtractEntities_Ignored(t *testing.T) {
+	// 邮箱、价格、纯数字话题、URL锚点、过长的符号
+	entities := utils.ExtractEntities("mail me@example.com, costs $100, issue #42, see https://x.com/a#b and $TOOLONGSYMBOL")
+
+	assert.Empty(t, entities)
+}
+
+// TestNormalizeTopic 测试话题和股票符号归一化
+func TestNormalizeTopic(t *testing.T) {
+	assert.Equal(t, "yolo", utils.NormalizeHashtag("#YOLO"))
+	assert.Equal(t, "ELON", utils.NormalizeSymbol("$elon"))
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+	"yolo/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// solidImage 创建纯色图片
+func solidImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// mp4Box 构造一个MP4 box
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], boxType)
+	return append(box, body...)
+}
+
+// buildTestMP4 构造只包含文件头的MP4文件
+func buildTestMP4(width, height int, duration time.Duration) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(duration.Milliseconds()))
+
+	// 音频轨道宽高为0
+	audio := make([]byte, 84)
+	video := make([]byte, 84)
+	binary.BigEndian.PutUint32(video[76:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(video[80:], uint32(height)<<16)
+
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Box("trak", mp4Box("tkhd", audio)),
+			mp4Box("trak", mp4Box("tkhd", video)),
+		),
+		mp4Box("mdat", []byte("data")),
+	}, nil)
+}
+
+// TestResizeToFit 测试等比缩放且不放大
+func TestResizeToFit(t *testing.T) {
+	resized := utils.ResizeToFit(solidImage(800, 200, color.White), 400, 400)
+	assert.Equal(t, image.Rect(0, 0, 400, 100), resized.Bounds())
+
+	resized = utils.ResizeToFit(solidImage(30, 60, color.White), 400, 400)
+	assert.Equal(t, image.Rect(0, 0, 30, 60), resized.Bounds())
+
+	r, g, b, _ := utils.ResizeToFit(solidImage(10, 10, color.RGBA{R: 200, G: 100, B: 50, A: 255}), 3, 3).At(1, 1).RGBA()
+	assert.Equal(t, []uint32{200, 100, 50}, []uint32{r >> 8, g >> 8, b >> 8})
+}
+
+// TestEncodeBlurhash 测试blurhash的结构：尺寸标记、最大值、平均色和交流分量
+func TestEncodeBlurhash(t *testing.T) {
+	hash, err := utils.EncodeBlurhash(solidImage(16, 16, color.RGBA{R: 255, A: 255}), 4, 3)
+	assert.NoError(t, err)
+	assert.Len(t, hash, 1+1+4+2*11)
+	assert.Equal(t, "L", hash[:1])     // (4-1)+(3-1)*9 = 21
+	assert.Equal(t, "TI:j", hash[2:6]) // 平均色 #FF0000
+
+	hash, err = utils.EncodeBlurhash(solidImage(16, 16, color.Black), 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "000000", hash)
+
+	_, err = utils.EncodeBlurhash(solidImage(4, 4, color.Black), 10, 1)
+	assert.Error(t, err)
+}
+
+// TestProbeMP4 测试读取MP4文件头中的尺寸和时长
+func TestProbeMP4(t *testing.T) {
+	info, err := utils.ProbeMP4(bytes.NewReader(buildTestMP4(640, 360, 5*time.Second)))
+	assert.NoError(t, err)
+	assert.Equal(t, 640, info.Width)
+	assert.Equal(t, 360, info.Height)
+	assert.Equal(t, 5*time.Second, info.Duration)
+
+	_, err = utils.ProbeMP4(bytes.NewReader(mp4Box("ftyp", []byte("isom"))))
+	assert.ErrorIs(t, err, utils.ErrInvalidMP4)
+
+	// box长度超出文件
+	truncated := buildTestMP4(640, 360, time.Second)
+	_, err = utils.ProbeMP4(bytes.NewReader(truncated[:len(truncated)-20]))
+	assert.ErrorIs(t, err, utils.ErrInvalidMP4)
+}
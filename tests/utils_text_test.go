@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"testing"
+	"yolo/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExtractLinks 测试链接提取
+func TestExtractLinks(t *testing.T) {
+	links := utils.ExtractLinks("see https://example.com/a?b=1, www.test.org. and (http://x.io/path)")
+	assert.Equal(t, []string{"https://example.com/a?b=1", "www.test.org", "http://x.io/path"}, links)
+
+	assert.Empty(t, utils.ExtractLinks("no links here, just example.com and a@b.com"))
+}
+
+// TestTextSimilarity 测试近似重复检测的相似度
+func TestTextSimilarity(t *testing.T) {
+	original := "Check out this amazing opportunity right now"
+
+	assert.Equal(t, 1.0, utils.TextSimilarity(original, "check out this AMAZING opportunity,   right now!!"))
+	assert.Greater(t, utils.TextSimilarity(original, "Check out this amazing opportunity right now!!! 🚀"), 0.9)
+	assert.Less(t, utils.TextSimilarity(original, "Quarterly results are out, revenue grew 12%"), 0.2)
+	assert.Equal(t, 0.0, utils.TextSimilarity("", original))
+}
+
+// TestParseSearchQuery 测试搜索关键词解析
+func TestParseSearchQuery(t *testing.T) {
+	query := utils.ParseSearchQuery(`Tesla "Interest Rates" $TSLA tesla, "solo" "unclosed phrase`)
+	assert.Equal(t, []string{"tesla", "tsla", "solo"}, query.Terms)
+	assert.Equal(t, [][]string{{"interest", "rates"}, {"unclosed", "phrase"}}, query.Phrases)
+
+	assert.True(t, utils.ParseSearchQuery(` "" !! `).Empty())
+	assert.Len(t, utils.ParseSearchQuery("a b c d e f g h i j k l m").Terms, 10)
+}
@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"yolo/controllers"
+	"yolo/decimal"
+	"yolo/models"
+	"yolo/routes"
+	"yolo/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// VisibilityTestSuite 帖子可见范围测试套件
+type VisibilityTestSuite struct {
+	suite.Suite
+	router   *gin.Engine
+	db       *gorm.DB
+	creator  *models.User
+	follower *models.User
+	holder   *models.User
+	stranger *models.User
+	posts    map[string]*models.Post
+}
+
+// SetupSuite 测试套件初始化
+func (suite *VisibilityTestSuite) SetupSuite() {
+	db, err := SetupTestEnvironment()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = routes.SetupRoutes()
+}
+
+// TearDownSuite 测试套件清理
+func (suite *VisibilityTestSuite) TearDownSuite() {
+	CleanupTestEnvironment(suite.db)
+}
+
+// SetupTest 每个测试前的准备：创作者发布四种可见范围的帖子
+func (suite *VisibilityTestSuite) SetupTest() {
+	resetDatabase(suite.db)
+
+	var err error
+	suite.creator, err = services.UserService.CreateUser("Cora", "cora", "cora@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.follower, err = services.UserService.CreateUser("Finn", "finn", "finn@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.holder, err = services.UserService.CreateUser("Hana", "hana", "hana@example.com", "password123")
+	suite.Require().NoError(err)
+	suite.stranger, err = services.UserService.CreateUser("Sid", "sid", "sid@example.com", "password123")
+	suite.Require().NoError(err)
+
+	stock := &models.Stock{UserID: suite.creator.ID, Name: "Cora", Symbol: "CORA", Status: "active"}
+	suite.Require().NoError(suite.db.Create(stock).Error)
+	suite.Require().NoError(suite.db.Create(&models.UserHolding{UserID: suite.holder.ID, StockID: stock.ID, Quantity: decimal.FromInt(10)}).Error)
+	suite.Require().NoError(services.UserService.Follow(suite.follower.ID, suite.creator.ID))
+
+	suite.posts = make(map[string]*models.Post)
+	for _, visibility := range []string{models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityOnlyMe, models.VisibilityHolders} {
+		post, err := services.PostService.CreatePostWithOptions(suite.creator.ID, services.CreatePostOptions{
+			Content:    visibility + " post #cora",
+			Visibility: visibility,
+		})
+		suite.Require().NoError(err)
+		suite.posts[visibility] = post
+	}
+}
+
+// get 以指定用户身份发起GET请求，user为nil时不带token
+func (suite *VisibilityTestSuite) get(url string, user *models.User) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	if user != nil {
+		req = createAuthenticatedRequest("GET", url, nil, user.ID.String())
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// visibleContents 返回列表接口中各帖子的可见范围
+func (suite *VisibilityTestSuite) visibleContents(url string, user *models.User) []string {
+	w := suite.get(url, user)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response struct {
+		Posts []controllers.PostResponse `json:"posts"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+	visibilities := make([]string, 0, len(response.Posts))
+	for _, post := range response.Posts {
+		visibilities = append(visibilities, post.Visibility)
+	}
+	return visibilities
+}
+
+// TestReadPaths_FilterByViewer 测试所有读取路径都按查看者过滤
+func (suite *VisibilityTestSuite) TestReadPaths_FilterByViewer() {
+	cases := []struct {
+		name     string
+		viewer   *models.User
+		expected []string
+	}{
+		{"anonymous", nil, []string{models.VisibilityPublic}},
+		{"stranger", suite.stranger, []string{models.VisibilityPublic}},
+		{"follower", suite.follower, []string{models.VisibilityFollowers, models.VisibilityPublic}},
+		{"holder", suite.holder, []string{models.VisibilityHolders, models.VisibilityPublic}},
+		{"author", suite.creator, []string{models.VisibilityHolders, models.VisibilityOnlyMe, models.VisibilityFollowers, models.VisibilityPublic}},
+	}
+
+	urls := []string{
+		"/api/v1/posts/timeline",
+		"/api/v1/posts/timeline?cursor=&includeTotal=true",
+		"/api/v1/users/cora/posts",
+		"/api/v1/users/cora/posts?cursor=",
+		"/api/v1/tags/cora/posts",
+	}
+
+	for _, tc := range cases {
+		for _, url := range urls {
+			assert.Equal(suite.T(), tc.expected, suite.visibleContents(url, tc.viewer), "%s: %s", tc.name, url)
+		}
+
+		for visibility, post := range suite.posts {
+			w := suite.get("/api/v1/posts/"+post.ID.String(), tc.viewer)
+			expected := http.StatusNotFound
+			for _, v := range tc.expected {
+				if v == visibility {
+					expected = http.StatusOK
+				}
+			}
+			assert.Equal(suite.T(), expected, w.Code, "%s: %s post", tc.name, visibility)
+		}
+	}
+
+	// 分页总数同样只统计可见帖子
+	page, err := services.PostService.GetTimelineByCursor(suite.follower.ID, "", 10, true)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), *page.Total)
+}
+
+// TestInvalidToken_TreatedAsAnonymous 测试公开路由携带无效token时按未登录处理
+func (suite *VisibilityTestSuite) TestInvalidToken_TreatedAsAnonymous() {
+	req, _ := http.NewRequest("GET", "/api/v1/posts/timeline", nil)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response controllers.TimelineResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().Len(response.Posts, 1)
+	assert.Equal(suite.T(), models.VisibilityPublic, response.Posts[0].Visibility)
+}
+
+// TestCreate_Validation 测试可见范围校验：仅创作者可发布持有者可见的帖子，非公开帖子不能转发或引用
+func (suite *VisibilityTestSuite) TestCreate_Validation() {
+	req := createAuthenticatedRequest("POST", "/api/v1/posts",
+		[]byte(`{"content":"secret","visibility":"holders"}`), suite.stranger.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	req = createAuthenticatedRequest("POST", "/api/v1/posts",
+		[]byte(`{"content":"secret","visibility":"friends"}`), suite.stranger.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// 关注者能看到但不能转发
+	_, err := services.PostService.Repost(suite.follower.ID, suite.posts[models.VisibilityFollowers].ID)
+	assert.ErrorIs(suite.T(), err, services.ErrPostNotShareable)
+	// 看不到的帖子视为不存在
+	_, err = services.PostService.CreateQuotePost(suite.stranger.ID, "leak", suite.posts[models.VisibilityOnlyMe].ID)
+	assert.ErrorIs(suite.T(), err, services.ErrPostNotFound)
+}
+
+// TestMention_OnlyNotifiesViewers 测试被提及但看不到帖子的用户不会收到通知
+func (suite *VisibilityTestSuite) TestMention_OnlyNotifiesViewers() {
+	_, err := services.PostService.CreatePostWithOptions(suite.creator.ID, services.CreatePostOptions{
+		Content:    "hi @finn and @sid",
+		Visibility: models.VisibilityFollowers,
+	})
+	suite.Require().NoError(err)
+
+	var mentions []models.Notification
+	suite.db.Where("type = ?", models.NotificationTypeMention).Find(&mentions)
+	suite.Require().Len(mentions, 1)
+	assert.Equal(suite.T(), suite.follower.ID, mentions[0].UserID)
+}
+
+// TestFollow 测试关注、重复关注和取消关注
+func (suite *VisibilityTestSuite) TestFollow() {
+	for i := 0; i < 2; i++ {
+		req := createAuthenticatedRequest("POST", "/api/v1/users/cora/follow", nil, suite.stranger.ID.String())
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		assert.JSONEq(suite.T(), `{"following":true,"followersCount":2}`, w.Body.String())
+	}
+
+	// 重复关注只通知一次
+	count, err := services.NotificationService.CountUnread(suite.creator.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), count)
+	assert.Contains(suite.T(), suite.visibleContents("/api/v1/posts/timeline", suite.stranger), models.VisibilityFollowers)
+
+	req := createAuthenticatedRequest("DELETE", "/api/v1/users/cora/follow", nil, suite.stranger.ID.String())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.JSONEq(suite.T(), `{"following":false,"followersCount":1}`, w.Body.String())
+
+	req = createAuthenticatedRequest("POST", "/api/v1/users/cora/follow", nil, suite.creator.ID.String())
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+// TestVisibilityTestSuite 运行可见范围测试套件
+func TestVisibilityTestSuite(t *testing.T) {
+	suite.Run(t, new(VisibilityTestSuite))
+}
@@ -0,0 +1,228 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// 抓取相关错误
+var (
+	ErrInvalidURL         = errors.New("only absolute http(s) urls without credentials are allowed")
+	ErrForbiddenAddress   = errors.New("destination address is not allowed")
+	ErrTooManyRedirects   = errors.New("too many redirects")
+	ErrUnsupportedContent = errors.New("response is not an html page")
+)
+
+// MaxURLLength 可抓取的链接最大长度
+const MaxURLLength = 2048
+
+// Config 抓取配置
+type Config struct {
+	Timeout      time.Duration // 单次抓取总耗时上限，包括重定向和读取响应
+	MaxBodyBytes int64         // 最多读取的响应字节数，元数据都在 <head> 中，超出部分直接丢弃
+	MaxRedirects int
+	UserAgent    string
+	AllowPrivate bool // 允许访问内网和本机地址，仅用于测试
+}
+
+// DefaultConfig 默认抓取配置
+func DefaultConfig() Config {
+	return Config{
+		Timeout:      5 * time.Second,
+		MaxBodyBytes: 1 << 20,
+		MaxRedirects: 5,
+		UserAgent:    "yolo-link-preview/1.0",
+	}
+}
+
+// Fetcher 抓取网页的 OpenGraph / Twitter Card 元数据。
+// 连接时按解析后的IP校验目标地址，重定向和DNS重绑定都无法绕过内网限制。
+type Fetcher struct {
+	config Config
+	client *http.Client
+}
+
+// New 创建抓取器
+func New(config Config) *Fetcher {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !IsPublicAddr(addr) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // 不走环境代理，否则校验的是代理地址
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   config.Timeout,
+		ResponseHeaderTimeout: config.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > config.MaxRedirects {
+					return ErrTooManyRedirects
+				}
+				return checkURL(req.URL)
+			},
+		},
+	}
+}
+
+// Fetch 抓取链接并解析元数据，返回的 URL 为重定向后的最终地址
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if err := checkURL(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		// 内网地址和重定向次数的错误被包在 url.Error 里，原样返回便于判断
+		if errors.Is(err, ErrForbiddenAddress) {
+			return nil, ErrForbiddenAddress
+		}
+		if errors.Is(err, ErrTooManyRedirects) {
+			return nil, ErrTooManyRedirects
+		}
+		if errors.Is(err, ErrInvalidURL) {
+			return nil, ErrInvalidURL
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrUnsupportedContent
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.config.MaxBodyBytes), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset: %w", err)
+	}
+	return Parse(body, resp.Request.URL), nil
+}
+
+// checkURL 只允许不带账号密码的 http(s) 绝对地址
+func checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrInvalidURL
+	}
+	if u.User != nil || u.Hostname() == "" || len(u.String()) > MaxURLLength {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// reservedPrefixes netip 没有覆盖到的保留地址段
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF协议分配
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留及广播
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64，可映射到内网IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // 本地NAT64
+	netip.MustParsePrefix("2002::/16"),       // 6to4，可映射到内网IPv4
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("100::/64"),        // 丢弃
+	netip.MustParsePrefix("2001:db8::/32"),   // 文档示例
+	netip.MustParsePrefix("fec0::/10"),       // 已废弃的站点本地地址
+	netip.MustParsePrefix("::ffff:0:0:0/96"), // IPv4转换地址
+}
+
+// IsPublicAddr 地址是否为公网地址，环回、私有、链路本地、组播和保留地址都不允许访问
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeURL 规范化帖子中的链接：www. 开头的补全为 https，域名转小写，去掉默认端口和锚点
+func NormalizeURL(raw string) (string, error) {
+	if len(raw) >= 4 && strings.EqualFold(raw[:4], "www.") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if err := checkURL(u); err != nil {
+		return "", err
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	normalized := u.String()
+	if len(normalized) > MaxURLLength {
+		return "", ErrInvalidURL
+	}
+	return normalized, nil
+}
@@ -0,0 +1,143 @@
+package unfurl
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 元数据字段长度上限（按字符）
+const (
+	MaxTitleLength       = 300
+	MaxDescriptionLength = 1000
+	MaxSiteNameLength    = 200
+)
+
+// Metadata 网页的预览信息
+type Metadata struct {
+	URL         string // 重定向后的最终地址
+	Title       string
+	Description string
+	ImageURL    string // 已转为绝对地址，非 http(s) 的图片会被忽略
+	SiteName    string // 没有声明时为域名
+}
+
+// Empty 是否没有任何可展示的信息
+func (m *Metadata) Empty() bool {
+	return m.Title == "" && m.Description == "" && m.ImageURL == ""
+}
+
+// Parse 解析 <head> 中的 OpenGraph、Twitter Card 和普通 meta 标签，
+// 依次按 og:*、twitter:*、<title>/description 的优先级取值
+func Parse(r io.Reader, base *url.URL) *Metadata {
+	meta := make(map[string]string)
+	var title strings.Builder
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(r)
+loop:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Body:
+				break loop
+			case atom.Title:
+				inTitle = title.Len() == 0
+			case atom.Meta:
+				var key, content string
+				hasContent := false
+				for _, attr := range token.Attr {
+					switch strings.ToLower(attr.Key) {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(attr.Val))
+						}
+					case "content":
+						content, hasContent = attr.Val, true
+					}
+				}
+				// 同名标签取第一个，例如多张 og:image 只展示第一张
+				if key != "" && hasContent {
+					if _, exists := meta[key]; !exists {
+						meta[key] = content
+					}
+				}
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if atom.Lookup(name) == atom.Title {
+				inTitle = false
+			} else if atom.Lookup(name) == atom.Head {
+				break loop
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := clean(meta[key]); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	result := &Metadata{
+		Title:       truncate(first("og:title", "twitter:title"), MaxTitleLength),
+		Description: truncate(first("og:description", "twitter:description", "description"), MaxDescriptionLength),
+		SiteName:    truncate(first("og:site_name", "application-name"), MaxSiteNameLength),
+	}
+	if result.Title == "" {
+		result.Title = truncate(clean(title.String()), MaxTitleLength)
+	}
+	if base != nil {
+		result.URL = base.String()
+		if result.SiteName == "" {
+			result.SiteName = base.Hostname()
+		}
+		result.ImageURL = resolveImage(base, first("og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src"))
+	}
+	return result
+}
+
+// resolveImage 将图片地址转为绝对地址
+func resolveImage(base *url.URL, raw string) string {
+	if raw == "" {
+		return ""
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if checkURL(resolved) != nil {
+		return ""
+	}
+	return resolved.String()
+}
+
+// clean 合并空白字符
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate 按字符截断
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 游标方向
+const (
+	CursorNext = "next" // 向更旧的内容翻页
+	CursorPrev = "prev" // 向更新的内容翻页
+)
+
+// Cursor 基于 (timestamp, id) 的键集分页游标，对客户端不透明
+type Cursor struct {
+	Timestamp time.Time
+	ID        uuid.UUID
+	Direction string
+}
+
+// cursorPayload 游标序列化格式
+type cursorPayload struct {
+	T int64     `json:"t"`
+	I uuid.UUID `json:"i"`
+	D string    `json:"d"`
+}
+
+// EncodeCursor 编码游标
+func EncodeCursor(timestamp time.Time, id uuid.UUID, direction string) string {
+	payload, _ := json.Marshal(cursorPayload{T: timestamp.UnixNano(), I: id, D: direction})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeCursor 解码游标
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding")
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("invalid cursor payload")
+	}
+	if payload.D != CursorNext && payload.D != CursorPrev {
+		return nil, fmt.Errorf("invalid cursor direction")
+	}
+
+	return &Cursor{
+		Timestamp: time.Unix(0, payload.T),
+		ID:        payload.I,
+		Direction: payload.D,
+	}, nil
+}
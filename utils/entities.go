@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"yolo/models"
+)
+
+// ParsedEntity 从帖子内容中解析出的实体
+// Start/End 为Unicode字符偏移（左闭右开），Value 为归一化后的值
+type ParsedEntity struct {
+	Type  string
+	Value string
+	Start int
+	End   int
+}
+
+var (
+	entityPattern  = regexp.MustCompile(`[#@$][\p{L}\p{N}_]+`)
+	mentionPattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,50}$`)
+	cashtagPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{0,9}$`)
+)
+
+// maxHashtagLength 话题标签最大长度
+const maxHashtagLength = 100
+
+// ExtractEntities 解析帖子中的 #hashtag、@mention 和 $SYMBOL
+func ExtractEntities(content string) []ParsedEntity {
+	var entities []ParsedEntity
+
+	for _, loc := range entityPattern.FindAllStringIndex(content, -1) {
+		// 前一个字符必须是边界，避免匹配邮箱、URL锚点或 "a#b"
+		if loc[0] > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(content[:loc[0]])
+			if isEntityWordRune(prev) || strings.ContainsRune("#@$/&", prev) {
+				continue
+			}
+		}
+
+		marker := content[loc[0]]
+		body := content[loc[0]+1 : loc[1]]
+
+		var entityType, value string
+		switch marker {
+		case '#':
+			if utf8.RuneCountInString(body) > maxHashtagLength || !containsLetter(body) {
+				continue
+			}
+			entityType, value = models.EntityTypeHashtag, strings.ToLower(body)
+		case '@':
+			if !mentionPattern.MatchString(body) {
+				continue
+			}
+			entityType, value = models.EntityTypeMention, body
+		case '$':
+			if !cashtagPattern.MatchString(body) {
+				continue
+			}
+			entityType, value = models.EntityTypeCashtag, strings.ToUpper(body)
+		}
+
+		start := utf8.RuneCountInString(content[:loc[0]])
+		entities = append(entities, ParsedEntity{
+			Type:  entityType,
+			Value: value,
+			Start: start,
+			End:   start + utf8.RuneCountInString(content[loc[0]:loc[1]]),
+		})
+	}
+
+	return entities
+}
+
+// NormalizeHashtag 归一化话题标签（去掉前缀#并转小写）
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// NormalizeSymbol 归一化股票符号（去掉前缀$并转大写）
+func NormalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(symbol), "$"))
+}
+
+func isEntityWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func containsLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
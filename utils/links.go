@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// linkPattern 匹配 http(s):// 或 www. 开头的链接
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// ExtractLinks 提取内容中的链接，去掉末尾的标点
+func ExtractLinks(content string) []string {
+	var links []string
+	for _, link := range linkPattern.FindAllString(content, -1) {
+		link = strings.TrimRight(link, ".,;:!?)]}'")
+		if link != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrInvalidMP4 不是可解析的 MP4/MOV 文件
+var ErrInvalidMP4 = errors.New("invalid mp4 file")
+
+// VideoInfo 视频基础信息
+type VideoInfo struct {
+	Width    int
+	Height   int
+	Duration time.Duration
+}
+
+// ProbeMP4 读取 MP4/MOV 文件头（moov/mvhd、trak/tkhd）获取视频尺寸和时长，不解码视频
+func ProbeMP4(r io.ReadSeeker) (*VideoInfo, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &VideoInfo{}
+	found := false
+	err = walkMP4Boxes(r, 0, size, func(boxType string, offset, length int64) error {
+		if boxType != "moov" {
+			return nil
+		}
+		found = true
+		return walkMP4Boxes(r, offset, length, func(boxType string, offset, length int64) error {
+			switch boxType {
+			case "mvhd":
+				return parseMVHD(r, offset, length, info)
+			case "trak":
+				return walkMP4Boxes(r, offset, length, func(boxType string, offset, length int64) error {
+					if boxType == "tkhd" && info.Width == 0 {
+						return parseTKHD(r, offset, length, info)
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: moov box not found", ErrInvalidMP4)
+	}
+	return info, nil
+}
+
+// walkMP4Boxes 遍历 [start, start+length) 范围内的box，回调参数为box内容的偏移和长度
+func walkMP4Boxes(r io.ReadSeeker, start, length int64, fn func(boxType string, offset, length int64) error) error {
+	end := start + length
+	for pos := start; pos+8 <= end; {
+		header := make([]byte, 16)
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMP4, err)
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			// 延伸到文件末尾
+			boxSize = end - pos
+		case 1:
+			// 64位长度
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidMP4, err)
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || pos+boxSize > end {
+			return fmt.Errorf("%w: box %q has invalid size", ErrInvalidMP4, boxType)
+		}
+
+		if err := fn(boxType, pos+headerSize, boxSize-headerSize); err != nil {
+			return err
+		}
+		pos += boxSize
+	}
+	return nil
+}
+
+// readMP4Box 读取box内容
+func readMP4Box(r io.ReadSeeker, offset, length, limit int64) ([]byte, error) {
+	if length > limit {
+		length = limit
+	}
+	buf := make([]byte, length)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMP4, err)
+	}
+	return buf, nil
+}
+
+// parseMVHD 解析影片头，获取时长
+func parseMVHD(r io.ReadSeeker, offset, length int64, info *VideoInfo) error {
+	buf, err := readMP4Box(r, offset, length, 32)
+	if err != nil {
+		return err
+	}
+
+	var timescale, duration uint64
+	switch {
+	case len(buf) >= 20 && buf[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(buf[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(buf[16:20]))
+	case len(buf) >= 32 && buf[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(buf[20:24]))
+		duration = binary.BigEndian.Uint64(buf[24:32])
+	default:
+		return fmt.Errorf("%w: malformed mvhd", ErrInvalidMP4)
+	}
+	if timescale == 0 {
+		return fmt.Errorf("%w: zero timescale", ErrInvalidMP4)
+	}
+
+	info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	return nil
+}
+
+// parseTKHD 解析轨道头，音频轨道的宽高为0会被跳过
+func parseTKHD(r io.ReadSeeker, offset, length int64, info *VideoInfo) error {
+	buf, err := readMP4Box(r, offset, length, 96)
+	if err != nil {
+		return err
+	}
+
+	// 宽高为16.16定点数，位于box末尾
+	sizeOffset := 76
+	if len(buf) > 0 && buf[0] == 1 {
+		sizeOffset = 88
+	}
+	if len(buf) < sizeOffset+8 {
+		return fmt.Errorf("%w: malformed tkhd", ErrInvalidMP4)
+	}
+
+	info.Width = int(binary.BigEndian.Uint32(buf[sizeOffset:sizeOffset+4]) >> 16)
+	info.Height = int(binary.BigEndian.Uint32(buf[sizeOffset+4:sizeOffset+8]) >> 16)
+	return nil
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 搜索关键词限制
+const (
+	maxSearchClauses    = 10 // 最多的词和短语数
+	maxSearchWordLength = 64
+)
+
+// SearchQuery 解析后的搜索条件，所有词和短语都必须命中
+type SearchQuery struct {
+	Terms   []string   // 单个词
+	Phrases [][]string // 双引号包围的短语，按顺序连续出现
+}
+
+// Empty 是否没有有效的搜索条件
+func (q SearchQuery) Empty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0
+}
+
+// ParseSearchQuery 解析搜索关键词：双引号内为短语，其余按空白和标点拆分为词。
+// 词只保留字母和数字并转为小写，与全文索引的分词方式一致；未闭合的引号按到结尾处理
+func ParseSearchQuery(q string) SearchQuery {
+	var query SearchQuery
+	seen := make(map[string]bool)
+	clauses := 0
+
+	for i, part := range strings.Split(q, `"`) {
+		words := searchWords(part)
+		if i%2 == 1 && len(words) > 1 {
+			if clauses < maxSearchClauses {
+				query.Phrases = append(query.Phrases, words)
+				clauses++
+			}
+			continue
+		}
+		for _, word := range words {
+			if seen[word] || clauses >= maxSearchClauses {
+				continue
+			}
+			seen[word] = true
+			query.Terms = append(query.Terms, word)
+			clauses++
+		}
+	}
+	return query
+}
+
+// searchWords 拆分出小写的字母数字串，过长的词截断
+func searchWords(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, field := range fields {
+		if utf8.RuneCountInString(field) > maxSearchWordLength {
+			fields[i] = string([]rune(field)[:maxSearchWordLength])
+		}
+	}
+	return fields
+}
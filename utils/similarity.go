@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// shingleSize 计算相似度时使用的字符片段长度
+const shingleSize = 3
+
+// NormalizeForComparison 归一化文本：小写、去掉标点和多余空白，用于近似重复检测
+func NormalizeForComparison(content string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(content) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+			space = false
+		case !space && b.Len() > 0:
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// shingles 返回归一化文本的字符片段集合
+func shingles(normalized string) map[string]struct{} {
+	runes := []rune(normalized)
+	set := make(map[string]struct{})
+	if len(runes) < shingleSize {
+		if len(runes) > 0 {
+			set[normalized] = struct{}{}
+		}
+		return set
+	}
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		set[string(runes[i:i+shingleSize])] = struct{}{}
+	}
+	return set
+}
+
+// TextSimilarity 返回两段文本的相似度（字符片段的Jaccard系数，0~1）
+// 大小写、标点和空白的差异会被忽略
+func TextSimilarity(a, b string) float64 {
+	setA := shingles(NormalizeForComparison(a))
+	setB := shingles(NormalizeForComparison(b))
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for s := range setA {
+		if _, ok := setB[s]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(setA)+len(setB)-intersection)
+}